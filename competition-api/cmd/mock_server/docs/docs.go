@@ -1120,10 +1120,14 @@ const docTemplate = `{
         "types.FuzzingEngine": {
             "type": "string",
             "enum": [
-                "libfuzzer"
+                "libfuzzer",
+                "afl",
+                "honggfuzz"
             ],
             "x-enum-varnames": [
-                "FuzzingEngineLibFuzzer"
+                "FuzzingEngineLibFuzzer",
+                "FuzzingEngineAFL",
+                "FuzzingEngineHonggfuzz"
             ]
         },
         "types.Message": {
@@ -6,9 +6,13 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/models"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
 )
 
 func (s *ServerTestSuite) Test_OutOfBudget() {
@@ -112,3 +116,365 @@ func (s *ServerTestSuite) Test_OutOfBudget() {
 		})
 	}
 }
+
+// Sends a request to the competition management API at `path` as the competition manager
+func (s *ServerTestSuite) competitionRequest(
+	method string,
+	path string,
+	payload string,
+) (int, map[string]any) {
+	req, err := http.NewRequest(
+		method,
+		fmt.Sprintf("%s/competition/%s", s.server.URL, path),
+		strings.NewReader(payload),
+	)
+	s.Require().NoError(err, "failed to construct http request")
+
+	req.Header.Add("Content-Type", "application/json")
+	req.SetBasicAuth(authCompetitionManager.ID.String(), authToken)
+
+	resp, err := doRequest(s.T(), req)
+	s.Require().NoError(err)
+
+	body := make(map[string]any)
+	s.Require().NoError(json.Unmarshal([]byte(resp.body), &body))
+
+	return resp.code, body
+}
+
+func (s *ServerTestSuite) Test_Release() {
+	releaseAt := time.Now().Add(time.Hour).UnixMilli()
+
+	s.Run("InvalidMissingFields", func() {
+		code, body := s.competitionRequest(http.MethodPost, "release/", `{"name": "challenge"}`)
+		s.Equal(http.StatusBadRequest, code, "incorrect status code")
+		assertErrorBodyWithFields(s.T(), body)
+	})
+
+	s.Run("InvalidUnknownTeam", func() {
+		code, body := s.competitionRequest(http.MethodPost, "release/", fmt.Sprintf(
+			`{"name": "challenge", "repo_url": "https://github.com/example/repo", "head_ref": "main", "duration_secs": 3600, "release_at": %d, "teams": ["%s"]}`,
+			releaseAt,
+			uuid.New().String(),
+		))
+		s.Equal(http.StatusBadRequest, code, "incorrect status code")
+		s.Contains(body["message"], "unknown team")
+	})
+
+	s.Run("Lifecycle", func() {
+		code, body := s.competitionRequest(http.MethodPost, "release/", fmt.Sprintf(
+			`{"name": "challenge", "repo_url": "https://github.com/example/repo", "head_ref": "main", "base_ref": "base", "duration_secs": 3600, "release_at": %d}`,
+			releaseAt,
+		))
+		s.Require().Equal(http.StatusOK, code, "incorrect status code")
+		s.Equal("scheduled", body["status"])
+		s.Equal("delta", body["type"])
+		s.Equal(*s.config.RoundID, body["round_id"])
+		s.InDelta(float64(releaseAt), body["release_at"], 0)
+
+		releaseID, ok := body["release_id"].(string)
+		s.Require().True(ok, "release_id should be a string")
+
+		code, body = s.competitionRequest(http.MethodGet, "release/?status=scheduled", "")
+		s.Require().Equal(http.StatusOK, code, "incorrect status code")
+		s.Len(body["releases"], 1)
+
+		code, body = s.competitionRequest(http.MethodPost, "release/"+releaseID+"/pause/", "")
+		s.Require().Equal(http.StatusOK, code, "incorrect status code")
+		s.Equal("paused", body["status"])
+
+		code, _ = s.competitionRequest(http.MethodPost, "release/"+releaseID+"/pause/", "")
+		s.Equal(http.StatusConflict, code, "incorrect status code")
+
+		code, body = s.competitionRequest(http.MethodPost, "release/"+releaseID+"/resume/", "")
+		s.Require().Equal(http.StatusOK, code, "incorrect status code")
+		s.Equal("scheduled", body["status"])
+
+		code, body = s.competitionRequest(http.MethodDelete, "release/"+releaseID+"/", "")
+		s.Require().Equal(http.StatusOK, code, "incorrect status code")
+		s.Equal("cancelled", body["status"])
+
+		code, body = s.competitionRequest(http.MethodPost, "release/"+releaseID+"/resume/", "")
+		s.Equal(http.StatusConflict, code, "incorrect status code")
+		s.Contains(body["message"], "from cancelled to scheduled")
+
+		code, body = s.competitionRequest(http.MethodGet, "release/"+releaseID+"/", "")
+		s.Require().Equal(http.StatusOK, code, "incorrect status code")
+		s.Equal("cancelled", body["status"])
+	})
+
+	s.Run("BlockedByPreflight", func() {
+		release := models.ScheduledRelease{
+			Name:         "blocked",
+			Type:         types.TaskTypeFull,
+			RepoURL:      "https://github.com/example/repo",
+			HeadRef:      "main",
+			RoundID:      *s.config.RoundID,
+			DurationSecs: 3600,
+			ReleaseAt:    time.Now(),
+			Status:       types.ReleaseStatusFailed,
+			Error:        models.NewNullFromData("challenge failed pre-flight: 1 of 1 checks failed"),
+			Preflight: &types.PreflightReport{
+				Checks: []types.PreflightCheckResult{
+					{Check: types.PreflightCheckBuild, Reason: "expected passed, got failed"},
+				},
+				Blocked: true,
+			},
+		}
+		s.Require().NoError(s.tx.Create(&release).Error)
+
+		code, body := s.competitionRequest(http.MethodGet, "release/"+release.ID.String()+"/", "")
+		s.Require().Equal(http.StatusOK, code, "incorrect status code")
+		preflight, ok := body["preflight"].(map[string]any)
+		s.Require().True(ok, "failed release should carry the pre-flight report")
+		s.Equal(true, preflight["blocked"])
+		s.Len(preflight["checks"], 1)
+	})
+
+	s.Run("NotFound", func() {
+		code, body := s.competitionRequest(http.MethodGet, "release/"+uuid.New().String()+"/", "")
+		s.Equal(http.StatusNotFound, code, "incorrect status code")
+		notFoundBodyTester(s.T(), body)
+	})
+}
+
+func (s *ServerTestSuite) Test_Round() {
+	submitFreeform := func() int {
+		req, err := http.NewRequest(
+			http.MethodPost,
+			fmt.Sprintf("%s/v1/task/%s/freeform/", s.server.URL, taskOpen.ID.String()),
+			strings.NewReader(fmt.Sprintf(`{"submission": "%s"}`, base64String(10))),
+		)
+		s.Require().NoError(err, "failed to construct http request")
+
+		req.Header.Add("Content-Type", "application/json")
+		req.SetBasicAuth(auth.ID.String(), authToken)
+
+		resp, err := doRequest(s.T(), req)
+		s.Require().NoError(err)
+
+		return resp.code
+	}
+
+	roundID := *s.config.RoundID
+
+	roundPayload := fmt.Sprintf(`{"round_id": "%s"}`, roundID)
+
+	code, body := s.competitionRequest(http.MethodPost, "round/", roundPayload)
+	s.Require().Equal(http.StatusOK, code, "incorrect status code")
+	s.Equal("planned", body["state"])
+
+	code, _ = s.competitionRequest(http.MethodPost, "round/", roundPayload)
+	s.Equal(http.StatusConflict, code, "duplicate round should conflict")
+
+	s.Equal(http.StatusBadRequest, submitFreeform(), "planned round should reject submissions")
+
+	code, body = s.competitionRequest(http.MethodPost, "round/"+roundID+"/open/", "")
+	s.Require().Equal(http.StatusOK, code, "incorrect status code")
+	s.Equal("open", body["state"])
+	s.NotNil(body["start_time"], "opening should set the start time")
+
+	s.Equal(http.StatusOK, submitFreeform(), "open round should accept submissions")
+
+	code, body = s.competitionRequest(http.MethodPost, "round/"+roundID+"/freeze/", "")
+	s.Require().Equal(http.StatusOK, code, "incorrect status code")
+	s.Equal("frozen", body["state"])
+
+	code, _ = s.competitionRequest(http.MethodPost, "round/"+roundID+"/freeze/", "")
+	s.Equal(http.StatusConflict, code, "frozen round can not be frozen again")
+
+	s.Equal(http.StatusBadRequest, submitFreeform(), "frozen round should reject submissions")
+
+	code, body = s.competitionRequest(http.MethodPost, "round/"+roundID+"/close/", "")
+	s.Require().Equal(http.StatusOK, code, "incorrect status code")
+	s.Equal("closed", body["state"])
+	s.NotNil(body["end_time"], "closing should set the end time")
+
+	code, _ = s.competitionRequest(http.MethodPost, "round/"+roundID+"/open/", "")
+	s.Equal(http.StatusConflict, code, "closed round can not be reopened")
+
+	s.Equal(http.StatusBadRequest, submitFreeform(), "closed round should reject submissions")
+
+	code, body = s.competitionRequest(http.MethodGet, "round/", "")
+	s.Require().Equal(http.StatusOK, code, "incorrect status code")
+	s.Len(body["rounds"], 1)
+
+	code, body = s.competitionRequest(http.MethodGet, "round/does-not-exist/", "")
+	s.Equal(http.StatusNotFound, code, "incorrect status code")
+	notFoundBodyTester(s.T(), body)
+}
+
+func (s *ServerTestSuite) Test_ExtendTask() {
+	extendPath := "task/" + taskExpired.ID.String() + "/extend/"
+
+	earlier := taskExpired.Deadline.Add(-time.Hour).UnixMilli()
+	code, body := s.competitionRequest(
+		http.MethodPost,
+		extendPath,
+		fmt.Sprintf(`{"deadline": %d}`, earlier),
+	)
+	s.Equal(http.StatusBadRequest, code, "earlier deadline should be rejected")
+	assertErrorBodyWithFields(s.T(), body)
+
+	code, _ = s.competitionRequest(http.MethodPost, extendPath, `{}`)
+	s.Equal(http.StatusBadRequest, code, "missing deadline should be rejected")
+
+	newDeadline := time.Now().Add(time.Hour).UnixMilli()
+	payload := fmt.Sprintf(`{"deadline": %d}`, newDeadline)
+	code, body = s.competitionRequest(http.MethodPost, extendPath, payload)
+	s.Require().Equal(http.StatusOK, code, "incorrect status code")
+	s.InDelta(float64(newDeadline), body["deadline"], 0)
+	s.GreaterOrEqual(body["reaccepted_submissions"], float64(1))
+
+	var task models.Task
+	s.Require().NoError(s.tx.First(&task, "id = ?", taskExpired.ID).Error)
+	s.Equal(newDeadline, task.Deadline.UnixMilli(), "deadline should be stored")
+	s.Equal(false, body["task_resent"], "tests have no job client to resend with")
+	s.True(task.ResendPending, "the unsent task should stay pending")
+
+	code, body = s.competitionRequest(http.MethodPost, extendPath, payload)
+	s.Require().Equal(http.StatusOK, code, "the same deadline should retry follow-ups")
+	s.InDelta(float64(0), body["reaccepted_submissions"], 0)
+	s.InDelta(float64(0), body["pending_evaluations"], 0)
+
+	var reaccepted models.Bundle
+	s.Require().NoError(s.tx.First(&reaccepted, "id = ?", bundleExpired.ID).Error)
+	s.Equal(types.SubmissionStatusAccepted, reaccepted.Status, "bundle should be re-accepted")
+
+	s.Require().NoError(
+		s.tx.Model(&models.Task{}).
+			Where("id = ?", taskExpired.ID).
+			Update("cancelled_at", time.Now()).Error,
+	)
+	later := fmt.Sprintf(`{"deadline": %d}`, time.Now().Add(2*time.Hour).UnixMilli())
+	code, body = s.competitionRequest(http.MethodPost, extendPath, later)
+	s.Equal(http.StatusConflict, code, "cancelled task should not be extended")
+	s.Contains(body["message"], "cancelled")
+	s.Require().NoError(s.tx.First(&task, "id = ?", taskExpired.ID).Error)
+	s.Equal(newDeadline, task.Deadline.UnixMilli(), "cancelled task should keep its deadline")
+
+	code, body = s.competitionRequest(http.MethodPost, "task/"+uuid.New().String()+"/extend/", payload)
+	s.Equal(http.StatusNotFound, code, "incorrect status code")
+	notFoundBodyTester(s.T(), body)
+}
+
+func (s *ServerTestSuite) Test_EvalSteps() {
+	started := time.Now().Add(-time.Minute)
+	step := types.EvalStep{
+		StartedAt: started,
+		Name:      types.EvalStepBuild,
+		Outcome:   types.EvalStepRunning,
+		Context:   types.ResultCtxHeadRepoTest,
+	}.Finish(started.Add(time.Second), types.EvalStepPassed, "")
+	s.Require().NoError(
+		s.tx.Model(&vuln).Updates(&models.POVSubmission{Steps: []types.EvalStep{step}}).Error,
+	)
+
+	code, body := s.competitionRequest(http.MethodGet, fmt.Sprintf("pov/%s/steps/", vuln.ID), "")
+	s.Require().Equal(http.StatusOK, code, "incorrect status code")
+	s.Require().Len(body["steps"], 1)
+	got, ok := body["steps"].([]any)[0].(map[string]any)
+	s.Require().True(ok)
+	s.Equal("build", got["name"])
+	s.Equal("passed", got["outcome"])
+	s.InDelta(1000, got["duration_ms"], 0)
+
+	code, body = s.competitionRequest(http.MethodGet, fmt.Sprintf("patch/%s/steps/", patch.ID), "")
+	s.Require().Equal(http.StatusOK, code, "incorrect status code")
+	s.Empty(body["steps"], "unevaluated patch should have no steps")
+
+	code, body = s.competitionRequest(http.MethodGet, fmt.Sprintf("pov/%s/steps/", uuid.New()), "")
+	s.Equal(http.StatusNotFound, code, "incorrect status code")
+	notFoundBodyTester(s.T(), body)
+}
+
+func (s *ServerTestSuite) Test_SubmissionResults() {
+	exitCode := 201
+	result := types.JobResult{
+		Cmd:        []string{"./run_pov.sh", "-x"},
+		StdoutBlob: types.Blob{ObjectName: "stdout"},
+		ExitCode:   &exitCode,
+		Context:    types.ResultCtxHeadRepoTest,
+	}
+	s.Require().NoError(
+		s.tx.Model(&vuln).Updates(&models.POVSubmission{Results: []types.JobResult{result}}).Error,
+	)
+
+	code, body := s.competitionRequest(http.MethodGet, fmt.Sprintf("pov/%s/results/", vuln.ID), "")
+	s.Require().Equal(http.StatusOK, code, "incorrect status code")
+	s.Require().Len(body["results"], 1)
+	got, ok := body["results"].([]any)[0].(map[string]any)
+	s.Require().True(ok)
+	s.Equal([]any{"./run_pov.sh", "-x"}, got["cmd"])
+	s.InDelta(201, got["return_code"], 0)
+
+	code, body = s.competitionRequest(
+		http.MethodGet,
+		fmt.Sprintf("patch/%s/results/", patch.ID),
+		"",
+	)
+	s.Require().Equal(http.StatusOK, code, "incorrect status code")
+	s.Empty(body["results"], "unevaluated patch should have no results")
+}
+
+func (s *ServerTestSuite) Test_POVClusters() {
+	clustersPath := "task/" + taskOpen.ID.String() + "/pov-clusters/"
+
+	code, body := s.competitionRequest(http.MethodGet, clustersPath, "")
+	s.Require().Equal(http.StatusOK, code, "incorrect status code")
+	s.Empty(body["clusters"], "task without passed povs should have no clusters")
+
+	s.Require().NoError(
+		s.tx.Model(&vuln).Updates(&models.POVSubmission{
+			Status: types.SubmissionStatusPassed,
+			Crash:  &types.CrashReport{Type: "SEGV", Signature: "SEGV|parse"},
+		}).Error,
+	)
+	_, err := models.ClusterPOV(s.T().Context(), s.tx, vuln.ID)
+	s.Require().NoError(err)
+
+	code, body = s.competitionRequest(http.MethodGet, clustersPath, "")
+	s.Require().Equal(http.StatusOK, code, "incorrect status code")
+	s.Require().Len(body["clusters"], 1)
+	got, ok := body["clusters"].([]any)[0].(map[string]any)
+	s.Require().True(ok)
+	s.Equal("SEGV|parse", got["signature"])
+	s.Equal(vuln.ID.String(), got["first_pov_id"])
+	s.Equal(vuln.SubmitterID.String(), got["first_submitter_id"])
+	s.Len(got["povs"], 1)
+
+	code, body = s.competitionRequest(http.MethodGet, "task/"+uuid.New().String()+"/pov-clusters/", "")
+	s.Equal(http.StatusNotFound, code, "incorrect status code")
+	notFoundBodyTester(s.T(), body)
+}
+
+func (s *ServerTestSuite) Test_TaskUsage() {
+	code, body := s.competitionRequest(http.MethodGet, "usage/", "")
+	s.Require().Equal(http.StatusOK, code, "incorrect status code")
+	s.Empty(body["tasks"], "tasks without evaluated submissions should have no usage")
+
+	s.Require().NoError(
+		s.tx.Model(&vuln).Updates(&models.POVSubmission{
+			Usage: &types.ResourceUsageTotal{WallTimeMs: 1000, Commands: 2},
+		}).Error,
+	)
+	s.Require().NoError(
+		s.tx.Model(&patch).Updates(&models.PatchSubmission{
+			Usage: &types.ResourceUsageTotal{WallTimeMs: 500, Commands: 3, Signaled: 1},
+		}).Error,
+	)
+
+	code, body = s.competitionRequest(http.MethodGet, "usage/", "")
+	s.Require().Equal(http.StatusOK, code, "incorrect status code")
+	s.Require().Len(body["tasks"], 1, "pov and patch were submitted against the same task")
+	got, ok := body["tasks"].([]any)[0].(map[string]any)
+	s.Require().True(ok)
+	s.Equal(taskOpen.ID.String(), got["task_id"])
+	s.InDelta(2, got["evaluations"], 0)
+	usage, ok := got["usage"].(map[string]any)
+	s.Require().True(ok)
+	s.InDelta(1500, usage["wall_time_ms"], 0)
+	s.InDelta(5, usage["commands"], 0)
+	s.InDelta(1, usage["signaled"], 0)
+	s.NotContains(usage, "max_rss_kb", "the containers' memory is not measured")
+}
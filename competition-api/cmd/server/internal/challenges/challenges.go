@@ -14,15 +14,12 @@ import (
 	"strings"
 	"time"
 
-	"github.com/go-git/go-git/v5"
 	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/google/uuid"
-	cp "github.com/otiai10/copy"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
-	"golang.org/x/sync/errgroup"
 	"gorm.io/gorm"
 
 	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/jobs"
@@ -43,7 +40,10 @@ type Client struct {
 	workingStore upload.Uploader
 	db           *gorm.DB
 	jobClient    *jobs.KubernetesClient
+	preflight    *config.PreflightConfig
 	tempDir      string
+	// Applied to timeouts a challenge.yaml leaves unset
+	evalTimeouts types.EvalTimeouts
 }
 
 func Create(
@@ -52,6 +52,8 @@ func Create(
 	jobClient *jobs.KubernetesClient,
 	archiver upload.Uploader,
 	workingStore upload.Uploader,
+	preflight *config.PreflightConfig,
+	evalTimeouts types.EvalTimeouts,
 ) *Client {
 	return &Client{
 		db:           db,
@@ -59,6 +61,8 @@ func Create(
 		jobClient:    jobClient,
 		archiver:     archiver,
 		workingStore: workingStore,
+		preflight:    preflight,
+		evalTimeouts: evalTimeouts,
 	}
 }
 
@@ -235,6 +239,12 @@ func extractRepoName(ctx context.Context, input string) (string, error) {
 
 	span.SetAttributes(attribute.String("extractRepoName.input", input))
 
+	// scp-like ssh remotes (git@host:org/repo.git) are not valid URLs
+	if host, repoPath, ok := strings.Cut(input, ":"); ok && !strings.Contains(input, "://") &&
+		strings.Contains(host, "@") {
+		input = fmt.Sprintf("ssh://%s/%s", host, repoPath)
+	}
+
 	span.AddEvent("parsing input as url")
 	parsedURL, err := url.Parse(input)
 	if err != nil {
@@ -256,84 +266,6 @@ func extractRepoName(ctx context.Context, input string) (string, error) {
 	return lastSegment, nil
 }
 
-// Get repo:
-func cloneRepo(ctx context.Context, url string, path string, auth githttp.BasicAuth) error {
-	_, span := tracer.Start(ctx, "cloneRepo")
-	defer span.End()
-
-	span.SetAttributes(
-		attribute.String("repo.url", url),
-		attribute.String("repo.path", path),
-	)
-
-	_, err := git.PlainClone(path, false, &git.CloneOptions{
-		URL:  url,
-		Auth: &auth,
-	})
-	if err != nil {
-		span.SetStatus(codes.Error, "error cloning repo")
-		span.RecordError(err)
-		return err
-	}
-
-	span.AddEvent("setting up LFS-capable authenticated URL for origin")
-	err = setRepoURL(ctx, path, url, &auth)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to set origin to authenticated URL")
-		span.RecordError(err)
-		return err
-	}
-
-	span.SetStatus(codes.Ok, "")
-	span.RecordError(nil)
-	return nil
-}
-
-func downloadRepo(
-	ctx context.Context,
-	repoURL string,
-	downloadPath string,
-	auth githttp.BasicAuth,
-) (string, error) {
-	ctx, span := tracer.Start(ctx, "downloadRepo")
-	defer span.End()
-
-	span.SetAttributes(
-		attribute.String("repo.url", repoURL),
-		attribute.String("repo.downloadPath", downloadPath),
-	)
-
-	repoURL = replaceSSH(repoURL)
-
-	repoName, err := extractRepoName(ctx, repoURL)
-	if err != nil {
-		span.SetStatus(codes.Error, "error extracting repo name")
-		span.RecordError(err)
-		return "", err
-	}
-
-	repoDir := path.Join(downloadPath, repoName)
-	span.SetAttributes(attribute.String("repo.dir", repoDir))
-	span.AddEvent("creating repo dir")
-	err = os.Mkdir(repoDir, 0700)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to create repo dir")
-		span.RecordError(err)
-		return "", err
-	}
-
-	err = cloneRepo(ctx, repoURL, repoDir, auth)
-	if err != nil {
-		span.SetStatus(codes.Error, "error cloning repo")
-		span.RecordError(err)
-		return "", err
-	}
-
-	span.SetStatus(codes.Ok, "")
-	span.RecordError(nil)
-	return repoDir, nil
-}
-
 // paths to strip are relative to repo root
 func stripRepo(ctx context.Context, pathsToStrip []string, repoPath string) error {
 	_, span := tracer.Start(ctx, "stripRepo")
@@ -580,6 +512,84 @@ func (h *Client) SendSARIFBroadcast(
 	h.send(ctx, "/v1/sarif/", roundID, taskID, messageID, deadline, sarifPayload, teams)
 }
 
+// Re-sends the task notification for an existing task. Used when the task has changed after it
+// was first sent, such as when its deadline is extended.
+func (h *Client) ResendTask(ctx context.Context, task *models.Task, teams []config.Team) error {
+	ctx, span := tracer.Start(ctx, "ResendTask")
+	defer span.End()
+
+	taskID := task.ID.String()
+	span.SetAttributes(attribute.String("task.id", taskID))
+
+	expiration := task.Deadline.Add(time.Minute * 5)
+	span.SetAttributes(attribute.Int64("presignedURLExpiration_ms", expiration.UnixMilli()))
+
+	sources := make([]types.SourceDetail, 0, len(task.Source))
+	for _, source := range task.Source {
+		span.AddEvent("getting presigned url for source", trace.WithAttributes(
+			attribute.String("source.type", source.Type),
+		))
+		sourceURL, err := h.workingStore.PresignedReadURL(
+			ctx,
+			source.URL,
+			time.Until(expiration),
+		)
+		if err != nil {
+			span.SetStatus(codes.Error, "failed to get presigned url for source")
+			span.RecordError(err)
+			return err
+		}
+
+		sources = append(sources, types.SourceDetail{
+			Type:   types.SourceType(source.Type),
+			URL:    sourceURL,
+			SHA256: source.SHA256,
+		})
+	}
+
+	body := types.Task{
+		MessageID:   uuid.New().String(),
+		MessageTime: types.UnixMilli(time.Now().UTC().UnixMilli()),
+		Tasks: []types.TaskDetail{
+			{
+				TaskID:            taskID,
+				Type:              task.Type,
+				Deadline:          types.UnixMilli(task.Deadline.UTC().UnixMilli()),
+				ProjectName:       task.ProjectName,
+				Focus:             task.Focus,
+				HarnessesIncluded: task.HarnessesIncluded,
+				Source:            sources,
+				Metadata: types.TaskMetadata{
+					TaskID:  taskID,
+					RoundID: task.RoundID,
+				},
+			},
+		},
+	}
+
+	span.AddEvent("marshaling message as JSON")
+	payload, err := json.Marshal(body)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to marshal message as JSON")
+		span.RecordError(err)
+		return err
+	}
+
+	h.sendTask(
+		ctx,
+		task.RoundID,
+		taskID,
+		body.MessageID,
+		task.Deadline.UTC(),
+		payload,
+		teams,
+	)
+
+	span.SetStatus(codes.Ok, "")
+	span.RecordError(nil)
+	return nil
+}
+
 func (h *Client) send(
 	ctx context.Context,
 	route string,
@@ -645,7 +655,6 @@ func (h *Client) RunFullScan(
 	)
 
 	tarsDir := "tarsDir"
-	stripRepoFiles := []string{".aixcc", ".github", ".git", ".gitattributes"}
 
 	workDir, err := setupWorkspace(ctx, h.tempDir, tarsDir)
 	if err != nil {
@@ -657,141 +666,39 @@ func (h *Client) RunFullScan(
 
 	tarsDir = path.Join(workDir, tarsDir)
 
-	repoPath, err := downloadRepo(ctx, challengeInputs.RepoURL, workDir, challengeInputs.AuthMethod)
+	packaged, err := h.packageFullScan(ctx, challengeInputs, roundID, workDir, tarsDir)
 	if err != nil {
-		span.SetStatus(codes.Error, "failed to download repo")
+		span.SetStatus(codes.Error, "failed to package repo")
 		span.RecordError(err)
 		return err
 	}
 
-	err = checkoutRef(ctx, repoPath, challengeInputs.HeadRef)
+	err = h.packageFuzzTooling(ctx, challengeInputs.Source, roundID, packaged, workDir, tarsDir)
 	if err != nil {
-		span.SetStatus(codes.Error, "failed to checkout head ref")
-		span.RecordError(err)
-		return err
-	}
-
-	headCommit, err := refToCommit(ctx, repoPath, challengeInputs.HeadRef)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to convert ref to commit")
-		span.RecordError(err)
-		return err
-	}
-
-	challengeYAML, err := types.ParseChallengeYAML(ctx, repoPath)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to parse challenge YAML")
+		span.SetStatus(codes.Error, "failed to package fuzz tooling")
 		span.RecordError(err)
 		return err
 	}
 
+	challengeYAML := packaged.challengeYAML
 	harnessesIncluded := len(challengeYAML.HarnessesList) > 0
+	headCommit := packaged.headCommit
+	unstrippedRepoFileHash := packaged.blobs[types.FileUnstrippedRepoTarball]
+	strippedRepoFileHash := packaged.blobs[types.FileStrippedRepoTarball]
+	ossFuzzFileHash := packaged.blobs[types.FileOSSFuzzTarball]
 
-	err = setRepoURL(ctx, repoPath, challengeInputs.RepoURL, nil)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to set origin to unauthenticated URL")
-		span.RecordError(err)
-		return err
-	}
-	unstrippedRepoTarPath, err := packageRepo(ctx, repoPath, tarsDir)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to package unstripped repo")
-		span.RecordError(err)
-		return err
-	}
-
-	err = stripRepo(ctx, stripRepoFiles, repoPath)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to strip repo")
-		span.RecordError(err)
-		return err
-	}
-
-	strippedRepoTarPath, err := packageRepo(ctx, repoPath, tarsDir)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to package stripped repo")
-		span.RecordError(err)
-		return err
-	}
-
-	ossFuzzPath, err := downloadRepo(
-		ctx,
-		challengeYAML.FuzzToolingURL,
-		workDir,
-		challengeInputs.AuthMethod,
-	)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to download fuzz tooling")
-		span.RecordError(err)
-		return err
-	}
-
-	err = checkoutRef(ctx, ossFuzzPath, challengeYAML.FuzzToolingRef)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to checkout fuzz tooling ref")
-		span.RecordError(err)
-		return err
-	}
-
-	err = stripRepo(ctx, stripRepoFiles, ossFuzzPath)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to strip fuzz tooling repo")
-		span.RecordError(err)
-		return err
-	}
-
-	span.AddEvent("rename oss-fuzz directory to fuzz-tooling")
-	newOssFuzzPath := path.Join(path.Dir(ossFuzzPath), "fuzz-tooling")
-	err = os.Rename(ossFuzzPath, newOssFuzzPath)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to rename oss-fuzz directory")
-		span.RecordError(err)
-		return err
-	}
-	ossFuzzPath = newOssFuzzPath
-
-	ossFuzzTarPath, err := packageRepo(ctx, ossFuzzPath, tarsDir)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to package fuzz tooling repo")
-		span.RecordError(err)
-		return err
-	}
-
-	unstrippedRepoFileHash, err := upload.HashedFile(
-		ctx,
-		h.workingStore,
-		unstrippedRepoTarPath,
-	)
-
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to get unstripped repo tarball hash")
-		span.RecordError(err)
-		return err
-	}
-
-	strippedRepoFileHash, err := upload.HashedFile(ctx, h.workingStore, strippedRepoTarPath)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to get stripped repo tarball hash")
-		span.RecordError(err)
-		return err
-	}
-
-	ossFuzzFileHash, err := upload.HashedFile(ctx, h.workingStore, ossFuzzTarPath)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to get fuzz tooling repo tarball hash")
-		span.RecordError(err)
-		return err
-	}
+	timeouts := challengeYAML.Timeouts.WithDefaults(h.evalTimeouts)
 
 	task := models.Task{
-		Type:     types.TaskTypeFull,
-		Deadline: time.Now().Add(challengeInputs.TaskDuration),
-		RoundID:  roundID,
-		Commit:   headCommit,
-		MemoryGB: challengeYAML.MemoryGB,
-		// baseRepoPath = tmp/base-repos/foobar
-		// base = foobar
-		Focus:             path.Base(repoPath),
+		Type:              types.TaskTypeFull,
+		Deadline:          time.Now().Add(challengeInputs.TaskDuration),
+		RoundID:           roundID,
+		Commit:            headCommit,
+		MemoryGB:          challengeYAML.MemoryGB,
+		Reproducibility:   challengeYAML.Reproducibility,
+		Timeouts:          &timeouts,
+		PatchPolicy:       challengeYAML.PatchPolicy,
+		Focus:             packaged.focus,
 		ProjectName:       challengeYAML.FuzzToolingProjectName,
 		HarnessesIncluded: harnessesIncluded,
 		Source: []models.Source{
@@ -820,6 +727,24 @@ func (h *Client) RunFullScan(
 		},
 	}
 
+	if h.preflightEnabled() {
+		err = h.preflightTask(
+			ctx,
+			challengeInputs.Name,
+			&task,
+			challengeYAML.CPUs,
+			packaged.groundTruth,
+		)
+		if err != nil {
+			span.SetStatus(codes.Error, "failed to pre-flight challenge")
+			span.RecordError(err)
+			return err
+		}
+
+		// pre-flight can take hours so the task window starts once it is done
+		task.Deadline = time.Now().Add(challengeInputs.TaskDuration)
+	}
+
 	span.AddEvent("inserting task into db")
 	err = db.Create(&task).Error
 	if err != nil {
@@ -856,26 +781,12 @@ func (h *Client) RunFullScan(
 	}
 
 	auditContext := audit.Context{RoundID: roundID, TaskID: &taskID}
-	files := []*archive.FileMetadata{
-		{
-			LocalFilePath: &unstrippedRepoTarPath,
-			ArchivedFile:  types.FileUnstrippedRepoTarball,
-			Entity:        audit.EntityTask,
-			EntityID:      taskID,
-		},
-		{
-			LocalFilePath: &strippedRepoTarPath,
-			ArchivedFile:  types.FileStrippedRepoTarball,
-			Entity:        audit.EntityTask,
-			EntityID:      taskID,
-		},
-		{
-			LocalFilePath: &ossFuzzTarPath,
-			ArchivedFile:  types.FileOSSFuzzTarball,
-			Entity:        audit.EntityTask,
-			EntityID:      taskID,
-		},
-	}
+	files := packaged.archiveFiles(
+		taskID,
+		types.FileUnstrippedRepoTarball,
+		types.FileStrippedRepoTarball,
+		types.FileOSSFuzzTarball,
+	)
 
 	for _, f := range files {
 		err = archive.ArchiveFile(ctx, auditContext, h.archiver, f)
@@ -972,8 +883,6 @@ func (h *Client) RunDeltaScan(
 	)
 
 	tarsDir := "tarsDir"
-	baseReposOuterDir := "baseRepos"
-	stripRepoFiles := []string{".aixcc", ".github", ".git", ".gitattributes"}
 
 	workDir, err := setupWorkspace(ctx, h.tempDir, tarsDir)
 	if err != nil {
@@ -984,261 +893,43 @@ func (h *Client) RunDeltaScan(
 	defer os.RemoveAll(workDir)
 
 	tarsDir = path.Join(workDir, tarsDir)
-	baseReposOuterDir = path.Join(workDir, baseReposOuterDir)
-
-	headRepoPath, err := downloadRepo(
-		ctx,
-		challengeInputs.RepoURL,
-		workDir,
-		challengeInputs.AuthMethod,
-	)
-	if err != nil {
-		span.SetStatus(codes.Error, "Failed to download repo to head repo path")
-		span.RecordError(err)
-		return err
-	}
-
-	// headRepoPath = /tmp/dir/foobar
-	// baseRepoPath = /tmp/dir/base-repos/foobar
-	baseRepoPath := path.Join(baseReposOuterDir, path.Base(headRepoPath))
-	span.SetAttributes(
-		attribute.String("baseRepoPath", baseRepoPath),
-		attribute.String("headRepoPath", headRepoPath),
-	)
-
-	span.AddEvent("creating repo dirs")
-	err = os.MkdirAll(baseRepoPath, 0700)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to create baseRepoPath")
-		span.RecordError(err)
-		return err
-	}
-
-	span.AddEvent("copying repo to baseRepoPath")
-	err = cp.Copy(headRepoPath, baseRepoPath, cp.Options{})
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to copy repo")
-		span.RecordError(err)
-		return err
-	}
-
-	err = checkoutRef(ctx, headRepoPath, challengeInputs.HeadRef)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to checkout head ref in head repo path")
-		span.RecordError(err)
-		return err
-	}
-
-	headCommit, err := refToCommit(ctx, headRepoPath, challengeInputs.HeadRef)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to convert head ref to commit")
-		span.RecordError(err)
-		return err
-	}
-
-	challengeYAML, err := types.ParseChallengeYAML(ctx, headRepoPath)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to parse challenge YAML")
-		span.RecordError(err)
-		return err
-	}
-
-	harnessesIncluded := len(challengeYAML.HarnessesList) > 0
-
-	err = checkoutRef(ctx, baseRepoPath, *challengeInputs.BaseRef)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to checkout base ref in base repo path")
-		span.RecordError(err)
-		return err
-	}
-
-	baseCommit, err := refToCommit(ctx, baseRepoPath, *challengeInputs.BaseRef)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to convert base ref to commit")
-		span.RecordError(err)
-		return err
-	}
-
-	eg, egctx := errgroup.WithContext(ctx)
-	var unstrippedHeadTarPath string
-	eg.Go(func() error {
-		fail := setRepoURL(ctx, headRepoPath, challengeInputs.RepoURL, nil)
-		if err != nil {
-			return fail
-		}
-		uhtp, fail := packageRepo(egctx, headRepoPath, tarsDir)
-		if fail != nil {
-			return fail
-		}
-		unstrippedHeadTarPath = uhtp
-		return nil
-	})
 
-	var unstrippedBaseTarPath string
-	eg.Go(func() error {
-		fail := setRepoURL(ctx, baseRepoPath, challengeInputs.RepoURL, nil)
-		if err != nil {
-			return fail
-		}
-		ubtp, fail := packageRepo(egctx, baseRepoPath, tarsDir)
-		if fail != nil {
-			return fail
-		}
-		unstrippedBaseTarPath = ubtp
-		return nil
-	})
-	err = eg.Wait()
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to package unstripped repos")
-		span.RecordError(err)
-		return err
-	}
-
-	diffPath, err := generateDiff(
-		ctx,
-		workDir,
-		headRepoPath,
-		challengeInputs.HeadRef,
-		*challengeInputs.BaseRef,
-		"ref.diff",
-		stripRepoFiles,
-	)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to generate diff")
-		span.RecordError(err)
-		return err
-	}
-
-	ossFuzzPath, err := downloadRepo(
-		ctx,
-		challengeYAML.FuzzToolingURL,
-		workDir,
-		challengeInputs.AuthMethod,
-	)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to download fuzz tooling repo")
-		span.RecordError(err)
-		return err
-	}
-
-	err = checkoutRef(ctx, ossFuzzPath, challengeYAML.FuzzToolingRef)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to checkout fuzz tooling ref")
-		span.RecordError(err)
-		return err
-	}
-
-	err = stripRepo(ctx, stripRepoFiles, baseRepoPath)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to strip base repo")
-		span.RecordError(err)
-		return err
-	}
-
-	err = stripRepo(ctx, stripRepoFiles, ossFuzzPath)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to strip fuzz tooling repo")
-		span.RecordError(err)
-		return err
-	}
-
-	eg, egctx = errgroup.WithContext(ctx)
-	var strippedRepoTarPath string
-	eg.Go(func() error {
-		srtp, fail := packageRepo(egctx, baseRepoPath, tarsDir)
-		if fail != nil {
-			return fail
-		}
-		strippedRepoTarPath = srtp
-		return nil
-	})
-	var ossFuzzTarPath string
-	eg.Go(func() error {
-		span.AddEvent("rename oss-fuzz directory to fuzz-tooling")
-		newOssFuzzPath := path.Join(path.Dir(ossFuzzPath), "fuzz-tooling")
-		fail := os.Rename(ossFuzzPath, newOssFuzzPath)
-		if fail != nil {
-			span.SetStatus(codes.Error, "failed to rename oss-fuzz directory")
-			span.RecordError(fail)
-			return fail
-		}
-		ossFuzzPath = newOssFuzzPath
-
-		oftp, fail := packageRepo(egctx, ossFuzzPath, tarsDir)
-		if fail != nil {
-			return fail
-		}
-		ossFuzzTarPath = oftp
-		return nil
-	})
-	var diffTarPath string
-	eg.Go(func() error {
-		dtp, fail := packageRepo(egctx, diffPath, tarsDir)
-		if fail != nil {
-			return fail
-		}
-		diffTarPath = dtp
-		return nil
-	})
-	err = eg.Wait()
+	packaged, err := h.packageDeltaScan(ctx, challengeInputs, roundID, workDir, tarsDir)
 	if err != nil {
 		span.SetStatus(codes.Error, "failed to package repos")
 		span.RecordError(err)
 		return err
 	}
 
-	unstrippedHeadFileHash, err := upload.HashedFile(
-		ctx,
-		h.workingStore,
-		unstrippedHeadTarPath,
-	)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to get unstripped head repo tarball hash")
-		span.RecordError(err)
-		return err
-	}
-
-	unstrippedBaseFileHash, err := upload.HashedFile(
-		ctx,
-		h.workingStore,
-		unstrippedBaseTarPath,
-	)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to get unstripped base repo tarball hash")
-		span.RecordError(err)
-		return err
-	}
-
-	strippedRepoFileHash, err := upload.HashedFile(ctx, h.workingStore, strippedRepoTarPath)
+	err = h.packageFuzzTooling(ctx, challengeInputs.Source, roundID, packaged, workDir, tarsDir)
 	if err != nil {
-		span.SetStatus(codes.Error, "failed to get stripped base repo tarball hash")
+		span.SetStatus(codes.Error, "failed to package fuzz tooling")
 		span.RecordError(err)
 		return err
 	}
 
-	ossFuzzFileHash, err := upload.HashedFile(ctx, h.workingStore, ossFuzzTarPath)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to get fuzz tooling repo tarball hash")
-		span.RecordError(err)
-		return err
-	}
+	challengeYAML := packaged.challengeYAML
+	harnessesIncluded := len(challengeYAML.HarnessesList) > 0
+	headCommit := packaged.headCommit
+	baseCommit := packaged.baseCommit
+	unstrippedHeadFileHash := packaged.blobs[types.FileUnstrippedHeadTarball]
+	unstrippedBaseFileHash := packaged.blobs[types.FileUnstrippedBaseTarball]
+	strippedRepoFileHash := packaged.blobs[types.FileStrippedRepoTarball]
+	ossFuzzFileHash := packaged.blobs[types.FileOSSFuzzTarball]
+	diffFileHash := packaged.blobs[types.FileDiffTarball]
 
-	diffFileHash, err := upload.HashedFile(ctx, h.workingStore, diffTarPath)
-	if err != nil {
-		span.SetStatus(codes.Error, "failed to get diff tarball hash")
-		span.RecordError(err)
-		return err
-	}
+	timeouts := challengeYAML.Timeouts.WithDefaults(h.evalTimeouts)
 
 	task := models.Task{
-		Type:     types.TaskTypeDelta,
-		Deadline: time.Now().Add(challengeInputs.TaskDuration),
-		RoundID:  roundID,
-		Commit:   headCommit,
-		MemoryGB: challengeYAML.MemoryGB,
-		// baseRepoPath = tmp/base-repos/foobar
-		// base = foobar
-		Focus:             path.Base(baseRepoPath),
+		Type:              types.TaskTypeDelta,
+		Deadline:          time.Now().Add(challengeInputs.TaskDuration),
+		RoundID:           roundID,
+		Commit:            headCommit,
+		MemoryGB:          challengeYAML.MemoryGB,
+		Reproducibility:   challengeYAML.Reproducibility,
+		Timeouts:          &timeouts,
+		PatchPolicy:       challengeYAML.PatchPolicy,
+		Focus:             packaged.focus,
 		ProjectName:       challengeYAML.FuzzToolingProjectName,
 		HarnessesIncluded: harnessesIncluded,
 		Source: []models.Source{
@@ -1277,6 +968,24 @@ func (h *Client) RunDeltaScan(
 		},
 	}
 
+	if h.preflightEnabled() {
+		err = h.preflightTask(
+			ctx,
+			challengeInputs.Name,
+			&task,
+			challengeYAML.CPUs,
+			packaged.groundTruth,
+		)
+		if err != nil {
+			span.SetStatus(codes.Error, "failed to pre-flight challenge")
+			span.RecordError(err)
+			return err
+		}
+
+		// pre-flight can take hours so the task window starts once it is done
+		task.Deadline = time.Now().Add(challengeInputs.TaskDuration)
+	}
+
 	span.AddEvent("inserting task into db")
 	err = db.Create(&task).Error
 	if err != nil {
@@ -1320,38 +1029,14 @@ func (h *Client) RunDeltaScan(
 	}
 
 	auditContext := audit.Context{RoundID: roundID, TaskID: &taskID}
-	files := []*archive.FileMetadata{
-		{
-			LocalFilePath: &unstrippedHeadTarPath,
-			ArchivedFile:  types.FileUnstrippedHeadTarball,
-			Entity:        audit.EntityTask,
-			EntityID:      taskID,
-		},
-		{
-			LocalFilePath: &unstrippedBaseTarPath,
-			ArchivedFile:  types.FileUnstrippedBaseTarball,
-			Entity:        audit.EntityTask,
-			EntityID:      taskID,
-		},
-		{
-			LocalFilePath: &diffTarPath,
-			ArchivedFile:  types.FileDiffTarball,
-			Entity:        audit.EntityTask,
-			EntityID:      taskID,
-		},
-		{
-			LocalFilePath: &strippedRepoTarPath,
-			ArchivedFile:  types.FileStrippedRepoTarball,
-			Entity:        audit.EntityTask,
-			EntityID:      taskID,
-		},
-		{
-			LocalFilePath: &ossFuzzTarPath,
-			ArchivedFile:  types.FileOSSFuzzTarball,
-			Entity:        audit.EntityTask,
-			EntityID:      taskID,
-		},
-	}
+	files := packaged.archiveFiles(
+		taskID,
+		types.FileUnstrippedHeadTarball,
+		types.FileUnstrippedBaseTarball,
+		types.FileDiffTarball,
+		types.FileStrippedRepoTarball,
+		types.FileOSSFuzzTarball,
+	)
 	for _, f := range files {
 		err = archive.ArchiveFile(ctx, auditContext, h.archiver, f)
 		if err != nil {
@@ -0,0 +1,573 @@
+package challenges
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+
+	cp "github.com/otiai10/copy"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/models"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/archive"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/audit"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/upload"
+)
+
+// Removed from repos before they are handed out to CRSs
+var stripRepoFiles = []string{".aixcc", ".github", ".git", ".gitattributes"}
+
+// Challenge tarballs ready to be made into a task, either freshly packaged or from the cache
+type packagedChallenge struct {
+	challengeYAML *types.ChallengeYAML
+	// Local tarballs by archived file, only set for tarballs packaged by this scan
+	paths map[types.ArchivedFile]string
+	// Working store object names by archived file
+	blobs       map[types.ArchivedFile]string
+	focus       string
+	headCommit  string
+	baseCommit  string
+	groundTruth []models.GroundTruthBlob
+}
+
+func packagedFromCache(entry *models.PackageCacheEntry) *packagedChallenge {
+	return &packagedChallenge{
+		challengeYAML: entry.Challenge,
+		paths:         map[types.ArchivedFile]string{},
+		blobs:         entry.Blobs,
+		focus:         entry.Focus,
+		headCommit:    entry.HeadCommit,
+		baseCommit:    entry.BaseCommit,
+		groundTruth:   entry.GroundTruth,
+	}
+}
+
+// Uploads the local tarballs in `paths` to the working store, recording their object names
+func (p *packagedChallenge) upload(
+	ctx context.Context,
+	workingStore upload.Uploader,
+	paths map[types.ArchivedFile]string,
+) error {
+	if p.paths == nil {
+		p.paths = map[types.ArchivedFile]string{}
+	}
+	if p.blobs == nil {
+		p.blobs = map[types.ArchivedFile]string{}
+	}
+
+	for file, tarPath := range paths {
+		blob, err := upload.HashedFile(ctx, workingStore, tarPath)
+		if err != nil {
+			return err
+		}
+
+		p.paths[file] = tarPath
+		p.blobs[file] = blob
+	}
+
+	return nil
+}
+
+// Describes the tarballs to archive for a task in the order given. Tarballs from the cache were
+// archived when they were first packaged so they are only logged.
+func (p *packagedChallenge) archiveFiles(
+	taskID string,
+	files ...types.ArchivedFile,
+) []*archive.FileMetadata {
+	metadata := make([]*archive.FileMetadata, 0, len(files))
+	for _, file := range files {
+		m := &archive.FileMetadata{
+			ArchivedFile: file,
+			Entity:       audit.EntityTask,
+			EntityID:     taskID,
+		}
+
+		if localPath, ok := p.paths[file]; ok {
+			m.LocalFilePath = &localPath
+		} else {
+			blob := p.blobs[file]
+			m.ObjectName = &blob
+		}
+
+		metadata = append(metadata, m)
+	}
+
+	return metadata
+}
+
+// Resolves `ref` for use in a cache key. Refs that can't be resolved leave the package uncached.
+func resolveForCache(
+	ctx context.Context,
+	source ChallengeSource,
+	repoURL string,
+	ref string,
+) (string, bool) {
+	span := trace.SpanFromContext(ctx)
+
+	commit, err := source.ResolveRef(ctx, repoURL, ref)
+	if err != nil {
+		if !errors.Is(err, ErrRefNotResolvable) {
+			span.AddEvent("failed to resolve ref for package cache", trace.WithAttributes(
+				attribute.String("ref", ref),
+				attribute.String("error", err.Error()),
+			))
+		}
+		return "", false
+	}
+
+	return commit, true
+}
+
+// Fetches and packages the repo for a full scan, or reuses the tarballs from an earlier scan of
+// the same commit
+func (h *Client) packageFullScan(
+	ctx context.Context,
+	challengeInputs ChallengeConfig,
+	roundID string,
+	workDir string,
+	tarsDir string,
+) (*packagedChallenge, error) {
+	ctx, span := tracer.Start(ctx, "packageFullScan")
+	defer span.End()
+
+	source := challengeInputs.Source
+	resolvedHead, cacheable := resolveForCache(
+		ctx,
+		source,
+		challengeInputs.RepoURL,
+		challengeInputs.HeadRef,
+	)
+	if cacheable {
+		key := fullScanCacheKey(challengeInputs.RepoURL, resolvedHead)
+		entry := h.cachedPackage(ctx, roundID, packageKindFullScan, key)
+		if entry != nil {
+			span.SetStatus(codes.Ok, "")
+			span.RecordError(nil)
+			return packagedFromCache(entry), nil
+		}
+	}
+
+	repoPath, err := source.FetchRepo(ctx, challengeInputs.RepoURL, workDir)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to download repo")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	err = checkoutRef(ctx, repoPath, challengeInputs.HeadRef)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to checkout head ref")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	headCommit, err := refToCommit(ctx, repoPath, challengeInputs.HeadRef)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to convert ref to commit")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	challengeYAML, err := types.ParseChallengeYAML(ctx, repoPath)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to parse challenge YAML")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	groundTruth, err := h.uploadGroundTruth(ctx, repoPath, challengeYAML.GroundTruth)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to upload ground truth")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	err = source.Scrub(ctx, repoPath, challengeInputs.RepoURL)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to scrub repo")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	unstrippedRepoTarPath, err := packageRepo(ctx, repoPath, tarsDir)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to package unstripped repo")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	err = stripRepo(ctx, stripRepoFiles, repoPath)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to strip repo")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	strippedRepoTarPath, err := packageRepo(ctx, repoPath, tarsDir)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to package stripped repo")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	packaged := &packagedChallenge{
+		challengeYAML: challengeYAML,
+		// baseRepoPath = tmp/base-repos/foobar
+		// base = foobar
+		focus:       path.Base(repoPath),
+		headCommit:  headCommit,
+		groundTruth: groundTruth,
+	}
+	err = packaged.upload(ctx, h.workingStore, map[types.ArchivedFile]string{
+		types.FileUnstrippedRepoTarball: unstrippedRepoTarPath,
+		types.FileStrippedRepoTarball:   strippedRepoTarPath,
+	})
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to upload repo tarballs")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	if cacheable {
+		h.cachePackage(ctx, &models.PackageCacheEntry{
+			Key:         fullScanCacheKey(challengeInputs.RepoURL, headCommit),
+			Kind:        packageKindFullScan,
+			Blobs:       packaged.blobs,
+			Challenge:   challengeYAML,
+			GroundTruth: groundTruth,
+			Focus:       packaged.focus,
+			HeadCommit:  headCommit,
+		})
+	}
+
+	span.SetStatus(codes.Ok, "")
+	span.RecordError(nil)
+	return packaged, nil
+}
+
+// Fetches and packages the head and base repos and the diff between them for a delta scan, or
+// reuses the tarballs from an earlier scan of the same commits
+//
+//gocyclo:ignore
+func (h *Client) packageDeltaScan(
+	ctx context.Context,
+	challengeInputs ChallengeConfig,
+	roundID string,
+	workDir string,
+	tarsDir string,
+) (*packagedChallenge, error) {
+	ctx, span := tracer.Start(ctx, "packageDeltaScan")
+	defer span.End()
+
+	source := challengeInputs.Source
+	resolvedHead, headCacheable := resolveForCache(
+		ctx,
+		source,
+		challengeInputs.RepoURL,
+		challengeInputs.HeadRef,
+	)
+	resolvedBase, baseCacheable := resolveForCache(
+		ctx,
+		source,
+		challengeInputs.RepoURL,
+		*challengeInputs.BaseRef,
+	)
+	cacheable := headCacheable && baseCacheable
+	if cacheable {
+		key := deltaScanCacheKey(challengeInputs.RepoURL, resolvedBase, resolvedHead)
+		entry := h.cachedPackage(ctx, roundID, packageKindDeltaScan, key)
+		if entry != nil {
+			span.SetStatus(codes.Ok, "")
+			span.RecordError(nil)
+			return packagedFromCache(entry), nil
+		}
+	}
+
+	baseReposOuterDir := path.Join(workDir, "baseRepos")
+
+	headRepoPath, err := source.FetchRepo(ctx, challengeInputs.RepoURL, workDir)
+	if err != nil {
+		span.SetStatus(codes.Error, "Failed to download repo to head repo path")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	// headRepoPath = /tmp/dir/foobar
+	// baseRepoPath = /tmp/dir/base-repos/foobar
+	baseRepoPath := path.Join(baseReposOuterDir, path.Base(headRepoPath))
+	span.SetAttributes(
+		attribute.String("baseRepoPath", baseRepoPath),
+		attribute.String("headRepoPath", headRepoPath),
+	)
+
+	span.AddEvent("creating repo dirs")
+	err = os.MkdirAll(baseRepoPath, 0700)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to create baseRepoPath")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	span.AddEvent("copying repo to baseRepoPath")
+	err = cp.Copy(headRepoPath, baseRepoPath, cp.Options{})
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to copy repo")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	err = checkoutRef(ctx, headRepoPath, challengeInputs.HeadRef)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to checkout head ref in head repo path")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	headCommit, err := refToCommit(ctx, headRepoPath, challengeInputs.HeadRef)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to convert head ref to commit")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	challengeYAML, err := types.ParseChallengeYAML(ctx, headRepoPath)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to parse challenge YAML")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	groundTruth, err := h.uploadGroundTruth(ctx, headRepoPath, challengeYAML.GroundTruth)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to upload ground truth")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	err = checkoutRef(ctx, baseRepoPath, *challengeInputs.BaseRef)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to checkout base ref in base repo path")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	baseCommit, err := refToCommit(ctx, baseRepoPath, *challengeInputs.BaseRef)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to convert base ref to commit")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	eg, egctx := errgroup.WithContext(ctx)
+	var unstrippedHeadTarPath string
+	eg.Go(func() error {
+		fail := source.Scrub(ctx, headRepoPath, challengeInputs.RepoURL)
+		if fail != nil {
+			return fail
+		}
+		uhtp, fail := packageRepo(egctx, headRepoPath, tarsDir)
+		if fail != nil {
+			return fail
+		}
+		unstrippedHeadTarPath = uhtp
+		return nil
+	})
+
+	var unstrippedBaseTarPath string
+	eg.Go(func() error {
+		fail := source.Scrub(ctx, baseRepoPath, challengeInputs.RepoURL)
+		if fail != nil {
+			return fail
+		}
+		ubtp, fail := packageRepo(egctx, baseRepoPath, tarsDir)
+		if fail != nil {
+			return fail
+		}
+		unstrippedBaseTarPath = ubtp
+		return nil
+	})
+	err = eg.Wait()
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to package unstripped repos")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	diffPath, err := generateDiff(
+		ctx,
+		workDir,
+		headRepoPath,
+		challengeInputs.HeadRef,
+		*challengeInputs.BaseRef,
+		"ref.diff",
+		stripRepoFiles,
+	)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to generate diff")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	err = stripRepo(ctx, stripRepoFiles, baseRepoPath)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to strip base repo")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	eg, egctx = errgroup.WithContext(ctx)
+	var strippedRepoTarPath string
+	eg.Go(func() error {
+		srtp, fail := packageRepo(egctx, baseRepoPath, tarsDir)
+		if fail != nil {
+			return fail
+		}
+		strippedRepoTarPath = srtp
+		return nil
+	})
+	var diffTarPath string
+	eg.Go(func() error {
+		dtp, fail := packageRepo(egctx, diffPath, tarsDir)
+		if fail != nil {
+			return fail
+		}
+		diffTarPath = dtp
+		return nil
+	})
+	err = eg.Wait()
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to package repos")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	packaged := &packagedChallenge{
+		challengeYAML: challengeYAML,
+		// baseRepoPath = tmp/base-repos/foobar
+		// base = foobar
+		focus:       path.Base(baseRepoPath),
+		headCommit:  headCommit,
+		baseCommit:  baseCommit,
+		groundTruth: groundTruth,
+	}
+	err = packaged.upload(ctx, h.workingStore, map[types.ArchivedFile]string{
+		types.FileUnstrippedHeadTarball: unstrippedHeadTarPath,
+		types.FileUnstrippedBaseTarball: unstrippedBaseTarPath,
+		types.FileStrippedRepoTarball:   strippedRepoTarPath,
+		types.FileDiffTarball:           diffTarPath,
+	})
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to upload repo tarballs")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	if cacheable {
+		h.cachePackage(ctx, &models.PackageCacheEntry{
+			Key:         deltaScanCacheKey(challengeInputs.RepoURL, baseCommit, headCommit),
+			Kind:        packageKindDeltaScan,
+			Blobs:       packaged.blobs,
+			Challenge:   challengeYAML,
+			GroundTruth: groundTruth,
+			Focus:       packaged.focus,
+			HeadCommit:  headCommit,
+			BaseCommit:  baseCommit,
+		})
+	}
+
+	span.SetStatus(codes.Ok, "")
+	span.RecordError(nil)
+	return packaged, nil
+}
+
+// Fetches and packages the fuzz tooling named in challenge.yaml into `packaged`, or reuses the
+// tarball from an earlier scan of the same fuzz tooling commit
+func (h *Client) packageFuzzTooling(
+	ctx context.Context,
+	source ChallengeSource,
+	roundID string,
+	packaged *packagedChallenge,
+	workDir string,
+	tarsDir string,
+) error {
+	ctx, span := tracer.Start(ctx, "packageFuzzTooling", trace.WithAttributes(
+		attribute.String("fuzzTooling.url", packaged.challengeYAML.FuzzToolingURL),
+		attribute.String("fuzzTooling.ref", packaged.challengeYAML.FuzzToolingRef),
+	))
+	defer span.End()
+
+	fuzzToolingURL := packaged.challengeYAML.FuzzToolingURL
+	ref := packaged.challengeYAML.FuzzToolingRef
+
+	commit, cacheable := resolveForCache(ctx, source, fuzzToolingURL, ref)
+	if cacheable {
+		key := fuzzToolingCacheKey(fuzzToolingURL, commit)
+		entry := h.cachedPackage(ctx, roundID, packageKindFuzzTooling, key)
+		if entry != nil {
+			packaged.blobs[types.FileOSSFuzzTarball] = entry.Blobs[types.FileOSSFuzzTarball]
+			span.SetStatus(codes.Ok, "")
+			span.RecordError(nil)
+			return nil
+		}
+	}
+
+	ossFuzzPath, err := source.FetchFuzzTooling(ctx, fuzzToolingURL, ref, workDir)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to download fuzz tooling")
+		span.RecordError(err)
+		return err
+	}
+
+	err = stripRepo(ctx, stripRepoFiles, ossFuzzPath)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to strip fuzz tooling repo")
+		span.RecordError(err)
+		return err
+	}
+
+	span.AddEvent("rename oss-fuzz directory to fuzz-tooling")
+	newOssFuzzPath := path.Join(path.Dir(ossFuzzPath), "fuzz-tooling")
+	err = os.Rename(ossFuzzPath, newOssFuzzPath)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to rename oss-fuzz directory")
+		span.RecordError(err)
+		return err
+	}
+	ossFuzzPath = newOssFuzzPath
+
+	ossFuzzTarPath, err := packageRepo(ctx, ossFuzzPath, tarsDir)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to package fuzz tooling repo")
+		span.RecordError(err)
+		return err
+	}
+
+	err = packaged.upload(ctx, h.workingStore, map[types.ArchivedFile]string{
+		types.FileOSSFuzzTarball: ossFuzzTarPath,
+	})
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to upload fuzz tooling tarball")
+		span.RecordError(err)
+		return err
+	}
+
+	if cacheable {
+		h.cachePackage(ctx, &models.PackageCacheEntry{
+			Key:  fuzzToolingCacheKey(fuzzToolingURL, commit),
+			Kind: packageKindFuzzTooling,
+			Blobs: map[types.ArchivedFile]string{
+				types.FileOSSFuzzTarball: packaged.blobs[types.FileOSSFuzzTarball],
+			},
+			HeadCommit: commit,
+		})
+	}
+
+	span.SetStatus(codes.Ok, "")
+	span.RecordError(nil)
+	return nil
+}
@@ -0,0 +1,149 @@
+package challenges
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm/clause"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/models"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/audit"
+)
+
+const (
+	packageKindFullScan    = "full_scan"
+	packageKindDeltaScan   = "delta_scan"
+	packageKindFuzzTooling = "fuzz_tooling"
+)
+
+// Content address for a packaged challenge. The strip list is part of every key so changing it
+// never hands out tarballs stripped differently.
+func packageCacheKey(kind string, parts ...string) string {
+	hash := sha256.Sum256([]byte(strings.Join(append([]string{kind}, parts...), "\n")))
+	return hex.EncodeToString(hash[:])
+}
+
+func fullScanCacheKey(repoURL, headCommit string) string {
+	return packageCacheKey(
+		packageKindFullScan,
+		repoURL,
+		headCommit,
+		strings.Join(stripRepoFiles, ","),
+	)
+}
+
+func deltaScanCacheKey(repoURL, baseCommit, headCommit string) string {
+	return packageCacheKey(
+		packageKindDeltaScan,
+		repoURL,
+		baseCommit,
+		headCommit,
+		strings.Join(stripRepoFiles, ","),
+	)
+}
+
+func fuzzToolingCacheKey(fuzzToolingURL, commit string) string {
+	return packageCacheKey(
+		packageKindFuzzTooling,
+		fuzzToolingURL,
+		commit,
+		strings.Join(stripRepoFiles, ","),
+	)
+}
+
+// Looks up a packaged challenge by key. Entries whose blobs are gone from the working store or
+// the archive are treated as misses, as are any errors looking them up.
+func (h *Client) cachedPackage(
+	ctx context.Context,
+	roundID string,
+	kind string,
+	key string,
+) *models.PackageCacheEntry {
+	ctx, span := tracer.Start(ctx, "cachedPackage", trace.WithAttributes(
+		attribute.String("package.kind", kind),
+		attribute.String("package.key", key),
+	))
+	defer span.End()
+
+	miss := func(reason string) *models.PackageCacheEntry {
+		span.AddEvent("package_cache_miss", trace.WithAttributes(
+			attribute.String("reason", reason),
+		))
+		audit.LogPackageCache(audit.Context{RoundID: roundID}, kind, key, false)
+		span.SetStatus(codes.Ok, "")
+		span.RecordError(nil)
+		return nil
+	}
+
+	var entries []models.PackageCacheEntry
+	err := h.db.WithContext(ctx).Where("key = ?", key).Limit(1).Find(&entries).Error
+	if err != nil {
+		span.RecordError(err)
+		return miss("failed to query package cache")
+	}
+	if len(entries) == 0 {
+		return miss("not found")
+	}
+	entry := &entries[0]
+
+	for file, blob := range entry.Blobs {
+		exists, err := h.workingStore.Exists(ctx, blob)
+		if err != nil || !exists {
+			span.RecordError(err)
+			return miss("blob missing from working store: " + string(file))
+		}
+
+		exists, err = h.archiver.Exists(ctx, blob)
+		if err != nil || !exists {
+			span.RecordError(err)
+			return miss("blob missing from archive: " + string(file))
+		}
+	}
+
+	for _, truth := range entry.GroundTruth {
+		for _, blob := range []string{truth.POVBlob, truth.PatchBlob} {
+			if blob == "" {
+				continue
+			}
+
+			exists, err := h.workingStore.Exists(ctx, blob)
+			if err != nil || !exists {
+				span.RecordError(err)
+				return miss("ground truth blob missing from working store")
+			}
+		}
+	}
+
+	span.AddEvent("package_cache_hit")
+	audit.LogPackageCache(audit.Context{RoundID: roundID}, kind, key, true)
+	span.SetStatus(codes.Ok, "")
+	span.RecordError(nil)
+	return entry
+}
+
+// Stores a packaged challenge for later scans. Failing to cache never fails tasking.
+func (h *Client) cachePackage(ctx context.Context, entry *models.PackageCacheEntry) {
+	ctx, span := tracer.Start(ctx, "cachePackage", trace.WithAttributes(
+		attribute.String("package.kind", entry.Kind),
+		attribute.String("package.key", entry.Key),
+	))
+	defer span.End()
+
+	err := h.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		UpdateAll: true,
+	}).Create(entry).Error
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to cache package")
+		return
+	}
+
+	span.SetStatus(codes.Ok, "")
+	span.RecordError(nil)
+}
@@ -0,0 +1,54 @@
+package challenges
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+)
+
+func TestPackageCacheKey(t *testing.T) {
+	key := fullScanCacheKey("https://github.com/foo/bar.git", "abc")
+	assert.Equal(t, key, fullScanCacheKey("https://github.com/foo/bar.git", "abc"))
+	assert.Len(t, key, 64)
+
+	assert.NotEqual(t, key, fullScanCacheKey("https://github.com/foo/bar.git", "def"))
+	assert.NotEqual(t, key, fuzzToolingCacheKey("https://github.com/foo/bar.git", "abc"))
+	assert.NotEqual(
+		t,
+		deltaScanCacheKey("https://github.com/foo/bar.git", "abc", "def"),
+		deltaScanCacheKey("https://github.com/foo/bar.git", "def", "abc"),
+	)
+}
+
+func TestPackagedChallengeArchiveFiles(t *testing.T) {
+	packaged := packagedChallenge{
+		paths: map[types.ArchivedFile]string{
+			types.FileStrippedRepoTarball: "/tmp/stripped.tar.gz",
+		},
+		blobs: map[types.ArchivedFile]string{
+			types.FileStrippedRepoTarball: "stripped",
+			types.FileOSSFuzzTarball:      "fuzz-tooling",
+		},
+	}
+
+	files := packaged.archiveFiles(
+		"task",
+		types.FileStrippedRepoTarball,
+		types.FileOSSFuzzTarball,
+	)
+	require.Len(t, files, 2)
+
+	assert.Equal(t, types.FileStrippedRepoTarball, files[0].ArchivedFile)
+	require.NotNil(t, files[0].LocalFilePath, "freshly packaged tarballs should be uploaded")
+	assert.Equal(t, "/tmp/stripped.tar.gz", *files[0].LocalFilePath)
+	assert.Nil(t, files[0].ObjectName)
+
+	assert.Equal(t, types.FileOSSFuzzTarball, files[1].ArchivedFile)
+	require.NotNil(t, files[1].ObjectName, "cached tarballs should only be logged")
+	assert.Equal(t, "fuzz-tooling", *files[1].ObjectName)
+	assert.Nil(t, files[1].LocalFilePath)
+	assert.Equal(t, "task", files[1].EntityID)
+}
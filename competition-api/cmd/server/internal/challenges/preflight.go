@@ -0,0 +1,352 @@
+package challenges
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/models"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/audit"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/upload"
+)
+
+var ErrPreflightFailed = errors.New("challenge failed pre-flight")
+
+// Returned when pre-flight blocks a task. The task is never stored, so the report travels with
+// the error for whoever released the challenge to keep.
+type PreflightBlockedError struct {
+	Report *types.PreflightReport
+	failed int
+}
+
+func (e *PreflightBlockedError) Error() string {
+	return fmt.Sprintf(
+		"%s: %d of %d checks failed",
+		ErrPreflightFailed,
+		e.failed,
+		len(e.Report.Checks),
+	)
+}
+
+func (e *PreflightBlockedError) Unwrap() error {
+	return ErrPreflightFailed
+}
+
+type preflightJob struct {
+	spec   *types.EvalSpec
+	result types.PreflightCheckResult
+	id     uuid.UUID
+}
+
+func (h *Client) preflightEnabled() bool {
+	return h.preflight != nil && h.preflight.Enabled
+}
+
+// Uploads the ground truth files listed in challenge.yaml so pre-flight jobs can fetch them.
+// Must be called before the .aixcc directory is stripped from the repo.
+func (h *Client) uploadGroundTruth(
+	ctx context.Context,
+	repoPath string,
+	groundTruth []types.GroundTruth,
+) ([]models.GroundTruthBlob, error) {
+	ctx, span := tracer.Start(ctx, "uploadGroundTruth", trace.WithAttributes(
+		attribute.String("repo.path", repoPath),
+		attribute.Int("groundTruth.count", len(groundTruth)),
+	))
+	defer span.End()
+
+	aixccDir := filepath.Join(repoPath, ".aixcc")
+	uploadFile := func(name string) (string, error) {
+		if !filepath.IsLocal(name) {
+			return "", fmt.Errorf("ground truth path escapes .aixcc: %s", name)
+		}
+		return upload.HashedFile(ctx, h.workingStore, filepath.Join(aixccDir, name))
+	}
+
+	blobs := make([]models.GroundTruthBlob, 0, len(groundTruth))
+	for _, truth := range groundTruth {
+		povBlob, err := uploadFile(truth.POV)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to upload ground truth pov")
+			return nil, err
+		}
+
+		var patchBlob string
+		if truth.Patch != "" {
+			patchBlob, err = uploadFile(truth.Patch)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, "failed to upload ground truth patch")
+				return nil, err
+			}
+		}
+
+		blobs = append(blobs, models.GroundTruthBlob{
+			Truth:     truth,
+			POVBlob:   povBlob,
+			PatchBlob: patchBlob,
+		})
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "uploaded ground truth")
+	return blobs, nil
+}
+
+// Runs the worker against a packaged task that has not been sent yet. Without ground truth the
+// challenge is only checked and built, otherwise every ground truth POV must crash and every
+// ground truth patch must fix it.
+//
+// The report is stored on the task. When pre-flight fails and blocking is configured a
+// [PreflightBlockedError] is returned and the task must not be sent.
+func (h *Client) preflightTask(
+	ctx context.Context,
+	challengeName string,
+	task *models.Task,
+	cpus int,
+	groundTruth []models.GroundTruthBlob,
+) error {
+	ctx, span := tracer.Start(ctx, "preflightTask", trace.WithAttributes(
+		attribute.String("challenge.name", challengeName),
+		attribute.Int("groundTruth.count", len(groundTruth)),
+	))
+	defer span.End()
+
+	expiration := h.preflight.Timeout + time.Hour
+	sources, err := task.GetSourceURLs(ctx, h.workingStore, expiration)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to get presigned source urls")
+		return err
+	}
+
+	// the job ids are filled in once the job rows exist
+	newSpec := func() *types.EvalSpec {
+		spec := models.NewEvalSpec(task, sources, types.JobTypeJob, "")
+		spec.ExportResults = true
+		return spec
+	}
+
+	var preflightJobs []preflightJob
+	if len(groundTruth) == 0 {
+		spec := newSpec()
+		spec.Build = true
+		preflightJobs = append(preflightJobs, preflightJob{
+			result: types.PreflightCheckResult{Check: types.PreflightCheckBuild},
+			spec:   spec,
+		})
+	}
+
+	for _, truth := range groundTruth {
+		triggerURL, err := h.workingStore.PresignedReadURL(ctx, truth.POVBlob, expiration)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to get presigned url for ground truth pov")
+			return err
+		}
+
+		pov := types.NewEvalPOV(
+			triggerURL,
+			truth.POVBlob,
+			truth.Truth.Sanitizer,
+			truth.Truth.Harness,
+			types.FuzzingEngine(truth.Truth.Engine),
+		)
+
+		spec := newSpec()
+		spec.POV = pov
+		preflightJobs = append(preflightJobs, preflightJob{
+			result: types.PreflightCheckResult{
+				Check:       types.PreflightCheckPOV,
+				GroundTruth: truth.Truth.POV,
+			},
+			spec: spec,
+		})
+
+		if truth.PatchBlob == "" {
+			continue
+		}
+
+		patchURL, err := h.workingStore.PresignedReadURL(ctx, truth.PatchBlob, expiration)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to get presigned url for ground truth patch")
+			return err
+		}
+
+		spec = newSpec()
+		spec.POV = pov
+		spec.Patch = types.NewEvalPatch(patchURL, truth.PatchBlob, false, task.PatchPolicy)
+		preflightJobs = append(preflightJobs, preflightJob{
+			result: types.PreflightCheckResult{
+				Check:       types.PreflightCheckPatch,
+				GroundTruth: truth.Truth.Patch,
+			},
+			spec: spec,
+		})
+	}
+
+	db := h.db.WithContext(ctx)
+	for i := range preflightJobs {
+		spec := preflightJobs[i].spec
+		job := models.Job{}
+		err = db.Create(&job).Error
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to create pre-flight job row")
+			return err
+		}
+
+		jobID := job.ID.String()
+		preflightJobs[i].id = job.ID
+		preflightJobs[i].result.JobID = jobID
+		spec.EntityID = jobID
+
+		err = db.Model(&job).Updates(&models.Job{EvalSpec: spec}).Error
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to store pre-flight eval spec")
+			return err
+		}
+
+		span.AddEvent("starting pre-flight job", trace.WithAttributes(
+			attribute.String("job.id", jobID),
+			attribute.String("check", string(preflightJobs[i].result.Check)),
+		))
+		_, err = h.jobClient.CreateEvalJob(
+			ctx,
+			spec,
+			task.MemoryGB,
+			cpus,
+			&task.RoundID,
+			nil,
+			nil,
+		)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to start pre-flight job")
+			return err
+		}
+	}
+
+	err = h.waitForPreflightJobs(ctx, preflightJobs)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to wait for pre-flight jobs")
+		return err
+	}
+
+	report := &types.PreflightReport{
+		Checks: make([]types.PreflightCheckResult, 0, len(preflightJobs)),
+		Passed: true,
+	}
+	failed := 0
+	for _, job := range preflightJobs {
+		if !job.result.Passed {
+			failed++
+			report.Passed = false
+		}
+		report.Checks = append(report.Checks, job.result)
+	}
+	report.Blocked = !report.Passed && h.preflight.Block
+	task.Preflight = report
+
+	span.SetAttributes(
+		attribute.Bool("preflight.passed", report.Passed),
+		attribute.Bool("preflight.blocked", report.Blocked),
+		attribute.Int("preflight.failed", failed),
+	)
+	audit.LogChallengePreflight(audit.Context{RoundID: task.RoundID}, challengeName, report)
+
+	if report.Blocked {
+		err = &PreflightBlockedError{Report: report, failed: failed}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "challenge failed pre-flight")
+		return err
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "ran pre-flight")
+	return nil
+}
+
+// Polls the job rows until every pre-flight job has finished or the pre-flight timeout passes,
+// judging each job as it finishes
+func (h *Client) waitForPreflightJobs(ctx context.Context, preflightJobs []preflightJob) error {
+	ctx, span := tracer.Start(ctx, "waitForPreflightJobs")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, h.preflight.Timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(h.preflight.PollInterval)
+	defer ticker.Stop()
+
+	pending := len(preflightJobs)
+	for pending > 0 {
+		select {
+		case <-ctx.Done():
+			span.AddEvent("hit_timeout", trace.WithAttributes(attribute.Int("pending", pending)))
+			for i := range preflightJobs {
+				if preflightJobs[i].result.Status == "" {
+					preflightJobs[i].result.Status = types.SubmissionStatusInconclusive
+					preflightJobs[i].result.Reason = "timed out waiting for job"
+				}
+			}
+
+			span.RecordError(nil)
+			span.SetStatus(codes.Ok, "timed out waiting for pre-flight jobs")
+			return nil
+		case <-ticker.C:
+		}
+
+		pending = 0
+		for i := range preflightJobs {
+			if preflightJobs[i].result.Status != "" {
+				continue
+			}
+
+			job, err := models.ByID[models.Job](ctx, h.db.WithContext(ctx), preflightJobs[i].id)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, "failed to fetch pre-flight job")
+				return err
+			}
+
+			if job.Status == types.SubmissionStatusAccepted {
+				pending++
+				continue
+			}
+
+			judgePreflightJob(&preflightJobs[i].result, job)
+		}
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "pre-flight jobs finished")
+	return nil
+}
+
+// Every check expects its job to pass
+func judgePreflightJob(result *types.PreflightCheckResult, job *models.Job) {
+	result.Status = job.Status
+	result.FunctionalityTestsPassing = models.PtrFromNull(job.FunctionalityTestsPassing)
+	result.Passed = job.Status == types.SubmissionStatusPassed
+	if result.Passed {
+		return
+	}
+
+	result.Reason = fmt.Sprintf("expected %s, got %s", types.SubmissionStatusPassed, job.Status)
+	if result.FunctionalityTestsPassing != nil && !*result.FunctionalityTestsPassing {
+		result.Reason += " (functionality tests failed)"
+	}
+}
@@ -0,0 +1,108 @@
+package challenges
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/models"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+	mockuploader "github.com/aixcyberchallenge/competition-api/competition-api/internal/upload/mock"
+)
+
+func TestJudgePreflightJob(t *testing.T) {
+	for name, tc := range map[string]struct {
+		reason string
+		job    models.Job
+		passed bool
+	}{
+		"Passed": {
+			job:    models.Job{Status: types.SubmissionStatusPassed},
+			passed: true,
+		},
+		"Failed": {
+			job:    models.Job{Status: types.SubmissionStatusFailed},
+			reason: "expected passed, got failed",
+		},
+		"TestsFailed": {
+			job: models.Job{
+				Status:                    types.SubmissionStatusFailed,
+				FunctionalityTestsPassing: models.NewNullFromData(false),
+			},
+			reason: "expected passed, got failed (functionality tests failed)",
+		},
+		"Errored": {
+			job:    models.Job{Status: types.SubmissionStatusErrored},
+			reason: "expected passed, got errored",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			result := types.PreflightCheckResult{Check: types.PreflightCheckPatch}
+			judgePreflightJob(&result, &tc.job)
+
+			assert.Equal(t, tc.passed, result.Passed)
+			assert.Equal(t, tc.reason, result.Reason)
+			assert.Equal(t, tc.job.Status, result.Status)
+		})
+	}
+}
+
+func TestPreflightBlockedError(t *testing.T) {
+	report := &types.PreflightReport{
+		Checks: []types.PreflightCheckResult{
+			{Check: types.PreflightCheckPOV, Passed: true},
+			{Check: types.PreflightCheckPatch},
+		},
+		Blocked: true,
+	}
+	err := fmt.Errorf("failed to run full scan: %w", &PreflightBlockedError{Report: report, failed: 1})
+
+	require.ErrorIs(t, err, ErrPreflightFailed)
+	var blocked *PreflightBlockedError
+	require.ErrorAs(t, err, &blocked, "the report should be reachable through wrapping")
+	assert.Same(t, report, blocked.Report)
+	assert.Equal(t, "challenge failed pre-flight: 1 of 2 checks failed", blocked.Error())
+}
+
+func TestUploadGroundTruth(t *testing.T) {
+	ctx := context.Background()
+	repoPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(path.Join(repoPath, ".aixcc", "povs"), 0700))
+	require.NoError(t, os.WriteFile(path.Join(repoPath, ".aixcc", "povs", "crash"), []byte("c"), 0600))
+
+	ctrl := gomock.NewController(t)
+	uploader := mockuploader.NewMockUploader(ctrl)
+	uploader.EXPECT().Exists(gomock.Any(), gomock.Any()).Return(true, nil).AnyTimes()
+
+	h := Client{workingStore: uploader}
+
+	t.Run("Valid", func(t *testing.T) {
+		blobs, err := h.uploadGroundTruth(ctx, repoPath, []types.GroundTruth{
+			{POV: "povs/crash", Harness: "fuzz", Sanitizer: "address"},
+		})
+		require.NoError(t, err)
+		require.Len(t, blobs, 1)
+		assert.NotEmpty(t, blobs[0].POVBlob)
+		assert.Empty(t, blobs[0].PatchBlob)
+	})
+
+	t.Run("Escapes", func(t *testing.T) {
+		_, err := h.uploadGroundTruth(ctx, repoPath, []types.GroundTruth{
+			{POV: "../../etc/passwd", Harness: "fuzz", Sanitizer: "address"},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		_, err := h.uploadGroundTruth(ctx, repoPath, []types.GroundTruth{
+			{POV: "povs/crash", Patch: "patches/missing.diff", Harness: "fuzz", Sanitizer: "address"},
+		})
+		assert.Error(t, err)
+	})
+}
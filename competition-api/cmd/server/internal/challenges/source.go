@@ -0,0 +1,706 @@
+package challenges
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/config"
+)
+
+// Kinds of challenge sources that can be configured
+const (
+	ChallengeSourceGitHub  = "github"
+	ChallengeSourceGit     = "git"
+	ChallengeSourceLocal   = "local"
+	ChallengeSourceTarball = "tarball"
+)
+
+// Refs tarball sources create in the challenge repo they extract. The repo tarball is committed as
+// the base ref and the diff tarball, if any, is applied on top of it as the head ref.
+const (
+	TarballBaseRef = "base"
+	TarballHeadRef = "head"
+)
+
+// Returned by ResolveRef when a source can only tell which commit a ref is at by fetching it
+var ErrRefNotResolvable = errors.New("ref can not be resolved without fetching")
+
+// Provides the git repositories that make up a challenge
+type ChallengeSource interface {
+	// Places a git repo for the challenge repo at `repoURL` in a directory named after the repo
+	// under `downloadPath` and returns its path
+	FetchRepo(ctx context.Context, repoURL string, downloadPath string) (string, error)
+	// Places the fuzz tooling at `fuzzToolingURL` with `ref` checked out under `downloadPath` and
+	// returns its path
+	FetchFuzzTooling(
+		ctx context.Context,
+		fuzzToolingURL string,
+		ref string,
+		downloadPath string,
+	) (string, error)
+	// Removes anything only needed to fetch from a repo fetched with FetchRepo, such as
+	// credentials, so it can be packaged and handed out
+	Scrub(ctx context.Context, repoPath string, repoURL string) error
+	// Returns the commit `ref` points at in the repo at `repoURL` without fetching the repo, or
+	// ErrRefNotResolvable
+	ResolveRef(ctx context.Context, repoURL string, ref string) (string, error)
+}
+
+// Builds the source described by `cfg`. GitHub sources need an installation token so they are
+// built by callers with NewHTTPSGitSource instead.
+func NewSourceFromConfig(cfg *config.ChallengeSourceConfig) (ChallengeSource, error) {
+	switch cfg.Type {
+	case ChallengeSourceGit:
+		if cfg.SSHKeyPath != "" {
+			return NewSSHGitSource(cfg.Username, cfg.SSHKeyPath, cfg.SSHKeyPassword)
+		}
+		return NewHTTPSGitSource(cfg.Username, cfg.Password), nil
+	case ChallengeSourceLocal:
+		return NewLocalGitSource(cfg.Dir), nil
+	case ChallengeSourceTarball:
+		return NewTarballSource(cfg.RepoTarball, cfg.FuzzToolingTarball, cfg.DiffTarball), nil
+	default:
+		return nil, fmt.Errorf("unsupported challenge source type: %q", cfg.Type)
+	}
+}
+
+// Clones challenges from a git remote over HTTPS or SSH
+type GitRemoteSource struct {
+	auth transport.AuthMethod
+	// only set for SSH remotes so git-lfs can use the same key as the clone
+	sshKeyPath string
+}
+
+var _ ChallengeSource = (*GitRemoteSource)(nil)
+
+// Clones over HTTPS. Empty credentials clone anonymously.
+func NewHTTPSGitSource(username string, password string) *GitRemoteSource {
+	if username == "" && password == "" {
+		return &GitRemoteSource{}
+	}
+
+	return &GitRemoteSource{
+		auth: &githttp.BasicAuth{
+			Username: username,
+			Password: password,
+		},
+	}
+}
+
+// Clones over SSH with the private key at `keyPath`
+func NewSSHGitSource(user string, keyPath string, keyPassword string) (*GitRemoteSource, error) {
+	if user == "" {
+		user = "git"
+	}
+
+	auth, err := gitssh.NewPublicKeysFromFile(user, keyPath, keyPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ssh key: %w", err)
+	}
+
+	return &GitRemoteSource{auth: auth, sshKeyPath: keyPath}, nil
+}
+
+func (s *GitRemoteSource) basicAuth() *githttp.BasicAuth {
+	auth, ok := s.auth.(*githttp.BasicAuth)
+	if !ok {
+		return nil
+	}
+	return auth
+}
+
+// Auth to reach `repoURL` with. An SSH key is only offered to SSH remotes, others are reached
+// anonymously.
+func (s *GitRemoteSource) authFor(repoURL string) transport.AuthMethod {
+	if s.sshKeyPath != "" && !isSSHURL(repoURL) {
+		return nil
+	}
+	return s.auth
+}
+
+// Whether `repoURL` is reached with the SSH key of the source
+func (s *GitRemoteSource) usesSSH(repoURL string) bool {
+	return s.sshKeyPath != "" && isSSHURL(repoURL)
+}
+
+// Whether `repoURL` is an SSH remote, including scp-like ones such as git@github.com:org/repo
+func isSSHURL(repoURL string) bool {
+	endpoint, err := transport.NewEndpoint(repoURL)
+	return err == nil && endpoint.Protocol == "ssh"
+}
+
+func (s *GitRemoteSource) remoteURL(repoURL string) string {
+	if s.sshKeyPath != "" {
+		return repoURL
+	}
+	return replaceSSH(repoURL)
+}
+
+func (s *GitRemoteSource) FetchRepo(
+	ctx context.Context,
+	repoURL string,
+	downloadPath string,
+) (string, error) {
+	ctx, span := tracer.Start(ctx, "GitRemoteSource.FetchRepo", trace.WithAttributes(
+		attribute.String("repo.url", repoURL),
+		attribute.String("repo.downloadPath", downloadPath),
+	))
+	defer span.End()
+
+	repoURL = s.remoteURL(repoURL)
+
+	repoDir, err := makeRepoDir(ctx, repoURL, downloadPath)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to create repo dir")
+		span.RecordError(err)
+		return "", err
+	}
+
+	span.AddEvent("cloning repo")
+	_, err = git.PlainCloneContext(ctx, repoDir, false, &git.CloneOptions{
+		URL:  repoURL,
+		Auth: s.authFor(repoURL),
+	})
+	if err != nil {
+		span.SetStatus(codes.Error, "error cloning repo")
+		span.RecordError(err)
+		return "", err
+	}
+
+	if s.usesSSH(repoURL) {
+		span.AddEvent("setting up LFS-capable ssh command for origin")
+		_, _, err = command(
+			ctx,
+			"",
+			[]int{},
+			"git",
+			"-C",
+			repoDir,
+			"config",
+			"core.sshCommand",
+			fmt.Sprintf("ssh -i %s -o IdentitiesOnly=yes", s.sshKeyPath),
+		)
+	} else {
+		span.AddEvent("setting up LFS-capable authenticated URL for origin")
+		err = setRepoURL(ctx, repoDir, repoURL, s.basicAuth())
+	}
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to set up origin for LFS")
+		span.RecordError(err)
+		return "", err
+	}
+
+	span.SetStatus(codes.Ok, "")
+	span.RecordError(nil)
+	return repoDir, nil
+}
+
+func (s *GitRemoteSource) FetchFuzzTooling(
+	ctx context.Context,
+	fuzzToolingURL string,
+	ref string,
+	downloadPath string,
+) (string, error) {
+	return fetchAndCheckout(ctx, s, fuzzToolingURL, ref, downloadPath)
+}
+
+func (s *GitRemoteSource) Scrub(ctx context.Context, repoPath string, repoURL string) error {
+	ctx, span := tracer.Start(ctx, "GitRemoteSource.Scrub", trace.WithAttributes(
+		attribute.String("repo.path", repoPath),
+	))
+	defer span.End()
+
+	if s.usesSSH(repoURL) {
+		span.AddEvent("removing ssh command")
+		_, _, err := command(
+			ctx,
+			"",
+			[]int{},
+			"git",
+			"-C",
+			repoPath,
+			"config",
+			"--unset",
+			"core.sshCommand",
+		)
+		if err != nil {
+			span.SetStatus(codes.Error, "failed to remove ssh command")
+			span.RecordError(err)
+			return err
+		}
+
+		span.SetStatus(codes.Ok, "")
+		span.RecordError(nil)
+		return nil
+	}
+
+	err := setRepoURL(ctx, repoPath, repoURL, nil)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to set origin to unauthenticated URL")
+		span.RecordError(err)
+		return err
+	}
+
+	span.SetStatus(codes.Ok, "")
+	span.RecordError(nil)
+	return nil
+}
+
+// Lists the refs on the remote and returns the commit `ref` is at. Annotated tags are peeled to
+// the commit they point at.
+func (s *GitRemoteSource) ResolveRef(
+	ctx context.Context,
+	repoURL string,
+	ref string,
+) (string, error) {
+	ctx, span := tracer.Start(ctx, "GitRemoteSource.ResolveRef", trace.WithAttributes(
+		attribute.String("repo.url", repoURL),
+		attribute.String("ref", ref),
+	))
+	defer span.End()
+
+	if plumbing.IsHash(ref) {
+		span.SetStatus(codes.Ok, "ref is a commit")
+		span.RecordError(nil)
+		return ref, nil
+	}
+
+	repoURL = s.remoteURL(repoURL)
+	remote := git.NewRemote(memory.NewStorage(), &gitconfig.RemoteConfig{
+		Name: "origin",
+		URLs: []string{repoURL},
+	})
+
+	span.AddEvent("listing remote refs")
+	refs, err := remote.ListContext(ctx, &git.ListOptions{
+		Auth:          s.authFor(repoURL),
+		PeelingOption: git.AppendPeeled,
+	})
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to list remote refs")
+		span.RecordError(err)
+		return "", err
+	}
+
+	byName := make(map[string]string, len(refs))
+	for _, r := range refs {
+		byName[r.Name().String()] = r.Hash().String()
+	}
+
+	// same precedence as git rev-parse, peeled tags first so annotated tags give their commit
+	for _, name := range []string{
+		ref,
+		"refs/" + ref,
+		"refs/tags/" + ref + "^{}",
+		"refs/tags/" + ref,
+		"refs/heads/" + ref,
+	} {
+		if commit, ok := byName[name]; ok {
+			span.SetAttributes(attribute.String("commit", commit))
+			span.SetStatus(codes.Ok, "")
+			span.RecordError(nil)
+			return commit, nil
+		}
+	}
+
+	err = fmt.Errorf("ref not found on remote: %s", ref)
+	span.SetStatus(codes.Error, "ref not found")
+	span.RecordError(err)
+	return "", err
+}
+
+// Clones challenges from git repos on the local filesystem so challenges can be authored and
+// tested offline
+type LocalGitSource struct {
+	// repos are looked up in here by the name in their URL
+	dir string
+}
+
+var _ ChallengeSource = (*LocalGitSource)(nil)
+
+func NewLocalGitSource(dir string) *LocalGitSource {
+	return &LocalGitSource{dir: dir}
+}
+
+// Resolves `repoURL` to a local repo. Existing directories are used as is, anything else is
+// looked up in the source dir by repo name.
+func (s *LocalGitSource) resolve(ctx context.Context, repoURL string) (string, error) {
+	if info, err := os.Stat(repoURL); err == nil && info.IsDir() {
+		return repoURL, nil
+	}
+
+	repoName, err := extractRepoName(ctx, repoURL)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(s.dir, repoName), nil
+}
+
+func (s *LocalGitSource) FetchRepo(
+	ctx context.Context,
+	repoURL string,
+	downloadPath string,
+) (string, error) {
+	ctx, span := tracer.Start(ctx, "LocalGitSource.FetchRepo", trace.WithAttributes(
+		attribute.String("repo.url", repoURL),
+		attribute.String("repo.downloadPath", downloadPath),
+	))
+	defer span.End()
+
+	localPath, err := s.resolve(ctx, repoURL)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to resolve local repo")
+		span.RecordError(err)
+		return "", err
+	}
+	span.SetAttributes(attribute.String("repo.localPath", localPath))
+
+	repoDir, err := makeRepoDir(ctx, repoURL, downloadPath)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to create repo dir")
+		span.RecordError(err)
+		return "", err
+	}
+
+	span.AddEvent("cloning local repo")
+	_, _, err = command(ctx, "", []int{}, "git", "clone", "--quiet", localPath, repoDir)
+	if err != nil {
+		span.SetStatus(codes.Error, "error cloning local repo")
+		span.RecordError(err)
+		return "", err
+	}
+
+	span.SetStatus(codes.Ok, "")
+	span.RecordError(nil)
+	return repoDir, nil
+}
+
+func (s *LocalGitSource) FetchFuzzTooling(
+	ctx context.Context,
+	fuzzToolingURL string,
+	ref string,
+	downloadPath string,
+) (string, error) {
+	return fetchAndCheckout(ctx, s, fuzzToolingURL, ref, downloadPath)
+}
+
+// Points origin at `repoURL` instead of the local path it was cloned from
+func (s *LocalGitSource) Scrub(ctx context.Context, repoPath string, repoURL string) error {
+	return setRepoURL(ctx, repoPath, repoURL, nil)
+}
+
+func (s *LocalGitSource) ResolveRef(
+	ctx context.Context,
+	repoURL string,
+	ref string,
+) (string, error) {
+	ctx, span := tracer.Start(ctx, "LocalGitSource.ResolveRef", trace.WithAttributes(
+		attribute.String("repo.url", repoURL),
+		attribute.String("ref", ref),
+	))
+	defer span.End()
+
+	localPath, err := s.resolve(ctx, repoURL)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to resolve local repo")
+		span.RecordError(err)
+		return "", err
+	}
+
+	commit, err := refToCommit(ctx, localPath, ref)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to resolve ref")
+		span.RecordError(err)
+		return "", err
+	}
+
+	span.SetStatus(codes.Ok, "")
+	span.RecordError(nil)
+	return commit, nil
+}
+
+// Builds challenges from pre-built tarballs so events can run without access to any git remote.
+//
+// The repo tarball holds the unstripped challenge repo at the base of a delta challenge, or at the
+// head of a full challenge, in a single top level directory. The diff tarball holds the diff a
+// delta challenge applies to it. The fuzz tooling tarball holds the fuzz tooling.
+type TarballSource struct {
+	diffTarball        *string
+	repoTarball        string
+	fuzzToolingTarball string
+}
+
+var _ ChallengeSource = (*TarballSource)(nil)
+
+func NewTarballSource(
+	repoTarball string,
+	fuzzToolingTarball string,
+	diffTarball *string,
+) *TarballSource {
+	return &TarballSource{
+		repoTarball:        repoTarball,
+		fuzzToolingTarball: fuzzToolingTarball,
+		diffTarball:        diffTarball,
+	}
+}
+
+// Extracts the repo tarball into a git repo with the repo committed as TarballBaseRef and the diff
+// applied on top as TarballHeadRef. Without a diff both refs point to the same commit.
+func (s *TarballSource) FetchRepo(
+	ctx context.Context,
+	_ string,
+	downloadPath string,
+) (string, error) {
+	ctx, span := tracer.Start(ctx, "TarballSource.FetchRepo", trace.WithAttributes(
+		attribute.String("tarball.repo", s.repoTarball),
+		attribute.String("repo.downloadPath", downloadPath),
+	))
+	defer span.End()
+
+	repoDir, err := extractTarball(ctx, s.repoTarball, downloadPath)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to extract repo tarball")
+		span.RecordError(err)
+		return "", err
+	}
+
+	if _, err := os.Stat(filepath.Join(repoDir, ".git")); os.IsNotExist(err) {
+		span.AddEvent("initializing git repo")
+		_, _, err = command(ctx, "", []int{}, "git", "-C", repoDir, "init", "--quiet")
+		if err != nil {
+			span.SetStatus(codes.Error, "failed to initialize git repo")
+			span.RecordError(err)
+			return "", err
+		}
+	}
+
+	err = commitAndTag(ctx, repoDir, TarballBaseRef)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to commit base")
+		span.RecordError(err)
+		return "", err
+	}
+
+	if s.diffTarball != nil {
+		span.AddEvent("applying diff", trace.WithAttributes(
+			attribute.String("tarball.diff", *s.diffTarball),
+		))
+		var diffDir string
+		diffDir, err = extractTarball(ctx, *s.diffTarball, downloadPath)
+		if err != nil {
+			span.SetStatus(codes.Error, "failed to extract diff tarball")
+			span.RecordError(err)
+			return "", err
+		}
+		defer os.RemoveAll(diffDir)
+
+		var diffs []string
+		diffs, err = filepath.Glob(filepath.Join(diffDir, "*.diff"))
+		if err != nil {
+			span.SetStatus(codes.Error, "failed to find diff")
+			span.RecordError(err)
+			return "", err
+		}
+		if len(diffs) != 1 {
+			err = fmt.Errorf(
+				"diff tarball must contain exactly one .diff file, found %d",
+				len(diffs),
+			)
+			span.SetStatus(codes.Error, "failed to find diff")
+			span.RecordError(err)
+			return "", err
+		}
+
+		_, _, err = command(ctx, "", []int{}, "git", "-C", repoDir, "apply", diffs[0])
+		if err != nil {
+			span.SetStatus(codes.Error, "failed to apply diff")
+			span.RecordError(err)
+			return "", err
+		}
+
+		err = commitAndTag(ctx, repoDir, TarballHeadRef)
+	} else {
+		_, _, err = command(ctx, "", []int{}, "git", "-C", repoDir, "tag", TarballHeadRef)
+	}
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to create head")
+		span.RecordError(err)
+		return "", err
+	}
+
+	span.SetStatus(codes.Ok, "")
+	span.RecordError(nil)
+	return repoDir, nil
+}
+
+// Extracts the fuzz tooling tarball. The tarball is already at the ref the challenge wants.
+func (s *TarballSource) FetchFuzzTooling(
+	ctx context.Context,
+	_ string,
+	_ string,
+	downloadPath string,
+) (string, error) {
+	return extractTarball(ctx, s.fuzzToolingTarball, downloadPath)
+}
+
+// Tarball repos never had a remote so there is nothing to scrub
+func (*TarballSource) Scrub(context.Context, string, string) error {
+	return nil
+}
+
+// Tarball refs are commits made when the tarball is extracted so they are never known up front
+func (*TarballSource) ResolveRef(context.Context, string, string) (string, error) {
+	return "", ErrRefNotResolvable
+}
+
+// Creates the directory a repo named in `repoURL` is fetched into
+func makeRepoDir(ctx context.Context, repoURL string, downloadPath string) (string, error) {
+	ctx, span := tracer.Start(ctx, "makeRepoDir")
+	defer span.End()
+
+	repoName, err := extractRepoName(ctx, repoURL)
+	if err != nil {
+		span.SetStatus(codes.Error, "error extracting repo name")
+		span.RecordError(err)
+		return "", err
+	}
+
+	repoDir := path.Join(downloadPath, repoName)
+	span.SetAttributes(attribute.String("repo.dir", repoDir))
+	span.AddEvent("creating repo dir")
+	err = os.Mkdir(repoDir, 0700)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to create repo dir")
+		span.RecordError(err)
+		return "", err
+	}
+
+	span.SetStatus(codes.Ok, "")
+	span.RecordError(nil)
+	return repoDir, nil
+}
+
+func fetchAndCheckout(
+	ctx context.Context,
+	source ChallengeSource,
+	repoURL string,
+	ref string,
+	downloadPath string,
+) (string, error) {
+	repoPath, err := source.FetchRepo(ctx, repoURL, downloadPath)
+	if err != nil {
+		return "", err
+	}
+
+	err = checkoutRef(ctx, repoPath, ref)
+	if err != nil {
+		return "", err
+	}
+
+	return repoPath, nil
+}
+
+// Extracts a tarball holding a single top level directory into `downloadPath` and returns the
+// path to that directory
+func extractTarball(ctx context.Context, tarball string, downloadPath string) (string, error) {
+	ctx, span := tracer.Start(ctx, "extractTarball", trace.WithAttributes(
+		attribute.String("tarball.path", tarball),
+		attribute.String("downloadPath", downloadPath),
+	))
+	defer span.End()
+
+	extractDir, err := os.MkdirTemp(downloadPath, "extract")
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to create extract dir")
+		span.RecordError(err)
+		return "", err
+	}
+	defer os.RemoveAll(extractDir)
+
+	span.AddEvent("extracting tarball")
+	_, _, err = command(ctx, "", []int{}, "tar", "-xzf", tarball, "-C", extractDir)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to extract tarball")
+		span.RecordError(err)
+		return "", err
+	}
+
+	entries, err := os.ReadDir(extractDir)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to read extract dir")
+		span.RecordError(err)
+		return "", err
+	}
+	if len(entries) != 1 || !entries[0].IsDir() {
+		err = errors.New("tarball must contain a single top level directory")
+		span.SetStatus(codes.Error, "unexpected tarball layout")
+		span.RecordError(err)
+		return "", err
+	}
+
+	outDir := filepath.Join(downloadPath, entries[0].Name())
+	span.SetAttributes(attribute.String("outDir", outDir))
+	err = os.Rename(filepath.Join(extractDir, entries[0].Name()), outDir)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to move extracted dir")
+		span.RecordError(err)
+		return "", err
+	}
+
+	span.SetStatus(codes.Ok, "")
+	span.RecordError(nil)
+	return outDir, nil
+}
+
+// Commits everything in the repo, including ignored files, and tags the commit with `tag`
+func commitAndTag(ctx context.Context, repoPath string, tag string) error {
+	ctx, span := tracer.Start(ctx, "commitAndTag", trace.WithAttributes(
+		attribute.String("repo.path", repoPath),
+		attribute.String("tag", tag),
+	))
+	defer span.End()
+
+	gitArgs := []string{
+		"-C", repoPath,
+		"-c", "user.name=competition-api",
+		"-c", "user.email=competition-api@localhost",
+		"-c", "commit.gpgsign=false",
+	}
+
+	for _, args := range [][]string{
+		{"add", "--all", "--force"},
+		{"commit", "--quiet", "--allow-empty", "--message", tag},
+		{"tag", tag},
+	} {
+		span.AddEvent("running git", trace.WithAttributes(
+			attribute.String("args", strings.Join(args, " ")),
+		))
+		_, _, err := command(ctx, "", []int{}, "git", append(gitArgs, args...)...)
+		if err != nil {
+			span.SetStatus(codes.Error, "failed to commit")
+			span.RecordError(err)
+			return err
+		}
+	}
+
+	span.SetStatus(codes.Ok, "")
+	span.RecordError(nil)
+	return nil
+}
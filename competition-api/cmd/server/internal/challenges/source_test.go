@@ -0,0 +1,180 @@
+package challenges
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path"
+	"testing"
+
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDiff = `diff --git a/a b/a
+--- a/a
++++ b/a
+@@ -1 +1 @@
+-1
++2
+`
+
+// makes `dir/name` holding a file `a` with `content` and returns its path
+func makeTestDir(t *testing.T, dir string, name string, content string) string {
+	repoPath := path.Join(dir, name)
+	require.NoError(t, os.Mkdir(repoPath, 0700))
+	require.NoError(t, os.WriteFile(path.Join(repoPath, "a"), []byte(content), 0600))
+	return repoPath
+}
+
+func readTestFile(t *testing.T, repoPath string) string {
+	content, err := os.ReadFile(path.Join(repoPath, "a"))
+	require.NoError(t, err, "failed to read file a")
+	return string(content)
+}
+
+func TestExtractRepoName(t *testing.T) {
+	for input, expected := range map[string]string{
+		"https://github.com/foo/bar.git": "bar",
+		"https://github.com/foo/bar":     "bar",
+		"git@github.com:foo/bar.git":     "bar",
+		"ssh://git@example.com/foo/bar":  "bar",
+		"/srv/challenges/bar":            "bar",
+	} {
+		t.Run(input, func(t *testing.T) {
+			name, err := extractRepoName(context.Background(), input)
+			require.NoError(t, err)
+			assert.Equal(t, expected, name)
+		})
+	}
+}
+
+func TestGitRemoteSourceAuth(t *testing.T) {
+	keyAuth := &gitssh.PublicKeys{User: "git"}
+	sshSource := &GitRemoteSource{auth: keyAuth, sshKeyPath: "/keys/id_ed25519"}
+	for repoURL, overSSH := range map[string]bool{
+		"git@github.com:foo/bar.git":       true,
+		"ssh://git@example.com/foo/bar":    true,
+		"https://github.com/foo/bar.git":   false,
+		"http://example.com/foo/bar.git":   false,
+		"https://example.com/oss-fuzz.git": false,
+	} {
+		assert.Equal(t, overSSH, sshSource.usesSSH(repoURL), repoURL)
+		if overSSH {
+			assert.Equal(t, keyAuth, sshSource.authFor(repoURL), repoURL)
+		} else {
+			assert.Nil(t, sshSource.authFor(repoURL), repoURL)
+		}
+	}
+
+	basicAuth := &githttp.BasicAuth{Username: "user", Password: "pass"}
+	httpsSource := &GitRemoteSource{auth: basicAuth}
+	assert.Equal(t, basicAuth, httpsSource.authFor("https://github.com/foo/bar.git"))
+	assert.False(t, httpsSource.usesSSH("git@github.com:foo/bar.git"))
+}
+
+func TestTarballSource(t *testing.T) {
+	ctx := context.Background()
+	srcDir := t.TempDir()
+	tarsDir := t.TempDir()
+
+	repoTarball, err := packageRepo(ctx, makeTestDir(t, srcDir, "bar", "1\n"), tarsDir)
+	require.NoError(t, err, "failed to package repo")
+
+	fuzzToolingTarball, err := packageRepo(ctx, makeTestDir(t, srcDir, "oss-fuzz", "f\n"), tarsDir)
+	require.NoError(t, err, "failed to package fuzz tooling")
+
+	diffDir := path.Join(srcDir, "diff")
+	require.NoError(t, os.Mkdir(diffDir, 0700))
+	require.NoError(t, os.WriteFile(path.Join(diffDir, "ref.diff"), []byte(testDiff), 0600))
+	diffTarball, err := packageRepo(ctx, diffDir, tarsDir)
+	require.NoError(t, err, "failed to package diff")
+
+	t.Run("Delta", func(t *testing.T) {
+		source := NewTarballSource(repoTarball, fuzzToolingTarball, &diffTarball)
+
+		repoPath, err := source.FetchRepo(ctx, "ignored", t.TempDir())
+		require.NoError(t, err, "failed to fetch repo")
+		assert.Equal(t, "bar", path.Base(repoPath), "repo dir should keep its name")
+
+		require.NoError(t, checkoutRef(ctx, repoPath, TarballBaseRef))
+		assert.Equal(t, "1\n", readTestFile(t, repoPath), "base should not have the diff")
+
+		require.NoError(t, checkoutRef(ctx, repoPath, TarballHeadRef))
+		assert.Equal(t, "2\n", readTestFile(t, repoPath), "head should have the diff")
+
+		require.NoError(t, source.Scrub(ctx, repoPath, "ignored"))
+	})
+
+	t.Run("Full", func(t *testing.T) {
+		source := NewTarballSource(repoTarball, fuzzToolingTarball, nil)
+
+		repoPath, err := source.FetchRepo(ctx, "ignored", t.TempDir())
+		require.NoError(t, err, "failed to fetch repo")
+
+		headCommit, err := refToCommit(ctx, repoPath, TarballHeadRef)
+		require.NoError(t, err)
+		baseCommit, err := refToCommit(ctx, repoPath, TarballBaseRef)
+		require.NoError(t, err)
+		assert.Equal(t, baseCommit, headCommit, "head and base should match without a diff")
+	})
+
+	t.Run("FuzzTooling", func(t *testing.T) {
+		source := NewTarballSource(repoTarball, fuzzToolingTarball, nil)
+
+		fuzzToolingPath, err := source.FetchFuzzTooling(ctx, "ignored", "ignored", t.TempDir())
+		require.NoError(t, err, "failed to fetch fuzz tooling")
+		assert.Equal(t, "f\n", readTestFile(t, fuzzToolingPath))
+	})
+
+	t.Run("ResolveRef", func(t *testing.T) {
+		source := NewTarballSource(repoTarball, fuzzToolingTarball, nil)
+
+		_, err := source.ResolveRef(ctx, "ignored", TarballHeadRef)
+		assert.ErrorIs(t, err, ErrRefNotResolvable, "tarballs have no stable commits")
+	})
+}
+
+func TestLocalGitSource(t *testing.T) {
+	ctx := context.Background()
+	reposDir := t.TempDir()
+
+	repoPath := makeTestDir(t, reposDir, "bar", "1\n")
+	require.NoError(t, exec.Command("git", "-C", repoPath, "init", "--quiet").Run())
+	require.NoError(t, commitAndTag(ctx, repoPath, "v1"))
+
+	source := NewLocalGitSource(reposDir)
+
+	t.Run("ByName", func(t *testing.T) {
+		clonePath, err := source.FetchRepo(ctx, "https://github.com/foo/bar.git", t.TempDir())
+		require.NoError(t, err, "failed to fetch repo")
+		assert.Equal(t, "1\n", readTestFile(t, clonePath))
+
+		require.NoError(t, source.Scrub(ctx, clonePath, "https://github.com/foo/bar.git"))
+		origin, err := exec.Command("git", "-C", clonePath, "remote", "get-url", "origin").Output()
+		require.NoError(t, err)
+		assert.Equal(t, "https://github.com/foo/bar.git\n", string(origin))
+	})
+
+	t.Run("ByPath", func(t *testing.T) {
+		clonePath, err := source.FetchFuzzTooling(ctx, repoPath, "v1", t.TempDir())
+		require.NoError(t, err, "failed to fetch fuzz tooling")
+		assert.Equal(t, "1\n", readTestFile(t, clonePath))
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		_, err := source.FetchRepo(ctx, "https://github.com/foo/missing.git", t.TempDir())
+		assert.Error(t, err, "missing repo should fail")
+	})
+
+	t.Run("ResolveRef", func(t *testing.T) {
+		expected, err := refToCommit(ctx, repoPath, "v1")
+		require.NoError(t, err)
+
+		commit, err := source.ResolveRef(ctx, "https://github.com/foo/bar.git", "v1")
+		require.NoError(t, err, "failed to resolve ref")
+		assert.Equal(t, expected, commit)
+	})
+}
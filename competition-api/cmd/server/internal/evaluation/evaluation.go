@@ -0,0 +1,186 @@
+package evaluation
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/jobs"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/models"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/upload"
+)
+
+var tracer = otel.Tracer(
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/evaluation",
+)
+
+// How long the presigned urls handed to an eval job stay valid
+const expiration = time.Hour * 100
+
+// Starts eval jobs for submissions
+type Client struct {
+	db                 *gorm.DB
+	jobClient          *jobs.KubernetesClient
+	submissionUploader upload.Uploader
+	sourcesUploader    upload.Uploader
+}
+
+func Create(
+	db *gorm.DB,
+	jobClient *jobs.KubernetesClient,
+	submissionUploader upload.Uploader,
+	sourcesUploader upload.Uploader,
+) *Client {
+	return &Client{
+		db:                 db,
+		jobClient:          jobClient,
+		submissionUploader: submissionUploader,
+		sourcesUploader:    sourcesUploader,
+	}
+}
+
+func (c *Client) StartPOV(
+	ctx context.Context,
+	task *models.Task,
+	pov *models.POVSubmission,
+) error {
+	ctx, span := tracer.Start(ctx, "StartPOV", trace.WithAttributes(
+		attribute.String("task.id", task.ID.String()),
+		attribute.String("pov.id", pov.ID.String()),
+	))
+	defer span.End()
+
+	span.AddEvent("getting presigned submission URL for job kickoff", trace.WithAttributes(
+		attribute.String("expiration", expiration.String()),
+	))
+	triggerURL, err := c.submissionUploader.PresignedReadURL(ctx, pov.TestcasePath, expiration)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get presigned url")
+		span.RecordError(err)
+		return err
+	}
+
+	span.AddEvent("getting presigned source URLs for job kickoff", trace.WithAttributes(
+		attribute.String("expiration", expiration.String()),
+	))
+	sources, err := task.GetSourceURLs(ctx, c.sourcesUploader, expiration)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get presigned url")
+		span.RecordError(err)
+		return err
+	}
+
+	spec := models.NewEvalSpec(task, sources, types.JobTypePOV, pov.ID.String())
+	spec.Architecture = types.Architecture(pov.Architecture)
+	// submission blobs are named by their sha256
+	spec.POV = types.NewEvalPOV(
+		triggerURL,
+		pov.TestcasePath,
+		pov.Sanitizer,
+		pov.FuzzerName,
+		types.FuzzingEngine(pov.Engine),
+	)
+	spec.ArchiveS3 = true
+
+	span.AddEvent("storing eval spec")
+	err = c.db.WithContext(ctx).Model(pov).Updates(&models.POVSubmission{EvalSpec: spec}).Error
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to store eval spec")
+		span.RecordError(err)
+		return err
+	}
+
+	taskID := task.ID.String()
+	submitterID := pov.SubmitterID.String()
+	span.AddEvent("starting job")
+	_, err = c.jobClient.CreateEvalJob(
+		ctx,
+		spec,
+		task.MemoryGB,
+		task.CPUs,
+		&task.RoundID,
+		&taskID,
+		&submitterID,
+	)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to start job")
+		span.RecordError(err)
+		return err
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "started pov job")
+	return nil
+}
+
+func (c *Client) StartPatch(
+	ctx context.Context,
+	task *models.Task,
+	patch *models.PatchSubmission,
+) error {
+	ctx, span := tracer.Start(ctx, "StartPatch", trace.WithAttributes(
+		attribute.String("task.id", task.ID.String()),
+		attribute.String("patch.id", patch.ID.String()),
+	))
+	defer span.End()
+
+	span.AddEvent("getting presigned submission URL for job kickoff", trace.WithAttributes(
+		attribute.String("expiration", expiration.String()),
+	))
+	patchURL, err := c.submissionUploader.PresignedReadURL(ctx, patch.PatchFilePath, expiration)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get presigned url")
+		span.RecordError(err)
+		return err
+	}
+
+	span.AddEvent("getting presigned source URLs for job kickoff", trace.WithAttributes(
+		attribute.String("expiration", expiration.String()),
+	))
+	sources, err := task.GetSourceURLs(ctx, c.sourcesUploader, expiration)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get presigned url")
+		span.RecordError(err)
+		return err
+	}
+
+	spec := models.NewEvalSpec(task, sources, types.JobTypePatch, patch.ID.String())
+	// submission blobs are named by their sha256
+	spec.Patch = types.NewEvalPatch(patchURL, patch.PatchFilePath, false, task.PatchPolicy)
+
+	span.AddEvent("storing eval spec")
+	err = c.db.WithContext(ctx).Model(patch).Updates(&models.PatchSubmission{EvalSpec: spec}).Error
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to store eval spec")
+		span.RecordError(err)
+		return err
+	}
+
+	taskID := task.ID.String()
+	submitterID := patch.SubmitterID.String()
+	span.AddEvent("starting job")
+	_, err = c.jobClient.CreateEvalJob(
+		ctx,
+		spec,
+		task.MemoryGB,
+		task.CPUs,
+		&task.RoundID,
+		&taskID,
+		&submitterID,
+	)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to start job")
+		span.RecordError(err)
+		return err
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "started patch job")
+	return nil
+}
@@ -14,6 +14,7 @@ import (
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -24,14 +25,17 @@ import (
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/config"
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/logger"
 	otelcompetitionapi "github.com/aixcyberchallenge/competition-api/competition-api/internal/otel"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/queue"
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
 )
 
 // Handles creating kubernetes jobs
 type KubernetesClient struct {
 	kubeClient *kubernetes.Clientset
-	config     *config.Config
-	namespace  string
+	// evaluation requests for worker pools, nil unless the eval mode is pool
+	evalQueue queue.Queuer
+	config    *config.Config
+	namespace string
 }
 
 const TeamLabel = "aixcc.tech/team"
@@ -69,11 +73,11 @@ func (jc *KubernetesClient) createJob(
 	return job, nil
 }
 
+// Starts evaluating `spec`, either as a kubernetes job or by queueing it for a worker pool. The
+// job is nil in pool mode.
 func (jc *KubernetesClient) CreateEvalJob(
 	ctx context.Context,
-	jobType types.JobType,
-	thingID string,
-	args []string,
+	spec *types.EvalSpec,
 	memoryGB int,
 	cpus int,
 	roundID *string,
@@ -83,6 +87,8 @@ func (jc *KubernetesClient) CreateEvalJob(
 	ctx, span := tracer.Start(ctx, "CreateJob")
 	defer span.End()
 
+	jobType := spec.Entity
+	thingID := spec.EntityID
 	annotations := map[string]string{
 		"aixcc.tech/job-type": string(jobType),
 	}
@@ -91,15 +97,41 @@ func (jc *KubernetesClient) CreateEvalJob(
 	span.SetAttributes(
 		attribute.String("jobType", string(jobType)),
 		attribute.String("thing.id", thingID),
-		attribute.StringSlice("args", args),
 		attribute.String("name", name),
 	)
-	l := logger.Logger.With("job_type", jobType, "name", name, "command", args)
+	l := logger.Logger.With("job_type", jobType, "name", name)
+
+	err := spec.Validate()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid eval spec")
+		return nil, err
+	}
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to marshal eval spec")
+		return nil, err
+	}
 
 	for k, v := range annotations {
 		l = l.With(k, v)
 	}
 
+	if jc.config.K8s.EvalMode == config.EvalModePool {
+		err = jc.enqueueEval(ctx, spec, taskID)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to enqueue eval")
+			return nil, err
+		}
+
+		l.InfoContext(ctx, "queued eval for worker pool")
+		span.RecordError(nil)
+		span.SetStatus(codes.Ok, "queued eval")
+		return nil, nil
+	}
+
 	evaluatorEnvVars := []corev1.EnvVar{
 		{
 			Name:  "AZURE_STORAGE_ACCOUNT_CONTAINERS_URL",
@@ -125,6 +157,10 @@ func (jc *KubernetesClient) CreateEvalJob(
 			Name:  "AZURE_STORAGE_ACCOUNT_RESULTS_QUEUE",
 			Value: jc.config.Azure.StorageAccount.Queues.Results,
 		},
+		{
+			Name:  "EVAL_SPEC",
+			Value: string(specJSON),
+		},
 	}
 
 	if roundID != nil {
@@ -222,7 +258,6 @@ func (jc *KubernetesClient) CreateEvalJob(
 		Name:        name,
 		Labels:      labels,
 		Annotations: annotations,
-		Args:        args,
 		Env:         evaluatorEnvVars,
 		Images: templates.EvaluateDataImages{
 			Job:  jc.config.K8s.JobImage,
@@ -238,8 +273,19 @@ func (jc *KubernetesClient) CreateEvalJob(
 			Key:   nodeAssignment.Toleration.Key,
 			Value: nodeAssignment.Toleration.Value,
 		},
-		DindMemoryGB: memoryGB,
-		DindCPUs:     cpus,
+		DindMemoryGB:  memoryGB,
+		DindCPUs:      cpus,
+		SimulateRules: jc.config.K8s.SimulateRules,
+	}
+
+	if fetchCache := jc.config.K8s.FetchCache; fetchCache != nil {
+		data.FetchCacheHostPath = fetchCache.HostPath
+		data.FetchCacheMaxBytes = fetchCache.MaxBytes
+	}
+
+	// only the unpatched build is the same for every submission against a task
+	if jc.config.K8s.BuildCache && jobType == types.JobTypePOV && taskID != nil {
+		data.BuildCacheKey = *taskID
 	}
 
 	job, err := jc.createJob(ctx, data.Render(ctx))
@@ -256,6 +302,47 @@ func (jc *KubernetesClient) CreateEvalJob(
 	return job, nil
 }
 
+// Queues the evaluation for a `worker serve` pod instead of creating a job
+func (jc *KubernetesClient) enqueueEval(
+	ctx context.Context,
+	spec *types.EvalSpec,
+	taskID *string,
+) error {
+	ctx, span := tracer.Start(ctx, "enqueueEval")
+	defer span.End()
+
+	if jc.evalQueue == nil {
+		err := errors.New("no eval queue configured for pool eval mode")
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "no eval queue")
+		return err
+	}
+
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	request := types.EvalRequest{
+		TraceContext: carrier,
+		Spec:         *spec,
+	}
+	// only the unpatched build is the same for every submission against a task
+	if jc.config.K8s.BuildCache && spec.Entity == types.JobTypePOV && taskID != nil {
+		request.BuildCacheKey = *taskID
+	}
+	request.SimulateRules = jc.config.K8s.SimulateRules
+
+	err := jc.evalQueue.Enqueue(ctx, request)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to enqueue eval request")
+		return err
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "enqueued eval request")
+	return nil
+}
+
 func (jc *KubernetesClient) CreateDeliveryJob(
 	ctx context.Context,
 	route string,
@@ -484,11 +571,13 @@ func (jc *KubernetesClient) CreateCancelJob(
 func CreateJobClient(
 	namespace string,
 	client *kubernetes.Clientset,
+	evalQueue queue.Queuer,
 	cfg *config.Config,
 ) KubernetesClient {
 	return KubernetesClient{
 		namespace:  namespace,
 		kubeClient: client,
+		evalQueue:  evalQueue,
 		config:     cfg,
 	}
 }
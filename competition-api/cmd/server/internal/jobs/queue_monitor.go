@@ -59,6 +59,19 @@ func (h *WorkerMsgHandler) HandleArtifactMessage(
 			span.SetStatus(codes.Error, "Failed to update job in DB with artifact")
 			return err
 		}
+
+		if msg.Artifact.Reproducibility != nil &&
+			msg.Artifact.Context == types.ResultCtxHeadRepoTest {
+			err = db.Model(&models.Job{}).
+				Where("id = ?", msg.EntityID).
+				Updates(&models.Job{Reproducibility: msg.Artifact.Reproducibility}).
+				Error
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, "failed to store job reproducibility")
+				return err
+			}
+		}
 	case types.JobTypePOV:
 		fallthrough
 	case types.JobTypePatch:
@@ -97,6 +110,31 @@ func (h *WorkerMsgHandler) HandleArtifactMessage(
 			return err
 		}
 
+		if msg.Entity == types.JobTypePOV &&
+			msg.Artifact.Context == types.ResultCtxHeadRepoTest &&
+			(msg.Artifact.Crash != nil || msg.Artifact.Reproducibility != nil) {
+			err = db.Model(&models.POVSubmission{}).
+				Where("id = ?", msg.EntityID).
+				Updates(&models.POVSubmission{
+					Crash:           msg.Artifact.Crash,
+					Reproducibility: msg.Artifact.Reproducibility,
+				}).
+				Error
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, "failed to store pov crash")
+				return err
+			}
+
+			// the pov may have already passed before its crash arrived
+			_, err = models.ClusterPOV(ctx, db, uuid.MustParse(msg.EntityID))
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, "failed to cluster pov")
+				return err
+			}
+		}
+
 		var row models.Submission
 		entity := audit.FileArchivedEntity(msg.Entity)
 
@@ -138,24 +176,31 @@ func (h *WorkerMsgHandler) HandleCommandResultMessage(
 	ctx context.Context,
 	msg *types.WorkerMsgCommandResult,
 ) error {
-	_, span := tracer.Start(ctx, "HandleCommandResultMessage")
+	ctx, span := tracer.Start(ctx, "HandleCommandResultMessage")
 	defer span.End()
 
 	db := h.db.WithContext(ctx)
 
-	if msg.Entity != types.JobTypeJob {
-		span.RecordError(nil)
-		span.SetStatus(codes.Ok, "skipped handling message because not a job")
-		return nil
-	}
-
 	if msg.Result == nil {
+		if msg.Entity != types.JobTypeJob {
+			span.RecordError(nil)
+			span.SetStatus(codes.Ok, "skipped handling message because not a job")
+			return nil
+		}
+
 		err := errors.New("empty command result message")
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 		return err
 	}
 
+	model, err := entityModel(msg.Entity)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unsupported entity")
+		return queue.WrapPoisonError(err)
+	}
+
 	cmdResultJSON, err := json.Marshal(msg.Result)
 	if err != nil {
 		span.RecordError(err)
@@ -163,15 +208,177 @@ func (h *WorkerMsgHandler) HandleCommandResultMessage(
 		return err
 	}
 
-	err = db.Model(&models.Job{}).Where("id = ?", msg.EntityID).Update(
+	err = db.Model(model).Where("id = ?", msg.EntityID).Update(
 		"results", gorm.Expr("CASE WHEN results IS NULL THEN '[]'::jsonb ELSE results END || ?::jsonb", cmdResultJSON),
 	).
 		Error
 	if err != nil {
 		span.RecordError(err)
-		span.SetStatus(codes.Error, "Failed to update job in DB with command result")
+		span.SetStatus(codes.Error, "Failed to update entity in DB with command result")
 		return err
 	}
+
+	if msg.Result.Usage != nil {
+		err = h.addUsage(ctx, msg.Entity, msg.EntityID, *msg.Result.Usage)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to record resource usage")
+			return err
+		}
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "recorded command result")
+	return nil
+}
+
+// Adds the usage of one command to the total of the entity it was run for
+func (h *WorkerMsgHandler) addUsage(
+	ctx context.Context,
+	entity types.JobType,
+	entityID string,
+	usage types.ResourceUsage,
+) error {
+	ctx, span := tracer.Start(ctx, "addUsage", trace.WithAttributes(
+		attribute.String("entity", string(entity)),
+		attribute.String("entity.id", entityID),
+	))
+	defer span.End()
+
+	db := h.db.WithContext(ctx)
+
+	entityUUID, err := uuid.Parse(entityID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to parse entity ID as UUID")
+		return queue.WrapPoisonError(fmt.Errorf("failed to parse entity ID as UUID: %w", err))
+	}
+
+	model, err := entityModel(entity)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unsupported entity")
+		return queue.WrapPoisonError(err)
+	}
+
+	err = db.Transaction(func(db *gorm.DB) error {
+		var row struct {
+			Usage *types.ResourceUsageTotal `gorm:"serializer:json"`
+		}
+		// results of commands run in parallel can be handled at the same time
+		result := db.Model(model).
+			Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+			Select("usage").
+			Where("id = ?", entityUUID).
+			Scan(&row)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		var total types.ResourceUsageTotal
+		if row.Usage != nil {
+			total = *row.Usage
+		}
+		usageJSON, err := json.Marshal(total.Add(usage))
+		if err != nil {
+			return err
+		}
+
+		return db.Model(model).
+			Where("id = ?", entityUUID).
+			Update("usage", gorm.Expr("?::jsonb", string(usageJSON))).
+			Error
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to record resource usage")
+		return err
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "recorded resource usage")
+	return nil
+}
+
+// Model of the table holding `entity`
+func entityModel(entity types.JobType) (any, error) {
+	switch entity {
+	case types.JobTypePOV:
+		return &models.POVSubmission{}, nil
+	case types.JobTypePatch:
+		return &models.PatchSubmission{}, nil
+	case types.JobTypeJob:
+		return &models.Job{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported entity: %s", entity)
+	}
+}
+
+func (h *WorkerMsgHandler) HandleStepMessage(
+	ctx context.Context,
+	msg *types.WorkerMsgStep,
+) error {
+	_, span := tracer.Start(ctx, "HandleStepMessage", trace.WithAttributes(
+		attribute.String("msg.step.name", string(msg.Step.Name)),
+		attribute.String("msg.step.outcome", string(msg.Step.Outcome)),
+		attribute.Int("msg.step.seq", msg.Step.Seq),
+	))
+	defer span.End()
+
+	db := h.db.WithContext(ctx)
+
+	entityUUID, err := uuid.Parse(msg.EntityID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to parse entity ID as UUID")
+		return queue.WrapPoisonError(fmt.Errorf("failed to parse entity ID as UUID: %w", err))
+	}
+
+	model, err := entityModel(msg.Entity)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unsupported entity")
+		return queue.WrapPoisonError(err)
+	}
+
+	err = db.Transaction(func(db *gorm.DB) error {
+		var row struct {
+			Steps []types.EvalStep `gorm:"serializer:json"`
+		}
+		// the start and end of a step can be handled at the same time
+		result := db.Model(model).
+			Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+			Select("steps").
+			Where("id = ?", entityUUID).
+			Scan(&row)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		stepsJSON, err := json.Marshal(types.MergeEvalStep(row.Steps, msg.Step))
+		if err != nil {
+			return err
+		}
+
+		return db.Model(model).
+			Where("id = ?", entityUUID).
+			Update("steps", gorm.Expr("?::jsonb", string(stepsJSON))).
+			Error
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to record step")
+		return err
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "recorded step")
 	return nil
 }
 
@@ -182,6 +389,7 @@ func (h *WorkerMsgHandler) HandleFinalMessage(
 	_, span := tracer.Start(ctx, "HandleFinalMessage", trace.WithAttributes(
 		attribute.String("msg.status", string(msg.Status)),
 		attribute.Bool("msg.patchTestsFailed", msg.PatchTestsFailed),
+		attribute.String("msg.reason", msg.Reason),
 	))
 	defer span.End()
 
@@ -206,6 +414,7 @@ func (h *WorkerMsgHandler) HandleFinalMessage(
 				Updates(
 					models.POVSubmission{
 						Status: msg.Status,
+						Reason: msg.Reason,
 					},
 				)
 		case types.JobTypePatch:
@@ -222,6 +431,7 @@ func (h *WorkerMsgHandler) HandleFinalMessage(
 				Updates(
 					models.PatchSubmission{
 						Status:                    msg.Status,
+						Reason:                    msg.Reason,
 						FunctionalityTestsPassing: models.NewNullFromData(!testsFailed),
 					},
 				)
@@ -237,6 +447,7 @@ func (h *WorkerMsgHandler) HandleFinalMessage(
 				Updates(
 					models.Job{
 						Status:                    msg.Status,
+						Reason:                    msg.Reason,
 						FunctionalityTestsPassing: models.NewNullFromData(!testsFailed),
 					},
 				)
@@ -254,6 +465,13 @@ func (h *WorkerMsgHandler) HandleFinalMessage(
 			return nil
 		}
 
+		if msg.Entity == types.JobTypePOV && msg.Status == types.SubmissionStatusPassed {
+			_, err = models.ClusterPOV(ctx, db, entityUUID)
+			if err != nil {
+				return err
+			}
+		}
+
 		if submission != nil {
 			teamID := submission.GetSubmitterID().String()
 			taskID := submission.GetTaskID().String()
@@ -323,6 +541,20 @@ func (h *WorkerMsgHandler) Handle(
 			return err
 		}
 
+	case types.MsgTypeStep:
+		specMsg := types.WorkerMsgStep{}
+		if err := json.Unmarshal(message, &specMsg); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to unmarshal queue message into specific type")
+			return queue.WrapPoisonError(err)
+		}
+
+		if err := h.HandleStepMessage(ctx, &specMsg); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to handle")
+			return err
+		}
+
 	case types.MsgTypeFinal:
 		specMsg := types.WorkerMsgFinal{}
 		if err := json.Unmarshal(message, &specMsg); err != nil {
@@ -2,6 +2,7 @@ package jobs
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"testing"
 	"time"
@@ -308,6 +309,68 @@ func (s *WorkerMsgHandlerTestSuite) Test_HandleCommandResultMessage_Success() {
 	s.Equal(res, job.Results[0])
 }
 
+func (s *WorkerMsgHandlerTestSuite) Test_HandleCommandResultMessage_Usage() {
+	job := &models.Job{
+		Model: models.Model{
+			ID: uuid.New(),
+		},
+	}
+	s.Require().NoError(s.tx.Model(&models.Job{}).Create(job).Error)
+
+	task := &models.Task{
+		Model: models.Model{
+			ID: uuid.New(),
+		},
+	}
+	s.Require().NoError(s.tx.Model(&models.Task{}).Create(task).Error)
+
+	auth := &models.Auth{
+		Model: models.Model{
+			ID: uuid.New(),
+		},
+		Active: datatypes.Null[bool]{V: true, Valid: true},
+	}
+	s.Require().NoError(s.tx.Model(&models.Auth{}).Create(auth).Error)
+
+	pov := &models.POVSubmission{
+		Model: models.Model{
+			ID: uuid.New(),
+		},
+		TaskID:      task.ID,
+		SubmitterID: auth.ID,
+	}
+	s.Require().NoError(s.tx.Model(&models.POVSubmission{}).Create(pov).Error)
+
+	handle := func(entity types.JobType, entityID string, usage types.ResourceUsage) {
+		s.Require().NoError(
+			s.handler.HandleCommandResultMessage(s.T().Context(), &types.WorkerMsgCommandResult{
+				WorkerMsg: types.WorkerMsg{
+					Entity:   entity,
+					EntityID: entityID,
+				},
+				Result: &types.JobResult{Usage: &usage},
+			}),
+		)
+	}
+
+	build := types.ResourceUsage{WallTimeMs: 3000}
+	pov1 := types.ResourceUsage{WallTimeMs: 1000}
+	killed := types.ResourceUsage{WallTimeMs: 5000, Signal: "SIGKILL"}
+
+	handle(types.JobTypeJob, job.ID.String(), build)
+	handle(types.JobTypeJob, job.ID.String(), pov1)
+	handle(types.JobTypePOV, pov.ID.String(), build)
+	handle(types.JobTypePOV, pov.ID.String(), killed)
+
+	s.Require().NoError(s.tx.Model(job).First(job).Error)
+	s.Len(job.Results, 2, "results should still be kept for jobs")
+	s.Equal(&types.ResourceUsageTotal{WallTimeMs: 4000, Commands: 2}, job.Usage)
+
+	s.Require().NoError(s.tx.Model(pov).First(pov).Error)
+	s.Len(pov.Results, 2, "results should be kept for submissions")
+	s.Equal(&types.ResourceUsageTotal{WallTimeMs: 8000, Commands: 2, Signaled: 1}, pov.Usage)
+}
+
 func (s *WorkerMsgHandlerTestSuite) Test_HandleFinalMessage_InvalidUUID() {
 	s.Require().ErrorContains(
 		s.handler.HandleFinalMessage(s.T().Context(), &types.WorkerMsgFinal{
@@ -401,8 +464,14 @@ func (s *WorkerMsgHandlerTestSuite) Test_HandleFinalMessage_Patch() {
 			Entity:   types.JobTypePatch,
 			EntityID: patchSubID.String(),
 		},
-		Status: types.SubmissionStatusPassed,
+		Status: types.SubmissionStatusErrored,
+		Reason: "fetch: sha256 mismatch",
 	}))
+
+	var patch models.PatchSubmission
+	s.Require().NoError(s.tx.First(&patch, "id = ?", patchSubID).Error)
+	s.Equal(types.SubmissionStatusErrored, patch.Status)
+	s.Equal("fetch: sha256 mismatch", patch.Reason, "reason should be stored on the submission")
 }
 
 func (s *WorkerMsgHandlerTestSuite) Test_HandleFinalMessage_Job() {
@@ -425,3 +494,288 @@ func (s *WorkerMsgHandlerTestSuite) Test_HandleFinalMessage_Job() {
 		Status: types.SubmissionStatusPassed,
 	}))
 }
+
+func (s *WorkerMsgHandlerTestSuite) Test_HandleStepMessage_Job() {
+	job := &models.Job{
+		Model: models.Model{
+			ID: uuid.New(),
+		},
+	}
+	s.Require().NoError(s.tx.Model(&models.Job{}).Create(job).Error)
+
+	step := func(seq int, name types.EvalStepName) types.EvalStep {
+		return types.EvalStep{
+			StartedAt: time.Now().Truncate(time.Millisecond),
+			Name:      name,
+			Outcome:   types.EvalStepRunning,
+			Seq:       seq,
+		}
+	}
+	handle := func(step types.EvalStep) {
+		s.Require().NoError(
+			s.handler.HandleStepMessage(s.T().Context(), &types.WorkerMsgStep{
+				WorkerMsg: types.WorkerMsg{
+					MsgType:  types.MsgTypeStep,
+					Entity:   types.JobTypeJob,
+					EntityID: job.ID.String(),
+				},
+				Step: step,
+			}),
+		)
+	}
+
+	fetch := step(0, types.EvalStepFetch)
+	build := step(1, types.EvalStepBuild)
+	fetchDone := fetch.Finish(fetch.StartedAt.Add(time.Second), types.EvalStepPassed, "")
+
+	// the finished fetch overtakes its start on the queue
+	handle(build)
+	handle(fetchDone)
+	handle(fetch)
+
+	s.Require().NoError(s.tx.Model(job).First(job).Error)
+	s.Require().Len(job.Steps, 2)
+	s.Equal(types.EvalStepFetch, job.Steps[0].Name)
+	s.Equal(types.EvalStepPassed, job.Steps[0].Outcome, "start should not replace finished step")
+	s.Equal(int64(1000), job.Steps[0].DurationMS)
+	s.Equal(types.EvalStepBuild, job.Steps[1].Name)
+	s.Equal(types.EvalStepRunning, job.Steps[1].Outcome)
+}
+
+func (s *WorkerMsgHandlerTestSuite) Test_HandleStepMessage_Rerun() {
+	job := &models.Job{
+		Model: models.Model{
+			ID: uuid.New(),
+		},
+	}
+	s.Require().NoError(s.tx.Model(&models.Job{}).Create(job).Error)
+
+	handle := func(step types.EvalStep) {
+		s.Require().NoError(
+			s.handler.HandleStepMessage(s.T().Context(), &types.WorkerMsgStep{
+				WorkerMsg: types.WorkerMsg{
+					MsgType:  types.MsgTypeStep,
+					Entity:   types.JobTypeJob,
+					EntityID: job.ID.String(),
+				},
+				Step: step,
+			}),
+		)
+	}
+
+	firstRun := uuid.Must(uuid.NewV7()).String()
+	secondRun := uuid.Must(uuid.NewV7()).String()
+	step := func(run string, seq int, name types.EvalStepName) types.EvalStep {
+		return types.EvalStep{
+			StartedAt: time.Now().Truncate(time.Millisecond),
+			Name:      name,
+			Outcome:   types.EvalStepRunning,
+			Run:       run,
+			Seq:       seq,
+		}
+	}
+	firstFetch := step(firstRun, 0, types.EvalStepFetch)
+
+	// the worker of the first run died during the build and the evaluation was picked up again
+	handle(firstFetch)
+	handle(firstFetch.Finish(firstFetch.StartedAt.Add(time.Second), types.EvalStepPassed, ""))
+	handle(step(firstRun, 1, types.EvalStepBuild))
+	handle(step(secondRun, 0, types.EvalStepFetch))
+	// a late message of the first run
+	handle(step(firstRun, 2, types.EvalStepRunPov))
+
+	s.Require().NoError(s.tx.Model(job).First(job).Error)
+	s.Require().Len(job.Steps, 1, "only the steps of the latest run should be kept")
+	s.Equal(secondRun, job.Steps[0].Run)
+	s.Equal(types.EvalStepFetch, job.Steps[0].Name)
+	s.Equal(types.EvalStepRunning, job.Steps[0].Outcome)
+}
+
+func (s *WorkerMsgHandlerTestSuite) Test_HandleStepMessage_NoEntity() {
+	s.Require().NoError(
+		s.handler.HandleStepMessage(s.T().Context(), &types.WorkerMsgStep{
+			WorkerMsg: types.WorkerMsg{
+				MsgType:  types.MsgTypeStep,
+				Entity:   types.JobTypePOV,
+				EntityID: uuid.NewString(),
+			},
+		}),
+	)
+}
+
+func (s *WorkerMsgHandlerTestSuite) Test_HandleArtifactMessage_POVCrash() {
+	fuzzOut := []byte("==1==ERROR: AddressSanitizer: SEGV on unknown address 0x000000000000")
+
+	s.fetcher.EXPECT().
+		Fetch(gomock.Any(), "fuzz.out").
+		DoAndReturn(func(context.Context, string) (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(fuzzOut)), nil
+		}).
+		Times(2)
+	s.archiver.EXPECT().StoreIdentifier(gomock.Any()).Return("bucket", nil).Times(2)
+	s.archiver.EXPECT().
+		Upload(gomock.Any(), gomock.Any(), int64(len(fuzzOut)), "fuzz.out").
+		Return(nil).
+		Times(2)
+
+	authID := uuid.New()
+	s.Require().NoError(
+		s.tx.Model(&models.Auth{}).Create(&models.Auth{
+			Model: models.Model{
+				ID: authID,
+			},
+			Active: datatypes.Null[bool]{V: true, Valid: true},
+		}).Error,
+	)
+
+	taskID := uuid.New()
+	s.Require().NoError(
+		s.tx.Model(&models.Task{}).Create(&models.Task{
+			Model: models.Model{
+				ID: taskID,
+			},
+			Type:    types.TaskTypeDelta,
+			RoundID: s.roundID.String(),
+		}).Error,
+	)
+
+	povID := uuid.New()
+	s.Require().NoError(
+		s.tx.Model(&models.POVSubmission{}).Create(&models.POVSubmission{
+			Model: models.Model{
+				ID: povID,
+			},
+			SubmitterID: authID,
+			TaskID:      taskID,
+		}).Error,
+	)
+
+	artifact := func(resultContext types.ResultContext, crashType string) *types.WorkerMsgArtifact {
+		return &types.WorkerMsgArtifact{
+			WorkerMsg: types.WorkerMsg{
+				Entity:   types.JobTypePOV,
+				EntityID: povID.String(),
+			},
+			Artifact: types.JobArtifact{
+				Crash: &types.CrashReport{
+					Sanitizer: "AddressSanitizer",
+					Type:      crashType,
+					Signature: crashType + "|main",
+				},
+				Reproducibility: &types.Reproducibility{
+					Runs:      4,
+					Crashes:   3,
+					CrashRate: 0.75,
+					Threshold: 0.5,
+				},
+				Blob:         types.Blob{ObjectName: "fuzz.out"},
+				ArchivedFile: types.FileFuzzOutHead,
+				Context:      resultContext,
+			},
+		}
+	}
+
+	s.Require().NoError(
+		s.handler.HandleArtifactMessage(s.T().Context(), artifact(types.ResultCtxHeadRepoTest, "SEGV")),
+	)
+	// the base repo crashing is not what the pov triggered
+	s.Require().NoError(
+		s.handler.HandleArtifactMessage(
+			s.T().Context(),
+			artifact(types.ResultCtxBaseRepoTest, "heap-buffer-overflow"),
+		),
+	)
+
+	var pov models.POVSubmission
+	s.Require().NoError(s.tx.First(&pov, "id = ?", povID).Error)
+	s.Require().NotNil(pov.Crash)
+	s.Equal("SEGV", pov.Crash.Type)
+	s.Equal("SEGV|main", pov.Crash.Signature)
+	s.Require().NotNil(pov.Reproducibility)
+	s.InDelta(0.75, pov.Reproducibility.CrashRate, 0)
+}
+
+func (s *WorkerMsgHandlerTestSuite) Test_HandleFinalMessage_POVCluster() {
+	taskID := uuid.New()
+	s.Require().NoError(
+		s.tx.Model(&models.Task{}).Create(&models.Task{
+			Model: models.Model{
+				ID: taskID,
+			},
+			Type:    types.TaskTypeDelta,
+			RoundID: s.roundID.String(),
+		}).Error,
+	)
+
+	teams := []uuid.UUID{uuid.New(), uuid.New()}
+	for _, id := range teams {
+		s.Require().NoError(
+			s.tx.Model(&models.Auth{}).Create(&models.Auth{
+				Model: models.Model{
+					ID: id,
+				},
+				Active: datatypes.Null[bool]{V: true, Valid: true},
+			}).Error,
+		)
+	}
+
+	submitted := time.Now().Add(-time.Hour)
+	newPOV := func(teamID uuid.UUID, after time.Duration, signature string) uuid.UUID {
+		id := uuid.New()
+		s.Require().NoError(
+			s.tx.Model(&models.POVSubmission{}).Create(&models.POVSubmission{
+				Model: models.Model{
+					ID:        id,
+					CreatedAt: submitted.Add(after),
+				},
+				Crash:       &types.CrashReport{Type: "SEGV", Signature: signature},
+				TaskID:      taskID,
+				SubmitterID: teamID,
+				Status:      types.SubmissionStatusAccepted,
+			}).Error,
+		)
+		return id
+	}
+	finish := func(id uuid.UUID, status types.SubmissionStatus) {
+		s.Require().NoError(s.handler.HandleFinalMessage(s.T().Context(), &types.WorkerMsgFinal{
+			WorkerMsg: types.WorkerMsg{
+				MsgType:  types.MsgTypeFinal,
+				Entity:   types.JobTypePOV,
+				EntityID: id.String(),
+			},
+			Status: status,
+		}))
+	}
+
+	first := newPOV(teams[0], 0, "SEGV|parse")
+	duplicate := newPOV(teams[1], time.Minute, "SEGV|parse")
+	other := newPOV(teams[1], 2*time.Minute, "SEGV|decode")
+	failed := newPOV(teams[0], 3*time.Minute, "SEGV|parse")
+
+	// the later duplicate finishes evaluating first
+	finish(duplicate, types.SubmissionStatusPassed)
+	finish(first, types.SubmissionStatusPassed)
+	finish(other, types.SubmissionStatusPassed)
+	finish(failed, types.SubmissionStatusFailed)
+
+	clusters, err := models.POVClustersForTask(s.T().Context(), s.tx, taskID)
+	s.Require().NoError(err)
+	s.Require().Len(clusters, 2)
+
+	s.Equal("SEGV|parse", clusters[0].Signature)
+	s.Equal(first.String(), clusters[0].FirstPOVID)
+	s.Equal(teams[0].String(), clusters[0].FirstSubmitterID)
+	s.Equal(types.UnixMilli(submitted.UnixMilli()), clusters[0].FirstSubmittedAt)
+	s.Require().Len(clusters[0].POVs, 2)
+	s.Equal(first.String(), clusters[0].POVs[0].POVID)
+	s.Equal(duplicate.String(), clusters[0].POVs[1].POVID)
+
+	s.Equal("SEGV|decode", clusters[1].Signature)
+	s.Equal(other.String(), clusters[1].FirstPOVID)
+	s.Equal(teams[1].String(), clusters[1].FirstSubmitterID)
+	s.Require().Len(clusters[1].POVs, 1)
+
+	var pov models.POVSubmission
+	s.Require().NoError(s.tx.First(&pov, "id = ?", failed).Error)
+	s.False(pov.ClusterID.Valid, "failed povs should not be clustered")
+}
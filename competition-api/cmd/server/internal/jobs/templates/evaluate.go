@@ -3,6 +3,7 @@ package templates
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"go.opentelemetry.io/otel/codes"
 	batchv1 "k8s.io/api/batch/v1"
@@ -17,18 +18,25 @@ type EvaluateDataImages struct {
 }
 
 type EvaluateData struct {
-	Labels       map[string]string
-	Annotations  map[string]string
-	TeamID       *string
-	Images       EvaluateDataImages
-	Affinity     KeyValue
-	Toleration   KeyValue
-	Name         string
-	TeamIDLabel  string
-	Args         []string
-	Env          []corev1.EnvVar
-	DindMemoryGB int
-	DindCPUs     int
+	Labels      map[string]string
+	Annotations map[string]string
+	TeamID      *string
+	Images      EvaluateDataImages
+	Affinity    KeyValue
+	Toleration  KeyValue
+	Name        string
+	TeamIDLabel string
+	// Rules of a simulated engine the worker evaluates with instead of the challenge scripts
+	SimulateRules string
+	// Node directory shared by eval pods to cache source tarballs, not mounted when empty
+	FetchCacheHostPath string
+	// Set for POV evaluations that may reuse the build of their task
+	BuildCacheKey      string
+	Args               []string
+	Env                []corev1.EnvVar
+	FetchCacheMaxBytes int64
+	DindMemoryGB       int
+	DindCPUs           int
 }
 
 func (d EvaluateData) Render(ctx context.Context) *batchv1.Job {
@@ -43,6 +51,79 @@ func (d EvaluateData) Render(ctx context.Context) *batchv1.Job {
 	runAsNonRoot := true
 	allowPrivilegeEscalation := false
 	always := corev1.ContainerRestartPolicyAlways
+	root := int64(0)
+	directoryOrCreate := corev1.HostPathDirectoryOrCreate
+
+	args := append([]string{"worker", "eval", "--base-dir", "/dind-shared"}, d.Args...)
+	evaluatorMounts := []corev1.VolumeMount{
+		{
+			Name:      "dind-shared",
+			MountPath: "/dind-shared",
+		},
+	}
+	volumes := []corev1.Volume{
+		{
+			Name: "dind-shared",
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		},
+		{
+			Name: "dind-data",
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		},
+	}
+	var fetchCacheInit []corev1.Container
+
+	if d.BuildCacheKey != "" {
+		args = append(args, "--build-cache-key", d.BuildCacheKey)
+	}
+
+	if d.SimulateRules != "" {
+		args = append(args, "--simulate", d.SimulateRules)
+	}
+
+	if d.FetchCacheHostPath != "" {
+		args = append(args,
+			"--fetch-cache-dir", "/fetch-cache",
+			"--fetch-cache-max-bytes", strconv.FormatInt(d.FetchCacheMaxBytes, 10),
+		)
+		fetchCacheMount := corev1.VolumeMount{
+			Name:      "fetch-cache",
+			MountPath: "/fetch-cache",
+		}
+		evaluatorMounts = append(evaluatorMounts, fetchCacheMount)
+		volumes = append(volumes, corev1.Volume{
+			Name: "fetch-cache",
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Path: d.FetchCacheHostPath,
+					Type: &directoryOrCreate,
+				},
+			},
+		})
+
+		// hostPath volumes ignore fsGroup and are created owned by root
+		fetchCacheInit = append(fetchCacheInit, corev1.Container{
+			Name:    "fetch-cache-permissions",
+			Image:   d.Images.DIND,
+			Command: []string{"chown", fmt.Sprintf("%d:%d", user, user), "/fetch-cache"},
+			VolumeMounts: []corev1.VolumeMount{
+				fetchCacheMount,
+			},
+			SecurityContext: &corev1.SecurityContext{
+				RunAsUser: &root,
+			},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceMemory: resource.MustParse("32Mi"),
+					corev1.ResourceCPU:    resource.MustParse("50m"),
+				},
+			},
+		})
+	}
 
 	nodeAffinity := corev1.NodeAffinity{
 		RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
@@ -135,8 +216,8 @@ func (d EvaluateData) Render(ctx context.Context) *batchv1.Job {
 						FSGroup: &user,
 					},
 					Tolerations: tolerations,
-					InitContainers: []corev1.Container{
-						{
+					InitContainers: append(fetchCacheInit,
+						corev1.Container{
 							RestartPolicy: &always,
 							Name:          "dind",
 							Image:         d.Images.DIND,
@@ -200,7 +281,7 @@ func (d EvaluateData) Render(ctx context.Context) *batchv1.Job {
 								},
 							},
 						},
-						{
+						corev1.Container{
 							Name:  "load-dind",
 							Image: d.Images.DIND,
 							Command: []string{
@@ -232,20 +313,13 @@ func (d EvaluateData) Render(ctx context.Context) *batchv1.Job {
 								},
 							},
 						},
-					},
+					),
 					Containers: []corev1.Container{
 						{
-							Name:  "evaluator",
-							Image: d.Images.Job,
-							Args: append(
-								[]string{"worker", "eval", "--base-dir", "/dind-shared"},
-								d.Args...),
-							VolumeMounts: []corev1.VolumeMount{
-								{
-									Name:      "dind-shared",
-									MountPath: "/dind-shared",
-								},
-							},
+							Name:         "evaluator",
+							Image:        d.Images.Job,
+							Args:         args,
+							VolumeMounts: evaluatorMounts,
 							Env: append(d.Env, corev1.EnvVar{
 								Name:  "DOCKER_HOST",
 								Value: "tcp://127.0.0.1:2375",
@@ -270,20 +344,7 @@ func (d EvaluateData) Render(ctx context.Context) *batchv1.Job {
 						},
 					},
 					RestartPolicy: corev1.RestartPolicyNever,
-					Volumes: []corev1.Volume{
-						{
-							Name: "dind-shared",
-							VolumeSource: corev1.VolumeSource{
-								EmptyDir: &corev1.EmptyDirVolumeSource{},
-							},
-						},
-						{
-							Name: "dind-data",
-							VolumeSource: corev1.VolumeSource{
-								EmptyDir: &corev1.EmptyDirVolumeSource{},
-							},
-						},
-					},
+					Volumes:       volumes,
 				},
 			},
 		},
@@ -0,0 +1,92 @@
+package templates
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluate(t *testing.T) {
+	data := EvaluateData{
+		Name: "foobar-name",
+		Args: []string{"--head-repo-url", "url"},
+		Images: EvaluateDataImages{
+			Job:  "jobImage",
+			DIND: "dindImage",
+		},
+		DindMemoryGB: 2,
+		DindCPUs:     1,
+	}
+
+	t.Run("Render", func(t *testing.T) {
+		jobSpec := data.Render(context.TODO())
+		spec := jobSpec.Spec.Template.Spec
+
+		assert.Equal(
+			t,
+			[]string{"worker", "eval", "--base-dir", "/dind-shared", "--head-repo-url", "url"},
+			spec.Containers[0].Args,
+		)
+		assert.Len(t, spec.Volumes, 2, "fetch cache should not be mounted")
+		assert.Len(t, spec.InitContainers, 2)
+	})
+
+	t.Run("FetchCache", func(t *testing.T) {
+		withCache := data
+		withCache.FetchCacheHostPath = "/var/cache/fetch"
+		withCache.FetchCacheMaxBytes = 1024
+
+		jobSpec := withCache.Render(context.TODO())
+		spec := jobSpec.Spec.Template.Spec
+
+		assert.Equal(
+			t,
+			[]string{
+				"worker", "eval", "--base-dir", "/dind-shared", "--head-repo-url", "url",
+				"--fetch-cache-dir", "/fetch-cache", "--fetch-cache-max-bytes", "1024",
+			},
+			spec.Containers[0].Args,
+		)
+
+		require.Len(t, spec.Volumes, 3)
+		require.NotNil(t, spec.Volumes[2].HostPath)
+		assert.Equal(t, "/var/cache/fetch", spec.Volumes[2].HostPath.Path)
+		assert.Len(t, spec.Containers[0].VolumeMounts, 2)
+		assert.Equal(t, "fetch-cache-permissions", spec.InitContainers[0].Name)
+	})
+
+	t.Run("BuildCache", func(t *testing.T) {
+		withCache := data
+		withCache.BuildCacheKey = "task-id"
+
+		jobSpec := withCache.Render(context.TODO())
+		spec := jobSpec.Spec.Template.Spec
+
+		assert.Equal(
+			t,
+			[]string{
+				"worker", "eval", "--base-dir", "/dind-shared", "--head-repo-url", "url",
+				"--build-cache-key", "task-id",
+			},
+			spec.Containers[0].Args,
+		)
+	})
+	t.Run("Simulate", func(t *testing.T) {
+		simulated := data
+		simulated.SimulateRules = "/etc/worker/simulate.yaml"
+
+		jobSpec := simulated.Render(context.TODO())
+		spec := jobSpec.Spec.Template.Spec
+
+		assert.Equal(
+			t,
+			[]string{
+				"worker", "eval", "--base-dir", "/dind-shared", "--head-repo-url", "url",
+				"--simulate", "/etc/worker/simulate.yaml",
+			},
+			spec.Containers[0].Args,
+		)
+	})
+}
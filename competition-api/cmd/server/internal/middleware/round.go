@@ -1,11 +1,14 @@
 package middleware
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"gorm.io/gorm"
 
 	srverr "github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/error"
 	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/models"
@@ -14,8 +17,15 @@ import (
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
 )
 
-// Ensures the current `validRoundIDs` overlap with the round id on the task
-func RoundID(validRoundIDs map[string]bool, taskParam string) echo.MiddlewareFunc {
+// Ensures the round on the task is either tracked in the db or one of the `validRoundIDs`.
+//
+// Rounds tracked in the db are stored in the context as `roundParam`.
+func RoundID(
+	h *Handler,
+	validRoundIDs map[string]bool,
+	taskParam string,
+	roundParam string,
+) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			ctx, span := tracer.Start(c.Request().Context(), "RoundID")
@@ -28,6 +38,23 @@ func RoundID(validRoundIDs map[string]bool, taskParam string) echo.MiddlewareFun
 				return response.InternalServerError
 			}
 
+			span.SetAttributes(attribute.String("round.id", task.RoundID))
+
+			span.AddEvent("fetching round")
+			round, err := models.RoundByRoundID(ctx, h.DB, task.RoundID)
+			switch {
+			case err == nil:
+				c.Set(roundParam, round)
+
+				span.RecordError(nil)
+				span.SetStatus(codes.Ok, "validated round id")
+				return next(c)
+			case !errors.Is(err, gorm.ErrRecordNotFound):
+				span.RecordError(err)
+				span.SetStatus(codes.Error, "failed to fetch round")
+				return response.InternalServerError
+			}
+
 			logger.Logger.DebugContext(
 				ctx,
 				"checking roundID",
@@ -51,3 +78,49 @@ func RoundID(validRoundIDs map[string]bool, taskParam string) echo.MiddlewareFun
 		}
 	}
 }
+
+// Rejects anything other than reads unless the round in `roundParam` is open.
+//
+// Rounds only tracked in config are always open.
+func RoundAcceptsSubmissions(roundParam string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			_, span := tracer.Start(c.Request().Context(), "RoundAcceptsSubmissions")
+			defer span.End()
+
+			method := c.Request().Method
+			if method == http.MethodGet || method == http.MethodHead {
+				span.RecordError(nil)
+				span.SetStatus(codes.Ok, "reads are always allowed")
+				return next(c)
+			}
+
+			round, ok := c.Get(roundParam).(*models.Round)
+			if !ok {
+				span.RecordError(nil)
+				span.SetStatus(codes.Ok, "round is not tracked in the db")
+				return next(c)
+			}
+
+			span.SetAttributes(
+				attribute.String("round.id", round.RoundID),
+				attribute.String("round.state", string(round.State)),
+			)
+
+			if !round.AcceptsSubmissions() {
+				span.RecordError(nil)
+				span.SetStatus(codes.Ok, "round is not accepting submissions")
+				return echo.NewHTTPError(
+					http.StatusBadRequest,
+					types.StringError(
+						fmt.Sprintf("round is %s and not accepting submissions", round.State),
+					),
+				)
+			}
+
+			span.RecordError(nil)
+			span.SetStatus(codes.Ok, "round is accepting submissions")
+			return next(c)
+		}
+	}
+}
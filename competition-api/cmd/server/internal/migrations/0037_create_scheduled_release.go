@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(Up0037, Down0037)
+}
+
+func Up0037(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `
+CREATE TABLE scheduled_release (
+	id UUID PRIMARY KEY DEFAULT uuidv7_sub_ms(),
+	name TEXT NOT NULL,
+	type TEXT NOT NULL,
+	repo_url TEXT NOT NULL,
+	head_ref TEXT NOT NULL,
+	base_ref TEXT,
+	duration_secs BIGINT NOT NULL,
+	release_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	teams JSONB NOT NULL DEFAULT '[]'::jsonb,
+	round_id TEXT NOT NULL,
+	installation_id BIGINT,
+	status TEXT NOT NULL DEFAULT 'scheduled',
+	released_at TIMESTAMP WITH TIME ZONE,
+	error TEXT,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT current_timestamp,
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT current_timestamp
+);`},
+		statement{query: `
+CREATE INDEX scheduled_release_status_release_at_idx ON scheduled_release (status, release_at);`},
+		statement{query: `
+CREATE TRIGGER touch_updated_at_trigger
+BEFORE UPDATE ON scheduled_release
+FOR EACH ROW EXECUTE PROCEDURE touch_updated_at();`},
+	)
+}
+
+func Down0037(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `DROP TABLE scheduled_release;`})
+}
@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(Up0038, Down0038)
+}
+
+func Up0038(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `
+CREATE TABLE round (
+	id UUID PRIMARY KEY DEFAULT uuidv7_sub_ms(),
+	round_id TEXT NOT NULL UNIQUE,
+	state TEXT NOT NULL DEFAULT 'planned',
+	start_time TIMESTAMP WITH TIME ZONE,
+	end_time TIMESTAMP WITH TIME ZONE,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT current_timestamp,
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT current_timestamp
+);`},
+		statement{query: `
+CREATE TRIGGER touch_updated_at_trigger
+BEFORE UPDATE ON round
+FOR EACH ROW EXECUTE PROCEDURE touch_updated_at();`},
+	)
+}
+
+func Down0038(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `DROP TABLE round;`})
+}
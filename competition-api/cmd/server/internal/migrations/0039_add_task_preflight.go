@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(Up0039, Down0039)
+}
+
+func Up0039(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `
+ALTER TABLE task ADD COLUMN preflight jsonb;
+`})
+}
+
+func Down0039(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `
+ALTER TABLE task DROP COLUMN preflight;`})
+}
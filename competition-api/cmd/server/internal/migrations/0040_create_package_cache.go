@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(Up0040, Down0040)
+}
+
+func Up0040(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `
+CREATE TABLE package_cache (
+	id UUID PRIMARY KEY DEFAULT uuidv7_sub_ms(),
+	key TEXT NOT NULL UNIQUE,
+	kind TEXT NOT NULL,
+	blobs JSONB NOT NULL,
+	challenge JSONB,
+	ground_truth JSONB,
+	focus TEXT NOT NULL DEFAULT '',
+	head_commit TEXT NOT NULL DEFAULT '',
+	base_commit TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT current_timestamp,
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT current_timestamp
+);`},
+		statement{query: `
+CREATE TRIGGER touch_updated_at_trigger
+BEFORE UPDATE ON package_cache
+FOR EACH ROW EXECUTE PROCEDURE touch_updated_at();`},
+	)
+}
+
+func Down0040(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `DROP TABLE package_cache;`})
+}
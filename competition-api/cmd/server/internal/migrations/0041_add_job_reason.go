@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(Up0041, Down0041)
+}
+
+func Up0041(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `
+ALTER TABLE job ADD COLUMN reason TEXT NOT NULL DEFAULT '';
+`})
+}
+
+func Down0041(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `
+ALTER TABLE job DROP COLUMN reason;`})
+}
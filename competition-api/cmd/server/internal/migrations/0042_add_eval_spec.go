@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(Up0042, Down0042)
+}
+
+func Up0042(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `
+ALTER TABLE pov_submission ADD COLUMN eval_spec JSONB;
+ALTER TABLE patch_submission ADD COLUMN eval_spec JSONB;
+ALTER TABLE job ADD COLUMN eval_spec JSONB;
+`})
+}
+
+func Down0042(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `
+ALTER TABLE pov_submission DROP COLUMN eval_spec;
+ALTER TABLE patch_submission DROP COLUMN eval_spec;
+ALTER TABLE job DROP COLUMN eval_spec;`})
+}
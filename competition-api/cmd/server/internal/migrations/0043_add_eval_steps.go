@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(Up0043, Down0043)
+}
+
+func Up0043(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `
+ALTER TABLE pov_submission ADD COLUMN steps JSONB;
+ALTER TABLE patch_submission ADD COLUMN steps JSONB;
+ALTER TABLE job ADD COLUMN steps JSONB;
+`})
+}
+
+func Down0043(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `
+ALTER TABLE pov_submission DROP COLUMN steps;
+ALTER TABLE patch_submission DROP COLUMN steps;
+ALTER TABLE job DROP COLUMN steps;`})
+}
@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(Up0044, Down0044)
+}
+
+func Up0044(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `
+ALTER TABLE pov_submission ADD COLUMN crash JSONB;
+`})
+}
+
+func Down0044(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `
+ALTER TABLE pov_submission DROP COLUMN crash;`})
+}
@@ -0,0 +1,42 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(Up0045, Down0045)
+}
+
+func Up0045(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `
+CREATE TABLE pov_cluster (
+	id UUID PRIMARY KEY DEFAULT uuidv7_sub_ms(),
+	task_id UUID NOT NULL REFERENCES task(id),
+	signature TEXT NOT NULL,
+	first_pov_id UUID NOT NULL REFERENCES pov_submission(id),
+	first_submitter_id UUID NOT NULL REFERENCES auth(id),
+	first_submitted_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT current_timestamp,
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT current_timestamp,
+	UNIQUE (task_id, signature)
+);`},
+		statement{query: `
+CREATE TRIGGER touch_updated_at_trigger
+BEFORE UPDATE ON pov_cluster
+FOR EACH ROW EXECUTE PROCEDURE touch_updated_at();`},
+		statement{query: `
+ALTER TABLE pov_submission ADD COLUMN cluster_id UUID REFERENCES pov_cluster(id);`},
+	)
+}
+
+func Down0045(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `ALTER TABLE pov_submission DROP COLUMN cluster_id;`},
+		statement{query: `DROP TABLE pov_cluster;`},
+	)
+}
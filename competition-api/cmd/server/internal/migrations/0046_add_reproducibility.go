@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(Up0046, Down0046)
+}
+
+func Up0046(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `
+ALTER TABLE task ADD COLUMN reproducibility JSONB;
+`},
+		statement{query: `
+ALTER TABLE pov_submission ADD COLUMN reproducibility JSONB;
+`},
+		statement{query: `
+ALTER TABLE job ADD COLUMN reproducibility JSONB;
+`})
+}
+
+func Down0046(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `
+ALTER TABLE job DROP COLUMN reproducibility;`},
+		statement{query: `
+ALTER TABLE pov_submission DROP COLUMN reproducibility;`},
+		statement{query: `
+ALTER TABLE task DROP COLUMN reproducibility;`})
+}
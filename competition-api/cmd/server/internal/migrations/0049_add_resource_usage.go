@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(Up0049, Down0049)
+}
+
+func Up0049(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `
+ALTER TABLE pov_submission ADD COLUMN usage JSONB;
+ALTER TABLE patch_submission ADD COLUMN usage JSONB;
+ALTER TABLE job ADD COLUMN usage JSONB;
+`})
+}
+
+func Down0049(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `
+ALTER TABLE pov_submission DROP COLUMN usage;
+ALTER TABLE patch_submission DROP COLUMN usage;
+ALTER TABLE job DROP COLUMN usage;`})
+}
@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(Up0050, Down0050)
+}
+
+func Up0050(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `
+ALTER TABLE scheduled_release ADD COLUMN heartbeat_at TIMESTAMP WITH TIME ZONE;`})
+}
+
+func Down0050(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `
+ALTER TABLE scheduled_release DROP COLUMN heartbeat_at;`})
+}
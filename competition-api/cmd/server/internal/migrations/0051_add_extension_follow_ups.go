@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(Up0051, Down0051)
+}
+
+func Up0051(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `
+ALTER TABLE pov_submission ADD COLUMN evaluation_pending BOOLEAN NOT NULL DEFAULT false;`},
+		statement{query: `
+ALTER TABLE patch_submission ADD COLUMN evaluation_pending BOOLEAN NOT NULL DEFAULT false;`},
+		statement{query: `
+ALTER TABLE task ADD COLUMN resend_pending BOOLEAN NOT NULL DEFAULT false;`})
+}
+
+func Down0051(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `
+ALTER TABLE task DROP COLUMN resend_pending;`},
+		statement{query: `
+ALTER TABLE patch_submission DROP COLUMN evaluation_pending;`},
+		statement{query: `
+ALTER TABLE pov_submission DROP COLUMN evaluation_pending;`})
+}
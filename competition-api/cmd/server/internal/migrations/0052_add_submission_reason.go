@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(Up0052, Down0052)
+}
+
+func Up0052(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `
+ALTER TABLE pov_submission ADD COLUMN reason TEXT NOT NULL DEFAULT '';`},
+		statement{query: `
+ALTER TABLE patch_submission ADD COLUMN reason TEXT NOT NULL DEFAULT '';`})
+}
+
+func Down0052(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `
+ALTER TABLE patch_submission DROP COLUMN reason;`},
+		statement{query: `
+ALTER TABLE pov_submission DROP COLUMN reason;`})
+}
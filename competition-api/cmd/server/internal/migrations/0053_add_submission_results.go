@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(Up0053, Down0053)
+}
+
+func Up0053(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `
+ALTER TABLE pov_submission ADD COLUMN results JSONB;
+ALTER TABLE patch_submission ADD COLUMN results JSONB;
+`})
+}
+
+func Down0053(ctx context.Context, tx *sql.Tx) error {
+	return execStatements(ctx, tx,
+		statement{query: `
+ALTER TABLE pov_submission DROP COLUMN results;
+ALTER TABLE patch_submission DROP COLUMN results;`})
+}
@@ -9,11 +9,20 @@ import (
 
 type (
 	Job struct {
+		// What the worker was asked to evaluate
+		EvalSpec *types.EvalSpec        `gorm:"type:jsonb;serializer:json"`
 		Status   types.SubmissionStatus `gorm:"type:text;default:'accepted'"`
 		CacheKey string
+		// why the evaluation errored, if it did
+		Reason string
+		// How often the POV crashed the head repo, nil when the job had no POV
+		Reproducibility *types.Reproducibility `gorm:"type:jsonb;serializer:json"`
+		// What the commands run for the job used, nil until one reported usage
+		Usage *types.ResourceUsageTotal `gorm:"type:jsonb;serializer:json"`
 		Model
 
 		Results                   []types.JobResult   `gorm:"type:jsonb;serializer:json"`
+		Steps                     []types.EvalStep    `gorm:"type:jsonb;serializer:json"`
 		Artifacts                 []types.JobArtifact `gorm:"type:jsonb;serializer:json"`
 		FunctionalityTestsPassing datatypes.Null[bool]
 	}
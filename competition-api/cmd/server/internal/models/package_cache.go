@@ -0,0 +1,40 @@
+package models
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+)
+
+type (
+	// Ground truth from challenge.yaml along with the working store objects its files were
+	// uploaded to
+	GroundTruthBlob struct {
+		Truth     types.GroundTruth `json:"truth"`
+		POVBlob   string            `json:"pov_blob"`
+		PatchBlob string            `json:"patch_blob"`
+	}
+
+	// Tarballs packaged from challenge sources, so tasks sharing a commit or fuzz tooling ref can
+	// reuse them instead of fetching and packaging again
+	PackageCacheEntry struct {
+		// Working store and archive object names, by archived file
+		Blobs       map[types.ArchivedFile]string `gorm:"type:jsonb;serializer:json"`
+		Challenge   *types.ChallengeYAML          `gorm:"type:jsonb;serializer:json"`
+		Key         string
+		Kind        string
+		Focus       string
+		HeadCommit  string
+		BaseCommit  string
+		GroundTruth []GroundTruthBlob `gorm:"type:jsonb;serializer:json"`
+		Model
+	}
+)
+
+func (PackageCacheEntry) TableName() string {
+	return "package_cache"
+}
+
+func (e PackageCacheEntry) GetID() uuid.UUID {
+	return e.ID
+}
@@ -9,12 +9,24 @@ import (
 )
 
 type PatchSubmission struct {
+	// What the worker was last asked to evaluate
+	EvalSpec *types.EvalSpec `gorm:"type:jsonb;serializer:json"`
+	// What the commands run across its evaluations used, nil until one reported usage
+	Usage *types.ResourceUsageTotal `gorm:"type:jsonb;serializer:json"`
+	// Commands run across its evaluations, in the order they finished
+	Results []types.JobResult `gorm:"type:jsonb;serializer:json"`
+	// Timeline the worker reported while evaluating
+	Steps         []types.EvalStep `gorm:"type:jsonb;serializer:json"`
 	PatchFilePath string
 	Status        types.SubmissionStatus `gorm:"type:text"`
+	// why the evaluation errored, if it did
+	Reason string
 	Model
 	SubmitterID               uuid.UUID // TODO: figure out gorm associations. fk constraints in place due to migrations
 	TaskID                    uuid.UUID
 	FunctionalityTestsPassing datatypes.Null[bool]
+	// Set while a deadline extension re-accepted it and its evaluation has not been started yet
+	EvaluationPending bool
 }
 
 var _ Submission = (*PatchSubmission)(nil)
@@ -2,21 +2,40 @@ package models
 
 import (
 	"github.com/google/uuid"
+	"gorm.io/datatypes"
 
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/audit"
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
 )
 
 type POVSubmission struct {
-	TestcasePath string // path in Azure Blob Container
-	FuzzerName   string // OSS Fuzz name for harness
-	Sanitizer    string
-	Architecture string
-	Status       types.SubmissionStatus `gorm:"type:text"`
-	Engine       string
+	// What the worker was last asked to evaluate
+	EvalSpec *types.EvalSpec `gorm:"type:jsonb;serializer:json"`
+	// Timeline the worker reported while evaluating
+	Steps []types.EvalStep `gorm:"type:jsonb;serializer:json"`
+	// Commands run across its evaluations, in the order they finished
+	Results []types.JobResult `gorm:"type:jsonb;serializer:json"`
+	// What the POV triggered in the head repo, nil until it has been run
+	Crash *types.CrashReport `gorm:"type:jsonb;serializer:json"`
+	// What the commands run across its evaluations used, nil until one reported usage
+	Usage *types.ResourceUsageTotal `gorm:"type:jsonb;serializer:json"`
+	// How often the POV crashed the head repo over repeated runs, nil until it has been run
+	Reproducibility *types.Reproducibility `gorm:"type:jsonb;serializer:json"`
+	TestcasePath    string                 // path in Azure Blob Container
+	FuzzerName      string                 // OSS Fuzz name for harness
+	Sanitizer       string
+	Architecture    string
+	Status          types.SubmissionStatus `gorm:"type:text"`
+	Engine          string
+	// why the evaluation errored, if it did
+	Reason string
 	Model
 	SubmitterID uuid.UUID // TODO: figure out gorm associations. the database has the constraits from manual migrations
 	TaskID      uuid.UUID // TODO: figure out gorm associations. the database has the constraits from manual migrations
+	// Crash signature group within the task, set once the POV passed with a parsed crash
+	ClusterID datatypes.Null[uuid.UUID]
+	// Set while a deadline extension re-accepted it and its evaluation has not been started yet
+	EvaluationPending bool
 }
 
 var _ Submission = (*POVSubmission)(nil)
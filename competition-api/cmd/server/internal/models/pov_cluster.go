@@ -0,0 +1,197 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+)
+
+// Passed POVs of a task with the same crash signature, see [POVSubmission.ClusterID]
+type POVCluster struct {
+	FirstSubmittedAt time.Time
+	Signature        string
+	Model
+	TaskID           uuid.UUID
+	FirstPOVID       uuid.UUID `gorm:"column:first_pov_id"`
+	FirstSubmitterID uuid.UUID
+}
+
+func (POVCluster) TableName() string {
+	return "pov_cluster"
+}
+
+func (c POVCluster) GetID() uuid.UUID {
+	return c.ID
+}
+
+func (c POVCluster) ToResponse(povs []POVSubmission) types.POVCluster {
+	cluster := types.POVCluster{
+		ClusterID:        c.ID.String(),
+		TaskID:           c.TaskID.String(),
+		Signature:        c.Signature,
+		FirstPOVID:       c.FirstPOVID.String(),
+		FirstSubmitterID: c.FirstSubmitterID.String(),
+		FirstSubmittedAt: types.UnixMilli(c.FirstSubmittedAt.UnixMilli()),
+		POVs:             make([]types.POVClusterMember, 0, len(povs)),
+	}
+
+	for _, pov := range povs {
+		cluster.POVs = append(cluster.POVs, types.POVClusterMember{
+			POVID:       pov.ID.String(),
+			SubmitterID: pov.SubmitterID.String(),
+			SubmittedAt: types.UnixMilli(pov.CreatedAt.UnixMilli()),
+		})
+	}
+
+	return cluster
+}
+
+// Ties on submission time go to the lower POV id so the first submitter never flips
+const earlierPOV = "(EXCLUDED.first_submitted_at, EXCLUDED.first_pov_id) < " +
+	"(pov_cluster.first_submitted_at, pov_cluster.first_pov_id)"
+
+func firstPOVColumn(column string) clause.Assignment {
+	return clause.Assignment{
+		Column: clause.Column{Name: column},
+		Value: gorm.Expr(fmt.Sprintf(
+			"CASE WHEN %s THEN EXCLUDED.%s ELSE pov_cluster.%s END", earlierPOV, column, column,
+		)),
+	}
+}
+
+// Adds a POV to the cluster for its task and crash signature, creating the cluster if this is the
+// first POV to trigger that crash. POVs can finish evaluating in any order, so the cluster keeps
+// whichever was submitted first.
+//
+// POVs that have not passed, have no crash signature or are already clustered are left alone.
+// Returns the POV's cluster, nil when it was not clustered.
+func ClusterPOV(ctx context.Context, db *gorm.DB, povID uuid.UUID) (*POVCluster, error) {
+	ctx, span := tracer.Start(ctx, "ClusterPOV")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("pov.id", povID.String()))
+
+	db = db.WithContext(ctx)
+
+	var cluster *POVCluster
+
+	span.AddEvent("clustering pov")
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var pov POVSubmission
+		// the final and artifact messages of a pov can be handled at the same time
+		err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+			First(&pov, povID).Error
+		if err != nil {
+			return err
+		}
+
+		if pov.ClusterID.Valid {
+			cluster, err = ByID[POVCluster](ctx, tx, pov.ClusterID.V)
+			return err
+		}
+		if pov.Status != types.SubmissionStatusPassed || pov.Crash == nil ||
+			pov.Crash.Signature == "" {
+			return nil
+		}
+
+		cluster = &POVCluster{
+			TaskID:           pov.TaskID,
+			Signature:        pov.Crash.Signature,
+			FirstPOVID:       pov.ID,
+			FirstSubmitterID: pov.SubmitterID,
+			FirstSubmittedAt: pov.CreatedAt,
+		}
+		err = tx.Clauses(
+			clause.OnConflict{
+				Columns: []clause.Column{{Name: "task_id"}, {Name: "signature"}},
+				DoUpdates: clause.Set{
+					firstPOVColumn("first_pov_id"),
+					firstPOVColumn("first_submitter_id"),
+					firstPOVColumn("first_submitted_at"),
+				},
+			},
+			clause.Returning{},
+		).Create(cluster).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&pov).Update("cluster_id", cluster.ID).Error
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to cluster pov")
+		return nil, err
+	}
+
+	if cluster == nil {
+		span.RecordError(nil)
+		span.SetStatus(codes.Ok, "pov not clusterable")
+		return nil, nil
+	}
+
+	span.SetAttributes(attribute.String("pov_cluster.id", cluster.ID.String()))
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "clustered pov")
+	return cluster, nil
+}
+
+// Gets the POV clusters of a task with their POVs, both ordered by submission time
+func POVClustersForTask(
+	ctx context.Context,
+	db *gorm.DB,
+	taskID uuid.UUID,
+) ([]types.POVCluster, error) {
+	ctx, span := tracer.Start(ctx, "POVClustersForTask")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("task.id", taskID.String()))
+
+	db = db.WithContext(ctx)
+
+	var clusters []POVCluster
+	err := db.Where("task_id = ?", taskID).
+		Order("first_submitted_at ASC").
+		Order("id ASC").
+		Find(&clusters).Error
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to get pov clusters")
+		return nil, err
+	}
+
+	var povs []POVSubmission
+	err = db.Where("task_id = ?", taskID).
+		Where("cluster_id IS NOT NULL").
+		Order("created_at ASC").
+		Order("id ASC").
+		Find(&povs).Error
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to get clustered povs")
+		return nil, err
+	}
+
+	members := make(map[uuid.UUID][]POVSubmission, len(clusters))
+	for _, pov := range povs {
+		members[pov.ClusterID.V] = append(members[pov.ClusterID.V], pov)
+	}
+
+	response := make([]types.POVCluster, 0, len(clusters))
+	for _, cluster := range clusters {
+		response = append(response, cluster.ToResponse(members[cluster.ID]))
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "got pov clusters")
+	return response, nil
+}
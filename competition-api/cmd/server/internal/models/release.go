@@ -0,0 +1,164 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+)
+
+type ScheduledRelease struct {
+	ReleaseAt  time.Time
+	ReleasedAt datatypes.Null[time.Time]
+	RoundID    string
+	Name       string
+	Type       types.TaskType `gorm:"type:text"`
+	RepoURL    string
+	HeadRef    string
+	Status     types.ReleaseStatus `gorm:"type:text;default:'scheduled'"`
+	// Last sign of life of the scheduler releasing it
+	HeartbeatAt datatypes.Null[time.Time]
+	// Report of the pre-flight that blocked the release. Released tasks keep their own.
+	Preflight *types.PreflightReport `gorm:"type:jsonb;serializer:json"`
+	Model
+	BaseRef        datatypes.Null[string]
+	Error          datatypes.Null[string]
+	Teams          datatypes.JSONSlice[string]
+	InstallationID datatypes.Null[int64]
+	DurationSecs   int64
+}
+
+func (ScheduledRelease) TableName() string {
+	return "scheduled_release"
+}
+
+func (r ScheduledRelease) GetID() uuid.UUID {
+	return r.ID
+}
+
+func (r ScheduledRelease) TaskDuration() time.Duration {
+	return time.Duration(r.DurationSecs) * time.Second
+}
+
+func (r ScheduledRelease) ToResponse() types.Release {
+	release := types.Release{
+		ReleaseID:      r.ID.String(),
+		Name:           r.Name,
+		Type:           r.Type,
+		RepoURL:        r.RepoURL,
+		HeadRef:        r.HeadRef,
+		BaseRef:        PtrFromNull(r.BaseRef),
+		DurationSecs:   r.DurationSecs,
+		ReleaseAt:      types.UnixMilli(r.ReleaseAt.UnixMilli()),
+		Teams:          r.Teams,
+		RoundID:        r.RoundID,
+		InstallationID: PtrFromNull(r.InstallationID),
+		Status:         r.Status,
+		Error:          PtrFromNull(r.Error),
+		Preflight:      r.Preflight,
+	}
+	if release.Teams == nil {
+		release.Teams = []string{}
+	}
+
+	if r.ReleasedAt.Valid {
+		releasedAt := types.UnixMilli(r.ReleasedAt.V.UnixMilli())
+		release.ReleasedAt = &releasedAt
+	}
+
+	return release
+}
+
+// Atomically moves the earliest due release from scheduled to releasing.
+//
+// Only one caller can ever claim a given release so a release is never tasked twice, even across
+// restarts.
+// Returns nil when there is nothing due.
+func ClaimDueRelease(ctx context.Context, db *gorm.DB, now time.Time) (*ScheduledRelease, error) {
+	ctx, span := tracer.Start(ctx, "ClaimDueRelease")
+	defer span.End()
+
+	db = db.WithContext(ctx)
+
+	var release ScheduledRelease
+
+	span.AddEvent("claiming due release")
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ?", types.ReleaseStatusScheduled).
+			Where("release_at <= ?", now).
+			Order("release_at ASC").
+			First(&release).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Model(&release).
+			Where("status = ?", types.ReleaseStatusScheduled).
+			Updates(map[string]any{
+				"status":       types.ReleaseStatusReleasing,
+				"heartbeat_at": now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			span.RecordError(nil)
+			span.SetStatus(codes.Ok, "no release due")
+			return nil, nil
+		}
+
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to claim due release")
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.String("release.id", release.ID.String()))
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "claimed due release")
+	return &release, nil
+}
+
+// Fails releases whose scheduler stopped sending heartbeats before `staleBefore`, it died while
+// releasing them. They may have been tasked out in part so they are never claimed again.
+//
+// Returns how many releases were failed.
+func FailStaleReleases(ctx context.Context, db *gorm.DB, staleBefore time.Time) (int64, error) {
+	ctx, span := tracer.Start(ctx, "FailStaleReleases")
+	defer span.End()
+
+	result := db.WithContext(ctx).
+		Model(&ScheduledRelease{}).
+		Where("status = ?", types.ReleaseStatusReleasing).
+		Where("heartbeat_at IS NULL OR heartbeat_at < ?", staleBefore).
+		Updates(map[string]any{
+			"status": types.ReleaseStatusFailed,
+			"error":  "scheduler stopped while releasing, the release may be partially tasked out",
+		})
+	if result.Error != nil {
+		span.RecordError(result.Error)
+		span.SetStatus(codes.Error, "failed to fail stale releases")
+		return 0, result.Error
+	}
+
+	span.SetAttributes(attribute.Int64("releases.failed", result.RowsAffected))
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "failed stale releases")
+	return result.RowsAffected, nil
+}
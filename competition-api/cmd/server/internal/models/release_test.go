@@ -0,0 +1,102 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+	gormpostgres "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/migrations"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+)
+
+func TestClaimDueRelease(t *testing.T) {
+	ctx := context.Background()
+
+	postgresContainer, err := postgres.Run(ctx,
+		"postgres:16.4-alpine",
+		postgres.WithDatabase("competitionapi"),
+		postgres.WithUsername("competitionapi"),
+		postgres.WithPassword("competitionapi"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(5*time.Second)),
+	)
+	defer func() {
+		err = testcontainers.TerminateContainer(postgresContainer)
+		assert.NoError(t, err, "failed to terminate container")
+	}()
+	require.NoError(t, err, "failed to start postgres container")
+
+	dsn, err := postgresContainer.ConnectionString(ctx)
+	require.NoError(t, err, "failed to get connection string to container")
+
+	db, err := gorm.Open(gormpostgres.Open(dsn))
+	require.NoError(t, err, "failed to connect to the database")
+
+	require.NoError(t, migrations.Up(ctx, db), "failed to migrate db")
+
+	now := time.Now()
+	newRelease := func(name string, releaseAt time.Time, status types.ReleaseStatus) ScheduledRelease {
+		return ScheduledRelease{
+			Name:         name,
+			Type:         types.TaskTypeFull,
+			RepoURL:      "https://github.com/example/repo",
+			HeadRef:      "main",
+			RoundID:      "round",
+			DurationSecs: 3600,
+			ReleaseAt:    releaseAt,
+			Status:       status,
+		}
+	}
+
+	releases := []ScheduledRelease{
+		newRelease("later", now.Add(-time.Minute), types.ReleaseStatusScheduled),
+		newRelease("earliest", now.Add(-time.Hour), types.ReleaseStatusScheduled),
+		newRelease("paused", now.Add(-2*time.Hour), types.ReleaseStatusPaused),
+		newRelease("future", now.Add(time.Hour), types.ReleaseStatusScheduled),
+	}
+	require.NoError(t, db.Create(&releases).Error, "failed to write releases to db")
+
+	release, err := ClaimDueRelease(ctx, db, now)
+	require.NoError(t, err, "failed to claim release")
+	require.NotNil(t, release, "should claim a release")
+	assert.Equal(t, "earliest", release.Name)
+
+	release, err = ClaimDueRelease(ctx, db, now)
+	require.NoError(t, err, "failed to claim release")
+	require.NotNil(t, release, "should claim a release")
+	assert.Equal(t, "later", release.Name)
+
+	release, err = ClaimDueRelease(ctx, db, now)
+	require.NoError(t, err, "failed to claim release")
+	assert.Nil(t, release, "should not claim paused, future, or already claimed releases")
+
+	var claimed int64
+	require.NoError(t, db.Model(&ScheduledRelease{}).
+		Where("status = ?", types.ReleaseStatusReleasing).
+		Count(&claimed).Error)
+	assert.Equal(t, int64(2), claimed)
+
+	failed, err := FailStaleReleases(ctx, db, now.Add(-time.Minute))
+	require.NoError(t, err, "failed to fail stale releases")
+	assert.Zero(t, failed, "releases claimed since should not be stale")
+
+	failed, err = FailStaleReleases(ctx, db, now.Add(time.Minute))
+	require.NoError(t, err, "failed to fail stale releases")
+	assert.Equal(t, int64(2), failed)
+
+	var stale ScheduledRelease
+	require.NoError(t, db.Where("name = ?", "earliest").First(&stale).Error)
+	assert.Equal(t, types.ReleaseStatusFailed, stale.Status)
+	assert.True(t, stale.Error.Valid, "stale releases should say why they failed")
+	assert.False(t, stale.ReleasedAt.Valid)
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"gorm.io/gorm"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+)
+
+// What evaluating the POVs and patches submitted against each task of a round used, the longest
+// running first. Tasks without any reported usage are left out.
+func TaskResourceUsage(
+	ctx context.Context,
+	db *gorm.DB,
+	roundID string,
+) ([]types.TaskResourceUsage, error) {
+	ctx, span := tracer.Start(ctx, "TaskResourceUsage")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("round.id", roundID))
+
+	db = db.WithContext(ctx)
+
+	roundTasks := db.Model(&Task{}).Select("id").Where("round_id = ?", roundID)
+
+	usage := make(map[uuid.UUID]*types.TaskResourceUsage)
+	for _, model := range []any{&POVSubmission{}, &PatchSubmission{}} {
+		var rows []struct {
+			Usage  *types.ResourceUsageTotal `gorm:"serializer:json"`
+			TaskID uuid.UUID
+		}
+		err := db.Model(model).
+			Select("task_id", "usage").
+			Where("usage IS NOT NULL").
+			Where("task_id IN (?)", roundTasks).
+			Scan(&rows).Error
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to get submission usage")
+			return nil, err
+		}
+
+		for _, row := range rows {
+			if row.Usage == nil {
+				continue
+			}
+			total, ok := usage[row.TaskID]
+			if !ok {
+				total = &types.TaskResourceUsage{TaskID: row.TaskID.String()}
+				usage[row.TaskID] = total
+			}
+			total.Usage = total.Usage.Merge(*row.Usage)
+			total.Evaluations++
+		}
+	}
+
+	taskIDs := make([]uuid.UUID, 0, len(usage))
+	for taskID := range usage {
+		taskIDs = append(taskIDs, taskID)
+	}
+
+	var tasks []Task
+	if len(taskIDs) > 0 {
+		err := db.Where("id IN ?", taskIDs).Find(&tasks).Error
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to get tasks")
+			return nil, err
+		}
+	}
+
+	response := make([]types.TaskResourceUsage, 0, len(tasks))
+	for _, task := range tasks {
+		total := usage[task.ID]
+		total.ProjectName = task.ProjectName
+		total.MemoryGB = task.MemoryGB
+		total.CPUs = task.CPUs
+		response = append(response, *total)
+	}
+
+	slices.SortFunc(response, func(a, b types.TaskResourceUsage) int {
+		return cmp.Or(
+			cmp.Compare(b.Usage.WallTimeMs, a.Usage.WallTimeMs),
+			cmp.Compare(a.TaskID, b.TaskID),
+		)
+	})
+
+	span.SetAttributes(attribute.Int("tasks", len(response)))
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "aggregated task resource usage")
+	return response, nil
+}
@@ -0,0 +1,149 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+)
+
+var ErrInvalidRoundTransition = errors.New("invalid round transition")
+
+// States a round may move to a given state from
+var roundTransitions = map[types.RoundState][]types.RoundState{
+	types.RoundStateOpen:   {types.RoundStatePlanned, types.RoundStateFrozen},
+	types.RoundStateFrozen: {types.RoundStateOpen},
+	types.RoundStateClosed: {types.RoundStatePlanned, types.RoundStateOpen, types.RoundStateFrozen},
+}
+
+type Round struct {
+	StartTime datatypes.Null[time.Time]
+	EndTime   datatypes.Null[time.Time]
+	RoundID   string
+	State     types.RoundState `gorm:"type:text;default:'planned'"`
+	Model
+}
+
+func (Round) TableName() string {
+	return "round"
+}
+
+func (r Round) GetID() uuid.UUID {
+	return r.ID
+}
+
+// Whether CRSs may submit into this round
+func (r Round) AcceptsSubmissions() bool {
+	return r.State == types.RoundStateOpen
+}
+
+func (r Round) ToResponse() types.Round {
+	round := types.Round{
+		RoundID: r.RoundID,
+		State:   r.State,
+	}
+
+	if r.StartTime.Valid {
+		startTime := types.UnixMilli(r.StartTime.V.UnixMilli())
+		round.StartTime = &startTime
+	}
+	if r.EndTime.Valid {
+		endTime := types.UnixMilli(r.EndTime.V.UnixMilli())
+		round.EndTime = &endTime
+	}
+
+	return round
+}
+
+// Gets a round by its round id
+func RoundByRoundID(ctx context.Context, db *gorm.DB, roundID string) (*Round, error) {
+	ctx, span := tracer.Start(ctx, "RoundByRoundID")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("round.id", roundID))
+
+	db = db.WithContext(ctx)
+
+	var round Round
+	err := db.First(&round, "round_id = ?", roundID).Error
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to get round")
+		return nil, err
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "got round")
+	return &round, nil
+}
+
+// Moves `round` to the state `to`, returning the state it was in before.
+//
+// Opening a round without a start time records the start time and closing a round records the end
+// time. Returns [ErrInvalidRoundTransition] if the round can not move to `to` from its current
+// state.
+func TransitionRound(
+	ctx context.Context,
+	db *gorm.DB,
+	round *Round,
+	to types.RoundState,
+	now time.Time,
+) (types.RoundState, error) {
+	ctx, span := tracer.Start(ctx, "TransitionRound")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("round.id", round.RoundID),
+		attribute.String("round.state.to", string(to)),
+	)
+
+	db = db.WithContext(ctx)
+
+	var from types.RoundState
+
+	span.AddEvent("updating round state")
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var current Round
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, round.ID).Error
+		if err != nil {
+			return err
+		}
+
+		from = current.State
+		if !slices.Contains(roundTransitions[to], from) {
+			return ErrInvalidRoundTransition
+		}
+
+		updates := map[string]any{"state": to}
+		switch to {
+		case types.RoundStateOpen:
+			if !current.StartTime.Valid {
+				updates["start_time"] = now
+			}
+		case types.RoundStateClosed:
+			updates["end_time"] = now
+		}
+
+		return tx.Model(round).Clauses(clause.Returning{}).Updates(updates).Error
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to transition round")
+		return from, err
+	}
+
+	span.SetAttributes(attribute.String("round.state.from", string(from)))
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "transitioned round")
+	return from, nil
+}
@@ -28,8 +28,18 @@ type (
 
 	Task struct {
 		UnstrippedSource UnstrippedSources `gorm:"type:jsonb;serializer:json"`
+		// Set when the challenge went through pre-flight before being sent
+		Preflight *types.PreflightReport `gorm:"type:jsonb;serializer:json"`
+		// How often POVs must crash the task, nil uses the worker's default
+		Reproducibility *types.ReproducibilityPolicy `gorm:"type:jsonb;serializer:json"`
+		// Timeouts evaluations of the task run with, nil uses the worker's defaults
+		Timeouts *types.EvalTimeouts `gorm:"type:jsonb;serializer:json"`
+		// What patches to the task may change, nil only checks languages
+		PatchPolicy *types.PatchPolicy `gorm:"type:jsonb;serializer:json"`
 		Model
-		Deadline          time.Time
+		Deadline time.Time
+		// Set once CRSs were told to stop working on the task
+		CancelledAt       datatypes.Null[time.Time]
 		Type              types.TaskType
 		RoundID           string
 		ProjectName       string
@@ -39,6 +49,8 @@ type (
 		MemoryGB          int `gorm:"column:memory_gb"`
 		CPUs              int `gorm:"column:cpus"`
 		HarnessesIncluded bool
+		// Set while CRSs have not been sent the task again since its deadline moved
+		ResendPending bool
 	}
 )
 
@@ -54,6 +66,47 @@ type PresignedSourceURLs struct {
 	HeadRepo    string
 	FuzzTooling string
 	BaseRepo    string
+	// Expected content of each url, empty when it is not known
+	HeadRepoSHA256    string
+	FuzzToolingSHA256 string
+	BaseRepoSHA256    string
+}
+
+// Starts the spec the worker evaluates `entityID` with, callers add the POV or patch to test.
+// This is the only place specs are made so every kind of evaluation describes the task the same
+// way.
+func NewEvalSpec(
+	task *Task,
+	sources *PresignedSourceURLs,
+	entity types.JobType,
+	entityID string,
+) *types.EvalSpec {
+	spec := &types.EvalSpec{
+		Version:     types.EvalSpecVersion,
+		Entity:      entity,
+		EntityID:    entityID,
+		HeadRepo:    types.EvalSource{URL: sources.HeadRepo, SHA256: sources.HeadRepoSHA256},
+		FuzzTooling: types.EvalSource{URL: sources.FuzzTooling, SHA256: sources.FuzzToolingSHA256},
+		Focus:       task.Focus,
+		ProjectName: task.ProjectName,
+		// challenges are only built for x86_64, POVs may say otherwise
+		Architecture: types.ArchitectureX8664,
+	}
+	if task.Reproducibility != nil {
+		policy := *task.Reproducibility
+		spec.Reproducibility = &policy
+	}
+	if task.Timeouts != nil {
+		timeouts := *task.Timeouts
+		spec.Timeouts = &timeouts
+	}
+	if task.MemoryGB > 0 || task.CPUs > 0 {
+		spec.Resources = &types.EvalResources{MemoryGB: task.MemoryGB, CPUs: task.CPUs}
+	}
+	if sources.BaseRepo != "" {
+		spec.BaseRepo = &types.EvalSource{URL: sources.BaseRepo, SHA256: sources.BaseRepoSHA256}
+	}
+	return spec
 }
 
 // Gets presigned urls for the sources that make up a task
@@ -80,8 +133,10 @@ func (t *Task) GetSourceURLs(
 	}
 
 	presignedURLs := &PresignedSourceURLs{
-		HeadRepo:    headRepo,
-		FuzzTooling: fuzzTooling,
+		HeadRepo:          headRepo,
+		FuzzTooling:       fuzzTooling,
+		HeadRepoSHA256:    t.UnstrippedSource.HeadRepo.SHA256,
+		FuzzToolingSHA256: t.UnstrippedSource.FuzzTooling.SHA256,
 	}
 
 	if t.Type == types.TaskTypeDelta {
@@ -97,6 +152,7 @@ func (t *Task) GetSourceURLs(
 			return nil, err
 		}
 		presignedURLs.BaseRepo = baseRepo
+		presignedURLs.BaseRepoSHA256 = t.UnstrippedSource.BaseRepo.SHA256
 	}
 
 	span.RecordError(nil)
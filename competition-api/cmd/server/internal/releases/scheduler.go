@@ -0,0 +1,350 @@
+package releases
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/challenges"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/github"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/models"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/config"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/logger"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+)
+
+const name = "github.com/aixcyberchallenge/competition-api/competition-api/server/releases"
+
+var tracer = otel.Tracer(name)
+
+// How often a scheduler releasing a challenge says it is still alive. Releases it stopped
+// heartbeating for several times as long are failed, their scheduler died.
+const (
+	heartbeatInterval = time.Second * 30
+	heartbeatStale    = heartbeatInterval * 4
+)
+
+// Releases scheduled challenges once their release time has passed.
+//
+// Only the elected leader polls the release calendar so multiple replicas never task the same
+// release.
+type Scheduler struct {
+	client           kubernetes.Interface
+	db               *gorm.DB
+	challengesClient *challenges.Client
+	githubClient     *github.Client
+	installationID   *int64
+	namespace        string
+	id               string
+	teams            []config.Team
+	pollInterval     time.Duration
+}
+
+func NewScheduler(
+	client kubernetes.Interface,
+	namespace string,
+	id string,
+	db *gorm.DB,
+	challengesClient *challenges.Client,
+	githubClient *github.Client,
+	cfg *config.Config,
+) *Scheduler {
+	return &Scheduler{
+		client:           client,
+		namespace:        namespace,
+		id:               id,
+		db:               db,
+		challengesClient: challengesClient,
+		githubClient:     githubClient,
+		installationID:   cfg.Generate.InstallationID,
+		teams:            cfg.Teams,
+		pollInterval:     time.Duration(*cfg.ReleasePollTimeSeconds) * time.Second,
+	}
+}
+
+// Runs leader election in loop until `ctx` is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	lock := &resourcelock.LeaseLock{
+		Client: s.client.CoordinationV1(),
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      "competitionapi-release-scheduler",
+			Namespace: s.namespace,
+		},
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: s.id,
+		},
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+			Lock:          lock,
+			LeaseDuration: time.Second * 15,
+			RenewDeadline: time.Second * 10,
+			RetryPeriod:   time.Second * 2,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(ctx context.Context) {
+					logger.Logger.InfoContext(ctx, "started leading release scheduler")
+					s.poll(ctx)
+				},
+				OnStoppedLeading: func() {
+					logger.Logger.Info("stopped leading release scheduler")
+				},
+			},
+		})
+
+		<-time.After(time.Second * 30)
+	}
+}
+
+// releases everything that is due every poll interval until `ctx` is cancelled
+func (s *Scheduler) poll(ctx context.Context) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.releaseDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// claims due releases until none are left and releases each in the background, so one waiting on
+// pre-flight for hours does not hold back those due after it. Claimed releases carry on past a
+// leadership change, their heartbeat keeps the next leader off them.
+func (s *Scheduler) releaseDue(ctx context.Context) {
+	stale, err := models.FailStaleReleases(ctx, s.db, time.Now().Add(-heartbeatStale))
+	if err != nil {
+		logger.Logger.ErrorContext(ctx, "failed to fail stale releases", "error", err)
+	} else if stale > 0 {
+		logger.Logger.WarnContext(ctx, "failed releases abandoned while releasing", "count", stale)
+	}
+
+	for ctx.Err() == nil {
+		release, err := models.ClaimDueRelease(ctx, s.db, time.Now())
+		if err != nil {
+			logger.Logger.ErrorContext(ctx, "failed to claim due release", "error", err)
+			return
+		}
+		if release == nil {
+			return
+		}
+
+		go s.release(ctx, release)
+	}
+}
+
+// tasks out a claimed release and records the outcome on it
+func (s *Scheduler) release(ctx context.Context, release *models.ScheduledRelease) {
+	ctx, span := tracer.Start(ctx, "Scheduler.release", trace.WithNewRoot(), trace.WithAttributes(
+		attribute.String("release.id", release.ID.String()),
+		attribute.String("release.name", release.Name),
+		attribute.String("release.type", string(release.Type)),
+		attribute.String("release.round_id", release.RoundID),
+	))
+	defer span.End()
+
+	// the task and its outcome must outlive a leadership change once it has been claimed
+	ctx = context.WithoutCancel(ctx)
+	db := s.db.WithContext(ctx)
+
+	stopHeartbeat := s.heartbeat(ctx, release)
+	err := s.runScan(ctx, release)
+	stopHeartbeat()
+
+	updates := map[string]any{
+		"status":       types.ReleaseStatusReleased,
+		"released_at":  time.Now(),
+		"heartbeat_at": nil,
+	}
+	if err != nil {
+		updates = map[string]any{
+			"status":       types.ReleaseStatusFailed,
+			"error":        err.Error(),
+			"heartbeat_at": nil,
+		}
+
+		// the blocked task is never stored, the release is the only place left for its report
+		var blocked *challenges.PreflightBlockedError
+		if errors.As(err, &blocked) {
+			report, jsonErr := json.Marshal(blocked.Report)
+			if jsonErr == nil {
+				updates["preflight"] = gorm.Expr("?::jsonb", string(report))
+			} else {
+				logger.Logger.ErrorContext(
+					ctx,
+					"failed to marshal pre-flight report",
+					"release",
+					release.ID.String(),
+					"error",
+					jsonErr,
+				)
+			}
+		}
+	}
+
+	span.AddEvent("recording release outcome")
+	dbErr := db.Model(release).
+		Where("status = ?", types.ReleaseStatusReleasing).
+		Updates(updates).Error
+	if dbErr != nil {
+		logger.Logger.ErrorContext(
+			ctx,
+			"failed to record release outcome",
+			"release",
+			release.ID.String(),
+			"error",
+			dbErr,
+		)
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to release challenge")
+		return
+	}
+	if dbErr != nil {
+		span.RecordError(dbErr)
+		span.SetStatus(codes.Error, "failed to record release outcome")
+		return
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "released challenge")
+}
+
+// Keeps `release` from going stale until the returned func is called
+func (s *Scheduler) heartbeat(ctx context.Context, release *models.ScheduledRelease) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			err := s.db.WithContext(ctx).
+				Model(&models.ScheduledRelease{}).
+				Where("id = ?", release.ID).
+				Where("status = ?", types.ReleaseStatusReleasing).
+				Update("heartbeat_at", time.Now()).Error
+			if err != nil && ctx.Err() == nil {
+				logger.Logger.WarnContext(
+					ctx,
+					"failed to heartbeat release",
+					"release",
+					release.ID.String(),
+					"error",
+					err,
+				)
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func (s *Scheduler) runScan(ctx context.Context, release *models.ScheduledRelease) error {
+	ctx, span := tracer.Start(ctx, "Scheduler.runScan")
+	defer span.End()
+
+	teams := s.targetTeams(release)
+	if len(teams) == 0 {
+		err := errors.New("release does not target any configured team")
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "no teams to task")
+		return err
+	}
+
+	installationID := s.installationID
+	if release.InstallationID.Valid {
+		installationID = &release.InstallationID.V
+	}
+	if installationID == nil {
+		err := errors.New("no github installation configured for release")
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "no github installation")
+		return err
+	}
+
+	span.AddEvent("get token for installation ID")
+	token, err := s.githubClient.CreateInstallationToken(ctx, *installationID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to get installation token")
+		return fmt.Errorf("failed to get installation token: %w", err)
+	}
+
+	challengeConfig := challenges.ChallengeConfig{
+		Name:         release.Name,
+		BaseRef:      models.PtrFromNull(release.BaseRef),
+		HeadRef:      release.HeadRef,
+		RepoURL:      release.RepoURL,
+		TaskDuration: release.TaskDuration(),
+		Source:       challenges.NewHTTPSGitSource("token", token.GetToken()),
+	}
+
+	if release.Type == types.TaskTypeDelta {
+		span.AddEvent("running delta scan")
+		err = s.challengesClient.RunDeltaScan(ctx, challengeConfig, teams, release.RoundID)
+	} else {
+		span.AddEvent("running full scan")
+		err = s.challengesClient.RunFullScan(ctx, challengeConfig, teams, release.RoundID)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to run scan")
+		return err
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "ran scan")
+	return nil
+}
+
+// resolves the teams targeted by a release. No teams on the release means all teams.
+func (s *Scheduler) targetTeams(release *models.ScheduledRelease) []config.Team {
+	if len(release.Teams) == 0 {
+		return s.teams
+	}
+
+	teams := make([]config.Team, 0, len(release.Teams))
+	for _, team := range s.teams {
+		if slices.Contains(release.Teams, team.ID) {
+			teams = append(teams, team)
+		}
+	}
+
+	return teams
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"go.opentelemetry.io/otel/attribute"
@@ -31,8 +32,20 @@ func (h *Handler) CancelTask(c echo.Context) error {
 		attribute.String("task.id", task.ID.String()),
 	)
 
+	// recorded first so the task can't be extended while CRSs are told to drop it
+	span.AddEvent("marking task cancelled")
+	err := h.db.WithContext(ctx).
+		Model(task).
+		Where("cancelled_at IS NULL").
+		Update("cancelled_at", time.Now()).Error
+	if err != nil {
+		span.SetStatus(codes.Error, "error marking task cancelled")
+		span.RecordError(err)
+		return response.InternalServerError
+	}
+
 	span.AddEvent("starting cancel task job")
-	_, err := h.jobClient.CreateCancelJob(
+	_, err = h.jobClient.CreateCancelJob(
 		ctx,
 		fmt.Sprintf("/v1/task/%s/", task.ID.String()),
 		h.Teams,
@@ -74,6 +87,18 @@ func (h *Handler) CancelAllTasks(c echo.Context) error {
 		return response.InternalServerError
 	}
 
+	span.AddEvent("marking tasks cancelled")
+	now := time.Now()
+	err = db.Model(&models.Task{}).
+		Where("deadline > ?", now).
+		Where("cancelled_at IS NULL").
+		Update("cancelled_at", now).Error
+	if err != nil {
+		span.SetStatus(codes.Error, "error marking tasks cancelled")
+		span.RecordError(err)
+		return response.InternalServerError
+	}
+
 	span.AddEvent("starting cancel all tasks job")
 	_, err = h.jobClient.CreateCancelJob(
 		ctx,
@@ -6,10 +6,13 @@ import (
 	"go.opentelemetry.io/otel"
 	"gorm.io/gorm"
 
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/challenges"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/evaluation"
 	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/jobs"
 	servermiddleware "github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/middleware"
 	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/models"
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/config"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/upload"
 )
 
 const name = "github.com/aixcyberchallenge/competition-api/competition-api/server/routes/competition"
@@ -17,19 +20,30 @@ const name = "github.com/aixcyberchallenge/competition-api/competition-api/serve
 var tracer = otel.Tracer(name)
 
 type Handler struct {
-	jobClient *jobs.KubernetesClient
-	db        *gorm.DB
+	jobClient        *jobs.KubernetesClient
+	challengesClient *challenges.Client
+	evaluator        *evaluation.Client
+	db               *gorm.DB
 	// TODO: maybe just save pointer to the whole config object?
 	RoundID string
 	Teams   []config.Team
 }
 
-func Create(c *config.Config, jobClient *jobs.KubernetesClient, db *gorm.DB) *Handler {
+func Create(
+	c *config.Config,
+	jobClient *jobs.KubernetesClient,
+	challengesClient *challenges.Client,
+	db *gorm.DB,
+	submissionUploader upload.Uploader,
+	sourcesUploader upload.Uploader,
+) *Handler {
 	return &Handler{
-		RoundID:   *c.RoundID,
-		Teams:     c.Teams,
-		jobClient: jobClient,
-		db:        db,
+		RoundID:          *c.RoundID,
+		Teams:            c.Teams,
+		jobClient:        jobClient,
+		challengesClient: challengesClient,
+		evaluator:        evaluation.Create(db, jobClient, submissionUploader, sourcesUploader),
+		db:               db,
 	}
 }
 
@@ -45,4 +59,78 @@ func (h *Handler) AddRoutes(e *echo.Echo, middlewareHandler *servermiddleware.Ha
 		h.CancelTask,
 		servermiddleware.PopulateFromIDParam[models.Task](middlewareHandler, "task_id", "task"),
 	)
+	competitionGroup.POST(
+		"/task/:task_id/extend/",
+		h.ExtendTask,
+		servermiddleware.PopulateFromIDParam[models.Task](middlewareHandler, "task_id", "task"),
+	)
+	competitionGroup.GET(
+		"/task/:task_id/pov-clusters/",
+		h.GetPOVClusters,
+		servermiddleware.PopulateFromIDParam[models.Task](middlewareHandler, "task_id", "task"),
+	)
+	competitionGroup.GET("/usage/", h.GetTaskUsage)
+	competitionGroup.GET(
+		"/pov/:pov_id/steps/",
+		h.GetPOVSteps,
+		servermiddleware.PopulateFromIDParam[models.POVSubmission](
+			middlewareHandler,
+			"pov_id",
+			"pov",
+		),
+	)
+	competitionGroup.GET(
+		"/patch/:patch_id/steps/",
+		h.GetPatchSteps,
+		servermiddleware.PopulateFromIDParam[models.PatchSubmission](
+			middlewareHandler,
+			"patch_id",
+			"patch",
+		),
+	)
+	competitionGroup.GET(
+		"/pov/:pov_id/results/",
+		h.GetPOVResults,
+		servermiddleware.PopulateFromIDParam[models.POVSubmission](
+			middlewareHandler,
+			"pov_id",
+			"pov",
+		),
+	)
+	competitionGroup.GET(
+		"/patch/:patch_id/results/",
+		h.GetPatchResults,
+		servermiddleware.PopulateFromIDParam[models.PatchSubmission](
+			middlewareHandler,
+			"patch_id",
+			"patch",
+		),
+	)
+
+	releaseGroup := competitionGroup.Group("/release")
+	releaseGroup.GET("/", h.ListReleases)
+	releaseGroup.POST("/", h.AddRelease)
+
+	releaseIDGroup := releaseGroup.Group(
+		"/:release_id",
+		servermiddleware.PopulateFromIDParam[models.ScheduledRelease](
+			middlewareHandler,
+			"release_id",
+			"release",
+		),
+	)
+	releaseIDGroup.GET("/", h.GetRelease)
+	releaseIDGroup.POST("/pause/", h.PauseRelease)
+	releaseIDGroup.POST("/resume/", h.ResumeRelease)
+	releaseIDGroup.DELETE("/", h.CancelRelease)
+
+	roundGroup := competitionGroup.Group("/round")
+	roundGroup.GET("/", h.ListRounds)
+	roundGroup.POST("/", h.AddRound)
+
+	roundIDGroup := roundGroup.Group("/:round_id", h.populateRound)
+	roundIDGroup.GET("/", h.GetRound)
+	roundIDGroup.POST("/open/", h.OpenRound)
+	roundIDGroup.POST("/freeze/", h.FreezeRound)
+	roundIDGroup.POST("/close/", h.CloseRound)
 }
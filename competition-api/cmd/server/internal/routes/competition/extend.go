@@ -0,0 +1,353 @@
+package competition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	k8serrs "k8s.io/apimachinery/pkg/api/errors"
+
+	srverr "github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/error"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/models"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/response"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/audit"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/logger"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+)
+
+var (
+	errTaskCancelled         = errors.New("task was cancelled")
+	errTaskCancelledResponse = echo.NewHTTPError(
+		http.StatusConflict,
+		types.StringError("task was cancelled and can not be extended"),
+	)
+)
+
+// Moves a task deadline forward. Cancelled tasks can't be extended.
+//
+// Submissions that were marked deadline_exceeded but were made before the new deadline are
+// accepted, POVs and patches among them are queued for evaluation, and CRSs are sent the task
+// again with the new deadline. Evaluations and the resend still pending after a failure are
+// recorded on their rows, extending to the current deadline again retries them.
+func (h *Handler) ExtendTask(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "ExtendTask")
+	defer span.End()
+
+	task, ok := c.Get("task").(*models.Task)
+	if !ok {
+		span.RecordError(srverr.ErrTypeAssertMismatch)
+		span.SetStatus(codes.Error, fmt.Sprintf("task: %s", srverr.ErrTypeAssertMismatch))
+		return response.InternalServerError
+	}
+
+	taskID := task.ID.String()
+	span.SetAttributes(
+		attribute.String("task.id", taskID),
+		attribute.String("round.id", task.RoundID),
+	)
+
+	span.AddEvent("parsing request body")
+	var rdata types.TaskExtensionSubmission
+	err := c.Bind(&rdata)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to parse request data")
+		span.RecordError(err)
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			types.StringError("failed to parse request data"),
+		)
+	}
+
+	span.AddEvent("validating request body")
+	err = c.Validate(rdata)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to validate request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, types.ValidationError(err))
+	}
+
+	if task.CancelledAt.Valid {
+		span.RecordError(nil)
+		span.SetStatus(codes.Error, "task was cancelled")
+		return errTaskCancelledResponse
+	}
+
+	oldDeadline := task.Deadline
+	newDeadline := time.UnixMilli(int64(rdata.Deadline)).UTC()
+	span.SetAttributes(
+		attribute.Int64("deadline.old_ms", oldDeadline.UnixMilli()),
+		attribute.Int64("deadline.new_ms", newDeadline.UnixMilli()),
+	)
+
+	if newDeadline.Before(oldDeadline) {
+		span.RecordError(nil)
+		span.SetStatus(codes.Error, "new deadline is before the current deadline")
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			types.Error{Message: "validation error", Fields: &map[string]string{
+				"deadline": "must not be before the current task deadline",
+			}},
+		)
+	}
+
+	reaccepted := 0
+
+	span.AddEvent("extending deadline and re-checking submissions")
+	err = h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		updates := map[string]any{"deadline": newDeadline}
+		if newDeadline.After(oldDeadline) {
+			updates["resend_pending"] = true
+		}
+		// the task may have been cancelled since it was loaded
+		result := tx.Model(task).
+			Clauses(clause.Returning{}).
+			Where("cancelled_at IS NULL").
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errTaskCancelled
+		}
+
+		withinDeadline := "status = ? AND created_at <= ?"
+		exceeded := types.SubmissionStatusDeadlineExceeded
+
+		for _, submission := range []any{&models.POVSubmission{}, &models.PatchSubmission{}} {
+			result := tx.Model(submission).
+				Where(withinDeadline, exceeded, newDeadline).
+				Where("task_id = ?", task.ID).
+				Updates(map[string]any{
+					"status":             types.SubmissionStatusAccepted,
+					"evaluation_pending": true,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			reaccepted += int(result.RowsAffected)
+		}
+
+		for _, submission := range []any{
+			&models.SARIFSubmission{},
+			&models.FreeformSubmission{},
+			&models.Bundle{},
+		} {
+			result := tx.Model(submission).
+				Where(withinDeadline, exceeded, newDeadline).
+				Where("task_id = ?", task.ID).
+				Update("status", types.SubmissionStatusAccepted)
+			if result.Error != nil {
+				return result.Error
+			}
+			reaccepted += int(result.RowsAffected)
+		}
+
+		result = tx.Model(&models.SARIFAssessment{}).
+			Where(withinDeadline, exceeded, newDeadline).
+			Where(
+				"sarif_broadcast_id IN (?)",
+				tx.Model(&models.SARIFBroadcast{}).Select("id").Where("task_id = ?", task.ID),
+			).
+			Update("status", types.SubmissionStatusAccepted)
+		if result.Error != nil {
+			return result.Error
+		}
+		reaccepted += int(result.RowsAffected)
+
+		return nil
+	})
+	if errors.Is(err, errTaskCancelled) {
+		span.RecordError(nil)
+		span.SetStatus(codes.Error, "task was cancelled")
+		return errTaskCancelledResponse
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to extend task deadline")
+		return response.InternalServerError
+	}
+
+	span.AddEvent("extended task deadline", trace.WithAttributes(
+		attribute.Int("reaccepted", reaccepted),
+	))
+
+	audit.LogTaskDeadlineExtended(
+		audit.Context{RoundID: task.RoundID, TaskID: &taskID},
+		types.UnixMilli(oldDeadline.UnixMilli()),
+		types.UnixMilli(newDeadline.UnixMilli()),
+		reaccepted,
+	)
+
+	pending, err := h.startPendingEvaluations(ctx, task)
+	if err == nil {
+		err = h.resendPendingTask(ctx, task)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to follow up on extended deadline")
+		return echo.NewHTTPError(http.StatusInternalServerError, types.Error{
+			Message: "deadline extended, but evaluations or the task resend could not be " +
+				"started; extend to the same deadline again to retry",
+			Fields: &map[string]string{
+				"pending_evaluations": strconv.Itoa(pending),
+				"task_resent":         strconv.FormatBool(!task.ResendPending),
+			},
+		})
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "extended task deadline")
+	return c.JSON(http.StatusOK, types.TaskExtensionResponse{
+		TaskID:                taskID,
+		Deadline:              types.UnixMilli(task.Deadline.UnixMilli()),
+		ReacceptedSubmissions: reaccepted,
+		PendingEvaluations:    pending,
+		TaskResent:            !task.ResendPending,
+	})
+}
+
+// Starts the evaluations of POVs and patches of `task` that are marked pending, and clears the
+// mark of each one started. Returns how many are still pending.
+func (h *Handler) startPendingEvaluations(ctx context.Context, task *models.Task) (int, error) {
+	ctx, span := tracer.Start(ctx, "startPendingEvaluations", trace.WithAttributes(
+		attribute.String("task.id", task.ID.String()),
+	))
+	defer span.End()
+
+	db := h.db.WithContext(ctx)
+	pendingForTask := db.Where(
+		"task_id = ? AND evaluation_pending AND status = ?",
+		task.ID,
+		types.SubmissionStatusAccepted,
+	)
+
+	var povs []models.POVSubmission
+	err := pendingForTask.Session(&gorm.Session{}).Find(&povs).Error
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to get pending povs")
+		return 0, err
+	}
+
+	var patches []models.PatchSubmission
+	err = pendingForTask.Session(&gorm.Session{}).Find(&patches).Error
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to get pending patches")
+		return len(povs), err
+	}
+
+	pending := len(povs) + len(patches)
+	span.SetAttributes(attribute.Int("pending", pending))
+
+	// TODO: figure out kind in tests or something such that tests can queue jobs
+	if h.jobClient == nil {
+		logger.Logger.WarnContext(
+			ctx,
+			"no job client, evaluations stay pending",
+			"task_id", task.ID.String(),
+			"pending", pending,
+		)
+		span.RecordError(nil)
+		span.SetStatus(codes.Ok, "no job client to start evaluations with")
+		return pending, nil
+	}
+
+	var errs []error
+	started := func(submission any, err error) {
+		// job names are derived from the submission, an existing one was started by a previous
+		// attempt that failed to clear the mark
+		if err != nil && !k8serrs.IsAlreadyExists(err) {
+			errs = append(errs, err)
+			return
+		}
+
+		err = db.Model(submission).Update("evaluation_pending", false).Error
+		if err != nil {
+			errs = append(errs, err)
+			return
+		}
+		pending--
+	}
+
+	for i := range povs {
+		span.AddEvent("starting pov job", trace.WithAttributes(
+			attribute.String("pov.id", povs[i].ID.String()),
+		))
+		started(&povs[i], h.evaluator.StartPOV(ctx, task, &povs[i]))
+	}
+
+	for i := range patches {
+		span.AddEvent("starting patch job", trace.WithAttributes(
+			attribute.String("patch.id", patches[i].ID.String()),
+		))
+		started(&patches[i], h.evaluator.StartPatch(ctx, task, &patches[i]))
+	}
+
+	err = errors.Join(errs...)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to start some evaluations")
+		return pending, err
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "started pending evaluations")
+	return 0, nil
+}
+
+// Sends CRSs `task` again if it is marked pending, and clears the mark once they were
+func (h *Handler) resendPendingTask(ctx context.Context, task *models.Task) error {
+	ctx, span := tracer.Start(ctx, "resendPendingTask", trace.WithAttributes(
+		attribute.String("task.id", task.ID.String()),
+		attribute.Bool("task.resend_pending", task.ResendPending),
+	))
+	defer span.End()
+
+	if !task.ResendPending {
+		span.RecordError(nil)
+		span.SetStatus(codes.Ok, "task was already resent")
+		return nil
+	}
+
+	// TODO: figure out kind in tests or something such that tests can queue jobs
+	if h.jobClient == nil {
+		logger.Logger.WarnContext(
+			ctx,
+			"no job client, task resend stays pending",
+			"task_id", task.ID.String(),
+		)
+		span.RecordError(nil)
+		span.SetStatus(codes.Ok, "no job client to resend the task with")
+		return nil
+	}
+
+	span.AddEvent("notifying crs of new deadline")
+	err := h.challengesClient.ResendTask(ctx, task, h.Teams)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to resend task")
+		return err
+	}
+
+	err = h.db.WithContext(ctx).Model(task).Update("resend_pending", false).Error
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to clear pending resend")
+		return err
+	}
+	task.ResendPending = false
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "resent task")
+	return nil
+}
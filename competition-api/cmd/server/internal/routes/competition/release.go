@@ -0,0 +1,242 @@
+package competition
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"gorm.io/gorm/clause"
+
+	srverr "github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/error"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/models"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/response"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+)
+
+func (h *Handler) ListReleases(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "ListReleases")
+	defer span.End()
+
+	db := h.db.WithContext(ctx)
+
+	query := db.Order("release_at ASC")
+	if status := c.QueryParam("status"); status != "" {
+		span.SetAttributes(attribute.String("release.status", status))
+		query = query.Where("status = ?", status)
+	}
+
+	span.AddEvent("fetching releases")
+	var releases []models.ScheduledRelease
+	err := query.Find(&releases).Error
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to fetch releases")
+		return response.InternalServerError
+	}
+
+	resp := types.ReleaseListResponse{Releases: make([]types.Release, 0, len(releases))}
+	for _, release := range releases {
+		resp.Releases = append(resp.Releases, release.ToResponse())
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "fetched releases")
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) GetRelease(c echo.Context) error {
+	_, span := tracer.Start(c.Request().Context(), "GetRelease")
+	defer span.End()
+
+	release, ok := c.Get("release").(*models.ScheduledRelease)
+	if !ok {
+		span.RecordError(srverr.ErrTypeAssertMismatch)
+		span.SetStatus(codes.Error, fmt.Sprintf("release: %s", srverr.ErrTypeAssertMismatch))
+		return response.InternalServerError
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "fetched release")
+	return c.JSON(http.StatusOK, release.ToResponse())
+}
+
+func (h *Handler) AddRelease(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "AddRelease")
+	defer span.End()
+
+	db := h.db.WithContext(ctx)
+
+	span.AddEvent("parsing request body")
+	var rdata types.ReleaseSubmission
+	err := c.Bind(&rdata)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to parse request data")
+		span.RecordError(err)
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			types.StringError("failed to parse request data"),
+		)
+	}
+
+	span.AddEvent("validating request body")
+	err = c.Validate(rdata)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to validate request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, types.ValidationError(err))
+	}
+
+	span.AddEvent("resolving target teams")
+	teams := make([]string, 0, len(rdata.Teams))
+	for _, teamID := range rdata.Teams {
+		found := false
+		for _, team := range h.Teams {
+			if strings.EqualFold(team.ID, teamID) {
+				teams = append(teams, team.ID)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			span.SetStatus(codes.Error, "unknown team in release")
+			span.RecordError(nil)
+			return echo.NewHTTPError(
+				http.StatusBadRequest,
+				types.StringError(fmt.Sprintf("unknown team: %s", teamID)),
+			)
+		}
+	}
+
+	roundID := h.RoundID
+	if rdata.RoundID != nil {
+		roundID = *rdata.RoundID
+	}
+
+	taskType := types.TaskTypeFull
+	if rdata.BaseRef != nil {
+		taskType = types.TaskTypeDelta
+	}
+
+	release := models.ScheduledRelease{
+		Name:           rdata.Name,
+		Type:           taskType,
+		RepoURL:        rdata.RepoURL,
+		HeadRef:        rdata.HeadRef,
+		BaseRef:        models.NewNull(rdata.BaseRef),
+		DurationSecs:   rdata.DurationSecs,
+		ReleaseAt:      time.UnixMilli(int64(rdata.ReleaseAt)).UTC(),
+		Teams:          teams,
+		RoundID:        roundID,
+		InstallationID: models.NewNull(rdata.InstallationID),
+		Status:         types.ReleaseStatusScheduled,
+	}
+
+	span.AddEvent("creating release")
+	err = db.Create(&release).Error
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to create release")
+		return response.InternalServerError
+	}
+
+	span.SetAttributes(attribute.String("release.id", release.ID.String()))
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "created release")
+	return c.JSON(http.StatusOK, release.ToResponse())
+}
+
+func (h *Handler) PauseRelease(c echo.Context) error {
+	return h.transitionRelease(
+		c,
+		"PauseRelease",
+		[]types.ReleaseStatus{types.ReleaseStatusScheduled},
+		types.ReleaseStatusPaused,
+	)
+}
+
+func (h *Handler) ResumeRelease(c echo.Context) error {
+	return h.transitionRelease(
+		c,
+		"ResumeRelease",
+		[]types.ReleaseStatus{types.ReleaseStatusPaused},
+		types.ReleaseStatusScheduled,
+	)
+}
+
+func (h *Handler) CancelRelease(c echo.Context) error {
+	return h.transitionRelease(
+		c,
+		"CancelRelease",
+		[]types.ReleaseStatus{types.ReleaseStatusScheduled, types.ReleaseStatusPaused},
+		types.ReleaseStatusCancelled,
+	)
+}
+
+// Moves a release to `to` if and only if it is currently in one of `from`.
+//
+// The status check is part of the update so a release the scheduler has already claimed can not
+// be modified.
+func (h *Handler) transitionRelease(
+	c echo.Context,
+	spanName string,
+	from []types.ReleaseStatus,
+	to types.ReleaseStatus,
+) error {
+	ctx, span := tracer.Start(c.Request().Context(), spanName)
+	defer span.End()
+
+	db := h.db.WithContext(ctx)
+
+	release, ok := c.Get("release").(*models.ScheduledRelease)
+	if !ok {
+		span.RecordError(srverr.ErrTypeAssertMismatch)
+		span.SetStatus(codes.Error, fmt.Sprintf("release: %s", srverr.ErrTypeAssertMismatch))
+		return response.InternalServerError
+	}
+
+	span.SetAttributes(
+		attribute.String("release.id", release.ID.String()),
+		attribute.String("release.status.to", string(to)),
+	)
+
+	span.AddEvent("updating release status")
+	result := db.Model(release).
+		Clauses(clause.Returning{}).
+		Where("status IN ?", from).
+		Update("status", to)
+	if result.Error != nil {
+		span.RecordError(result.Error)
+		span.SetStatus(codes.Error, "failed to update release status")
+		return response.InternalServerError
+	}
+
+	if result.RowsAffected == 0 {
+		// the update already wrote `to` into release, the row says what it actually is
+		var current models.ScheduledRelease
+		err := db.Select("status").Where("id = ?", release.ID).First(&current).Error
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to get current release status")
+			return response.InternalServerError
+		}
+
+		span.RecordError(nil)
+		span.SetStatus(codes.Ok, "release is not in a valid state for transition")
+		return echo.NewHTTPError(
+			http.StatusConflict,
+			types.StringError(
+				fmt.Sprintf("release can not move from %s to %s", current.Status, to),
+			),
+		)
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "updated release status")
+	return c.JSON(http.StatusOK, release.ToResponse())
+}
@@ -0,0 +1,60 @@
+package competition
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	srverr "github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/error"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/models"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/response"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+)
+
+// Commands the worker ran evaluating a POV, with their exit codes and output blobs
+func (h *Handler) GetPOVResults(c echo.Context) error {
+	_, span := tracer.Start(c.Request().Context(), "GetPOVResults")
+	defer span.End()
+
+	pov, ok := c.Get("pov").(*models.POVSubmission)
+	if !ok {
+		span.RecordError(srverr.ErrTypeAssertMismatch)
+		span.SetStatus(codes.Error, fmt.Sprintf("pov: %s", srverr.ErrTypeAssertMismatch))
+		return response.InternalServerError
+	}
+
+	span.SetAttributes(attribute.String("pov.id", pov.ID.String()))
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "fetched pov results")
+	return c.JSON(http.StatusOK, resultsResponse(pov.Results))
+}
+
+// Commands the worker ran evaluating a patch, with their exit codes and output blobs
+func (h *Handler) GetPatchResults(c echo.Context) error {
+	_, span := tracer.Start(c.Request().Context(), "GetPatchResults")
+	defer span.End()
+
+	patch, ok := c.Get("patch").(*models.PatchSubmission)
+	if !ok {
+		span.RecordError(srverr.ErrTypeAssertMismatch)
+		span.SetStatus(codes.Error, fmt.Sprintf("patch: %s", srverr.ErrTypeAssertMismatch))
+		return response.InternalServerError
+	}
+
+	span.SetAttributes(attribute.String("patch.id", patch.ID.String()))
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "fetched patch results")
+	return c.JSON(http.StatusOK, resultsResponse(patch.Results))
+}
+
+func resultsResponse(results []types.JobResult) types.SubmissionResultsResponse {
+	if results == nil {
+		results = []types.JobResult{}
+	}
+	return types.SubmissionResultsResponse{Results: results}
+}
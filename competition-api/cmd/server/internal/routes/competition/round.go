@@ -0,0 +1,208 @@
+package competition
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"gorm.io/gorm"
+
+	srverr "github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/error"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/models"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/response"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/audit"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+)
+
+// Retrieves the round named in the `round_id` param from the db
+func (h *Handler) populateRound(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx, span := tracer.Start(c.Request().Context(), "populateRound")
+		defer span.End()
+
+		roundID := c.Param("round_id")
+		span.SetAttributes(attribute.String("round.id", roundID))
+
+		span.AddEvent("fetching round")
+		round, err := models.RoundByRoundID(ctx, h.db, roundID)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to fetch round")
+
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return response.NotFoundError
+			}
+
+			return response.InternalServerError
+		}
+
+		c.Set("round", round)
+
+		span.RecordError(nil)
+		span.SetStatus(codes.Ok, "fetched round")
+		return next(c)
+	}
+}
+
+func (h *Handler) ListRounds(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "ListRounds")
+	defer span.End()
+
+	db := h.db.WithContext(ctx)
+
+	span.AddEvent("fetching rounds")
+	var rounds []models.Round
+	err := db.Order("created_at ASC").Find(&rounds).Error
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to fetch rounds")
+		return response.InternalServerError
+	}
+
+	resp := types.RoundListResponse{Rounds: make([]types.Round, 0, len(rounds))}
+	for _, round := range rounds {
+		resp.Rounds = append(resp.Rounds, round.ToResponse())
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "fetched rounds")
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) GetRound(c echo.Context) error {
+	_, span := tracer.Start(c.Request().Context(), "GetRound")
+	defer span.End()
+
+	round, ok := c.Get("round").(*models.Round)
+	if !ok {
+		span.RecordError(srverr.ErrTypeAssertMismatch)
+		span.SetStatus(codes.Error, fmt.Sprintf("round: %s", srverr.ErrTypeAssertMismatch))
+		return response.InternalServerError
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "fetched round")
+	return c.JSON(http.StatusOK, round.ToResponse())
+}
+
+func (h *Handler) AddRound(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "AddRound")
+	defer span.End()
+
+	db := h.db.WithContext(ctx)
+
+	span.AddEvent("parsing request body")
+	var rdata types.RoundSubmission
+	err := c.Bind(&rdata)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to parse request data")
+		span.RecordError(err)
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			types.StringError("failed to parse request data"),
+		)
+	}
+
+	span.AddEvent("validating request body")
+	err = c.Validate(rdata)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to validate request")
+		span.RecordError(err)
+		return echo.NewHTTPError(http.StatusBadRequest, types.ValidationError(err))
+	}
+
+	span.SetAttributes(attribute.String("round.id", rdata.RoundID))
+
+	round := models.Round{
+		RoundID: rdata.RoundID,
+		State:   types.RoundStatePlanned,
+	}
+	if rdata.StartTime != nil {
+		round.StartTime = models.NewNullFromData(time.UnixMilli(int64(*rdata.StartTime)).UTC())
+	}
+	if rdata.EndTime != nil {
+		round.EndTime = models.NewNullFromData(time.UnixMilli(int64(*rdata.EndTime)).UTC())
+	}
+
+	span.AddEvent("checking if round already exists")
+	exists, err := models.Exists[models.Round](ctx, db, "round_id = ?", rdata.RoundID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to check if round exists")
+		return response.InternalServerError
+	}
+
+	if exists {
+		span.RecordError(nil)
+		span.SetStatus(codes.Error, "round already exists")
+		return echo.NewHTTPError(http.StatusConflict, types.StringError("round already exists"))
+	}
+
+	span.AddEvent("creating round")
+	err = db.Create(&round).Error
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to create round")
+		return response.InternalServerError
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "created round")
+	return c.JSON(http.StatusOK, round.ToResponse())
+}
+
+func (h *Handler) OpenRound(c echo.Context) error {
+	return h.transitionRound(c, "OpenRound", types.RoundStateOpen)
+}
+
+func (h *Handler) FreezeRound(c echo.Context) error {
+	return h.transitionRound(c, "FreezeRound", types.RoundStateFrozen)
+}
+
+func (h *Handler) CloseRound(c echo.Context) error {
+	return h.transitionRound(c, "CloseRound", types.RoundStateClosed)
+}
+
+func (h *Handler) transitionRound(c echo.Context, spanName string, to types.RoundState) error {
+	ctx, span := tracer.Start(c.Request().Context(), spanName)
+	defer span.End()
+
+	round, ok := c.Get("round").(*models.Round)
+	if !ok {
+		span.RecordError(srverr.ErrTypeAssertMismatch)
+		span.SetStatus(codes.Error, fmt.Sprintf("round: %s", srverr.ErrTypeAssertMismatch))
+		return response.InternalServerError
+	}
+
+	span.SetAttributes(
+		attribute.String("round.id", round.RoundID),
+		attribute.String("round.state.to", string(to)),
+	)
+
+	span.AddEvent("transitioning round")
+	from, err := models.TransitionRound(ctx, h.db, round, to, time.Now())
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidRoundTransition) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "invalid round transition")
+			return echo.NewHTTPError(
+				http.StatusConflict,
+				types.StringError(fmt.Sprintf("round can not move from %s to %s", from, to)),
+			)
+		}
+
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to transition round")
+		return response.InternalServerError
+	}
+
+	audit.LogRoundTransition(audit.Context{RoundID: round.RoundID}, from, to)
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "transitioned round")
+	return c.JSON(http.StatusOK, round.ToResponse())
+}
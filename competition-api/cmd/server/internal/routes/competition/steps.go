@@ -0,0 +1,60 @@
+package competition
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	srverr "github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/error"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/models"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/response"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+)
+
+// Timeline of the latest evaluation of a POV, as reported by the worker
+func (h *Handler) GetPOVSteps(c echo.Context) error {
+	_, span := tracer.Start(c.Request().Context(), "GetPOVSteps")
+	defer span.End()
+
+	pov, ok := c.Get("pov").(*models.POVSubmission)
+	if !ok {
+		span.RecordError(srverr.ErrTypeAssertMismatch)
+		span.SetStatus(codes.Error, fmt.Sprintf("pov: %s", srverr.ErrTypeAssertMismatch))
+		return response.InternalServerError
+	}
+
+	span.SetAttributes(attribute.String("pov.id", pov.ID.String()))
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "fetched pov steps")
+	return c.JSON(http.StatusOK, stepsResponse(pov.Steps))
+}
+
+// Timeline of the latest evaluation of a patch, as reported by the worker
+func (h *Handler) GetPatchSteps(c echo.Context) error {
+	_, span := tracer.Start(c.Request().Context(), "GetPatchSteps")
+	defer span.End()
+
+	patch, ok := c.Get("patch").(*models.PatchSubmission)
+	if !ok {
+		span.RecordError(srverr.ErrTypeAssertMismatch)
+		span.SetStatus(codes.Error, fmt.Sprintf("patch: %s", srverr.ErrTypeAssertMismatch))
+		return response.InternalServerError
+	}
+
+	span.SetAttributes(attribute.String("patch.id", patch.ID.String()))
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "fetched patch steps")
+	return c.JSON(http.StatusOK, stepsResponse(patch.Steps))
+}
+
+func stepsResponse(steps []types.EvalStep) types.EvalStepsResponse {
+	if steps == nil {
+		steps = []types.EvalStep{}
+	}
+	return types.EvalStepsResponse{Steps: steps}
+}
@@ -0,0 +1,33 @@
+package competition
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/models"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/response"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+)
+
+// How long evaluating submissions against each task of the round took, next to the memory and
+// CPUs the task's evaluations are given. The longest running tasks come first.
+func (h *Handler) GetTaskUsage(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "GetTaskUsage")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("round.id", h.RoundID))
+
+	tasks, err := models.TaskResourceUsage(ctx, h.db, h.RoundID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to get task resource usage")
+		return response.InternalServerError
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "fetched task resource usage")
+	return c.JSON(http.StatusOK, types.TaskResourceUsageListResponse{Tasks: tasks})
+}
@@ -6,6 +6,7 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"strconv"
@@ -27,7 +28,6 @@ import (
 	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/response"
 	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/taskrunner"
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/config"
-	"github.com/aixcyberchallenge/competition-api/competition-api/internal/identifier"
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/upload"
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/validator"
@@ -82,6 +82,12 @@ func (h *Handler) AddRoutes(e *echo.Echo, middlewareHandler *servermiddleware.Ha
 		servermiddleware.PopulateFromIDParam[models.Job](middlewareHandler, "job_id", "job"),
 	)
 
+	jobsGroup.GET(
+		"/task/:task_id/pov-clusters/",
+		h.GetPOVClusters,
+		servermiddleware.PopulateFromIDParam[models.Task](middlewareHandler, "task_id", "task"),
+	)
+
 	jobsGroup.POST("/job/", h.RunTest)
 	jobsGroup.POST("/job/bulk/", h.RunBulkTests)
 	jobsGroup.POST("/job/bulk/results/", h.PostJobResultsBulk)
@@ -226,11 +232,16 @@ func (h *Handler) doJobWork(
 		span.AddEvent("cpus is set, overriding cpus with the value")
 		task.CPUs = *jobRequest.CPUs
 	}
+	if jobRequest.Reproducibility != nil {
+		span.AddEvent("reproducibility is set, overriding the task's reproducibility policy")
+		task.Reproducibility = jobRequest.Reproducibility
+	}
 	if jobRequest.HeadRepoTarballURL != nil {
 		span.AddEvent(
 			"repo_tarball_url is set, overriding Repo Tarball URL with provided URL",
 		)
 		sources.HeadRepo = *jobRequest.HeadRepoTarballURL
+		sources.HeadRepoSHA256 = ""
 		cacheToHash = append(cacheToHash, sources.HeadRepo)
 	} else {
 		cacheToHash = append(cacheToHash, task.UnstrippedSource.HeadRepo.SHA256)
@@ -240,6 +251,7 @@ func (h *Handler) doJobWork(
 			"oss_fuzz_tarball is set, overriding OSS Fuzz Tarball URL with provided URL",
 		)
 		sources.FuzzTooling = *jobRequest.OssFuzzTarballURL
+		sources.FuzzToolingSHA256 = ""
 		cacheToHash = append(cacheToHash, sources.FuzzTooling)
 	} else {
 		cacheToHash = append(cacheToHash, task.UnstrippedSource.FuzzTooling.SHA256)
@@ -249,6 +261,7 @@ func (h *Handler) doJobWork(
 			"diff_tarball is set, overriding Diff Tarball URL with provided URL",
 		)
 		sources.BaseRepo = *jobRequest.BaseTarballURL
+		sources.BaseRepoSHA256 = ""
 		cacheToHash = append(cacheToHash, sources.BaseRepo)
 	} else if task.UnstrippedSource.BaseRepo != nil {
 		cacheToHash = append(cacheToHash, task.UnstrippedSource.BaseRepo.SHA256)
@@ -259,23 +272,18 @@ func (h *Handler) doJobWork(
 		skipPatchTests = *jobRequest.SkipPatchTests
 	}
 
-	args := []string{
-		"--head-repo-url", sources.HeadRepo,
-		"--oss-fuzz-url", sources.FuzzTooling,
-		"--focus", task.Focus,
-		"--project-name", task.ProjectName,
-		"--architecture", *jobRequest.Architecture,
-		"--export-results",
-	}
+	// the job id is filled in once the job row exists
+	spec := models.NewEvalSpec(task, sources, types.JobTypeJob, "")
+	spec.Architecture = types.Architecture(*jobRequest.Architecture)
+	spec.ExportResults = true
 	cacheToHash = append(cacheToHash, task.Focus, task.ProjectName)
-
-	if skipPatchTests {
-		args = append(args, "--skip-patch-tests")
-	}
 	cacheToHash = append(cacheToHash, strconv.FormatBool(skipPatchTests))
-
-	if sources.BaseRepo != "" {
-		args = append(args, "--base-repo-url", sources.BaseRepo)
+	if task.Reproducibility != nil {
+		cacheToHash = append(
+			cacheToHash,
+			strconv.Itoa(task.Reproducibility.Runs),
+			strconv.FormatFloat(task.Reproducibility.Threshold, 'g', -1, 64),
+		)
 	}
 
 	testcaseBlob := jobRequest.TestcaseHash
@@ -361,11 +369,12 @@ func (h *Handler) doJobWork(
 			span.RecordError(err)
 			return nil, response.InternalServerError
 		}
-		args = append(args,
-			"--trigger-url", triggerURL,
-			"--sanitizer", *jobRequest.Sanitizer,
-			"--harness-name", *jobRequest.FuzzerName,
-			"--engine", *jobRequest.Engine,
+		spec.POV = types.NewEvalPOV(
+			triggerURL,
+			*testcaseBlob,
+			*jobRequest.Sanitizer,
+			*jobRequest.FuzzerName,
+			types.FuzzingEngine(*jobRequest.Engine),
 		)
 		cacheToHash = append(
 			cacheToHash,
@@ -460,12 +469,17 @@ func (h *Handler) doJobWork(
 			span.RecordError(err)
 			return nil, response.InternalServerError
 		}
-		args = append(args,
-			"--allowed-languages", identifier.LanguageC,
-			"--allowed-languages", identifier.LanguageJava,
-			"--patch-url", patchURL,
-		)
+		spec.Patch = types.NewEvalPatch(patchURL, *patchBlob, skipPatchTests, task.PatchPolicy)
 		cacheToHash = append(cacheToHash, "patch", *patchBlob)
+		if task.PatchPolicy != nil {
+			policy, err := json.Marshal(task.PatchPolicy)
+			if err != nil {
+				span.SetStatus(codes.Error, "failed to marshal patch policy")
+				span.RecordError(err)
+				return nil, response.InternalServerError
+			}
+			cacheToHash = append(cacheToHash, "patch_policy", string(policy))
+		}
 	}
 
 	if h.JobClient == nil {
@@ -492,15 +506,19 @@ func (h *Handler) doJobWork(
 		}
 		jobID := job.ID.String()
 		span.SetAttributes(attribute.String("job.id", jobID))
-		args = append(args, "--job-id", jobID)
 
 		if result.RowsAffected == 1 {
+			spec.EntityID = jobID
+			span.AddEvent("storing eval spec")
+			err := db.Model(job).Updates(&models.Job{EvalSpec: spec}).Error
+			if err != nil {
+				return err
+			}
+
 			span.AddEvent("starting job")
-			_, err := h.JobClient.CreateEvalJob(
+			_, err = h.JobClient.CreateEvalJob(
 				ctx,
-				types.JobTypeJob,
-				jobID,
-				args,
+				spec,
 				task.MemoryGB,
 				task.CPUs,
 				nil,
@@ -533,7 +551,11 @@ func (h *Handler) doJobWork(
 		JobID:                     job.ID.String(),
 		Status:                    job.Status,
 		FunctionalityTestsPassing: models.PtrFromNull(job.FunctionalityTestsPassing),
+		Reason:                    job.Reason,
 		Results:                   presigned.Results,
 		Artifacts:                 presigned.Artifacts,
+		Steps:                     job.Steps,
+		Reproducibility:           job.Reproducibility,
+		Usage:                     job.Usage,
 	}, nil
 }
@@ -0,0 +1,41 @@
+package jobrunner
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	srverr "github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/error"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/models"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/response"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+)
+
+// Passed POVs of a task grouped by the crash they triggered, with who found each crash first
+func (h *Handler) GetPOVClusters(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "GetPOVClusters")
+	defer span.End()
+
+	task, ok := c.Get("task").(*models.Task)
+	if !ok {
+		span.RecordError(srverr.ErrTypeAssertMismatch)
+		span.SetStatus(codes.Error, fmt.Sprintf("task: %s", srverr.ErrTypeAssertMismatch))
+		return response.InternalServerError
+	}
+
+	span.SetAttributes(attribute.String("task.id", task.ID.String()))
+
+	clusters, err := models.POVClustersForTask(ctx, h.DB, task.ID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to get pov clusters")
+		return response.InternalServerError
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "fetched pov clusters")
+	return c.JSON(http.StatusOK, types.POVClusterListResponse{Clusters: clusters})
+}
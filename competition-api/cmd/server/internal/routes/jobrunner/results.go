@@ -71,8 +71,12 @@ func (h *Handler) PostJobResultsBulk(c echo.Context) error {
 			JobID:                     job.ID.String(),
 			Status:                    job.Status,
 			FunctionalityTestsPassing: models.PtrFromNull(job.FunctionalityTestsPassing),
+			Reason:                    job.Reason,
 			Artifacts:                 presigned.Artifacts,
 			Results:                   presigned.Results,
+			Steps:                     job.Steps,
+			Reproducibility:           job.Reproducibility,
+			Usage:                     job.Usage,
 		})
 	}
 
@@ -105,7 +109,11 @@ func (h *Handler) GetJobResults(c echo.Context) error {
 		JobID:                     job.ID.String(),
 		Status:                    job.Status,
 		FunctionalityTestsPassing: models.PtrFromNull(job.FunctionalityTestsPassing),
+		Reason:                    job.Reason,
 		Artifacts:                 presigned.Artifacts,
 		Results:                   presigned.Results,
+		Steps:                     job.Steps,
+		Reproducibility:           job.Reproducibility,
+		Usage:                     job.Usage,
 	})
 }
@@ -2,6 +2,7 @@ package v1
 
 import (
 	"bytes"
+	"cmp"
 	"encoding/base64"
 	"fmt"
 	"net/http"
@@ -17,7 +18,7 @@ import (
 	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/response"
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/archive"
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/audit"
-	"github.com/aixcyberchallenge/competition-api/competition-api/internal/identifier"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/patchpolicy"
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/upload"
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/validator"
@@ -125,6 +126,26 @@ func (h *Handler) SubmitPatch(c echo.Context) error {
 		)
 	}
 
+	span.AddEvent("checking submission against the patch policy")
+	if violations := checkPatchPolicy(task, patchData); len(violations) > 0 {
+		span.SetAttributes(attribute.Int("patch.violations", len(violations)))
+		span.SetStatus(codes.Ok, "submission violates the patch policy")
+		span.RecordError(nil)
+		fields := make(map[string]string, len(violations))
+		for _, v := range violations {
+			// violations of patch wide limits have no path
+			key := cmp.Or(v.Path, "patch")
+			if fields[key] != "" {
+				fields[key] += ", "
+			}
+			fields[key] += string(v.Rule)
+		}
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			types.Error{Message: "patch violates the task's patch policy", Fields: &fields},
+		)
+	}
+
 	span.AddEvent("uploading submission")
 	blobName, err := upload.Hashed(
 		ctx,
@@ -185,54 +206,8 @@ func (h *Handler) SubmitPatch(c echo.Context) error {
 	}
 
 	if !deadlinePassed && h.JobClient != nil {
-		expiration := time.Hour * 100
-
-		span.AddEvent("getting presigned submission URL for job kickoff", trace.WithAttributes(
-			attribute.String("expiration", expiration.String()),
-		))
-		patchURL, err := h.submissionUploader.PresignedReadURL(ctx, patch.PatchFilePath, expiration)
-		if err != nil {
-			span.SetStatus(codes.Error, "failed to get presigned url")
-			span.RecordError(err)
-			return response.InternalServerError
-		}
-
-		span.AddEvent("getting presigned source URLs for job kickoff", trace.WithAttributes(
-			attribute.String("expiration", expiration.String()),
-		))
-		sources, err := task.GetSourceURLs(ctx, h.sourcesUploader, expiration)
-		if err != nil {
-			span.SetStatus(codes.Error, "failed to get presigned url")
-			span.RecordError(err)
-			return response.InternalServerError
-		}
-
-		args := []string{
-			"--head-repo-url", sources.HeadRepo,
-			"--focus", task.Focus,
-			"--project-name", task.ProjectName,
-			"--oss-fuzz-url", sources.FuzzTooling,
-			"--architecture", "x86_64",
-			"--patch-url", patchURL,
-			"--allowed-languages", identifier.LanguageC,
-			"--allowed-languages", identifier.LanguageJava,
-			"--patch-id", patch.ID.String(),
-		}
-
-		taskID := task.ID.String()
-		authID := auth.ID.String()
 		span.AddEvent("starting job")
-		_, err = h.JobClient.CreateEvalJob(
-			ctx,
-			types.JobTypePatch,
-			patch.ID.String(),
-			args,
-			task.MemoryGB,
-			task.CPUs,
-			&task.RoundID,
-			&taskID,
-			&authID,
-		)
+		err = h.evaluator.StartPatch(ctx, task, &patch)
 		if err != nil {
 			span.SetStatus(codes.Error, "failed to start job")
 			span.RecordError(err)
@@ -254,6 +229,19 @@ func (h *Handler) SubmitPatch(c echo.Context) error {
 	})
 }
 
+// Checks `patchData` against the task's policy. Patches that don't parse are left for the
+// worker to fail like they always have.
+func checkPatchPolicy(task *models.Task, patchData []byte) []types.PatchViolation {
+	if task.PatchPolicy == nil {
+		return nil
+	}
+	files, err := patchpolicy.Parse(bytes.NewReader(patchData))
+	if err != nil {
+		return nil
+	}
+	return patchpolicy.Check(task.PatchPolicy, files)
+}
+
 func (*Handler) PatchStatus(c echo.Context) error {
 	_, span := tracer.Start(c.Request().Context(), "PatchStatus")
 	defer span.End()
@@ -188,65 +188,8 @@ func (h *Handler) SubmitPOV(c echo.Context) error {
 	}
 
 	if !deadlinePassed && h.JobClient != nil {
-		expiration := time.Hour * 100
-
-		span.AddEvent("getting presigned submission URL for job kickoff", trace.WithAttributes(
-			attribute.String("expiration", expiration.String()),
-		))
-		triggerURL, err := h.submissionUploader.PresignedReadURL(
-			ctx,
-			povSubmission.TestcasePath,
-			expiration,
-		)
-		if err != nil {
-			span.SetStatus(codes.Error, "failed to get presigned url")
-			span.RecordError(err)
-			return response.InternalServerError
-		}
-
-		span.AddEvent("getting presigned source URLs for job kickoff", trace.WithAttributes(
-			attribute.String("expiration", expiration.String()),
-		))
-		sources, err := task.GetSourceURLs(ctx, h.sourcesUploader, expiration)
-		if err != nil {
-			span.SetStatus(codes.Error, "failed to get presigned url")
-			span.RecordError(err)
-			return response.InternalServerError
-		}
-
-		args := []string{
-			"--head-repo-url", sources.HeadRepo,
-			"--focus", task.Focus,
-			"--project-name", task.ProjectName,
-			"--oss-fuzz-url", sources.FuzzTooling,
-			"--architecture", povSubmission.Architecture,
-			"--trigger-url", triggerURL,
-			"--sanitizer", povSubmission.Sanitizer,
-			"--harness-name", povSubmission.FuzzerName,
-			"--engine", povSubmission.Engine,
-			"--pov-id", povSubmission.ID.String(),
-			"--archive-s3",
-		}
-
-		// this is only set if the task type is delta and if it exists in the getSourceURLS function
-		if sources.BaseRepo != "" {
-			args = append(args, "--base-repo-url", sources.BaseRepo)
-		}
-
-		taskID := task.ID.String()
-		authID := auth.ID.String()
 		span.AddEvent("starting job")
-		_, err = h.JobClient.CreateEvalJob(
-			ctx,
-			types.JobTypePOV,
-			povSubmission.ID.String(),
-			args,
-			task.MemoryGB,
-			task.CPUs,
-			&task.RoundID,
-			&taskID,
-			&authID,
-		)
+		err = h.evaluator.StartPOV(ctx, task, &povSubmission)
 		if err != nil {
 			span.SetStatus(codes.Error, "failed to start job")
 			span.RecordError(err)
@@ -6,7 +6,6 @@ import (
 	"net/http"
 	"time"
 
-	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/labstack/echo/v4"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -108,13 +107,10 @@ func (h *Handler) RequestChallenge(c echo.Context) error {
 		ctx, span := tracer.Start(ctx, "RequestChallngeTaskingFunction")
 		defer span.End()
 
-		span.AddEvent("get token for installation ID")
-		token, err := h.githubClient.CreateInstallationToken(
-			ctx,
-			*h.config.Generate.InstallationID,
-		)
+		span.AddEvent("building challenge source")
+		source, err := h.challengeSource(ctx, challenge)
 		if err != nil {
-			span.SetStatus(codes.Error, "failed to get installation token")
+			span.SetStatus(codes.Error, "failed to build challenge source")
 			span.RecordError(err)
 			return
 		}
@@ -125,10 +121,7 @@ func (h *Handler) RequestChallenge(c echo.Context) error {
 			HeadRef:      *challenge.Config.HeadRef,
 			RepoURL:      *challenge.Config.RepoURL,
 			TaskDuration: time.Second * time.Duration(*rdata.DurationSecs),
-			AuthMethod: githttp.BasicAuth{
-				Username: "token",
-				Password: token.GetToken(),
-			},
+			Source:       source,
 		}
 
 		if challenge.Config.BaseRef == nil {
@@ -178,6 +171,45 @@ func (h *Handler) RequestChallenge(c echo.Context) error {
 	return c.JSON(http.StatusOK, types.Message{Message: "received request for task"})
 }
 
+// Builds the source a configured challenge is fetched from. Challenges without a source, or with
+// a github source, are fetched from GitHub with an installation token.
+func (h *Handler) challengeSource(
+	ctx context.Context,
+	challenge *config.GenerateChallengeConfig,
+) (challenges.ChallengeSource, error) {
+	ctx, span := tracer.Start(ctx, "challengeSource")
+	defer span.End()
+
+	sourceConfig := challenge.Config.Source
+	if sourceConfig != nil && sourceConfig.Type != "" &&
+		sourceConfig.Type != challenges.ChallengeSourceGitHub {
+		span.SetAttributes(attribute.String("challenge.source", sourceConfig.Type))
+		source, err := challenges.NewSourceFromConfig(sourceConfig)
+		if err != nil {
+			span.SetStatus(codes.Error, "failed to build challenge source from config")
+			span.RecordError(err)
+			return nil, err
+		}
+
+		span.SetStatus(codes.Ok, "")
+		span.RecordError(nil)
+		return source, nil
+	}
+
+	span.SetAttributes(attribute.String("challenge.source", challenges.ChallengeSourceGitHub))
+	span.AddEvent("get token for installation ID")
+	token, err := h.githubClient.CreateInstallationToken(ctx, *h.config.Generate.InstallationID)
+	if err != nil {
+		span.SetStatus(codes.Error, "failed to get installation token")
+		span.RecordError(err)
+		return nil, err
+	}
+
+	span.SetStatus(codes.Ok, "")
+	span.RecordError(nil)
+	return challenges.NewHTTPSGitSource("token", token.GetToken()), nil
+}
+
 func (h *Handler) RequestList(c echo.Context) error {
 	_, span := tracer.Start(c.Request().Context(), "RequestList")
 	defer span.End()
@@ -11,6 +11,7 @@ import (
 
 	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/challenges"
 	srverr "github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/error"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/evaluation"
 	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/github"
 	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/jobs"
 	servermiddleware "github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/middleware"
@@ -35,6 +36,7 @@ type Handler struct {
 	githubClient       *github.Client
 	taskrunnerClient   *taskrunner.Client
 	challengesClient   *challenges.Client
+	evaluator          *evaluation.Client
 	config             *config.Config
 	archiver           upload.Uploader
 	submissionUploader upload.Uploader
@@ -108,6 +110,7 @@ func NewHandler(
 		taskrunnerClient:   taskrunnerClient,
 		githubClient:       githubClient,
 		challengesClient:   challengesClient,
+		evaluator:          evaluation.Create(db, jobClient, submissionUploader, sourcesUploader),
 		config:             cfg,
 		archiver:           archiver,
 		submissionUploader: submissionUploader,
@@ -143,9 +146,12 @@ func (h *Handler) AddRoutes(e *echo.Echo, middlewareHandler *servermiddleware.Ha
 		servermiddleware.HasPermissions("auth", &models.Permissions{CRS: true}),
 		servermiddleware.PopulateFromIDParam[models.Task](middlewareHandler, "task_id", "task"),
 		servermiddleware.RoundID(
+			middlewareHandler,
 			map[string]bool{*h.config.RoundID: true, *h.config.Generate.RoundID: true},
 			"task",
+			"round",
 		),
+		servermiddleware.RoundAcceptsSubmissions("round"),
 	)
 	requestGroup := v1Group.Group(
 		"/request",
@@ -9,7 +9,6 @@ import (
 	"slices"
 	"time"
 
-	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/google/go-github/v66/github"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -193,10 +192,7 @@ func (h *Handler) HandleGithubWebhook(c echo.Context) error {
 				RepoURL:      repoURL,
 				HeadRef:      diffRef,
 				BaseRef:      &baseRef,
-				AuthMethod: githttp.BasicAuth{
-					Username: "arbitrary",
-					Password: token.GetToken(),
-				},
+				Source:       challenges.NewHTTPSGitSource("arbitrary", token.GetToken()),
 			}, h.teams, h.roundID)
 
 			if err != nil {
@@ -291,10 +287,7 @@ func (h *Handler) HandleGithubWebhook(c echo.Context) error {
 				TaskDuration: duration,
 				RepoURL:      repoURL,
 				HeadRef:      e.Release.GetTagName(),
-				AuthMethod: githttp.BasicAuth{
-					Username: "arbitrary",
-					Password: token.GetToken(),
-				},
+				Source:       challenges.NewHTTPSGitSource("arbitrary", token.GetToken()),
 			}, h.teams, h.roundID)
 
 			if err != nil {
@@ -34,6 +34,7 @@ import (
 	servermiddleware "github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/middleware"
 	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/migrations"
 	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/models"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/releases"
 	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/routes"
 	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/routes/competition"
 	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/routes/jobrunner"
@@ -61,6 +62,8 @@ type server struct {
 	otelShutdown                   func(context.Context) error
 	competitionAPIController       *jobs.CompetitionAPIController
 	competitionAPIControllerCancel func()
+	releaseScheduler               *releases.Scheduler
+	releaseSchedulerCancel         func()
 	jobClient                      jobs.KubernetesClient
 }
 
@@ -239,10 +242,11 @@ func initServer(ctx context.Context) (*server, error) {
 		return nil, fmt.Errorf("error creating k8s client from cluster config: %w", err)
 	}
 
+	replicaID := uuid.New().String()
 	server.competitionAPIController, err = jobs.NewCompetitionAPIController(
 		k8sClient,
 		cfg.K8s.Namespace,
-		uuid.New().String(),
+		replicaID,
 		db,
 	)
 	if err != nil {
@@ -253,10 +257,33 @@ func initServer(ctx context.Context) (*server, error) {
 
 	span.AddEvent("initialized k8s client")
 
+	var evalQueue queue.Queuer
+	if cfg.K8s.EvalMode == config.EvalModePool {
+		if cfg.Azure.StorageAccount.Queues.Evals == "" {
+			err = errors.New("azure.storage_account.queues.evals is required in pool eval mode")
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "no evals queue configured")
+			return nil, err
+		}
+
+		evalQueue, err = queue.NewAzureQueuer(
+			cfg.Azure.StorageAccount.Name,
+			cfg.Azure.StorageAccount.Key,
+			cfg.Azure.StorageAccount.Queues.URL,
+			cfg.Azure.StorageAccount.Queues.Evals,
+		)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to create evals queue")
+			return nil, err
+		}
+	}
+
 	taskRunnerClient := taskrunner.Create()
 	jobClient := jobs.CreateJobClient(
 		cfg.K8s.Namespace,
 		k8sClient,
+		evalQueue,
 		cfg,
 	)
 
@@ -291,6 +318,8 @@ func initServer(ctx context.Context) (*server, error) {
 		&jobClient,
 		upload.NewRetryUploader(archiver),
 		upload.NewRetryUploader(sourcesUploader),
+		cfg.Preflight,
+		cfg.EvalTimeouts.EvalTimeouts(),
 	)
 	githubClient, err := github.Create(cfg.Github)
 	if err != nil {
@@ -301,6 +330,16 @@ func initServer(ctx context.Context) (*server, error) {
 
 	span.AddEvent("initialized github client")
 
+	server.releaseScheduler = releases.NewScheduler(
+		k8sClient,
+		cfg.K8s.Namespace,
+		replicaID,
+		db,
+		challengesClient,
+		githubClient,
+		cfg,
+	)
+
 	webhookHandler := webhooks.CreateHandler(
 		db,
 		taskRunnerClient,
@@ -328,7 +367,14 @@ func initServer(ctx context.Context) (*server, error) {
 		upload.NewRetryUploaderBackoff(submissionUploader, backoff),
 		upload.NewRetryUploaderBackoff(sourcesUploader, backoff),
 	)
-	competitionHandler := competition.Create(cfg, &jobClient, db)
+	competitionHandler := competition.Create(
+		cfg,
+		&jobClient,
+		challengesClient,
+		db,
+		upload.NewRetryUploaderBackoff(submissionUploader, backoff),
+		upload.NewRetryUploaderBackoff(sourcesUploader, backoff),
+	)
 	middlewareHandler := servermiddleware.Handler{DB: db}
 	jobrunnerHandler := jobrunner.NewHandler(
 		db,
@@ -398,6 +444,10 @@ func (s *server) Start(ctx context.Context) error {
 	go s.competitionAPIController.Run(competitionAPIControllerCtx)
 	s.competitionAPIControllerCancel = competitionAPIControllerCancel
 
+	releaseSchedulerCtx, releaseSchedulerCancel := context.WithCancel(ctx)
+	go s.releaseScheduler.Run(releaseSchedulerCtx)
+	s.releaseSchedulerCancel = releaseSchedulerCancel
+
 	logger.Logger.Info("Starting services...")
 
 	err = s.router.Start(s.config.ListenAddress)
@@ -418,6 +468,7 @@ func (s *server) Shutdown() error {
 	defer cancelTimeout()
 
 	s.competitionAPIControllerCancel()
+	s.releaseSchedulerCancel()
 
 	// TODO: do we want these serialized shutdowns?
 	if err := s.router.Shutdown(ctx); err != nil {
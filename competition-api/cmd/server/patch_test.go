@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -8,6 +9,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/server/internal/models"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
 )
 
 func (s *ServerTestSuite) Test_PatchSubmission() {
@@ -192,6 +196,66 @@ func (s *ServerTestSuite) Test_PatchSubmission() {
 	}
 }
 
+func (s *ServerTestSuite) Test_PatchSubmissionPolicy() {
+	s.Require().NoError(
+		s.tx.Model(&taskOpen).Updates(&models.Task{PatchPolicy: &types.PatchPolicy{
+			HarnessSources: []string{"fuzz/*.c"},
+			Deny:           []string{"tests/**"},
+		}}).Error,
+	)
+
+	diff := func(names ...string) string {
+		var b strings.Builder
+		for _, name := range names {
+			fmt.Fprintf(&b, "diff --git a/%[1]s b/%[1]s\n--- a/%[1]s\n+++ b/%[1]s\n", name)
+			b.WriteString("@@ -1 +1 @@\n-old\n+new\n")
+		}
+		return base64.StdEncoding.EncodeToString([]byte(b.String()))
+	}
+
+	tests := []struct {
+		fields         map[string]any
+		name           string
+		patch          string
+		expectedStatus int
+	}{
+		{name: "Allowed", patch: diff("src/main.c"), expectedStatus: http.StatusOK},
+		{
+			name:           "HarnessAndTests",
+			patch:          diff("src/main.c", "fuzz/fuzzer.c", "tests/check.c"),
+			expectedStatus: http.StatusBadRequest,
+			fields: map[string]any{
+				"fuzz/fuzzer.c": string(types.PatchRuleHarnessSource),
+				"tests/check.c": string(types.PatchRuleDenied),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			req, err := http.NewRequest(
+				http.MethodPost,
+				fmt.Sprintf("%s/v1/task/%s/patch/", s.server.URL, taskOpen.ID.String()),
+				strings.NewReader(fmt.Sprintf(`{"patch":"%s"}`, tt.patch)),
+			)
+			s.Require().NoError(err, "failed to construct http request")
+			req.Header.Add("Content-Type", "application/json")
+			req.SetBasicAuth(auth.ID.String(), authToken)
+
+			resp, err := doRequest(s.T(), req)
+			s.Require().NoError(err)
+			s.Equal(tt.expectedStatus, resp.code, "incorrect status code")
+
+			body := make(map[string]any)
+			s.Require().NoError(json.Unmarshal([]byte(resp.body), &body))
+			if tt.fields != nil {
+				assertErrorBodyWithFields(s.T(), body)
+				s.Equal(tt.fields, body["fields"])
+			}
+		})
+	}
+}
+
 func (s *ServerTestSuite) Test_PatchStatus() {
 	tests := []struct {
 		name         string
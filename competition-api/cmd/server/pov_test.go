@@ -83,6 +83,37 @@ func (s *ServerTestSuite) Test_POVSubmission() {
 				assert.Contains(t, body["status"], "accepted")
 			},
 		},
+		{
+			name:           "ValidEngineAFL",
+			taskID:         taskOpen.ID.String(),
+			auth:           &clientAuth{auth.ID.String(), authToken},
+			dataFile:       base64String(10),
+			harnessName:    "harness_1",
+			sanitizer:      "address",
+			architecture:   "x86_64",
+			engine:         "afl",
+			expectedStatus: http.StatusOK,
+			bodyTester: func(t *testing.T, body map[string]any) {
+				assert.Contains(t, body, "status", "contains status key")
+				assert.Contains(t, body["status"], "accepted")
+			},
+		},
+		{
+			name:           "InvalidEngine",
+			taskID:         taskOpen.ID.String(),
+			auth:           &clientAuth{auth.ID.String(), authToken},
+			dataFile:       base64String(10),
+			harnessName:    "harness_1",
+			sanitizer:      "address",
+			architecture:   "x86_64",
+			engine:         "centipede",
+			expectedStatus: http.StatusBadRequest,
+			bodyTester: func(t *testing.T, body map[string]any) {
+				assertErrorBodyWithFields(t, body)
+				assert.Contains(t, body["message"], "validation error")
+				assert.Contains(t, body["fields"], "engine")
+			},
+		},
 		{
 			name:   "ValidLongDataFile",
 			taskID: taskOpen.ID.String(),
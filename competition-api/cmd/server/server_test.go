@@ -388,7 +388,7 @@ func (s *ServerTestSuite) SetupTest() {
 		s.archiver,
 		s.archiver,
 	)
-	competitionHandler := competition.Handler{RoundID: *s.config.RoundID}
+	competitionHandler := competition.Create(s.config, nil, nil, s.tx, s.archiver, s.archiver)
 	middlewareHandler := middleware.Handler{DB: s.tx}
 
 	e, err := routes.BuildEcho(logger.Logger)
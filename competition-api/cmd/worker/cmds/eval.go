@@ -2,8 +2,9 @@ package cmds
 
 import (
 	"context"
+	"fmt"
+	"maps"
 	"os"
-	"time"
 
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/spf13/cobra"
@@ -23,22 +24,19 @@ import (
 	workererrors "github.com/aixcyberchallenge/competition-api/competition-api/internal/worker_errors"
 )
 
-var (
-	patchID          string
-	patchURL         string
-	skipPatchTests   bool
-	allowedLanguages identifier.LanguageSlice
+// Everything `worker eval` is told about a single evaluation
+type evalOptions struct {
+	spec     string
+	patchID  string
+	patchURL string
 
 	triggerURL  string
 	sanitizer   string
 	harnessName string
 	engine      string
 
-	archiveS3   bool
 	baseRepoURL string
-
-	jobID         string
-	exportResults bool
+	jobID       string
 
 	povID          string
 	focus          string
@@ -47,6 +45,34 @@ var (
 	headRepoURL    string
 	architecture   string
 	baseDir        string
+
+	extractorName string
+
+	headRepoSHA256    string
+	baseRepoSHA256    string
+	ossFuzzRepoSHA256 string
+	triggerSHA256     string
+	patchSHA256       string
+	fetchCacheDir     string
+	buildCacheKey     string
+	recordDir         string
+	simulateRules     string
+	imageTag          string
+
+	allowedLanguages  identifier.LanguageSlice
+	extractLimits     extract.Limits
+	commandLimits     command.Limits
+	fetchCacheMaxSize int64
+
+	skipPatchTests bool
+	buildHead      bool
+	archiveS3      bool
+	exportResults  bool
+}
+
+const (
+	extractorNative = "native"
+	extractorTar    = "tar"
 )
 
 var evalCmd = &cobra.Command{
@@ -58,165 +84,357 @@ var evalCmd = &cobra.Command{
   - false in stdout otherwise
 - Exits with a 1 for all other errors.`,
 	RunE: func(cmd *cobra.Command, _ []string) error {
-		ctx, span := tracer.Start(cmd.Context(), "evalCmd")
-		defer span.End()
-
-		timeout := (time.Hour * 8)
-
-		logger.Logger.InfoContext(ctx,
-			"Starting test job",
-			"head-repo",
-			headRepoURL,
-			"project-name",
-			projectName,
-			"head-repo-url",
-			headRepoURL,
-			"fuzz-tooling-url",
-			ossFuzzRepoURL,
-			"architecture",
-			architecture,
-			"patch-url",
-			patchURL,
-			"skip-patch-tests",
-			skipPatchTests,
-			"allowed-languages",
-			allowedLanguages,
-			"timeout-duration",
-			timeout,
-		)
+		return evalOpts.run(cmd.Context())
+	},
+}
 
-		var entity types.JobType
-		var entityID string
-		if jobID != "" {
-			entity = types.JobTypeJob
-			entityID = jobID
-		}
-		if povID != "" {
-			entity = types.JobTypePOV
-			entityID = povID
+var evalOpts evalOptions
+
+func (o *evalOptions) run(ctx context.Context) error {
+	spec, err := o.evalSpec()
+	if err != nil {
+		return err
+	}
+	return o.evaluate(ctx, spec)
+}
+
+// Spec from --spec or $EVAL_SPEC, falling back to the individual flags for manual runs
+func (o *evalOptions) evalSpec() (*types.EvalSpec, error) {
+	specJSON := o.spec
+	if specJSON == "" {
+		specJSON = os.Getenv("EVAL_SPEC")
+	}
+	if specJSON != "" {
+		return types.ParseEvalSpec([]byte(specJSON))
+	}
+
+	spec := &types.EvalSpec{
+		Version:       types.EvalSpecVersion,
+		HeadRepo:      types.EvalSource{URL: o.headRepoURL, SHA256: o.headRepoSHA256},
+		FuzzTooling:   types.EvalSource{URL: o.ossFuzzRepoURL, SHA256: o.ossFuzzRepoSHA256},
+		Focus:         o.focus,
+		ProjectName:   o.projectName,
+		Architecture:  types.Architecture(o.architecture),
+		Build:         o.buildHead,
+		ArchiveS3:     o.archiveS3,
+		ExportResults: o.exportResults,
+	}
+	if o.jobID != "" {
+		spec.Entity = types.JobTypeJob
+		spec.EntityID = o.jobID
+	}
+	if o.povID != "" {
+		spec.Entity = types.JobTypePOV
+		spec.EntityID = o.povID
+	}
+	if o.patchID != "" {
+		spec.Entity = types.JobTypePatch
+		spec.EntityID = o.patchID
+	}
+	if o.baseRepoURL != "" {
+		spec.BaseRepo = &types.EvalSource{URL: o.baseRepoURL, SHA256: o.baseRepoSHA256}
+	}
+	if o.triggerURL != "" {
+		spec.POV = &types.EvalPOV{
+			Trigger:     types.EvalSource{URL: o.triggerURL, SHA256: o.triggerSHA256},
+			Sanitizer:   o.sanitizer,
+			HarnessName: o.harnessName,
+			Engine:      types.FuzzingEngine(o.engine),
 		}
-		if patchID != "" {
-			entity = types.JobTypePatch
-			entityID = patchID
+	}
+	if o.patchURL != "" {
+		spec.Patch = &types.EvalPatch{
+			Patch:            types.EvalSource{URL: o.patchURL, SHA256: o.patchSHA256},
+			AllowedLanguages: o.allowedLanguages,
+			SkipTests:        o.skipPatchTests,
 		}
+	}
 
-		executor := command.NewShellExecutor()
-		queuer, err := common.GetAzureQueueClient()
-		if err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, "failed to make azure queue")
-			return err
-		}
-		workerqueuer := workerqueue.NewWorkerQueue(entityID, entity, queuer)
+	err := spec.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid eval flags: %w", err)
+	}
+	return spec, nil
+}
 
-		httpClient := retryablehttp.NewClient()
-		httpClient.RetryMax = 3
-		fetcher := fetch.NewHTTPFetcher(httpClient.StandardClient())
-		extractor := extract.NewTarGzExtractor(executor)
-		azureUploader, err := common.GetAzureBlobClient()
-		if err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, "failed to make azure blob uploader")
-			return err
-		}
+func (o *evalOptions) evaluate(ctx context.Context, spec *types.EvalSpec) error {
+	ctx, span := tracer.Start(ctx, "evalCmd")
+	defer span.End()
 
-		aixccEngine := workerengine.NewAixccEngine(
-			executor,
-			upload.NewRetryUploader(azureUploader),
-			workerqueuer,
-			entity,
-		)
+	timeouts := spec.EvalTimeouts()
+	timeout := timeouts.Total()
+
+	logger.Logger.InfoContext(ctx,
+		"Starting test job",
+		"entity",
+		spec.Entity,
+		"entity-id",
+		spec.EntityID,
+		"project-name",
+		spec.ProjectName,
+		"head-repo-url",
+		spec.HeadRepo.URL,
+		"fuzz-tooling-url",
+		spec.FuzzTooling.URL,
+		"architecture",
+		spec.Architecture,
+		"pov",
+		spec.POV != nil,
+		"patch",
+		spec.Patch != nil,
+		"timeout-duration",
+		timeout,
+	)
+
+	var baseRepoURL, triggerURL, patchURL string
+	hashes := map[string]string{
+		spec.HeadRepo.URL:    spec.HeadRepo.SHA256,
+		spec.FuzzTooling.URL: spec.FuzzTooling.SHA256,
+	}
+	if spec.BaseRepo != nil {
+		baseRepoURL = spec.BaseRepo.URL
+		hashes[baseRepoURL] = spec.BaseRepo.SHA256
+	}
+
+	// checking and building without a trigger still needs an engine
+	sanitizer, harnessName, engine := "", "", string(types.FuzzingEngineLibFuzzer)
+	if spec.POV != nil {
+		triggerURL = spec.POV.Trigger.URL
+		sanitizer = spec.POV.Sanitizer
+		harnessName = spec.POV.HarnessName
+		engine = string(spec.POV.Engine)
+	}
+	skipPatchTests := false
+	var allowedLanguages identifier.LanguageSlice
+	var patchPolicy *types.PatchPolicy
+	if spec.Patch != nil {
+		patchURL = spec.Patch.Patch.URL
+		skipPatchTests = spec.Patch.SkipTests
+		allowedLanguages = spec.Patch.AllowedLanguages
+		patchPolicy = spec.Patch.Policy
+	}
+
+	executor := command.NewShellExecutor()
+	queuer, err := common.GetAzureQueueClient()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to make azure queue")
+		return err
+	}
+	workerqueuer := workerqueue.NewWorkerQueue(spec.EntityID, spec.Entity, queuer)
+
+	httpClient := retryablehttp.NewClient()
+	httpClient.RetryMax = 3
+	var fetcher fetch.Fetcher = fetch.NewHTTPFetcher(httpClient.StandardClient())
+	if o.fetchCacheDir != "" {
+		fetcher = fetch.NewCachingFetcher(fetcher, o.fetchCacheDir, o.fetchCacheMaxSize, hashes)
+	}
+	// triggers and patches aren't worth caching but are still verified
+	hashes = maps.Clone(hashes)
+	if spec.POV != nil {
+		hashes[triggerURL] = spec.POV.Trigger.SHA256
+	}
+	if spec.Patch != nil {
+		hashes[patchURL] = spec.Patch.Patch.SHA256
+	}
+	fetcher = fetch.NewVerifyingFetcher(fetcher, hashes)
+	var extractor extract.Extractor
+	switch o.extractorName {
+	case extractorNative:
+		extractor = extract.NewNativeTarGzExtractor(o.extractLimits)
+	case extractorTar:
+		extractor = extract.NewTarGzExtractor(executor)
+	default:
+		err = fmt.Errorf("unknown extractor: %s", o.extractorName)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unknown extractor")
+		return err
+	}
+	azureUploader, err := common.GetAzureBlobClient()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to make azure blob uploader")
+		return err
+	}
 
-		evaluator := evaluate.NewEvaluator(
+	artifactUploader := upload.NewRetryUploader(azureUploader)
+	limits := o.commandLimits
+	if spec.Resources != nil {
+		limits.ContainerMemoryBytes = int64(spec.Resources.MemoryGB) << 30
+		limits.ContainerCPUs = int64(spec.Resources.CPUs)
+	}
+	var engineExecutor command.Executor = command.NewSandboxExecutor(executor, limits)
+	// recorded outside the sandbox, so fixtures don't depend on the limits it adds
+	if o.recordDir != "" {
+		engineExecutor = command.NewRecordingExecutor(engineExecutor, o.recordDir)
+	}
+	aixccEngine := workerengine.NewAixccEngine(
+		engineExecutor,
+		artifactUploader,
+		workerqueuer,
+		spec.Entity,
+	)
+
+	var evalEngine workerengine.Engine = workerengine.NewBuildRetryEngine(aixccEngine)
+	// patched repos build differently every time
+	if o.buildCacheKey != "" && spec.Patch == nil {
+		evalEngine = workerengine.NewBuildCacheEngine(
+			evalEngine,
+			artifactUploader,
 			fetcher,
 			extractor,
-			baseDir,
-			workerengine.NewBuildRetryEngine(aixccEngine),
-			workerqueuer,
+			o.buildCacheKey,
+			o.baseDir,
 		)
-
-		commonEngineParams := workerengine.NewParams(
-			sanitizer,
-			architecture,
-			engine,
-			harnessName,
-			projectName,
-			focus,
-			allowedLanguages,
-		)
-		timeoutCtx, cancel := context.WithTimeout(ctx, time.Hour*8)
-		defer cancel()
-
-		err = evaluator.Evaluate(
-			timeoutCtx,
-			ossFuzzRepoURL,
-			headRepoURL,
-			baseRepoURL,
-			triggerURL,
-			patchURL,
-			skipPatchTests,
-			&commonEngineParams,
+	}
+	// every patch against the task is judged against the tests of the same unpatched head
+	if spec.Patch != nil && !skipPatchTests &&
+		spec.HeadRepo.SHA256 != "" && spec.FuzzTooling.SHA256 != "" {
+		evalEngine = workerengine.NewTestBaselineCacheEngine(
+			evalEngine,
+			artifactUploader,
+			fetcher,
+			spec.HeadRepo.SHA256+spec.FuzzTooling.SHA256,
 		)
+	}
+
+	// load and integration tests evaluate without building or running anything
+	if o.simulateRules != "" {
+		rules, err := workerengine.LoadSimulationRules(o.simulateRules)
 		if err != nil {
 			span.RecordError(err)
-			span.SetStatus(codes.Error, "failed to evaluate")
-			return workererrors.ExitErrorWrap(types.ExitErrored, err)
+			span.SetStatus(codes.Error, "failed to load simulation rules")
+			return err
 		}
+		evalEngine = workerengine.NewSimulatedEngine(rules)
+	}
+
+	evaluator := evaluate.NewEvaluator(
+		fetcher,
+		extractor,
+		o.baseDir,
+		evalEngine,
+		workerqueuer,
+	)
 
+	commonEngineParams := workerengine.NewParams(
+		sanitizer,
+		string(spec.Architecture),
+		engine,
+		harnessName,
+		spec.ProjectName,
+		spec.Focus,
+		allowedLanguages,
+	)
+	if spec.Reproducibility != nil {
+		commonEngineParams = commonEngineParams.WithReproducibility(*spec.Reproducibility)
+	}
+	commonEngineParams = commonEngineParams.
+		WithTimeouts(timeouts).
+		WithPatchPolicy(patchPolicy).
+		WithTempDir(o.baseDir)
+	if o.imageTag != "" {
+		commonEngineParams = commonEngineParams.WithImageTag(o.imageTag)
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err = evaluator.Evaluate(
+		timeoutCtx,
+		spec.FuzzTooling.URL,
+		spec.HeadRepo.URL,
+		baseRepoURL,
+		triggerURL,
+		patchURL,
+		skipPatchTests,
+		spec.Build,
+		&commonEngineParams,
+	)
+	if err != nil {
 		span.RecordError(err)
-		span.SetStatus(codes.Ok, "evaluated successfully")
-		return nil
-	},
+		span.SetStatus(codes.Error, "failed to evaluate")
+		return workererrors.ExitErrorWrap(types.ExitErrored, err)
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Ok, "evaluated successfully")
+	return nil
 }
 
 func init() {
 	rootCmd.AddCommand(evalCmd)
 
-	// Globally required flags
-	evalCmd.Flags().StringVar(&headRepoURL, "head-repo-url", "", "Repository tar url (required)")
-	evalCmd.Flags().StringVar(&focus, "focus", "", "Main repo (required)")
-	evalCmd.Flags().StringVar(&projectName, "project-name", "", "OSS Fuzz project name (required)")
-	evalCmd.Flags().StringVar(&ossFuzzRepoURL, "oss-fuzz-url", "", "OSS Fuzz tar url (required)")
-	evalCmd.Flags().StringVar(&architecture, "architecture", "", "Architecture (required)")
+	evalCmd.Flags().
+		StringVar(&evalOpts.spec, "spec", "", "Eval spec JSON, defaults to $EVAL_SPEC. Replaces the flags describing the evaluation.")
 
-	for _, requiredFlag := range []string{"head-repo-url", "focus", "project-name", "oss-fuzz-url", "architecture"} {
-		err := evalCmd.MarkFlagRequired(requiredFlag)
-		if err != nil {
-			logger.Logger.Error(
-				"error setting flag required",
-				"flag",
-				requiredFlag,
-				"error",
-				err,
-			)
-			os.Exit(types.ExitErrored)
-		}
-	}
+	// Required without a spec
+	evalCmd.Flags().StringVar(&evalOpts.headRepoURL, "head-repo-url", "", "Repository tar url")
+	evalCmd.Flags().StringVar(&evalOpts.focus, "focus", "", "Main repo")
+	evalCmd.Flags().StringVar(&evalOpts.projectName, "project-name", "", "OSS Fuzz project name")
+	evalCmd.Flags().StringVar(&evalOpts.ossFuzzRepoURL, "oss-fuzz-url", "", "OSS Fuzz tar url")
+	evalCmd.Flags().StringVar(&evalOpts.architecture, "architecture", "", "Architecture")
 
 	// Optional flags
-	evalCmd.Flags().StringVar(&baseRepoURL, "base-repo-url", "", "Repository tar url")
+	evalCmd.Flags().StringVar(&evalOpts.baseRepoURL, "base-repo-url", "", "Repository tar url")
+	evalCmd.Flags().
+		StringVar(&evalOpts.baseDir, "base-dir", "", "Base dir to create temporary directories in. Defaults to default temporary directory for the system.")
+	evalCmd.Flags().BoolVar(&evalOpts.archiveS3, "archive-s3", false, "Archive files to s3")
+	evalCmd.Flags().
+		BoolVar(&evalOpts.buildHead, "build", false, "Build the head repo even when there is no trigger or patch to test")
+	evalCmd.Flags().
+		StringVar(&evalOpts.extractorName, "extractor", extractorNative, "Tarball extractor, native or tar. Limits only apply to native.")
+	evalCmd.Flags().
+		Int64Var(&evalOpts.extractLimits.MaxBytes, "extract-max-bytes", extract.DefaultLimits.MaxBytes, "Max total bytes extracted from a tarball")
+	evalCmd.Flags().
+		IntVar(&evalOpts.extractLimits.MaxFiles, "extract-max-files", extract.DefaultLimits.MaxFiles, "Max entries extracted from a tarball")
+	evalCmd.Flags().
+		IntVar(&evalOpts.extractLimits.MaxDepth, "extract-max-depth", extract.DefaultLimits.MaxDepth, "Max directory depth of tarball entries")
+	evalCmd.Flags().
+		Int64Var(&evalOpts.commandLimits.MaxCPUSeconds, "command-max-cpu-seconds", command.DefaultLimits.MaxCPUSeconds, "Max CPU seconds of each process of the challenge scripts, not their containers. 0 is unlimited.")
+	evalCmd.Flags().
+		Int64Var(&evalOpts.commandLimits.MaxAddressSpaceBytes, "command-max-address-space-bytes", command.DefaultLimits.MaxAddressSpaceBytes, "Max virtual memory of each process of the challenge scripts. Containers get the memory of the task instead. 0 is unlimited.")
+	evalCmd.Flags().
+		Int64Var(&evalOpts.commandLimits.MaxFileSizeBytes, "command-max-file-size-bytes", command.DefaultLimits.MaxFileSizeBytes, "Max size of files written by the challenge scripts, not their containers. 0 is unlimited.")
+	evalCmd.Flags().
+		Int64Var(&evalOpts.commandLimits.MaxProcesses, "command-max-processes", command.DefaultLimits.MaxProcesses, "Max processes of the worker's user while a challenge script runs, does not bind root. 0 is unlimited.")
+
+	evalCmd.Flags().
+		StringVar(&evalOpts.headRepoSHA256, "head-repo-sha256", "", "Expected sha256 of the head repo tarball")
+	evalCmd.Flags().
+		StringVar(&evalOpts.baseRepoSHA256, "base-repo-sha256", "", "Expected sha256 of the base repo tarball")
+	evalCmd.Flags().
+		StringVar(&evalOpts.ossFuzzRepoSHA256, "oss-fuzz-sha256", "", "Expected sha256 of the OSS Fuzz tarball")
+	evalCmd.Flags().
+		StringVar(&evalOpts.triggerSHA256, "trigger-sha256", "", "Expected sha256 of the trigger")
+	evalCmd.Flags().
+		StringVar(&evalOpts.patchSHA256, "patch-sha256", "", "Expected sha256 of the patch")
+	evalCmd.Flags().
+		StringVar(&evalOpts.fetchCacheDir, "fetch-cache-dir", "", "Directory shared by workers on the node to cache tarballs with a known sha256 in. Disabled when empty.")
+	evalCmd.Flags().
+		Int64Var(&evalOpts.fetchCacheMaxSize, "fetch-cache-max-bytes", 50<<30, "Max size of the fetch cache before least recently used tarballs are evicted")
+	evalCmd.Flags().
+		StringVar(&evalOpts.simulateRules, "simulate", "", "YAML rules of a simulated engine to evaluate with instead of the challenge scripts, for load and integration tests. Disabled when empty.")
+	evalCmd.Flags().
+		StringVar(&evalOpts.recordDir, "record-commands", "", "Directory to record the challenge commands and their results in, as fixtures for replaying in tests. Disabled when empty.")
 	evalCmd.Flags().
-		StringVar(&baseDir, "base-dir", "", "Base dir to create temporary directories in. Defaults to default temporary directory for the system.")
-	evalCmd.Flags().BoolVar(&archiveS3, "archive-s3", false, "Archive files to s3")
+		StringVar(&evalOpts.buildCacheKey, "build-cache-key", "", "Identifies the task so unpatched builds can be reused by later POV evaluations. Disabled when empty.")
 
 	// Job flags
 	evalCmd.Flags().
-		StringVar(&jobID, "job-id", "", "ID for the Job in the DB.  Only useful in job runner mode.")
+		StringVar(&evalOpts.jobID, "job-id", "", "ID for the Job in the DB.  Only useful in job runner mode.")
 	evalCmd.Flags().
-		BoolVar(&exportResults, "export-results", false, "Export artifacts to blob storage & log command results on job row")
+		BoolVar(&evalOpts.exportResults, "export-results", false, "Export artifacts to blob storage & log command results on job row")
 
 	// Patch flags
-	evalCmd.Flags().StringVar(&patchID, "patch-id", "", "Patch ID")
-	evalCmd.Flags().StringVar(&patchURL, "patch-url", "", "Patch url to apply and evaluate.")
-	evalCmd.Flags().BoolVar(&skipPatchTests, "skip-patch-tests", false, "Skip patch tests")
+	evalCmd.Flags().StringVar(&evalOpts.patchID, "patch-id", "", "Patch ID")
+	evalCmd.Flags().StringVar(&evalOpts.patchURL, "patch-url", "", "Patch url to apply and evaluate.")
+	evalCmd.Flags().BoolVar(&evalOpts.skipPatchTests, "skip-patch-tests", false, "Skip patch tests")
 	evalCmd.Flags().
-		Var(&allowedLanguages, "allowed-languages", "Allowed languages to patch")
-	evalCmd.MarkFlagsRequiredTogether("patch-url", "allowed-languages")
+		Var(&evalOpts.allowedLanguages, "allowed-languages", "Allowed languages to patch")
 	// PoV flags
-	evalCmd.Flags().StringVar(&povID, "pov-id", "", "PoV ID")
-	evalCmd.Flags().StringVar(&triggerURL, "trigger-url", "", "Trigger URL")
-	evalCmd.Flags().StringVar(&sanitizer, "sanitizer", "", "Sanitizer")
-	evalCmd.Flags().StringVar(&harnessName, "harness-name", "", "Harness Name")
-	evalCmd.Flags().StringVar(&engine, "engine", string(types.FuzzingEngineLibFuzzer), "Engine")
-	evalCmd.MarkFlagsRequiredTogether("trigger-url", "sanitizer", "harness-name", "engine")
+	evalCmd.Flags().StringVar(&evalOpts.povID, "pov-id", "", "PoV ID")
+	evalCmd.Flags().StringVar(&evalOpts.triggerURL, "trigger-url", "", "Trigger URL")
+	evalCmd.Flags().StringVar(&evalOpts.sanitizer, "sanitizer", "", "Sanitizer")
+	evalCmd.Flags().StringVar(&evalOpts.harnessName, "harness-name", "", "Harness Name")
+	evalCmd.Flags().StringVar(&evalOpts.engine, "engine", string(types.FuzzingEngineLibFuzzer), "Engine")
 }
@@ -0,0 +1,168 @@
+package cmds
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"regexp"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/command"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/common"
+	workerengine "github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/engine"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/extract"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/pool"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/logger"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+	workererrors "github.com/aixcyberchallenge/competition-api/competition-api/internal/worker_errors"
+)
+
+var (
+	serveConcurrency       int
+	serveTimeout           time.Duration
+	serveBaseDir           string
+	serveFetchCacheDir     string
+	serveSimulateRules     string
+	serveCommandLimits     command.Limits
+	serveFetchCacheMaxSize int64
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Pull evaluation requests from the evals queue and run them until interrupted",
+	Long: `
+Long running alternative to one eval job per submission. Each request carries the
+same arguments as "worker eval". Docker layers built by earlier evaluations stay
+around so later ones against the same project start warm.
+
+On SIGTERM or interrupt no more requests are taken and the running evaluations are
+finished, a second signal kills the worker.`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ctx, span := tracer.Start(cmd.Context(), "serveCmd")
+		defer span.End()
+
+		// running evaluations finish after the first signal, a second one kills the worker
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		context.AfterFunc(ctx, stop)
+
+		queuer, err := common.GetAzureEvalQueueClient()
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to make azure evals queue")
+			return workererrors.ExitErrorWrap(types.ExitErrored, err)
+		}
+
+		logger.Logger.InfoContext(ctx,
+			"Starting worker pool",
+			"concurrency",
+			serveConcurrency,
+			"timeout",
+			serveTimeout,
+		)
+
+		pool.New(queuer, serveConcurrency, serveTimeout, func(
+			ctx context.Context,
+			request *types.EvalRequest,
+		) error {
+			opts := &evalOptions{
+				baseDir:           serveBaseDir,
+				extractorName:     extractorNative,
+				extractLimits:     extract.DefaultLimits,
+				commandLimits:     serveCommandLimits,
+				fetchCacheDir:     serveFetchCacheDir,
+				fetchCacheMaxSize: serveFetchCacheMaxSize,
+				buildCacheKey:     request.BuildCacheKey,
+				simulateRules:     cmp.Or(request.SimulateRules, serveSimulateRules),
+				imageTag:          evalImageTag(&request.Spec),
+			}
+			return serveEval(ctx, opts, &request.Spec)
+		}).Run(ctx)
+
+		span.RecordError(nil)
+		span.SetStatus(codes.Ok, "pool stopped")
+		return nil
+	},
+}
+
+// Runs one evaluation from the pool, the spec has already been validated
+func serveEval(ctx context.Context, opts *evalOptions, spec *types.EvalSpec) error {
+	ctx, span := tracer.Start(ctx, "serveEval", trace.WithAttributes(
+		attribute.String("project.name", spec.ProjectName),
+		attribute.String("image.tag", opts.imageTag),
+	))
+	defer span.End()
+
+	err := opts.evaluate(ctx, spec)
+	// simulated evaluations build no image
+	if opts.simulateRules == "" {
+		removeImage(ctx, workerengine.ProjectImage(spec.ProjectName, opts.imageTag))
+	}
+	if err != nil {
+		// no final status was sent, the request goes back on the queue for another try
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to evaluate")
+		return err
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "evaluated")
+	return nil
+}
+
+// Docker tags allow fewer characters than entity IDs
+var invalidTagChars = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// Tag the project image of the evaluation of `spec` is built under. Evaluations share the docker
+// daemon, with a tag of their own they can build the same project at once.
+func evalImageTag(spec *types.EvalSpec) string {
+	return "eval-" + invalidTagChars.ReplaceAllString(spec.EntityID, "-")
+}
+
+// Untags `image` once its evaluation is done. Its layers stay in the build cache, so later
+// evaluations of the project still start warm.
+func removeImage(ctx context.Context, image string) {
+	result, err := command.NewShellExecutor().Execute(
+		context.WithoutCancel(ctx),
+		command.New("docker", "image", "rm", "--no-prune", image),
+	)
+	if err == nil && result.ExitCode != 0 {
+		err = fmt.Errorf("docker exited with %d: %s", result.ExitCode, result.Stderr)
+	}
+	if err != nil {
+		logger.Logger.WarnContext(ctx, "failed to remove image", "image", image, "error", err)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().
+		IntVar(&serveConcurrency, "concurrency", 1, "Max evaluations to run at once")
+	serveCmd.Flags().
+		DurationVar(&serveTimeout, "timeout", time.Hour*9, "Longest an evaluation may run including time to report its result. Requests whose total timeout doesn't fit are dropped.")
+	serveCmd.Flags().
+		StringVar(&serveBaseDir, "base-dir", "", "Base dir to create temporary directories in. Defaults to default temporary directory for the system.")
+	serveCmd.Flags().
+		StringVar(&serveFetchCacheDir, "fetch-cache-dir", "", "Directory to cache tarballs with a known sha256 in. Disabled when empty.")
+	serveCmd.Flags().
+		Int64Var(&serveFetchCacheMaxSize, "fetch-cache-max-bytes", 50<<30, "Max size of the fetch cache before least recently used tarballs are evicted")
+	serveCmd.Flags().
+		StringVar(&serveSimulateRules, "simulate", "", "YAML rules of a simulated engine to evaluate with instead of the challenge scripts, for load and integration tests. Requests naming rules of their own override it. Disabled when empty.")
+	serveCmd.Flags().
+		Int64Var(&serveCommandLimits.MaxCPUSeconds, "command-max-cpu-seconds", command.DefaultLimits.MaxCPUSeconds, "Max CPU seconds of each process of the challenge scripts, not their containers. 0 is unlimited.")
+	serveCmd.Flags().
+		Int64Var(&serveCommandLimits.MaxAddressSpaceBytes, "command-max-address-space-bytes", command.DefaultLimits.MaxAddressSpaceBytes, "Max virtual memory of each process of the challenge scripts. Containers get the memory of the task instead. 0 is unlimited.")
+	serveCmd.Flags().
+		Int64Var(&serveCommandLimits.MaxFileSizeBytes, "command-max-file-size-bytes", command.DefaultLimits.MaxFileSizeBytes, "Max size of files written by the challenge scripts, not their containers. 0 is unlimited.")
+	serveCmd.Flags().
+		Int64Var(&serveCommandLimits.MaxProcesses, "command-max-processes", command.DefaultLimits.MaxProcesses, "Max processes of the worker's user while a challenge script runs, does not bind root. 0 is unlimited.")
+}
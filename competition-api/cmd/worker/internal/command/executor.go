@@ -3,8 +3,11 @@ package command
 import (
 	"context"
 	"io"
+	"time"
 
 	"go.opentelemetry.io/otel"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
 )
 
 var tracer = otel.Tracer(
@@ -12,16 +15,26 @@ var tracer = otel.Tracer(
 )
 
 type Result struct {
+	// Limit of a [SandboxExecutor] the command ran out of, empty within its limits
+	LimitExceeded Limit
+	// What the command used, nil when the executor can't tell
+	Usage    *types.ResourceUsage
 	Cmd      []string
 	Stdout   []byte
 	Stderr   []byte
 	ExitCode int
+	// CPU time of the command's own processes, to tell CPU limits by. Containers don't count.
+	CPUTime time.Duration
 }
 
 type Command struct {
+	// Limits to run under in a process group of its own, set by a [SandboxExecutor]
+	Limits  *Limits
 	Stdin   io.Reader
 	Program string
 	Args    []string
+	// Extra KEY=value environment variables on top of the worker's own
+	Env []string
 }
 
 func New(program string, args ...string) *Command {
@@ -31,6 +44,13 @@ func New(program string, args ...string) *Command {
 	}
 }
 
+// Program and arguments, as reported in a [Result]
+func (c *Command) Line() []string {
+	line := make([]string, 0, len(c.Args)+1)
+	line = append(line, c.Program)
+	return append(line, c.Args...)
+}
+
 //go:generate mockgen -destination ./mock/mock.go -package mock . Executor
 
 type Executor interface {
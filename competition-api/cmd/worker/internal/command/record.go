@@ -0,0 +1,177 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+)
+
+// Ensure RecordingExecutor implements Executor interface.
+var _ Executor = (*RecordingExecutor)(nil)
+
+// Commands hand the directories they write their output to over in variables ending in this,
+// like POV_OUT_DIR. What is left in them is part of the recording.
+const outDirEnvSuffix = "_OUT_DIR"
+
+// One command run by a [RecordingExecutor], stored as a json file. Output is kept as text so
+// fixtures can be read and edited by hand, bytes that aren't valid UTF-8 don't survive.
+type recording struct {
+	// Files the command left in each of its out dirs, by variable and path within the dir
+	OutDirs map[string]map[string]string `json:"out_dirs,omitempty"`
+	Usage   *types.ResourceUsage         `json:"usage,omitempty"`
+	Program string                       `json:"program"`
+	Stdout  string                       `json:"stdout"`
+	Stderr  string                       `json:"stderr"`
+	// Set when the executor failed to run the command at all
+	Error    string   `json:"error,omitempty"`
+	Args     []string `json:"args"`
+	Env      []string `json:"env,omitempty"`
+	ExitCode int      `json:"exit_code"`
+}
+
+// Name of the `seq`th recording in a fixture dir
+func recordingName(seq int) string {
+	return fmt.Sprintf("%04d.json", seq)
+}
+
+// Runs commands with another executor and writes each invocation and its result to a fixture
+// dir, so a [ReplayExecutor] can serve them back in tests. Stdin isn't recorded.
+type RecordingExecutor struct {
+	executor Executor
+	dir      string
+
+	mu  sync.Mutex
+	seq int
+}
+
+func NewRecordingExecutor(executor Executor, dir string) *RecordingExecutor {
+	return &RecordingExecutor{
+		executor: executor,
+		dir:      dir,
+	}
+}
+
+func (r *RecordingExecutor) Execute(ctx context.Context, command *Command) (*Result, error) {
+	ctx, span := tracer.Start(ctx, "RecordingExecutor.Execute", trace.WithAttributes(
+		attribute.String("program", command.Program),
+		attribute.StringSlice("args", command.Args),
+		attribute.String("dir", r.dir),
+	))
+	defer span.End()
+
+	result, execErr := r.executor.Execute(ctx, command)
+
+	rec := recording{
+		Program: command.Program,
+		Args:    command.Args,
+		Env:     command.Env,
+	}
+	if execErr != nil {
+		rec.Error = execErr.Error()
+	} else {
+		rec.Stdout = string(result.Stdout)
+		rec.Stderr = string(result.Stderr)
+		rec.ExitCode = result.ExitCode
+		rec.Usage = result.Usage
+	}
+
+	outDirs, err := readOutDirs(command.Env)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to read command out dirs")
+		return nil, err
+	}
+	rec.OutDirs = outDirs
+
+	err = r.save(&rec)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to save recording")
+		return nil, err
+	}
+
+	if execErr != nil {
+		span.RecordError(execErr)
+		span.SetStatus(codes.Error, "failed to execute command")
+		return nil, execErr
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "recorded command")
+	return result, nil
+}
+
+func (r *RecordingExecutor) save(rec *recording) error {
+	encoded, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err = os.MkdirAll(r.dir, 0o755)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(filepath.Join(r.dir, recordingName(r.seq)), encoded, 0o644)
+	if err != nil {
+		return err
+	}
+	r.seq++
+	return nil
+}
+
+// Contents of the files in each out dir of `env`, by variable and path within the dir
+func readOutDirs(env []string) (map[string]map[string]string, error) {
+	var outDirs map[string]map[string]string
+	for _, variable := range env {
+		name, dir, ok := strings.Cut(variable, "=")
+		if !ok || !strings.HasSuffix(name, outDirEnvSuffix) {
+			continue
+		}
+
+		files := map[string]string{}
+		err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrNotExist) && path == dir {
+				// the command never made it
+				return fs.SkipAll
+			}
+			if err != nil || !entry.Type().IsRegular() {
+				return err
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			files[filepath.ToSlash(rel)] = string(content)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		if outDirs == nil {
+			outDirs = map[string]map[string]string{}
+		}
+		outDirs[name] = files
+	}
+	return outDirs, nil
+}
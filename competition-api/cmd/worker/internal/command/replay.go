@@ -0,0 +1,185 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Ensure ReplayExecutor implements Executor interface.
+var _ Executor = (*ReplayExecutor)(nil)
+
+var (
+	ErrReplayExhausted   = errors.New("no recorded command left to replay")
+	ErrUnexpectedCommand = errors.New("command does not match the recording")
+)
+
+// Stands in for absolute paths when matching commands, they point into temp dirs that differ
+// between runs
+const replayPathPlaceholder = "<path>"
+
+// Serves the commands recorded by a [RecordingExecutor] back in the order they were run, without
+// running anything. Each command must match its recording, apart from absolute paths. Files the
+// recorded command left in its out dirs are written to the out dirs of the replayed one.
+type ReplayExecutor struct {
+	recordings []recording
+
+	mu   sync.Mutex
+	next int
+}
+
+// Loads the recordings in fixture `dir`
+func NewReplayExecutor(dir string) (*ReplayExecutor, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no recordings in %s", dir)
+	}
+	slices.Sort(names)
+
+	recordings := make([]recording, 0, len(names))
+	for _, name := range names {
+		content, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		var rec recording
+		err = json.Unmarshal(content, &rec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", name, err)
+		}
+		recordings = append(recordings, rec)
+	}
+
+	return &ReplayExecutor{recordings: recordings}, nil
+}
+
+// Recorded commands that weren't replayed yet, a complete replay leaves none
+func (r *ReplayExecutor) Unplayed() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.recordings) - r.next
+}
+
+func (r *ReplayExecutor) Execute(ctx context.Context, command *Command) (*Result, error) {
+	_, span := tracer.Start(ctx, "ReplayExecutor.Execute", trace.WithAttributes(
+		attribute.String("program", command.Program),
+		attribute.StringSlice("args", command.Args),
+	))
+	defer span.End()
+
+	rec, err := r.take(command)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to find recording")
+		return nil, err
+	}
+
+	err = writeOutDirs(command.Env, rec.OutDirs)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to restore command out dirs")
+		return nil, err
+	}
+
+	if rec.Error != "" {
+		err = errors.New(rec.Error)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to execute command")
+		return nil, err
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "replayed command")
+	return &Result{
+		Usage:    rec.Usage,
+		Cmd:      command.Line(),
+		Stdout:   []byte(rec.Stdout),
+		Stderr:   []byte(rec.Stderr),
+		ExitCode: rec.ExitCode,
+	}, nil
+}
+
+// Next recording, which has to match `command`
+func (r *ReplayExecutor) take(command *Command) (*recording, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.next >= len(r.recordings) {
+		return nil, fmt.Errorf("%w: %s", ErrReplayExhausted, strings.Join(command.Line(), " "))
+	}
+
+	rec := &r.recordings[r.next]
+	matches := rec.Program == command.Program &&
+		slices.Equal(replayPattern(rec.Args), replayPattern(command.Args)) &&
+		slices.Equal(replayPattern(rec.Env), replayPattern(command.Env))
+	if !matches {
+		return nil, fmt.Errorf(
+			"%w %s: recorded %q, got %q",
+			ErrUnexpectedCommand,
+			recordingName(r.next),
+			replayPattern(slices.Concat([]string{rec.Program}, rec.Args)),
+			replayPattern(command.Line()),
+		)
+	}
+
+	r.next++
+	return rec, nil
+}
+
+// `values` with absolute paths, also those assigned to variables, replaced by a placeholder
+func replayPattern(values []string) []string {
+	pattern := make([]string, 0, len(values))
+	for _, value := range values {
+		if name, assigned, ok := strings.Cut(value, "="); ok && filepath.IsAbs(assigned) {
+			value = name + "=" + replayPathPlaceholder
+		} else if filepath.IsAbs(value) {
+			value = replayPathPlaceholder
+		}
+		pattern = append(pattern, value)
+	}
+	return pattern
+}
+
+// Writes the recorded files of each out dir to the matching out dir of `env`
+func writeOutDirs(env []string, outDirs map[string]map[string]string) error {
+	for name, files := range outDirs {
+		var dir string
+		for _, variable := range env {
+			if value, ok := strings.CutPrefix(variable, name+"="); ok {
+				dir = value
+			}
+		}
+		if dir == "" {
+			return fmt.Errorf("%w: %s is not set", ErrUnexpectedCommand, name)
+		}
+
+		for rel, content := range files {
+			path := filepath.Join(dir, filepath.FromSlash(rel))
+			if !strings.HasPrefix(path, filepath.Clean(dir)+string(filepath.Separator)) {
+				return fmt.Errorf("recorded file %s escapes %s", rel, name)
+			}
+			err := os.MkdirAll(filepath.Dir(path), 0o755)
+			if err != nil {
+				return err
+			}
+			err = os.WriteFile(path, []byte(content), 0o644)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
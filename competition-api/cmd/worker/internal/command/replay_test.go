@@ -0,0 +1,78 @@
+package command_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/command"
+)
+
+func TestRecordReplay(t *testing.T) {
+	ctx := context.Background()
+	fixtures := t.TempDir()
+
+	// the out dir lives in a temp dir that differs between runs
+	script := func(outDir string) *command.Command {
+		cmd := command.New(
+			"sh",
+			"-c",
+			`echo building; echo warning >&2; mkdir -p "$POV_OUT_DIR/logs"; `+
+				`echo crashed > "$POV_OUT_DIR/fuzz.out"; echo trace > "$POV_OUT_DIR/logs/run.log"; exit 3`,
+			filepath.Join(outDir, "unused"),
+		)
+		cmd.Env = []string{"POV_OUT_DIR=" + outDir}
+		return cmd
+	}
+
+	recorder := command.NewRecordingExecutor(command.NewShellExecutor(), fixtures)
+	recorded, err := recorder.Execute(ctx, script(t.TempDir()))
+	require.NoError(t, err)
+	_, missingErr := recorder.Execute(ctx, command.New("/nonexistent/program"))
+	require.Error(t, missingErr, "missing programs should fail as without recording")
+
+	replay, err := command.NewReplayExecutor(fixtures)
+	require.NoError(t, err)
+	assert.Equal(t, 2, replay.Unplayed())
+
+	t.Run("Mismatch", func(t *testing.T) {
+		_, err := replay.Execute(ctx, command.New("sh", "-c", "echo other"))
+		require.ErrorIs(t, err, command.ErrUnexpectedCommand)
+		assert.Equal(t, 2, replay.Unplayed(), "mismatched commands should not use up a recording")
+	})
+
+	t.Run("Replayed", func(t *testing.T) {
+		outDir := t.TempDir()
+		cmd := script(outDir)
+		replayed, err := replay.Execute(ctx, cmd)
+		require.NoError(t, err)
+
+		assert.Equal(t, cmd.Line(), replayed.Cmd, "cmd should be the replayed invocation")
+		assert.Equal(t, []byte("building\n"), replayed.Stdout)
+		assert.Equal(t, []byte("warning\n"), replayed.Stderr)
+		assert.Equal(t, 3, replayed.ExitCode)
+		assert.Equal(t, recorded.Usage, replayed.Usage)
+
+		content, err := os.ReadFile(filepath.Join(outDir, "fuzz.out"))
+		require.NoError(t, err)
+		assert.Equal(t, "crashed\n", string(content))
+		content, err = os.ReadFile(filepath.Join(outDir, "logs", "run.log"))
+		require.NoError(t, err)
+		assert.Equal(t, "trace\n", string(content))
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		_, err := replay.Execute(ctx, command.New("/nonexistent/program"))
+		require.EqualError(t, err, missingErr.Error())
+		assert.Zero(t, replay.Unplayed())
+	})
+
+	t.Run("Exhausted", func(t *testing.T) {
+		_, err := replay.Execute(ctx, command.New("true"))
+		require.ErrorIs(t, err, command.ErrReplayExhausted)
+	})
+}
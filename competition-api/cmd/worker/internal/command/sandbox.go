@@ -0,0 +1,285 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sys/unix"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/logger"
+)
+
+// Ensure SandboxExecutor implements Executor interface.
+var _ Executor = (*SandboxExecutor)(nil)
+
+// Resource a command ran out of
+type Limit string
+
+const (
+	LimitCPU      Limit = "cpu"
+	LimitFileSize Limit = "file_size"
+	// A container the command started was killed for running out of its memory
+	LimitMemory Limit = "memory"
+	// The context of the command ended before it did
+	LimitTime Limit = "time"
+)
+
+// Limits on what a command and everything it starts may use, applied as rlimits. Containers
+// started through docker are not children of the command and aren't bound by them, the scripts
+// pass the container limits on to them from $DOCKER_RUN_LIMITS. Zero or negative values are
+// unlimited.
+type Limits struct {
+	// CPU time of each process, it gets SIGXCPU at the limit and SIGKILL a second later
+	MaxCPUSeconds int64
+	// Virtual memory of each process, allocations beyond it fail
+	MaxAddressSpaceBytes int64
+	// Size of any file written, writes beyond it get SIGXFSZ
+	MaxFileSizeBytes int64
+	// Processes of the worker's user, not only those of the command. Root is not bound by it.
+	MaxProcesses int64
+	// Memory of each container, the task's own memory rather than a worker wide default
+	ContainerMemoryBytes int64
+	// CPUs each container may use, it is throttled rather than killed beyond them
+	ContainerCPUs int64
+}
+
+var DefaultLimits = Limits{
+	MaxCPUSeconds:        60 * 60,
+	MaxAddressSpaceBytes: 16 << 30,
+	MaxFileSizeBytes:     32 << 30,
+}
+
+// Label of the containers a command starts, to find their events by
+const containerLabel = "competition-api.command"
+
+// `docker run` flags applying the container limits to the containers of command `id`. Process
+// limits aren't passed on, they count all threads of a container and would kill long builds.
+func (l *Limits) dockerRunArgs(id string) []string {
+	var args []string
+	if l.ContainerMemoryBytes > 0 {
+		args = append(args,
+			"--memory", strconv.FormatInt(l.ContainerMemoryBytes, 10),
+			"--label", containerLabel+"="+id,
+		)
+	}
+	if l.ContainerCPUs > 0 {
+		args = append(args, "--cpus", strconv.FormatInt(l.ContainerCPUs, 10))
+	}
+	return args
+}
+
+// Runs commands of the wrapped executor under limits, in a process group of their own that is
+// killed as a whole when the context ends, so nothing they started outlives them. Results say
+// which limit, if any, a command ran out of.
+type SandboxExecutor struct {
+	executor Executor
+	limits   Limits
+}
+
+func NewSandboxExecutor(executor Executor, limits Limits) *SandboxExecutor {
+	return &SandboxExecutor{executor: executor, limits: limits}
+}
+
+func (s *SandboxExecutor) Execute(ctx context.Context, command *Command) (*Result, error) {
+	ctx, span := tracer.Start(ctx, "SandboxExecutor.Execute", trace.WithAttributes(
+		attribute.String("program", command.Program),
+		attribute.StringSlice("args", command.Args),
+	))
+	defer span.End()
+
+	id := uuid.NewString()
+	sandboxed := *command
+	sandboxed.Limits = &s.limits
+	sandboxed.Env = append(
+		slices.Clip(command.Env),
+		"DOCKER_RUN_LIMITS="+strings.Join(s.limits.dockerRunArgs(id), " "),
+	)
+	start := time.Now()
+	result, err := s.executor.Execute(ctx, &sandboxed)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to execute command")
+		return nil, err
+	}
+
+	result.LimitExceeded = s.exceeded(ctx, result)
+	// containers killed for their memory only show as a failed docker run to the scripts
+	if result.LimitExceeded == "" && result.ExitCode != 0 && s.limits.ContainerMemoryBytes > 0 {
+		oom, err := s.containerOOM(ctx, command.Env, id, start)
+		if err != nil {
+			logger.Logger.WarnContext(ctx, "failed to check containers for oom kills", "error", err)
+		} else if oom {
+			result.LimitExceeded = LimitMemory
+		}
+	}
+	if result.LimitExceeded != "" {
+		span.AddEvent("limit_exceeded", trace.WithAttributes(
+			attribute.String("limit", string(result.LimitExceeded)),
+		))
+		logger.Logger.WarnContext(
+			ctx,
+			"command exceeded its limits",
+			"cmd", result.Cmd,
+			"limit", result.LimitExceeded,
+		)
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "executed sandboxed command")
+	return result, nil
+}
+
+// Limit `result` ran out of, by the signal that killed the command or one of its children and
+// the CPU time it used. Running out of address space or processes has no signal to tell it by,
+// output saying so may as well be the submission's own, so those are not reported. Containers
+// are checked apart, their processes aren't the command's children.
+func (s *SandboxExecutor) exceeded(ctx context.Context, result *Result) Limit {
+	signal := ""
+	cpuMs := result.CPUTime.Milliseconds()
+	limitMs := s.limits.MaxCPUSeconds * 1000
+	if result.Usage != nil {
+		signal = result.Usage.Signal
+	}
+	// shells exit with 128 plus the signal that killed their child
+	if signal == "" && result.ExitCode > 128 {
+		signal = unix.SignalName(unix.Signal(result.ExitCode - 128))
+	}
+
+	switch {
+	case signal == "SIGXCPU":
+		return LimitCPU
+	case signal == "SIGXFSZ":
+		return LimitFileSize
+	case signal == "SIGKILL" && limitMs > 0 && cpuMs >= limitMs:
+		return LimitCPU
+	case ctx.Err() == context.DeadlineExceeded:
+		return LimitTime
+	}
+	return ""
+}
+
+// Whether any container command `id` started since `start` had a process killed for running out
+// of memory, by the events of the docker daemon in `env`
+func (s *SandboxExecutor) containerOOM(
+	ctx context.Context,
+	env []string,
+	id string,
+	start time.Time,
+) (bool, error) {
+	events := New(
+		"docker",
+		"events",
+		"--since", strconv.FormatInt(start.Unix(), 10),
+		"--until", strconv.FormatInt(time.Now().Unix()+1, 10),
+		"--filter", "label="+containerLabel+"="+id,
+		"--filter", "event=oom",
+		"--format", "{{.ID}}",
+	)
+	events.Env = env
+	result, err := s.executor.Execute(ctx, events)
+	if err != nil {
+		return false, err
+	}
+	if result.ExitCode != 0 {
+		return false, fmt.Errorf("docker events exited with %d: %s", result.ExitCode, result.Stderr)
+	}
+	return len(bytes.TrimSpace(result.Stdout)) > 0, nil
+}
+
+// Runs a held back command once the gate on fd 3 opens, and not at all when it closes. Limits
+// survive the exec.
+const gateScript = `read -r _ <&3 || exit 126; exec 3<&-; exec "$0" "$@"`
+
+// Makes `cmd` start as a shell that waits for [release] before it execs the command, so the limits
+// are set before the command gets to start anything. Returns the end of the gate to release with.
+func holdBack(cmd *exec.Cmd) (*os.File, error) {
+	gateRead, gateWrite, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to make gate: %w", err)
+	}
+
+	cmd.Args = append([]string{"sh", "-c", gateScript, cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = "/bin/sh"
+	cmd.ExtraFiles = []*os.File{gateRead}
+	return gateWrite, nil
+}
+
+// Limits held back `cmd` and lets it run
+func release(cmd *exec.Cmd, gate *os.File, limits *Limits) error {
+	err := cmd.ExtraFiles[0].Close()
+	if err != nil {
+		return err
+	}
+
+	err = limits.apply(cmd.Process.Pid)
+	if err != nil {
+		return err
+	}
+
+	_, err = gate.WriteString("\n")
+	if err != nil {
+		return fmt.Errorf("failed to release command: %w", err)
+	}
+	return gate.Close()
+}
+
+// Sets the limits on process `pid`, everything it starts inherits them. Limits are only ever
+// lowered, a hard limit of the worker below the configured one stays.
+func (l *Limits) apply(pid int) error {
+	rlimits := []struct {
+		max      int64
+		resource int
+		// soft limit below the hard one, for a warning signal before the process is killed
+		grace uint64
+	}{
+		{max: l.MaxCPUSeconds, resource: unix.RLIMIT_CPU, grace: 1},
+		{max: l.MaxAddressSpaceBytes, resource: unix.RLIMIT_AS},
+		{max: l.MaxFileSizeBytes, resource: unix.RLIMIT_FSIZE},
+		{max: l.MaxProcesses, resource: unix.RLIMIT_NPROC},
+	}
+
+	for _, rlimit := range rlimits {
+		if rlimit.max <= 0 {
+			continue
+		}
+
+		var current unix.Rlimit
+		err := unix.Prlimit(pid, rlimit.resource, nil, &current)
+		if err != nil {
+			return fmt.Errorf("failed to get rlimit %d: %w", rlimit.resource, err)
+		}
+
+		limit := unix.Rlimit{Cur: uint64(rlimit.max), Max: uint64(rlimit.max) + rlimit.grace}
+		limit.Max = min(limit.Max, current.Max)
+		limit.Cur = min(limit.Cur, limit.Max)
+		err = unix.Prlimit(pid, rlimit.resource, &limit, nil)
+		if err != nil {
+			return fmt.Errorf("failed to set rlimit %d: %w", rlimit.resource, err)
+		}
+	}
+	return nil
+}
+
+// Kills process group `pgid` and with it everything the command started that didn't leave it
+func killGroup(pgid int) error {
+	err := unix.Kill(-pgid, unix.SIGKILL)
+	if errors.Is(err, unix.ESRCH) {
+		return os.ErrProcessDone
+	}
+	if err != nil {
+		return fmt.Errorf("failed to kill process group %d: %w", pgid, err)
+	}
+	return nil
+}
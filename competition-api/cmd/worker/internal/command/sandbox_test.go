@@ -0,0 +1,160 @@
+package command_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/command"
+)
+
+// Whether process `pid` is gone, reaped or not
+func exited(pid int) bool {
+	stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return true
+	}
+	// the state follows the parenthesized program name
+	_, state, _ := strings.Cut(string(stat), ") ")
+	return strings.HasPrefix(state, "Z")
+}
+
+func TestSandbox(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("WithinLimits", func(t *testing.T) {
+		sandbox := command.NewSandboxExecutor(command.NewShellExecutor(), command.DefaultLimits)
+		result, err := sandbox.Execute(ctx, command.New("sh", "-c", "echo -n a; exit 3"))
+		require.NoError(t, err)
+		assert.Equal(t, []byte("a"), result.Stdout)
+		assert.Equal(t, 3, result.ExitCode)
+		assert.Empty(t, result.LimitExceeded)
+	})
+
+	t.Run("Applied", func(t *testing.T) {
+		sandbox := command.NewSandboxExecutor(command.NewShellExecutor(), command.Limits{
+			MaxCPUSeconds:        30,
+			MaxAddressSpaceBytes: 1 << 30,
+		})
+		result, err := sandbox.Execute(ctx, command.New("sh", "-c", "ulimit -t; ulimit -v"))
+		require.NoError(t, err)
+		assert.Equal(t, "30\n1048576\n", string(result.Stdout))
+	})
+
+	t.Run("CPU", func(t *testing.T) {
+		sandbox := command.NewSandboxExecutor(
+			command.NewShellExecutor(),
+			command.Limits{MaxCPUSeconds: 1},
+		)
+		result, err := sandbox.Execute(ctx, command.New("sh", "-c", "while :; do :; done"))
+		require.NoError(t, err)
+		assert.Equal(t, command.LimitCPU, result.LimitExceeded)
+		assert.Equal(t, "SIGXCPU", result.Usage.Signal)
+	})
+
+	t.Run("FileSize", func(t *testing.T) {
+		sandbox := command.NewSandboxExecutor(
+			command.NewShellExecutor(),
+			command.Limits{MaxFileSizeBytes: 1 << 20},
+		)
+		// the shell survives the writer and exits with the signal that killed it
+		result, err := sandbox.Execute(ctx, command.New(
+			"sh",
+			"-c",
+			`head -c 2097152 /dev/zero > "$1"`,
+			"sh",
+			filepath.Join(t.TempDir(), "out"),
+		))
+		require.NoError(t, err)
+		assert.Empty(t, result.Usage.Signal)
+		assert.Equal(t, command.LimitFileSize, result.LimitExceeded)
+	})
+
+	t.Run("TimeoutKillsGroup", func(t *testing.T) {
+		sandbox := command.NewSandboxExecutor(command.NewShellExecutor(), command.Limits{})
+		timeoutCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		result, err := sandbox.Execute(
+			timeoutCtx,
+			command.New("sh", "-c", "sleep 30 & echo $!; wait"),
+		)
+		require.NoError(t, err)
+		assert.Less(t, time.Since(start), 5*time.Second, "children should not hold up the command")
+		assert.Equal(t, command.LimitTime, result.LimitExceeded)
+
+		pid, err := strconv.Atoi(strings.TrimSpace(string(result.Stdout)))
+		require.NoError(t, err)
+		assert.Eventually(t, func() bool { return exited(pid) }, time.Second, 10*time.Millisecond,
+			"background children should be killed with the command")
+	})
+
+	t.Run("CrashOutputNotALimit", func(t *testing.T) {
+		// a fuzz target running out of memory says so itself
+		sandbox := command.NewSandboxExecutor(
+			command.NewShellExecutor(),
+			command.Limits{MaxAddressSpaceBytes: 1 << 30, MaxProcesses: 1 << 16},
+		)
+		result, err := sandbox.Execute(ctx, command.New(
+			"sh",
+			"-c",
+			`echo "==1==ERROR: AddressSanitizer: out of memory" >&2; exit 1`,
+		))
+		require.NoError(t, err)
+		assert.Empty(t, result.LimitExceeded)
+	})
+
+	t.Run("DockerRunLimits", func(t *testing.T) {
+		sandbox := command.NewSandboxExecutor(command.NewShellExecutor(), command.Limits{
+			MaxCPUSeconds:        60,
+			MaxAddressSpaceBytes: 1 << 30,
+			MaxProcesses:         100,
+			ContainerMemoryBytes: 2 << 30,
+			ContainerCPUs:        4,
+		})
+		cmd := command.New("sh", "-c", `echo "$DOCKER_RUN_LIMITS"`)
+		cmd.Env = []string{"TEST_OUT_DIR=/tmp"}
+		result, err := sandbox.Execute(ctx, cmd)
+		require.NoError(t, err)
+		assert.Regexp(
+			t,
+			`^--memory 2147483648 --label competition-api.command=[-0-9a-f]{36} --cpus 4\n$`,
+			string(result.Stdout),
+		)
+		assert.Equal(t, []string{"TEST_OUT_DIR=/tmp"}, cmd.Env, "the command should be left as is")
+
+		sandbox = command.NewSandboxExecutor(command.NewShellExecutor(), command.DefaultLimits)
+		result, err = sandbox.Execute(ctx, cmd)
+		require.NoError(t, err)
+		assert.Equal(t, "\n", string(result.Stdout), "containers should be unlimited by default")
+	})
+
+	t.Run("ContainerOOM", func(t *testing.T) {
+		// stands in for the docker daemon, reporting an oom event for the command's container
+		bin := t.TempDir()
+		docker := `#!/bin/sh
+[ "$1" = events ] && echo 3f2a`
+		require.NoError(t, os.WriteFile(filepath.Join(bin, "docker"), []byte(docker), 0o755))
+		t.Setenv("PATH", bin+":"+os.Getenv("PATH"))
+
+		sandbox := command.NewSandboxExecutor(
+			command.NewShellExecutor(),
+			command.Limits{ContainerMemoryBytes: 1 << 30},
+		)
+		result, err := sandbox.Execute(ctx, command.New("sh", "-c", "exit 201"))
+		require.NoError(t, err)
+		assert.Equal(t, command.LimitMemory, result.LimitExceeded)
+
+		result, err = sandbox.Execute(ctx, command.New("sh", "-c", "exit 0"))
+		require.NoError(t, err)
+		assert.Empty(t, result.LimitExceeded, "commands that succeeded should not be checked")
+	})
+}
@@ -5,14 +5,18 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"os"
 	"os/exec"
+	"syscall"
 	"time"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sys/unix"
 
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/logger"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
 )
 
 // Ensure ShellExecutor implements Executor interface.
@@ -37,11 +41,47 @@ func (*ShellExecutor) Execute(ctx context.Context, command *Command) (*Result, e
 	//nolint:gosec // G204: not controllable by sanitizing here; callers should ensure sanitization
 	cmd := exec.CommandContext(ctx, command.Program, command.Args...)
 	cmd.Stdin = command.Stdin
+	if len(command.Env) > 0 {
+		cmd.Env = append(os.Environ(), command.Env...)
+	}
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 	cmd.WaitDelay = time.Second
 
-	err := cmd.Run()
+	var gate *os.File
+	if command.Limits != nil && cmd.Err == nil {
+		// a timeout kills everything the command started, not only the command
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		cmd.Cancel = func() error {
+			return killGroup(cmd.Process.Pid)
+		}
+
+		var err error
+		gate, err = holdBack(cmd)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to hold back command")
+			return nil, err
+		}
+		defer gate.Close()
+	}
+
+	start := time.Now()
+	err := cmd.Start()
+	if err == nil && gate != nil {
+		err = release(cmd, gate, command.Limits)
+		if err != nil {
+			_ = killGroup(cmd.Process.Pid)
+			_ = cmd.Wait()
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to limit command")
+			return nil, err
+		}
+	}
+	if err == nil {
+		err = cmd.Wait()
+	}
+	wallTime := time.Since(start)
 	if err != nil {
 		var ee *exec.ExitError
 		if !errors.As(err, &ee) {
@@ -65,20 +105,32 @@ func (*ShellExecutor) Execute(ctx context.Context, command *Command) (*Result, e
 		logger.Logger.DebugContext(ctx, "stderr", "line", line)
 	}
 
+	usage := resourceUsage(cmd.ProcessState, wallTime)
+	cpuTime := cmd.ProcessState.UserTime() + cmd.ProcessState.SystemTime()
 	span.AddEvent("executed", trace.WithAttributes(
 		attribute.Int("exitCode", cmd.ProcessState.ExitCode()),
+		attribute.Int64("usage.wallTimeMs", usage.WallTimeMs),
+		attribute.Int64("cpuTimeMs", cpuTime.Milliseconds()),
+		attribute.String("usage.signal", usage.Signal),
 	))
 
-	executed := make([]string, 0, len(command.Args)+1)
-	executed = append(executed, command.Program)
-	executed = append(executed, command.Args...)
-
 	span.RecordError(nil)
 	span.SetStatus(codes.Ok, "successfully executed command")
 	return &Result{
-		Cmd:      executed,
+		Usage:    usage,
+		Cmd:      command.Line(),
 		Stdout:   stdoutBytes,
 		Stderr:   stderrBytes,
 		ExitCode: cmd.ProcessState.ExitCode(),
+		CPUTime:  cpuTime,
 	}, nil
 }
+
+// Reads what the finished process used from its rusage, Linux reports the peak memory in KiB
+func resourceUsage(state *os.ProcessState, wallTime time.Duration) *types.ResourceUsage {
+	usage := &types.ResourceUsage{WallTimeMs: wallTime.Milliseconds()}
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		usage.Signal = unix.SignalName(status.Signal())
+	}
+	return usage
+}
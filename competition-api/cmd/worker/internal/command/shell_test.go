@@ -26,6 +26,8 @@ func TestExecute(t *testing.T) {
 		cmd := command.New("echo", "-n", "a")
 		result, err := shell.Execute(ctx, cmd)
 		require.NoError(t, err, "failed to run command")
+		require.NotNil(t, result.Usage, "usage should be recorded")
+		result.Usage, result.CPUTime = nil, 0
 		assert.Equal(t, expected, result, "command result did not match")
 	})
 
@@ -45,9 +47,22 @@ Try 'grep --help' for more information.
 		cmd := command.New("grep", "-y")
 		result, err := shell.Execute(ctx, cmd)
 		require.NoError(t, err, "failed to run command")
+		require.NotNil(t, result.Usage, "usage should be recorded")
+		result.Usage, result.CPUTime = nil, 0
 		assert.Equal(t, expected, result, "command result did not match")
 	})
 
+	t.Run("Env", func(t *testing.T) {
+		ctx := context.Background()
+		shell := command.NewShellExecutor()
+
+		cmd := command.New("sh", "-c", "echo -n $POV_OUT_DIR")
+		cmd.Env = []string{"POV_OUT_DIR=/out"}
+		result, err := shell.Execute(ctx, cmd)
+		require.NoError(t, err, "failed to run command")
+		assert.Equal(t, "/out", string(result.Stdout), "env should be passed to the command")
+	})
+
 	t.Run("Cancel context graceful shutdown", func(t *testing.T) {
 		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
 		defer cancel()
@@ -58,5 +73,30 @@ Try 'grep --help' for more information.
 		result, err := shell.Execute(ctx, cmd)
 		require.NoError(t, err, "context cancel sets return code -1")
 		assert.Equal(t, -1, result.ExitCode, "context cancel sets return code to -1")
+		assert.Equal(t, "SIGKILL", result.Usage.Signal, "context cancel kills the command")
+	})
+
+	t.Run("Usage", func(t *testing.T) {
+		ctx := context.Background()
+		shell := command.NewShellExecutor()
+
+		result, err := shell.Execute(ctx, command.New("sleep", "0.1"))
+		require.NoError(t, err, "failed to run command")
+		require.NotNil(t, result.Usage)
+
+		usage := result.Usage
+		assert.Empty(t, usage.Signal, "command exited by itself")
+		assert.GreaterOrEqual(t, usage.WallTimeMs, int64(100))
+	})
+
+	t.Run("Signal", func(t *testing.T) {
+		ctx := context.Background()
+		shell := command.NewShellExecutor()
+
+		cmd := command.New("sh", "-c", "kill -TERM $$")
+		result, err := shell.Execute(ctx, cmd)
+		require.NoError(t, err, "failed to run command")
+		assert.Equal(t, -1, result.ExitCode)
+		assert.Equal(t, "SIGTERM", result.Usage.Signal)
 	})
 }
@@ -49,3 +49,13 @@ func GetAzureQueueClient() (*queue.AzureQueuer, error) {
 		os.Getenv("AZURE_STORAGE_ACCOUNT_RESULTS_QUEUE"),
 	)
 }
+
+// Queue `worker serve` pulls evaluation requests from
+func GetAzureEvalQueueClient() (*queue.AzureQueuer, error) {
+	return queue.NewAzureQueuer(
+		os.Getenv("AZURE_STORAGE_ACCOUNT_NAME"),
+		os.Getenv("AZURE_STORAGE_ACCOUNT_KEY"),
+		os.Getenv("AZURE_STORAGE_ACCOUNT_QUEUES_URL"),
+		os.Getenv("AZURE_STORAGE_ACCOUNT_EVALS_QUEUE"),
+	)
+}
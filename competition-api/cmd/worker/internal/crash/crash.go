@@ -0,0 +1,207 @@
+package crash
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+)
+
+const (
+	// Fuzzer output can have huge lines, like hex dumps of the input. Longer lines end the parse.
+	maxLineSize = 1 << 20
+	// Frames that go into the signature, deeper ones mostly differ by how the harness got there
+	signatureFrames = 3
+
+	sanitizerJazzer    = "Jazzer"
+	sanitizerUBSan     = "UndefinedBehaviorSanitizer"
+	sanitizerLibFuzzer = "libFuzzer"
+
+	jazzerSecurityIssue = "com.code_intelligence.jazzer.api.FuzzerSecurityIssue"
+)
+
+var (
+	// ==1==ERROR: AddressSanitizer: heap-buffer-overflow on address 0x602000000011 at pc ...
+	sanitizerHeader = regexp.MustCompile(`^==\d+==\s*(?:ERROR|WARNING): (\w+): (.+)$`)
+	// /src/file.c:12:5: runtime error: signed integer overflow: ...
+	ubsanHeader   = regexp.MustCompile(`^\S+:\d+(?::\d+)?: runtime error: (.+)$`)
+	jazzerHeader  = regexp.MustCompile(`^== Java Exception: (.+)$`)
+	summaryLine   = regexp.MustCompile(`^SUMMARY: (.+)$`)
+	nativeFrame   = regexp.MustCompile(`^\s*#(\d+)\s+0x[0-9a-fA-F]+(?:\s+(.*))?$`)
+	javaFrame     = regexp.MustCompile(`^\s+at ([^\s(]+)\((.*)\)$`)
+	faultAddress  = regexp.MustCompile(`\bon (?:unknown )?(?:address )?(0x[0-9a-fA-F]+)`)
+	frameLocation = regexp.MustCompile(`^[/(]|^\S+:\d+(?::\d+)?$`)
+	buildID       = regexp.MustCompile(`\s+\(BuildId: \w+\)$`)
+	numbers       = regexp.MustCompile(`\b(?:0x[0-9a-fA-F]+|\d+)\b`)
+
+	// sanitizer, fuzzer and libc frames say nothing about the bug
+	runtimeFramePrefixes = []string{
+		"__asan",
+		"__msan",
+		"__ubsan",
+		"__lsan",
+		"__sanitizer",
+		"__interceptor_",
+		"___interceptor_",
+		"__libc_start",
+		"_start",
+		"fuzzer::",
+		"com.code_intelligence.jazzer.",
+	}
+)
+
+// Reads the first sanitizer or Jazzer report out of fuzzer output. Keeps up to `maxFrames` of
+// its symbolized frames, returns nil when the output has no report.
+func Parse(r io.Reader, maxFrames int) (*types.CrashReport, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	var report *types.CrashReport
+	var frames []types.CrashFrame
+	inStack := false
+	stackDone := false
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if report == nil {
+			report = parseHeader(line)
+			continue
+		}
+
+		if m := summaryLine.FindStringSubmatch(line); m != nil && report.Summary == "" {
+			report.Summary = m[1]
+			continue
+		}
+		if stackDone {
+			continue
+		}
+
+		frame, index, ok := parseFrame(line)
+		switch {
+		case ok && index == 0 && inStack:
+			// the next stack, like where the memory was freed
+			stackDone = true
+		case ok:
+			inStack = true
+			frames = append(frames, frame)
+		case inStack:
+			stackDone = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if report == nil {
+		return nil, nil
+	}
+
+	if report.Sanitizer != sanitizerLibFuzzer && report.Sanitizer != sanitizerUBSan &&
+		report.Sanitizer != sanitizerJazzer {
+		// the summary names the bug with a single token, the header sometimes with a sentence
+		if kind, ok := strings.CutPrefix(report.Summary, report.Sanitizer+": "); ok {
+			report.Type, _, _ = strings.Cut(kind, " ")
+		}
+	}
+
+	report.Frames = []types.CrashFrame{}
+	for _, frame := range frames {
+		if len(report.Frames) == maxFrames {
+			break
+		}
+		if frame.Function == "" || isRuntimeFrame(frame.Function) {
+			continue
+		}
+		report.Frames = append(report.Frames, frame)
+	}
+	report.Signature = signature(report)
+
+	return report, nil
+}
+
+func parseHeader(line string) *types.CrashReport {
+	if m := sanitizerHeader.FindStringSubmatch(line); m != nil {
+		report := &types.CrashReport{Sanitizer: m[1], Type: m[2]}
+		if a := faultAddress.FindStringSubmatch(m[2]); a != nil {
+			report.Address = a[1]
+		}
+		for _, sep := range []string{" on ", " after ", " ("} {
+			report.Type, _, _ = strings.Cut(report.Type, sep)
+		}
+		return report
+	}
+
+	if m := ubsanHeader.FindStringSubmatch(line); m != nil {
+		kind, _, _ := strings.Cut(m[1], ":")
+		return &types.CrashReport{
+			Sanitizer: sanitizerUBSan,
+			Type:      numbers.ReplaceAllString(kind, "N"),
+		}
+	}
+
+	if m := jazzerHeader.FindStringSubmatch(line); m != nil {
+		class, message, _ := strings.Cut(m[1], ": ")
+		kind := class
+		if strings.HasPrefix(class, jazzerSecurityIssue) && message != "" {
+			kind = message
+		}
+		return &types.CrashReport{Sanitizer: sanitizerJazzer, Type: kind, Summary: m[1]}
+	}
+
+	return nil
+}
+
+// Parses native `#0 0x4f3c2e in func /src/file.c:1:2` and java `at pkg.Class.method(File.java:1)`
+// frames
+func parseFrame(line string) (types.CrashFrame, int, bool) {
+	if m := javaFrame.FindStringSubmatch(line); m != nil {
+		return types.CrashFrame{Function: m[1], Location: m[2]}, -1, true
+	}
+
+	m := nativeFrame.FindStringSubmatch(line)
+	if m == nil {
+		return types.CrashFrame{}, 0, false
+	}
+	index, err := strconv.Atoi(m[1])
+	if err != nil {
+		return types.CrashFrame{}, 0, false
+	}
+
+	rest := buildID.ReplaceAllString(strings.TrimSpace(m[2]), "")
+	function, hasFunction := strings.CutPrefix(rest, "in ")
+	location := ""
+	if !hasFunction {
+		function, location = "", rest
+	} else if i := strings.LastIndex(function, " "); i != -1 &&
+		frameLocation.MatchString(function[i+1:]) {
+		function, location = function[:i], function[i+1:]
+	}
+	location = strings.TrimSuffix(strings.TrimPrefix(location, "("), ")")
+
+	return types.CrashFrame{Function: function, Location: location}, index, true
+}
+
+func isRuntimeFrame(function string) bool {
+	for _, prefix := range runtimeFramePrefixes {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Joins the crash type and top frame functions without their arguments, so the same bug found
+// by different inputs, builds or teams gets the same signature
+func signature(report *types.CrashReport) string {
+	parts := []string{report.Type}
+	for i, frame := range report.Frames {
+		if i == signatureFrames {
+			break
+		}
+		function, _, _ := strings.Cut(frame.Function, "(")
+		parts = append(parts, strings.TrimSpace(function))
+	}
+	return strings.Join(parts, "|")
+}
@@ -0,0 +1,204 @@
+package crash_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/crash"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+)
+
+const asanHeapOverflow = `INFO: Running with entropic power schedule (0xFF, 100).
+INFO: Seed: 1337
+Running: /tmp/pov
+=================================================================
+==18==ERROR: AddressSanitizer: heap-buffer-overflow on address 0x602000000011 at pc 0x55d8 bp 0x7ffd sp 0x7ffd
+READ of size 1 at 0x602000000011 thread T0
+    #0 0x55d8a1c3 in __asan_memcpy /src/llvm-project/compiler-rt/lib/asan/asan_interceptors.cpp:63:3
+    #1 0x55d8a1c4 in png_read_row /src/libpng/pngread.c:123:45
+    #2 0x55d8a1c5 in png_read_image /src/libpng/pngread.c:700:7
+    #3 0x55d8a1c6 in LLVMFuzzerTestOneInput /src/libpng_read_fuzzer.cc:150:3
+    #4 0x55d8a1c7 in fuzzer::Fuzzer::ExecuteCallback(unsigned char const*, unsigned long) /src/llvm-project/compiler-rt/lib/fuzzer/FuzzerLoop.cpp:614:13
+    #5 0x7f3c in __libc_start_main (/lib/x86_64-linux-gnu/libc.so.6+0x21b96) (BuildId: 9fdb74e7b217d06c93172a8243f8547f947ee6d1)
+
+0x602000000011 is located 0 bytes after 1-byte region [0x602000000010,0x602000000011)
+allocated by thread T0 here:
+    #0 0x55d8a1d0 in malloc /src/llvm-project/compiler-rt/lib/asan/asan_malloc_linux.cpp:69:3
+    #1 0x55d8a1d1 in png_malloc /src/libpng/pngmem.c:10:3
+
+SUMMARY: AddressSanitizer: heap-buffer-overflow /src/libpng/pngread.c:123:45 in png_read_row
+Shadow bytes around the buggy address:
+==18==ABORTING
+`
+
+const asanSEGV = `==7==ERROR: AddressSanitizer: SEGV on unknown address 0x000000000000 (pc 0x5631 bp 0x7ffc sp 0x7ffc T0)
+==7==The signal is caused by a READ memory access.
+==7==Hint: address points to the zero page.
+    #0 0x5631 in Foo::parse(char const*, int) /src/foo/parser.cc:88:12
+    #1 0x5632 in LLVMFuzzerTestOneInput /src/foo/fuzz.cc:9:3
+    #2 0x5633 (/out/fuzz+0x5ad3c1)
+
+AddressSanitizer can not provide additional info.
+SUMMARY: AddressSanitizer: SEGV /src/foo/parser.cc:88:12 in Foo::parse(char const*, int)
+`
+
+const ubsanOverflow = `/src/mruby/src/numeric.c:1234:5: runtime error: signed integer overflow: 2147483647 + 1 cannot be represented in type 'int'
+    #0 0x4e1f in int_add /src/mruby/src/numeric.c:1234:5
+    #1 0x4e20 in mrb_vm_exec /src/mruby/src/vm.c:1500:9
+SUMMARY: UndefinedBehaviorSanitizer: undefined-behavior /src/mruby/src/numeric.c:1234:5 in
+`
+
+const msanUninit = `==3==WARNING: MemorySanitizer: use-of-uninitialized-value
+    #0 0x49c1 in decode_block /src/codec/decode.c:42:7
+    #1 0x49c2 in LLVMFuzzerTestOneInput /src/codec/fuzz.c:20:3
+
+  Uninitialized value was created by a heap allocation
+    #0 0x4000 in malloc
+SUMMARY: MemorySanitizer: use-of-uninitialized-value /src/codec/decode.c:42:7 in decode_block
+`
+
+const jazzerCommandInjection = `INFO: Instrumented com.example.Shell
+== Java Exception: com.code_intelligence.jazzer.api.FuzzerSecurityIssueCritical: OS Command Injection
+Executing OS commands with attacker-controlled data can lead to remote code execution.
+	at com.code_intelligence.jazzer.sanitizers.OsCommandInjection.processImplStartHook(OsCommandInjection.kt:49)
+	at java.base/java.lang.ProcessImpl.start(ProcessImpl.java:110)
+	at java.base/java.lang.ProcessBuilder.start(ProcessBuilder.java:1107)
+	at com.example.Shell.run(Shell.java:31)
+	at com.example.ShellFuzzer.fuzzerTestOneInput(ShellFuzzer.java:12)
+DEDUP_TOKEN: 2bbe4ef2d0e0f9b4
+== libFuzzer crashing input ==
+`
+
+const libFuzzerTimeout = `ALARM: working on the last Unit for 26 seconds
+==13== ERROR: libFuzzer: timeout after 26 seconds
+    #0 0x51 in __sanitizer_print_stack_trace /src/llvm-project/compiler-rt/lib/asan/asan_stack.cpp:87:3
+    #1 0x52 in fuzzer::PrintStackTrace() /src/llvm-project/compiler-rt/lib/fuzzer/FuzzerUtil.cpp:210:5
+    #2 0x53 in spin /src/loop/loop.c:5:3
+SUMMARY: libFuzzer: timeout
+`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expected *types.CrashReport
+		name     string
+		output   string
+	}{
+		{
+			name:   "ASanHeapOverflow",
+			output: asanHeapOverflow,
+			expected: &types.CrashReport{
+				Sanitizer: "AddressSanitizer",
+				Type:      "heap-buffer-overflow",
+				Address:   "0x602000000011",
+				Summary:   "AddressSanitizer: heap-buffer-overflow /src/libpng/pngread.c:123:45 in png_read_row",
+				Signature: "heap-buffer-overflow|png_read_row|png_read_image|LLVMFuzzerTestOneInput",
+				Frames: []types.CrashFrame{
+					{Function: "png_read_row", Location: "/src/libpng/pngread.c:123:45"},
+					{Function: "png_read_image", Location: "/src/libpng/pngread.c:700:7"},
+					{Function: "LLVMFuzzerTestOneInput", Location: "/src/libpng_read_fuzzer.cc:150:3"},
+				},
+			},
+		},
+		{
+			name:   "ASanSEGV",
+			output: asanSEGV,
+			expected: &types.CrashReport{
+				Sanitizer: "AddressSanitizer",
+				Type:      "SEGV",
+				Address:   "0x000000000000",
+				Summary:   "AddressSanitizer: SEGV /src/foo/parser.cc:88:12 in Foo::parse(char const*, int)",
+				Signature: "SEGV|Foo::parse|LLVMFuzzerTestOneInput",
+				Frames: []types.CrashFrame{
+					{Function: "Foo::parse(char const*, int)", Location: "/src/foo/parser.cc:88:12"},
+					{Function: "LLVMFuzzerTestOneInput", Location: "/src/foo/fuzz.cc:9:3"},
+				},
+			},
+		},
+		{
+			name:   "UBSan",
+			output: ubsanOverflow,
+			expected: &types.CrashReport{
+				Sanitizer: "UndefinedBehaviorSanitizer",
+				Type:      "signed integer overflow",
+				Summary:   "UndefinedBehaviorSanitizer: undefined-behavior /src/mruby/src/numeric.c:1234:5 in",
+				Signature: "signed integer overflow|int_add|mrb_vm_exec",
+				Frames: []types.CrashFrame{
+					{Function: "int_add", Location: "/src/mruby/src/numeric.c:1234:5"},
+					{Function: "mrb_vm_exec", Location: "/src/mruby/src/vm.c:1500:9"},
+				},
+			},
+		},
+		{
+			name:   "MSan",
+			output: msanUninit,
+			expected: &types.CrashReport{
+				Sanitizer: "MemorySanitizer",
+				Type:      "use-of-uninitialized-value",
+				Summary:   "MemorySanitizer: use-of-uninitialized-value /src/codec/decode.c:42:7 in decode_block",
+				Signature: "use-of-uninitialized-value|decode_block|LLVMFuzzerTestOneInput",
+				Frames: []types.CrashFrame{
+					{Function: "decode_block", Location: "/src/codec/decode.c:42:7"},
+					{Function: "LLVMFuzzerTestOneInput", Location: "/src/codec/fuzz.c:20:3"},
+				},
+			},
+		},
+		{
+			name:   "Jazzer",
+			output: jazzerCommandInjection,
+			expected: &types.CrashReport{
+				Sanitizer: "Jazzer",
+				Type:      "OS Command Injection",
+				Summary:   "com.code_intelligence.jazzer.api.FuzzerSecurityIssueCritical: OS Command Injection",
+				Signature: "OS Command Injection|java.base/java.lang.ProcessImpl.start|java.base/java.lang.ProcessBuilder.start|com.example.Shell.run",
+				Frames: []types.CrashFrame{
+					{Function: "java.base/java.lang.ProcessImpl.start", Location: "ProcessImpl.java:110"},
+					{Function: "java.base/java.lang.ProcessBuilder.start", Location: "ProcessBuilder.java:1107"},
+					{Function: "com.example.Shell.run", Location: "Shell.java:31"},
+				},
+			},
+		},
+		{
+			name:   "LibFuzzerTimeout",
+			output: libFuzzerTimeout,
+			expected: &types.CrashReport{
+				Sanitizer: "libFuzzer",
+				Type:      "timeout",
+				Summary:   "libFuzzer: timeout",
+				Signature: "timeout|spin",
+				Frames: []types.CrashFrame{
+					{Function: "spin", Location: "/src/loop/loop.c:5:3"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := crash.Parse(strings.NewReader(tt.output), 3)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, report)
+		})
+	}
+}
+
+func TestParseNoCrash(t *testing.T) {
+	report, err := crash.Parse(strings.NewReader("INFO: Seed: 1\nDone 1 runs in 0 second(s)\n"), 3)
+	require.NoError(t, err)
+	assert.Nil(t, report)
+}
+
+func TestParseSignatureIgnoresAddresses(t *testing.T) {
+	moved := strings.NewReplacer("0x602000000011", "0x603000000042", "0x55d8", "0x7a11").
+		Replace(asanHeapOverflow)
+
+	first, err := crash.Parse(strings.NewReader(asanHeapOverflow), 3)
+	require.NoError(t, err)
+	second, err := crash.Parse(strings.NewReader(moved), 3)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, first.Address, second.Address)
+	assert.Equal(t, first.Signature, second.Signature)
+}
@@ -3,13 +3,16 @@ package engine
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bluekeyes/go-gitdiff/gitdiff"
 	"go.opentelemetry.io/otel/attribute"
@@ -18,15 +21,43 @@ import (
 	"gopkg.in/yaml.v2"
 
 	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/command"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/crash"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/testresults"
 	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/workerqueue"
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/common"
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/identifier"
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/logger"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/patchpolicy"
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/upload"
 	workererrors "github.com/aixcyberchallenge/competition-api/competition-api/internal/worker_errors"
 )
 
+// Command output beyond this is cut from the middle before uploading, the start and end of a log
+// are what explain a failure
+const maxCommandOutputSize = 8 << 20
+
+// Frames kept from a parsed crash report
+const maxCrashFrames = 10
+
+// Runs of a trigger that must not crash, enough to catch crashes that only reproduce sometimes
+const unexpectedCrashRuns = 3
+
+// Time a run of run_pov.sh takes beyond the fuzzer timeout, starting the container and
+// interpreting the crash
+const povRunOverhead = 5 * time.Minute
+
+// One execution of run_pov.sh and the directory it wrote its output to
+type povRun struct {
+	result *command.Result
+	outDir string
+}
+
+// Docker image helper.py builds `project` into, tagged `tag`
+func ProjectImage(project string, tag string) string {
+	return fmt.Sprintf("aixcc-afc/%s:%s", project, tag)
+}
+
 // Ensure AixccEngine implementes Engine interface
 var _ Engine = (*AixccEngine)(nil)
 
@@ -83,7 +114,7 @@ func (c *AixccEngine) Check(ctx context.Context, data *Params) error {
 		return workererrors.StatusErrorWrap(types.SubmissionStatusFailed, false, err)
 	}
 
-	if data.engine != string(types.FuzzingEngineLibFuzzer) {
+	if !slices.Contains(types.DefaultFuzzingEngines, types.FuzzingEngine(data.engine)) {
 		err = fmt.Errorf("invalid engine: %s", data.engine)
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "invalid engine")
@@ -91,8 +122,9 @@ func (c *AixccEngine) Check(ctx context.Context, data *Params) error {
 	}
 
 	type projectYAML struct {
-		Language   string   `yaml:"language"`
-		Sanitizers []string `yaml:"sanitizers"`
+		Language       string                `yaml:"language"`
+		Sanitizers     []string              `yaml:"sanitizers"`
+		FuzzingEngines []types.FuzzingEngine `yaml:"fuzzing_engines"`
 	}
 	projectYAMLPath := filepath.Join(fuzzToolingDir, "projects", data.projectName, "project.yaml")
 	projectYamlData, err := os.ReadFile(projectYAMLPath)
@@ -153,6 +185,17 @@ func (c *AixccEngine) Check(ctx context.Context, data *Params) error {
 		fmt.Fprint(dockerfile, "\nCOPY settings.xml /root/.m2/settings.xml\n")
 	}
 
+	engines := p.FuzzingEngines
+	if len(engines) == 0 {
+		engines = types.DefaultFuzzingEnginesFor(p.Language)
+	}
+	if !slices.Contains(engines, types.FuzzingEngine(data.engine)) {
+		err = fmt.Errorf("engine not supported by project: %s", data.engine)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "engine not supported by project")
+		return workererrors.StatusErrorWrap(types.SubmissionStatusFailed, false, err)
+	}
+
 	if data.sanitizer != "" && !slices.Contains(p.Sanitizers, data.sanitizer) {
 		err = fmt.Errorf("invalid sanitizer: %s", data.sanitizer)
 		span.RecordError(err)
@@ -189,6 +232,7 @@ func (c *AixccEngine) Build(
 		attribute.String("data.repoDir", data.repoDir),
 		attribute.String("data.fuzzToolingDir", data.fuzzToolingDir),
 		attribute.String("data.focus", data.focus),
+		attribute.String("data.engine", data.engine),
 	))
 	defer span.End()
 
@@ -217,6 +261,9 @@ func (c *AixccEngine) Build(
 		// TODO: warn about missing sanitizer
 		args = append(args, "-s", data.sanitizer)
 	}
+	if data.engine != "" {
+		args = append(args, "-E", data.engine)
+	}
 
 	args = append(args,
 		"-a", data.architecture,
@@ -224,6 +271,9 @@ func (c *AixccEngine) Build(
 		"-r", filepath.Join(data.repoDir, data.focus),
 		"-o", fuzzToolingDir,
 	)
+	if data.imageTag != "" {
+		args = append(args, "-d", data.imageTag)
+	}
 
 	cmd := command.New("./build_cr.sh", args...)
 	result, err := c.executor.Execute(ctx, cmd)
@@ -233,6 +283,24 @@ func (c *AixccEngine) Build(
 		return err
 	}
 
+	buildContext := types.ResultCtxHeadRepoBuild
+	if data.resultContext == types.ResultCtxBaseRepoTest {
+		buildContext = types.ResultCtxBaseRepoBuild
+	}
+	err = c.sendCommandResult(ctx, result, buildContext)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to send build command result")
+		return err
+	}
+
+	err = limitError(result)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "build exceeded its limits")
+		return err
+	}
+
 	if result.ExitCode == 202 {
 		if strings.Contains(string(result.Stderr), "Unable to fetch some archives") {
 			span.RecordError(ErrAptUnreachable)
@@ -299,6 +367,12 @@ func (c *AixccEngine) RunPov(
 		return err
 	}
 
+	// the step may be unlimited but run_pov.sh always needs a fuzzer timeout
+	povTimeout := data.timeouts.RunPovSecs
+	if povTimeout <= 0 {
+		povTimeout = types.DefaultEvalTimeouts.RunPovSecs
+	}
+
 	args := make([]string, 0, 20)
 	if !crashExpected {
 		args = append(args, "-x")
@@ -315,71 +389,96 @@ func (c *AixccEngine) RunPov(
 		"-b", triggerPath,
 		"-n",
 		// Seconds
-		"-t", "1800",
+		"-t", strconv.Itoa(povTimeout),
 	)
 
-	loopCount := 1
+	// a crash that reproduces some of the time must not slip past a patch or the base repo, and
+	// one expected to crash has to do so often enough
+	loopCount := data.reproducibility.Runs
 	if !crashExpected {
-		loopCount = 3
+		loopCount = unexpectedCrashRuns
 	}
+	span.SetAttributes(attribute.Int("max_loop_count", loopCount))
 
-	var result *command.Result
-	cmd := command.New("./run_pov.sh", args...)
-	for range loopCount {
-		span.SetAttributes(attribute.Int("max_loop_count", loopCount))
+	// run_pov.sh writes its output here, other evaluations may be running alongside this one
+	povOutDir, err := os.MkdirTemp("", "pov-*")
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to make pov out dir")
+		return err
+	}
+	defer os.RemoveAll(povOutDir)
+
+	var last, lastCrash *povRun
+	var runsUsage []*types.ResourceUsage
+	runs, crashes := 0, 0
+	for i := range loopCount {
+		run := &povRun{outDir: filepath.Join(povOutDir, strconv.Itoa(i))}
+		err = os.Mkdir(run.outDir, 0o700)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to make pov run out dir")
+			return err
+		}
 
-		result, err = c.executor.Execute(ctx, cmd)
+		cmd := command.New("./run_pov.sh", args...)
+		cmd.Env = []string{"POV_OUT_DIR=" + run.outDir}
+		run.result, err = c.executor.Execute(ctx, cmd)
 		if err != nil {
 			span.RecordError(err)
 			span.SetStatus(codes.Error, "failed to execute command")
 			return err
 		}
+		runs++
+		last = run
+		runsUsage = append(runsUsage, run.result.Usage)
 
-		if !crashExpected && result.ExitCode == 202 {
+		if run.result.LimitExceeded != "" ||
+			(run.result.ExitCode != 0 && run.result.ExitCode != 202) {
+			break
+		}
+		// run_pov.sh exits 202 when the outcome differs from what -x asked for
+		if crashed := (run.result.ExitCode == 0) == crashExpected; !crashed {
+			continue
+		}
+		crashes++
+		lastCrash = run
+		if !crashExpected {
 			span.AddEvent("crashed_with_patch")
 			break
 		}
 	}
 
-	stdoutHash, err := upload.Hashed(
-		ctx,
-		c.artifactUploader,
-		bytes.NewReader(result.Stdout),
-		int64(len(result.Stdout)),
-	)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, "failed to upload stdout")
-		return err
+	// the output of a crashing run says what the pov triggered, script errors explain themselves
+	reported := last
+	if lastCrash != nil && (last.result.ExitCode == 0 || last.result.ExitCode == 202) {
+		reported = lastCrash
 	}
-	stderrHash, err := upload.Hashed(
-		ctx,
-		c.artifactUploader,
-		bytes.NewReader(result.Stderr),
-		int64(len(result.Stderr)),
-	)
 
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, "failed to upload stderr")
-		return err
+	var reproducibility *types.Reproducibility
+	if crashExpected {
+		measured := data.reproducibility.Measure(runs, crashes)
+		reproducibility = &measured
+		span.SetAttributes(
+			attribute.Int("pov.runs", measured.Runs),
+			attribute.Int("pov.crashes", measured.Crashes),
+			attribute.Float64("pov.crash_rate", measured.CrashRate),
+		)
 	}
 
-	err = c.workerqueuer.CommandResult(ctx, &types.JobResult{
-		Cmd:        result.Cmd,
-		StdoutBlob: types.Blob{ObjectName: stdoutHash},
-		StderrBlob: types.Blob{ObjectName: stderrHash},
-		ExitCode:   &result.ExitCode,
-		Context:    data.resultContext,
-	})
+	// only one run is reported, what the others used still counts
+	reportedResult := *reported.result
+	reportedResult.Usage = combinedUsage(runsUsage, reported.result.Usage)
+	err = c.sendCommandResult(ctx, &reportedResult, data.resultContext)
 	if err != nil {
 		span.RecordError(err)
-		span.SetStatus(codes.Error, "failed to queue command result")
+		span.SetStatus(codes.Error, "failed to send command result")
 		return err
 	}
 
+	fuzzOutPath := filepath.Join(reported.outDir, "fuzz.out")
 	exists := true
-	stat, err := os.Stat("/tmp/fuzz.out")
+	stat, err := os.Stat(fuzzOutPath)
 	if err != nil {
 		if !os.IsNotExist(err) {
 			span.RecordError(err)
@@ -391,7 +490,7 @@ func (c *AixccEngine) RunPov(
 	}
 
 	if exists {
-		fuzzOutFile, err := os.Open("/tmp/fuzz.out")
+		fuzzOutFile, err := os.Open(fuzzOutPath)
 		if err != nil {
 			span.RecordError(err)
 			span.SetStatus(codes.Error, "failed to open fuzz out")
@@ -399,6 +498,16 @@ func (c *AixccEngine) RunPov(
 		}
 		defer fuzzOutFile.Close()
 
+		// the crash is only informational, evaluate the pov even when its output is unreadable
+		report, err := crash.Parse(fuzzOutFile, maxCrashFrames)
+		if err != nil {
+			logger.Logger.WarnContext(ctx, "failed to parse crash report", "error", err)
+			span.AddEvent("failed_to_parse_crash")
+		}
+		if report != nil {
+			span.SetAttributes(attribute.String("crash.signature", report.Signature))
+		}
+
 		fuzzOutHash, err := upload.Hashed(ctx, c.artifactUploader, fuzzOutFile, stat.Size())
 		if err != nil {
 			span.RecordError(err)
@@ -412,10 +521,12 @@ func (c *AixccEngine) RunPov(
 		}
 
 		err = c.workerqueuer.Artifact(ctx, types.JobArtifact{
-			Blob:         types.Blob{ObjectName: fuzzOutHash},
-			Context:      data.resultContext,
-			Filename:     fuzzOutFile.Name(),
-			ArchivedFile: archivedFile,
+			Crash:           report,
+			Reproducibility: reproducibility,
+			Blob:            types.Blob{ObjectName: fuzzOutHash},
+			Context:         data.resultContext,
+			Filename:        fuzzOutFile.Name(),
+			ArchivedFile:    archivedFile,
 		})
 		if err != nil {
 			span.RecordError(err)
@@ -424,45 +535,60 @@ func (c *AixccEngine) RunPov(
 		}
 	}
 
-	// the linter thinks this is better...
-	switch result.ExitCode {
-	case 0:
-	case 202:
-		span.RecordError(nil)
-		span.SetStatus(codes.Error, "script error")
-		return workererrors.StatusErrorWrap(types.SubmissionStatusFailed, false, nil)
-	default:
-		err = fmt.Errorf("unexpected exit code: %d", result.ExitCode)
+	err = limitError(last.result)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "pov exceeded its limits")
+		return err
+	}
+
+	if code := last.result.ExitCode; code != 0 && code != 202 {
+		err = fmt.Errorf("unexpected exit code: %d", code)
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unexpected exit code")
 		return err
 	}
 
+	if (crashExpected && !reproducibility.Passed()) || (!crashExpected && crashes > 0) {
+		span.RecordError(nil)
+		span.SetStatus(codes.Error, "script error")
+		return workererrors.StatusErrorWrap(types.SubmissionStatusFailed, false, nil)
+	}
+
 	span.RecordError(nil)
 	span.SetStatus(codes.Ok, "ran pov")
 	return nil
 }
 
-func removePatchPrefix(ctx context.Context, input string) string {
-	_, span := tracer.Start(
-		ctx,
-		"removePatchPrefix",
-		trace.WithAttributes(attribute.String("input", input)),
-	)
-	defer span.End()
+// Error for a command that ran out of a sandbox limit, its exit code is no verdict on the
+// submission then
+func limitError(result *command.Result) error {
+	if result.LimitExceeded == "" {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrLimitExceeded, result.LimitExceeded)
+}
 
-	for _, pfx := range []string{"a/", "b/"} {
-		if strings.HasPrefix(input, pfx) {
-			span.SetAttributes(
-				attribute.Bool("found_prefix", true),
-				attribute.String("prefix", pfx),
-			)
-			return strings.TrimPrefix(input, pfx)
+// Usage of commands run one after the other, with the signal of the one `reported`. nil when
+// none of them have usage.
+func combinedUsage(
+	usages []*types.ResourceUsage,
+	reported *types.ResourceUsage,
+) *types.ResourceUsage {
+	var combined *types.ResourceUsage
+	for _, usage := range usages {
+		if usage == nil {
+			continue
+		}
+		if combined == nil {
+			combined = &types.ResourceUsage{}
 		}
+		combined.WallTimeMs += usage.WallTimeMs
 	}
-
-	span.SetAttributes(attribute.Bool("found_prefix", false))
-	return input
+	if combined != nil && reported != nil {
+		combined.Signal = reported.Signal
+	}
+	return combined
 }
 
 func (c *AixccEngine) ApplyPatch(
@@ -496,6 +622,19 @@ func (c *AixccEngine) ApplyPatch(
 		return workererrors.StatusErrorWrap(types.SubmissionStatusFailed, false, nil)
 	}
 
+	violations := patchpolicy.Check(data.patchPolicy, files)
+	if len(violations) > 0 {
+		span.SetAttributes(attribute.Int("patch.violations", len(violations)))
+		err = workererrors.StatusErrorWrap(
+			types.SubmissionStatusFailed,
+			false,
+			fmt.Errorf("%w: %s", ErrPatchPolicy, patchpolicy.Describe(violations)),
+		)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "patch violates patch policy")
+		return err
+	}
+
 	allowed := true
 	for _, file := range files {
 		if file.IsNew {
@@ -504,7 +643,7 @@ func (c *AixccEngine) ApplyPatch(
 
 		fileAllowed, err := c.checkFile(
 			ctx,
-			removePatchPrefix(ctx, file.OldName),
+			file.OldName,
 			data,
 		)
 		if err != nil {
@@ -541,6 +680,20 @@ func (c *AixccEngine) ApplyPatch(
 		return err
 	}
 
+	err = c.sendCommandResult(ctx, result, types.ResultCtxApplyPatch)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to send patch command result")
+		return err
+	}
+
+	err = limitError(result)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "patch command exceeded its limits")
+		return err
+	}
+
 	allowed = true
 	for _, file := range files {
 		if file.IsDelete {
@@ -549,7 +702,7 @@ func (c *AixccEngine) ApplyPatch(
 
 		fileAllowed, err := c.checkFile(
 			ctx,
-			removePatchPrefix(ctx, file.NewName),
+			file.NewName,
 			data,
 		)
 		if err != nil {
@@ -589,12 +742,13 @@ func (c *AixccEngine) RunTests(
 	ctx context.Context,
 	data *Params,
 	successExpected bool,
-) error {
+) (*types.TestResults, error) {
 	ctx, span := tracer.Start(ctx, "AixccChallenge.RunTests", trace.WithAttributes(
 		attribute.Bool("successExpected", successExpected),
 		attribute.String("data.projectName", data.projectName),
 		attribute.String("data.repoDir", data.repoDir),
 		attribute.String("data.focus", data.focus),
+		attribute.Bool("data.testBaseline", data.testBaseline != nil),
 	))
 	defer span.End()
 
@@ -604,7 +758,7 @@ func (c *AixccEngine) RunTests(
 		err := errors.New("missing call params")
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "missing call params")
-		return err
+		return nil, err
 	}
 
 	args := make([]string, 0, 6)
@@ -617,49 +771,250 @@ func (c *AixccEngine) RunTests(
 		"-p", data.projectName,
 		"-r", filepath.Join(data.repoDir, data.focus),
 	)
+	if data.imageTag != "" {
+		args = append(args, "-d", data.imageTag)
+	}
+
+	// run_tests.sh hands this to the test script for JUnit reports
+	testOutDir, err := os.MkdirTemp(data.tempDir, "tests-*")
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to make test out dir")
+		return nil, err
+	}
+	defer os.RemoveAll(testOutDir)
 
 	cmd := command.New("./run_tests.sh", args...)
+	cmd.Env = []string{"TEST_OUT_DIR=" + testOutDir}
 	result, err := c.executor.Execute(ctx, cmd)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to execute command")
-		return err
+		return nil, err
+	}
+
+	resultContext := types.ResultCtxRunTests
+	if data.resultContext == types.ResultCtxTestBaseline {
+		resultContext = types.ResultCtxTestBaseline
+	}
+	err = c.sendCommandResult(ctx, result, resultContext)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to send tests command result")
+		return nil, err
+	}
+
+	err = limitError(result)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "tests exceeded their limits")
+		return nil, err
+	}
+
+	if code := result.ExitCode; code != 0 && code != 202 {
+		err = fmt.Errorf("unexpected exit code: %d", code)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unexpected exit code")
+		return nil, err
+	}
+
+	// per-test results only refine the exit code, judge by it when they are unreadable
+	results, err := testresults.Parse(result.Stdout, testOutDir)
+	if err != nil {
+		logger.Logger.WarnContext(ctx, "failed to parse test results", "error", err)
+		span.AddEvent("failed_to_parse_test_results")
+	}
+	if results != nil {
+		span.SetAttributes(
+			attribute.String("results.format", string(results.Format)),
+			attribute.Int("results.cases", len(results.Cases)),
+		)
+
+		err = c.sendTestResults(ctx, results, data.resultContext)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to send test results")
+			return nil, err
+		}
+	}
+
+	// a suite already partly broken on the head must not fail every patch, only what the patch
+	// broke counts
+	baseline := data.testBaseline
+	if successExpected && baseline != nil && results != nil && baseline.Format == results.Format {
+		regressions := baseline.Regressions(results)
+		span.SetAttributes(attribute.Int("regressions", len(regressions)))
+		if len(regressions) > 0 {
+			err = fmt.Errorf("%w: %s", ErrTestsRegressed, describeRegressions(regressions))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "tests regressed against the baseline")
+			return results, workererrors.StatusErrorWrap(types.SubmissionStatusFailed, true, err)
+		}
+
+		// the script's own verdict stands unless its failure is down to tests already failing
+		if result.ExitCode == 0 || baseline.ExplainsFailures(results) {
+			span.RecordError(nil)
+			span.SetStatus(codes.Ok, "no tests regressed against the baseline")
+			return results, nil
+		}
+		span.AddEvent("test failure not explained by the baseline")
 	}
 
 	if result.ExitCode == 202 {
 		span.RecordError(nil)
 		span.SetStatus(codes.Error, "tests did not match expected state")
-		return workererrors.StatusErrorWrap(types.SubmissionStatusFailed, true, nil)
-	} else if result.ExitCode != 0 {
-		err = fmt.Errorf("unexpected exit code: %d", result.ExitCode)
+		return results, workererrors.StatusErrorWrap(types.SubmissionStatusFailed, true, nil)
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "successfully ran tests")
+	return results, nil
+}
+
+// Regressions named in a failure's reason, a patch breaking the whole suite would bury it
+const maxDescribedRegressions = 10
+
+func describeRegressions(regressions []string) string {
+	if len(regressions) <= maxDescribedRegressions {
+		return strings.Join(regressions, ", ")
+	}
+	return fmt.Sprintf(
+		"%s and %d more",
+		strings.Join(regressions[:maxDescribedRegressions], ", "),
+		len(regressions)-maxDescribedRegressions,
+	)
+}
+
+// Uploads the per-test outcomes of a run and reports them to the server as an artifact
+func (c *AixccEngine) sendTestResults(
+	ctx context.Context,
+	results *types.TestResults,
+	resultContext types.ResultContext,
+) error {
+	ctx, span := tracer.Start(ctx, "AixccEngine.sendTestResults", trace.WithAttributes(
+		attribute.String("resultContext", string(resultContext)),
+	))
+	defer span.End()
+
+	encoded, err := json.Marshal(results)
+	if err != nil {
 		span.RecordError(err)
-		span.SetStatus(codes.Error, "unexpected exit code")
+		span.SetStatus(codes.Error, "failed to encode test results")
+		return err
+	}
+
+	resultsHash, err := upload.Hashed(
+		ctx,
+		c.artifactUploader,
+		bytes.NewReader(encoded),
+		int64(len(encoded)),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to upload test results")
+		return err
+	}
+
+	err = c.workerqueuer.Artifact(ctx, types.JobArtifact{
+		Blob:         types.Blob{ObjectName: resultsHash},
+		Context:      resultContext,
+		Filename:     "test_results.json",
+		ArchivedFile: types.FileTestResults,
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to queue artifact")
 		return err
 	}
 
 	span.RecordError(nil)
-	span.SetStatus(codes.Ok, "successfully ran tests")
+	span.SetStatus(codes.Ok, "sent test results")
 	return nil
 }
 
-func parsePatch(patchPath string) ([]*gitdiff.File, error) {
-	patchFile, err := os.Open(patchPath)
+// Uploads the output of `result` and reports it to the server under `resultContext`
+func (c *AixccEngine) sendCommandResult(
+	ctx context.Context,
+	result *command.Result,
+	resultContext types.ResultContext,
+) error {
+	ctx, span := tracer.Start(ctx, "AixccEngine.sendCommandResult", trace.WithAttributes(
+		attribute.String("resultContext", string(resultContext)),
+		attribute.Int("stdout.size", len(result.Stdout)),
+		attribute.Int("stderr.size", len(result.Stderr)),
+	))
+	defer span.End()
+
+	stdout := truncateOutput(result.Stdout, maxCommandOutputSize)
+	stdoutHash, err := upload.Hashed(
+		ctx,
+		c.artifactUploader,
+		bytes.NewReader(stdout),
+		int64(len(stdout)),
+	)
 	if err != nil {
-		return nil, fmt.Errorf("error opening patch file: %w", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to upload stdout")
+		return err
 	}
-	defer patchFile.Close()
 
-	files, _, err := gitdiff.Parse(patchFile)
+	stderr := truncateOutput(result.Stderr, maxCommandOutputSize)
+	stderrHash, err := upload.Hashed(
+		ctx,
+		c.artifactUploader,
+		bytes.NewReader(stderr),
+		int64(len(stderr)),
+	)
 	if err != nil {
-		return nil, fmt.Errorf("error parsing patch file: %w", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to upload stderr")
+		return err
 	}
 
-	for _, file := range files {
-		file.NewName = strings.TrimSpace(file.NewName)
-		file.OldName = strings.TrimSpace(file.OldName)
+	err = c.workerqueuer.CommandResult(ctx, &types.JobResult{
+		Usage:      result.Usage,
+		Cmd:        result.Cmd,
+		StdoutBlob: types.Blob{ObjectName: stdoutHash},
+		StderrBlob: types.Blob{ObjectName: stderrHash},
+		ExitCode:   &result.ExitCode,
+		Context:    resultContext,
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to queue command result")
+		return err
 	}
 
-	return files, nil
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "sent command result")
+	return nil
+}
+
+// Keeps the start and end of `output` when it is over `limit` bytes, with a marker saying how
+// much was cut in between
+func truncateOutput(output []byte, limit int) []byte {
+	if len(output) <= limit {
+		return output
+	}
+
+	marker := fmt.Appendf(nil, "\n... [%d bytes truncated] ...\n", len(output)-limit)
+	head := limit / 2
+	tail := limit - head
+
+	truncated := make([]byte, 0, limit+len(marker))
+	truncated = append(truncated, output[:head]...)
+	truncated = append(truncated, marker...)
+	return append(truncated, output[len(output)-tail:]...)
+}
+
+func parsePatch(patchPath string) ([]*gitdiff.File, error) {
+	patchFile, err := os.Open(patchPath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening patch file: %w", err)
+	}
+	defer patchFile.Close()
+
+	return patchpolicy.Parse(patchFile)
 }
 
 // true if file is allowed
@@ -698,21 +1053,13 @@ func (c *AixccEngine) getFuzzToolingDir(ctx context.Context, data *Params) (stri
 	defer span.End()
 
 	l.DebugContext(ctx, "reading dir")
-	entries, err := os.ReadDir(data.fuzzToolingDir)
+	fuzzToolingDir, err := fuzzToolingRoot(data)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to read fuzztooling dir")
 		return "", err
 	}
-	if len(entries) == 0 {
-		err = errors.New("invalid format for fuzz tooling tar")
-		span.RecordError(err)
-		span.SetStatus(codes.Error, "fuzz tooling dir was empty")
-		return "", err
-	}
-
-	l.DebugContext(ctx, "choosing first entry", "entryName", entries[0].Name())
-	fuzzToolingDir := filepath.Join(data.fuzzToolingDir, entries[0].Name())
+	l.DebugContext(ctx, "chose first entry", "fuzzToolingRoot", fuzzToolingDir)
 
 	if c.entity == types.JobTypeJob {
 		override, err := os.Open("./helper.py")
@@ -743,3 +1090,16 @@ func (c *AixccEngine) getFuzzToolingDir(ctx context.Context, data *Params) (stri
 	span.SetStatus(codes.Ok, "calculated fuzz dir")
 	return fuzzToolingDir, nil
 }
+
+// The fuzz tooling tarball extracts to a single top level directory
+func fuzzToolingRoot(data *Params) (string, error) {
+	entries, err := os.ReadDir(data.fuzzToolingDir)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return "", errors.New("invalid format for fuzz tooling tar")
+	}
+
+	return filepath.Join(data.fuzzToolingDir, entries[0].Name()), nil
+}
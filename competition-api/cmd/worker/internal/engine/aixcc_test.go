@@ -1,13 +1,63 @@
 package engine
 
 import (
+	"bytes"
+	"context"
+	"io"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/command"
+	mockcommand "github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/command/mock"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/workerqueue"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/identifier"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/queue"
+	mockqueue "github.com/aixcyberchallenge/competition-api/competition-api/internal/queue/mock"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/upload"
+	mockupload "github.com/aixcyberchallenge/competition-api/competition-api/internal/upload/mock"
+	workererrors "github.com/aixcyberchallenge/competition-api/competition-api/internal/worker_errors"
 )
 
+// Engine evaluating an `entity` with mocked collaborators
+func newTestEngine(
+	executor command.Executor,
+	uploader upload.Uploader,
+	queuer queue.Queuer,
+	entity types.JobType,
+) *AixccEngine {
+	return NewAixccEngine(executor, uploader, workerqueue.NewWorkerQueue("id", entity, queuer), entity)
+}
+
+// Uploader with nothing stored yet that takes every upload
+func acceptingUploader(ctrl *gomock.Controller) *mockupload.MockUploader {
+	uploader := mockupload.NewMockUploader(ctrl)
+	uploader.EXPECT().Exists(gomock.Any(), gomock.Any()).Return(false, nil).AnyTimes()
+	uploader.EXPECT().
+		Upload(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil).
+		AnyTimes()
+	return uploader
+}
+
+// Params for an address sanitizer libfuzzer build of harness in project, with fuzz tooling that
+// has the oss-fuzz checkout the scripts run from
+func testParams(t *testing.T, resultContext types.ResultContext) Params {
+	fuzzToolingDir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(fuzzToolingDir, "oss-fuzz"), 0755))
+	return NewParams("address", "x86_64", "libfuzzer", "harness", "project", "focus", nil).
+		WithFuzzToolingDir(fuzzToolingDir).
+		WithRepo(resultContext, t.TempDir())
+}
+
 func TestParsePatch(t *testing.T) {
 	files, err := parsePatch("d4678cfc9822ca149668dad1552c794d8e1edaac7c1a40d2a08a5702633e9b8f")
 	require.NoError(t, err, "failed to read patch file")
@@ -25,3 +75,513 @@ func TestParsePatch(t *testing.T) {
 		)
 	}
 }
+
+func TestTruncateOutput(t *testing.T) {
+	short := []byte("short output")
+	assert.Equal(t, short, truncateOutput(short, 100), "output under the limit should be kept")
+
+	long := []byte(strings.Repeat("<", 50) + strings.Repeat("#", 100) + strings.Repeat(">", 50))
+	truncated := string(truncateOutput(long, 100))
+	assert.True(t, strings.HasPrefix(truncated, strings.Repeat("<", 50)), "should keep the start")
+	assert.True(t, strings.HasSuffix(truncated, strings.Repeat(">", 50)), "should keep the end")
+	assert.Contains(t, truncated, "[100 bytes truncated]")
+	assert.NotContains(t, truncated, "#")
+}
+
+func TestBuildSendsCommandResult(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+
+	executor := mockcommand.NewMockExecutor(ctrl)
+	executor.EXPECT().
+		Execute(gomock.Any(), gomock.Any()).
+		Return(&command.Result{
+			Cmd:      []string{"./build_cr.sh"},
+			Stdout:   bytes.Repeat([]byte("x"), maxCommandOutputSize+1),
+			Stderr:   []byte("error: oops"),
+			ExitCode: 202,
+		}, nil)
+
+	uploaded := map[string]int{}
+	uploader := mockupload.NewMockUploader(ctrl)
+	uploader.EXPECT().Exists(gomock.Any(), gomock.Any()).Return(false, nil).AnyTimes()
+	uploader.EXPECT().
+		Upload(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ io.ReadSeeker, size int64, name string) error {
+			uploaded[name] = int(size)
+			return nil
+		}).
+		Times(2)
+
+	var sent types.WorkerMsgCommandResult
+	queuer := mockqueue.NewMockQueuer(ctrl)
+	queuer.EXPECT().
+		Enqueue(gomock.Any(), gomock.AssignableToTypeOf(types.WorkerMsgCommandResult{})).
+		Do(func(_ context.Context, msg any) {
+			sent = msg.(types.WorkerMsgCommandResult)
+		}).
+		Times(1)
+
+	params := testParams(t, types.ResultCtxBaseRepoTest)
+
+	aixcc := newTestEngine(executor, uploader, queuer, types.JobTypePOV)
+	err := aixcc.Build(ctx, &params)
+	require.ErrorIs(t, err, ErrBuildingFailed)
+
+	require.NotNil(t, sent.Result)
+	assert.Equal(t, types.ResultCtxBaseRepoBuild, sent.Result.Context)
+	assert.Equal(t, 202, *sent.Result.ExitCode)
+	assert.Less(
+		t,
+		uploaded[sent.Result.StdoutBlob.ObjectName],
+		maxCommandOutputSize+100,
+		"stdout should be truncated",
+	)
+	assert.Equal(t, len("error: oops"), uploaded[sent.Result.StderrBlob.ObjectName])
+}
+
+// Evaluations sharing a docker daemon build the project image under their own tag
+func TestBuildImageTag(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	var args []string
+	executor := mockcommand.NewMockExecutor(ctrl)
+	executor.EXPECT().
+		Execute(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, cmd *command.Command) (*command.Result, error) {
+			args = cmd.Args
+			return &command.Result{Cmd: []string{"./build_cr.sh"}}, nil
+		})
+
+	uploader := acceptingUploader(ctrl)
+	queuer := mockqueue.NewMockQueuer(ctrl)
+	queuer.EXPECT().Enqueue(gomock.Any(), gomock.Any()).Times(1)
+
+	params := testParams(t, types.ResultCtxHeadRepoTest).WithImageTag("eval-id")
+
+	aixcc := newTestEngine(executor, uploader, queuer, types.JobTypePOV)
+	require.NoError(t, aixcc.Build(context.Background(), &params))
+	assert.Subset(t, args, []string{"-d", "eval-id"})
+	assert.Equal(t, "aixcc-afc/project:eval-id", ProjectImage("project", "eval-id"))
+}
+
+// A build that ran out of its limits errors, whatever its exit code says about the submission
+func TestBuildLimitExceeded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	executor := mockcommand.NewMockExecutor(ctrl)
+	executor.EXPECT().
+		Execute(gomock.Any(), gomock.Any()).
+		Return(&command.Result{
+			LimitExceeded: command.LimitCPU,
+			Cmd:           []string{"./build_cr.sh"},
+			ExitCode:      202,
+		}, nil)
+
+	uploader := acceptingUploader(ctrl)
+	queuer := mockqueue.NewMockQueuer(ctrl)
+	queuer.EXPECT().Enqueue(gomock.Any(), gomock.Any()).Times(1)
+
+	params := testParams(t, types.ResultCtxHeadRepoTest)
+
+	aixcc := newTestEngine(executor, uploader, queuer, types.JobTypePOV)
+	err := aixcc.Build(context.Background(), &params)
+	require.ErrorIs(t, err, ErrLimitExceeded)
+	require.NotErrorIs(t, err, ErrBuildingFailed)
+	var se workererrors.StatusError
+	require.NotErrorAs(t, err, &se, "the submission should not be failed")
+}
+
+// run_pov.sh gets the task's POV timeout, every run of it fits in the step's timeout
+func TestRunPovTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	executor := mockcommand.NewMockExecutor(ctrl)
+	executor.EXPECT().
+		Execute(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, cmd *command.Command) (*command.Result, error) {
+			i := slices.Index(cmd.Args, "-t")
+			require.NotEqual(t, -1, i, "run_pov.sh should get a timeout")
+			assert.Equal(t, "42", cmd.Args[i+1])
+			return &command.Result{Cmd: []string{"./run_pov.sh"}, ExitCode: 0}, nil
+		}).
+		Times(unexpectedCrashRuns)
+
+	uploader := acceptingUploader(ctrl)
+	queuer := mockqueue.NewMockQueuer(ctrl)
+	queuer.EXPECT().Enqueue(gomock.Any(), gomock.Any()).AnyTimes()
+
+	params := testParams(t, types.ResultCtxBaseRepoTest).
+		WithTimeouts(types.EvalTimeouts{RunPovSecs: 42})
+
+	aixcc := newTestEngine(executor, uploader, queuer, types.JobTypePOV)
+	require.NoError(t, aixcc.RunPov(context.Background(), &params, "trigger", false))
+
+	assert.Equal(t,
+		time.Duration(unexpectedCrashRuns)*(42*time.Second+povRunOverhead),
+		params.StepTimeout(types.EvalStepRunPov),
+	)
+	assert.Zero(t, params.StepTimeout(types.EvalStepBuild), "unset timeouts should not limit")
+}
+
+// Policy violations fail the patch before it is applied and name the offending files
+func TestApplyPatchPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	executor := mockcommand.NewMockExecutor(ctrl)
+	executor.EXPECT().Execute(gomock.Any(), gomock.Any()).Times(0)
+
+	patchPath := filepath.Join(t.TempDir(), "patch.diff")
+	require.NoError(t, os.WriteFile(patchPath, []byte(
+		"diff --git a/fuzz/fuzzer.c b/fuzz/fuzzer.c\n"+
+			"--- a/fuzz/fuzzer.c\n"+
+			"+++ b/fuzz/fuzzer.c\n"+
+			"@@ -1 +1 @@\n"+
+			"-old\n"+
+			"+new\n",
+	), 0600))
+
+	params := NewParams("address", "x86_64", "libfuzzer", "harness", "project", "focus",
+		identifier.LanguageSlice{identifier.LanguageC}).
+		WithRepo(types.ResultCtxHeadRepoTest, t.TempDir()).
+		WithPatchPolicy(&types.PatchPolicy{HarnessSources: []string{"fuzz/**"}})
+
+	queuer := mockqueue.NewMockQueuer(ctrl)
+	aixcc := newTestEngine(executor, mockupload.NewMockUploader(ctrl), queuer, types.JobTypePatch)
+	err := aixcc.ApplyPatch(context.Background(), &params, patchPath)
+
+	var se workererrors.StatusError
+	require.ErrorAs(t, err, &se)
+	assert.Equal(t, types.SubmissionStatusFailed, se.Status)
+	require.ErrorIs(t, err, ErrPatchPolicy)
+	assert.Contains(t, err.Error(), "fuzz/fuzzer.c: harness_source")
+}
+
+func TestRunPovReproducibility(t *testing.T) {
+	tests := []struct {
+		name      string
+		exitCodes []int
+		threshold float64
+		passed    bool
+	}{
+		{name: "AboveThreshold", exitCodes: []int{0, 202, 0, 0}, threshold: 0.75, passed: true},
+		{name: "BelowThreshold", exitCodes: []int{0, 202, 202, 0}, threshold: 0.75, passed: false},
+		{name: "NeverCrashed", exitCodes: []int{202, 202, 202, 202}, threshold: 0.25, passed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			ctrl := gomock.NewController(t)
+
+			run := 0
+			executor := mockcommand.NewMockExecutor(ctrl)
+			executor.EXPECT().
+				Execute(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, cmd *command.Command) (*command.Result, error) {
+					outDir, ok := strings.CutPrefix(cmd.Env[0], "POV_OUT_DIR=")
+					require.True(t, ok)
+					output := []byte("no crash\n")
+					if tt.exitCodes[run] == 0 {
+						output = []byte("==1==ERROR: AddressSanitizer: SEGV on unknown address 0x0\n")
+					}
+					require.NoError(t, os.WriteFile(filepath.Join(outDir, "fuzz.out"), output, 0600))
+
+					exitCode := tt.exitCodes[run]
+					run++
+					return &command.Result{Cmd: []string{"./run_pov.sh"}, ExitCode: exitCode}, nil
+				}).
+				Times(len(tt.exitCodes))
+
+			uploader := acceptingUploader(ctrl)
+
+			var artifact types.WorkerMsgArtifact
+			queuer := mockqueue.NewMockQueuer(ctrl)
+			queuer.EXPECT().
+				Enqueue(gomock.Any(), gomock.AssignableToTypeOf(types.WorkerMsgCommandResult{})).
+				Times(1)
+			queuer.EXPECT().
+				Enqueue(gomock.Any(), gomock.AssignableToTypeOf(types.WorkerMsgArtifact{})).
+				Do(func(_ context.Context, msg any) {
+					artifact = msg.(types.WorkerMsgArtifact)
+				}).
+				Times(1)
+
+			params := testParams(t, types.ResultCtxHeadRepoTest).
+				WithReproducibility(types.ReproducibilityPolicy{
+					Runs:      len(tt.exitCodes),
+					Threshold: tt.threshold,
+				})
+
+			aixcc := newTestEngine(executor, uploader, queuer, types.JobTypePOV)
+			err := aixcc.RunPov(ctx, &params, "trigger", true)
+			if tt.passed {
+				require.NoError(t, err)
+			} else {
+				var se workererrors.StatusError
+				require.ErrorAs(t, err, &se)
+				assert.Equal(t, types.SubmissionStatusFailed, se.Status)
+			}
+
+			crashes := 0
+			for _, code := range tt.exitCodes {
+				if code == 0 {
+					crashes++
+				}
+			}
+			require.NotNil(t, artifact.Artifact.Reproducibility)
+			assert.Equal(t, len(tt.exitCodes), artifact.Artifact.Reproducibility.Runs)
+			assert.Equal(t, crashes, artifact.Artifact.Reproducibility.Crashes)
+			assert.Equal(t, tt.threshold, artifact.Artifact.Reproducibility.Threshold)
+			if crashes > 0 {
+				require.NotNil(t, artifact.Artifact.Crash, "should report a crashing run")
+				assert.Equal(t, "SEGV", artifact.Artifact.Crash.Type)
+			} else {
+				assert.Nil(t, artifact.Artifact.Crash)
+			}
+		})
+	}
+}
+
+func TestRunPovStopsOnUnexpectedCrash(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+
+	executor := mockcommand.NewMockExecutor(ctrl)
+	executor.EXPECT().
+		Execute(gomock.Any(), gomock.Any()).
+		Return(&command.Result{Cmd: []string{"./run_pov.sh"}, ExitCode: 0}, nil).
+		Times(1)
+	executor.EXPECT().
+		Execute(gomock.Any(), gomock.Any()).
+		Return(&command.Result{Cmd: []string{"./run_pov.sh"}, ExitCode: 202}, nil).
+		Times(1)
+
+	uploader := acceptingUploader(ctrl)
+
+	queuer := mockqueue.NewMockQueuer(ctrl)
+	queuer.EXPECT().
+		Enqueue(gomock.Any(), gomock.AssignableToTypeOf(types.WorkerMsgCommandResult{})).
+		Times(1)
+
+	params := testParams(t, types.ResultCtxBaseRepoTest)
+
+	aixcc := newTestEngine(executor, uploader, queuer, types.JobTypePOV)
+	err := aixcc.RunPov(ctx, &params, "trigger", false)
+	var se workererrors.StatusError
+	require.ErrorAs(t, err, &se, "crashing the base repo should fail the pov")
+	assert.Equal(t, types.SubmissionStatusFailed, se.Status)
+}
+
+func TestCheckEngine(t *testing.T) {
+	tests := []struct {
+		name        string
+		projectYAML string
+		engine      string
+		valid       bool
+	}{
+		{
+			name:        "Listed",
+			projectYAML: "language: c\nsanitizers: [address]\nfuzzing_engines: [libfuzzer, afl]\n",
+			engine:      "afl",
+			valid:       true,
+		},
+		{
+			name:        "NotListed",
+			projectYAML: "language: c\nsanitizers: [address]\nfuzzing_engines: [libfuzzer, afl]\n",
+			engine:      "honggfuzz",
+		},
+		{
+			name:        "DefaultEngines",
+			projectYAML: "language: c\nsanitizers: [address]\n",
+			engine:      "honggfuzz",
+			valid:       true,
+		},
+		{
+			name:        "UnsupportedEngine",
+			projectYAML: "language: c\nsanitizers: [address]\nfuzzing_engines: [centipede]\n",
+			engine:      "centipede",
+		},
+		{
+			name:        "JVMDefaultEngines",
+			projectYAML: "language: jvm\nsanitizers: [address]\n",
+			engine:      "libfuzzer",
+			valid:       true,
+		},
+		{
+			name:        "JVMDefaultEnginesNotListed",
+			projectYAML: "language: jvm\nsanitizers: [address]\n",
+			engine:      "afl",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// jvm projects get the maven settings from the working directory
+			workDir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(workDir, "settings.xml"), nil, 0600))
+			t.Chdir(workDir)
+
+			fuzzToolingDir := t.TempDir()
+			projectDir := filepath.Join(fuzzToolingDir, "oss-fuzz", "projects", "project")
+			require.NoError(t, os.MkdirAll(projectDir, 0755))
+			require.NoError(t, os.WriteFile(
+				filepath.Join(projectDir, "project.yaml"),
+				[]byte(tt.projectYAML),
+				0600,
+			))
+
+			repoDir := t.TempDir()
+			aixccDir := filepath.Join(repoDir, "focus", ".aixcc")
+			require.NoError(t, os.MkdirAll(aixccDir, 0755))
+			require.NoError(t, os.WriteFile(
+				filepath.Join(aixccDir, "challenge.yaml"),
+				[]byte("fuzz_tooling_project_name: project\n"+
+					"fuzz_tooling_url: url\n"+
+					"fuzz_tooling_ref: ref\n"+
+					"harnesses: [harness]\n"),
+				0600,
+			))
+
+			params := NewParams("address", "x86_64", tt.engine, "harness", "project", "focus", nil).
+				WithFuzzToolingDir(fuzzToolingDir).
+				WithRepo(types.ResultCtxHeadRepoTest, repoDir)
+
+			aixcc := NewAixccEngine(nil, nil, nil, types.JobTypePOV)
+			err := aixcc.Check(context.Background(), &params)
+			if tt.valid {
+				require.NoError(t, err)
+				return
+			}
+			var se workererrors.StatusError
+			require.ErrorAs(t, err, &se, "unsupported engines should fail the pov")
+			assert.Equal(t, types.SubmissionStatusFailed, se.Status)
+		})
+	}
+}
+
+// Tests failing on the unpatched head don't fail a patch, tests it breaks do
+func TestRunTestsBaseline(t *testing.T) {
+	baseline := &types.TestResults{
+		Format: types.TestFormatCTest,
+		Cases: []types.TestCase{
+			{Name: "parser_test", Outcome: types.TestPassed},
+			{Name: "network_test", Outcome: types.TestFailed},
+		},
+	}
+
+	tests := []struct {
+		baseline  *types.TestResults
+		name      string
+		output    string
+		exitCode  int
+		regressed bool
+		failed    bool
+	}{
+		{
+			name:     "NoBaseline",
+			output:   "1/2 Test #1: parser_test ....   Passed\n2/2 Test #2: network_test ....***Failed\n",
+			exitCode: 202,
+			failed:   true,
+		},
+		{
+			name:     "AlreadyFailing",
+			baseline: baseline,
+			output:   "1/2 Test #1: parser_test ....   Passed\n2/2 Test #2: network_test ....***Failed\n",
+			exitCode: 202,
+		},
+		{
+			name:      "Regressed",
+			baseline:  baseline,
+			output:    "1/2 Test #1: parser_test ....***Failed\n2/2 Test #2: network_test ....***Failed\n",
+			exitCode:  202,
+			regressed: true,
+			failed:    true,
+		},
+		{
+			name:     "FailedWithoutFailingTests",
+			baseline: baseline,
+			output:   "1/2 Test #1: parser_test ....   Passed\n2/2 Test #2: network_test ....   Passed\n",
+			exitCode: 202,
+			failed:   true,
+		},
+		{
+			name:     "NewTestFailing",
+			baseline: baseline,
+			output: "1/3 Test #1: parser_test ....   Passed\n" +
+				"2/3 Test #2: network_test ....***Failed\n" +
+				"3/3 Test #3: extra_test ....***Failed\n",
+			exitCode: 202,
+			failed:   true,
+		},
+		{
+			name:      "Missing",
+			baseline:  baseline,
+			output:    "1/1 Test #2: network_test ....   Passed\n",
+			regressed: true,
+			failed:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			ctrl := gomock.NewController(t)
+			tempDir := t.TempDir()
+
+			executor := mockcommand.NewMockExecutor(ctrl)
+			executor.EXPECT().
+				Execute(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, cmd *command.Command) (*command.Result, error) {
+					assert.Equal(t, []string{"-p", "project", "-r"}, cmd.Args[:3])
+					testOutDir, ok := strings.CutPrefix(cmd.Env[0], "TEST_OUT_DIR=")
+					assert.True(t, ok)
+					assert.Equal(t, tempDir, filepath.Dir(testOutDir))
+					return &command.Result{
+						Cmd:      []string{"./run_tests.sh"},
+						Stdout:   []byte(tt.output),
+						ExitCode: tt.exitCode,
+					}, nil
+				}).
+				Times(1)
+
+			uploader := acceptingUploader(ctrl)
+
+			var artifact types.WorkerMsgArtifact
+			queuer := mockqueue.NewMockQueuer(ctrl)
+			queuer.EXPECT().
+				Enqueue(gomock.Any(), gomock.AssignableToTypeOf(types.WorkerMsgCommandResult{})).
+				Times(1)
+			queuer.EXPECT().
+				Enqueue(gomock.Any(), gomock.AssignableToTypeOf(types.WorkerMsgArtifact{})).
+				Do(func(_ context.Context, msg any) {
+					artifact = msg.(types.WorkerMsgArtifact)
+				}).
+				Times(1)
+
+			params := testParams(t, types.ResultCtxHeadRepoTest).
+				WithTestBaseline(tt.baseline).
+				WithTempDir(tempDir)
+
+			aixcc := newTestEngine(executor, uploader, queuer, types.JobTypePatch)
+			results, err := aixcc.RunTests(ctx, &params, true)
+			require.NotNil(t, results)
+			assert.Equal(t, types.TestFormatCTest, results.Format)
+			assert.Equal(t, types.FileTestResults, artifact.Artifact.ArchivedFile)
+			assert.Equal(t, types.ResultCtxHeadRepoTest, artifact.Artifact.Context)
+
+			if !tt.failed {
+				require.NoError(t, err)
+				return
+			}
+			var se workererrors.StatusError
+			require.ErrorAs(t, err, &se)
+			assert.Equal(t, types.SubmissionStatusFailed, se.Status)
+			assert.True(t, se.PatchTestsFailed)
+			if tt.regressed {
+				require.ErrorIs(t, err, ErrTestsRegressed)
+				assert.ErrorContains(t, err, "parser_test")
+			}
+		})
+	}
+}
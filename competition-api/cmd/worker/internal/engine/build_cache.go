@@ -0,0 +1,346 @@
+package engine
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/extract"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/fetch"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/upload"
+)
+
+// Ensure BuildCacheEngine implementes Engine interface
+var _ Engine = (*BuildCacheEngine)(nil)
+
+// How long the presigned url used to fetch a cached build stays valid
+const buildCacheURLExpiration = time.Hour
+
+// Reuses the fuzzers built for a task across evaluations. Building the unpatched repo gives the
+// same result for every POV against a task, so the first evaluation uploads its build output and
+// later ones extract it instead of building.
+//
+// Only wrap engines evaluating POVs, patches change what is built.
+type BuildCacheEngine struct {
+	engine    Engine
+	store     upload.Uploader
+	fetcher   fetch.Fetcher
+	extractor extract.Extractor
+	// identifies the task, the sanitizer and friends are added per build
+	key     string
+	tempDir string
+}
+
+func NewBuildCacheEngine(
+	engine Engine, //nolint:revive // import-shadowing: no better variable name to use here
+	store upload.Uploader,
+	fetcher fetch.Fetcher,
+	extractor extract.Extractor,
+	key string,
+	tempDir string,
+) *BuildCacheEngine {
+	return &BuildCacheEngine{
+		engine:    engine,
+		store:     store,
+		fetcher:   fetcher,
+		extractor: extractor,
+		key:       key,
+		tempDir:   tempDir,
+	}
+}
+
+// Name of the blob holding the build output of `data`
+func (b *BuildCacheEngine) blobName(data *Params) string {
+	hash := sha256.New()
+	for _, part := range []string{
+		b.key,
+		string(data.resultContext),
+		data.projectName,
+		data.focus,
+		data.sanitizer,
+		data.architecture,
+		data.engine,
+	} {
+		// null separated so parts can't run into each other
+		fmt.Fprintf(hash, "%s\x00", part)
+	}
+	return "build-cache/" + hex.EncodeToString(hash.Sum(nil))
+}
+
+// Where build_cr.sh leaves the fuzzers that run_pov.sh reproduces with
+func outDir(data *Params) (string, error) {
+	fuzzToolingDir, err := fuzzToolingRoot(data)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(fuzzToolingDir, "build", "out", data.projectName), nil
+}
+
+// Build implements Engine.
+func (b *BuildCacheEngine) Build(ctx context.Context, data *Params) error {
+	blobName := b.blobName(data)
+	ctx, span := tracer.Start(ctx, "BuildCacheEngine.Build", trace.WithAttributes(
+		attribute.String("blob.name", blobName),
+		attribute.String("data.resultContext", string(data.resultContext)),
+		attribute.String("data.sanitizer", data.sanitizer),
+		attribute.String("data.architecture", data.architecture),
+	))
+	defer span.End()
+
+	out, err := outDir(data)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to find out dir")
+		return err
+	}
+
+	// a broken cache should never fail an evaluation, it only costs a build
+	err = b.restore(ctx, blobName, out)
+	if err == nil {
+		span.AddEvent("build_cache_hit")
+		span.RecordError(nil)
+		span.SetStatus(codes.Ok, "restored build from cache")
+		return nil
+	}
+	span.AddEvent("build_cache_miss", trace.WithAttributes(
+		attribute.String("reason", err.Error()),
+	))
+
+	err = b.engine.Build(ctx, data)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to build")
+		return err
+	}
+
+	err = b.save(ctx, blobName, out)
+	if err != nil {
+		span.AddEvent("failed to save build to cache", trace.WithAttributes(
+			attribute.String("error", err.Error()),
+		))
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "built")
+	return nil
+}
+
+var errBuildNotCached = errors.New("build not cached")
+
+func (b *BuildCacheEngine) restore(ctx context.Context, blobName string, out string) error {
+	ctx, span := tracer.Start(ctx, "BuildCacheEngine.restore", trace.WithAttributes(
+		attribute.String("blob.name", blobName),
+		attribute.String("out", out),
+	))
+	defer span.End()
+
+	exists, err := b.store.Exists(ctx, blobName)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to check for cached build")
+		return err
+	}
+	if !exists {
+		span.RecordError(nil)
+		span.SetStatus(codes.Ok, "build not cached")
+		return errBuildNotCached
+	}
+
+	url, err := b.store.PresignedReadURL(ctx, blobName, buildCacheURLExpiration)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to get presigned url for cached build")
+		return err
+	}
+
+	body, err := b.fetcher.Fetch(ctx, url)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to fetch cached build")
+		return err
+	}
+	defer body.Close()
+
+	err = os.MkdirAll(out, 0755)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to make out dir")
+		return err
+	}
+
+	err = b.extractor.Extract(ctx, body, out)
+	if err != nil {
+		// leave nothing half extracted behind for the build to trip over
+		os.RemoveAll(out)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to extract cached build")
+		return err
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "restored cached build")
+	return nil
+}
+
+func (b *BuildCacheEngine) save(ctx context.Context, blobName string, out string) error {
+	ctx, span := tracer.Start(ctx, "BuildCacheEngine.save", trace.WithAttributes(
+		attribute.String("blob.name", blobName),
+		attribute.String("out", out),
+	))
+	defer span.End()
+
+	archive, err := os.CreateTemp(b.tempDir, "build-*.tar.gz")
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to create archive")
+		return err
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	err = archiveDir(out, archive)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to archive out dir")
+		return err
+	}
+
+	size, err := archive.Seek(0, io.SeekCurrent)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to get archive size")
+		return err
+	}
+	span.SetAttributes(attribute.Int64("archive.size", size))
+
+	_, err = archive.Seek(0, io.SeekStart)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to seek to start of archive")
+		return err
+	}
+
+	err = b.store.Upload(ctx, archive, size, blobName)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to upload archive")
+		return err
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "saved build to cache")
+	return nil
+}
+
+// Writes a .tar.gz of the contents of `dir` to `w`
+func archiveDir(dir string, w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		if info.Mode()&fs.ModeSymlink != 0 {
+			link, err = os.Readlink(path)
+			if err != nil {
+				return err
+			}
+		} else if !info.Mode().IsRegular() && !info.IsDir() {
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if info.IsDir() && !strings.HasSuffix(header.Name, "/") {
+			header.Name += "/"
+		}
+
+		err = tw.WriteHeader(header)
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// ApplyPatch implements Engine.
+func (b *BuildCacheEngine) ApplyPatch(ctx context.Context, data *Params, patchPath string) error {
+	return b.engine.ApplyPatch(ctx, data, patchPath)
+}
+
+// Check implements Engine.
+func (b *BuildCacheEngine) Check(ctx context.Context, data *Params) error {
+	return b.engine.Check(ctx, data)
+}
+
+// RunPov implements Engine.
+func (b *BuildCacheEngine) RunPov(
+	ctx context.Context,
+	data *Params,
+	triggerPath string,
+	shouldCrash bool,
+) error {
+	return b.engine.RunPov(ctx, data, triggerPath, shouldCrash)
+}
+
+// RunTests implements Engine.
+func (b *BuildCacheEngine) RunTests(
+	ctx context.Context,
+	data *Params,
+	shouldPass bool,
+) (*types.TestResults, error) {
+	return b.engine.RunTests(ctx, data, shouldPass)
+}
@@ -0,0 +1,159 @@
+package engine_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/engine"
+	mockengine "github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/engine/mock"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/extract"
+	mockfetch "github.com/aixcyberchallenge/competition-api/competition-api/internal/fetch/mock"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/identifier"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+	mockupload "github.com/aixcyberchallenge/competition-api/competition-api/internal/upload/mock"
+)
+
+// Blob store backed by a map, presigned urls are the blob names
+func memoryStore(ctrl *gomock.Controller, blobs map[string][]byte) *mockupload.MockUploader {
+	store := mockupload.NewMockUploader(ctrl)
+	store.EXPECT().
+		Exists(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, name string) (bool, error) {
+			_, ok := blobs[name]
+			return ok, nil
+		}).
+		AnyTimes()
+	store.EXPECT().
+		PresignedReadURL(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, name string, _ time.Duration) (string, error) {
+			return name, nil
+		}).
+		AnyTimes()
+	store.EXPECT().
+		Upload(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, r io.ReadSeeker, _ int64, name string) error {
+			content, err := io.ReadAll(r)
+			blobs[name] = content
+			return err
+		}).
+		AnyTimes()
+	return store
+}
+
+func memoryFetcher(ctrl *gomock.Controller, blobs map[string][]byte) *mockfetch.MockFetcher {
+	fetcher := mockfetch.NewMockFetcher(ctrl)
+	fetcher.EXPECT().
+		Fetch(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, name string) (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(blobs[name])), nil
+		}).
+		AnyTimes()
+	return fetcher
+}
+
+// Params with a fresh fuzz tooling dir, like every evaluation gets
+func buildParams(t *testing.T, sanitizer string) (engine.Params, string) {
+	fuzzToolingDir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(fuzzToolingDir, "oss-fuzz"), 0755))
+
+	params := engine.NewParams(
+		sanitizer,
+		"x86_64",
+		"libfuzzer",
+		"harness",
+		"project",
+		"focus",
+		identifier.LanguageSlice{identifier.LanguageC},
+	).
+		WithFuzzToolingDir(fuzzToolingDir).
+		WithRepo(types.ResultCtxHeadRepoTest, t.TempDir())
+	out := filepath.Join(fuzzToolingDir, "oss-fuzz", "build", "out", "project")
+	return params, out
+}
+
+func TestBuildCache(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	blobs := map[string][]byte{}
+	store := memoryStore(ctrl, blobs)
+	fetcher := memoryFetcher(ctrl, blobs)
+	extractor := extract.NewNativeTarGzExtractor(extract.DefaultLimits)
+
+	build := func(out string) func(context.Context, *engine.Params) error {
+		return func(context.Context, *engine.Params) error {
+			if err := os.MkdirAll(out, 0755); err != nil {
+				return err
+			}
+			return os.WriteFile(filepath.Join(out, "harness"), []byte("fuzzer"), 0755)
+		}
+	}
+
+	t.Run("MissThenHit", func(t *testing.T) {
+		params, out := buildParams(t, "address")
+		inner := mockengine.NewMockEngine(ctrl)
+		inner.EXPECT().Build(gomock.Any(), gomock.Any()).DoAndReturn(build(out)).Times(1)
+
+		cached := engine.NewBuildCacheEngine(inner, store, fetcher, extractor, "task", t.TempDir())
+		require.NoError(t, cached.Build(ctx, &params))
+		assert.Len(t, blobs, 1, "build should be saved")
+
+		params, out = buildParams(t, "address")
+		inner = mockengine.NewMockEngine(ctrl)
+		inner.EXPECT().Build(gomock.Any(), gomock.Any()).Times(0)
+
+		cached = engine.NewBuildCacheEngine(inner, store, fetcher, extractor, "task", t.TempDir())
+		require.NoError(t, cached.Build(ctx, &params))
+
+		content, err := os.ReadFile(filepath.Join(out, "harness"))
+		require.NoError(t, err, "cached build should be restored")
+		assert.Equal(t, "fuzzer", string(content))
+	})
+
+	t.Run("OtherSanitizerMisses", func(t *testing.T) {
+		params, out := buildParams(t, "memory")
+		inner := mockengine.NewMockEngine(ctrl)
+		inner.EXPECT().Build(gomock.Any(), gomock.Any()).DoAndReturn(build(out)).Times(1)
+
+		cached := engine.NewBuildCacheEngine(inner, store, fetcher, extractor, "task", t.TempDir())
+		require.NoError(t, cached.Build(ctx, &params))
+	})
+
+	t.Run("CorruptCacheBuilds", func(t *testing.T) {
+		params, out := buildParams(t, "undefined")
+		inner := mockengine.NewMockEngine(ctrl)
+		inner.EXPECT().Build(gomock.Any(), gomock.Any()).DoAndReturn(build(out)).Times(1)
+
+		cached := engine.NewBuildCacheEngine(inner, store, fetcher, extractor, "other", t.TempDir())
+		require.NoError(t, cached.Build(ctx, &params))
+		for name := range blobs {
+			blobs[name] = []byte("not a tarball")
+		}
+
+		params, out = buildParams(t, "undefined")
+		inner = mockengine.NewMockEngine(ctrl)
+		inner.EXPECT().Build(gomock.Any(), gomock.Any()).DoAndReturn(build(out)).Times(1)
+
+		cached = engine.NewBuildCacheEngine(inner, store, fetcher, extractor, "other", t.TempDir())
+		require.NoError(t, cached.Build(ctx, &params))
+	})
+
+	t.Run("FailedBuildNotSaved", func(t *testing.T) {
+		params, _ := buildParams(t, "thread")
+		inner := mockengine.NewMockEngine(ctrl)
+		inner.EXPECT().Build(gomock.Any(), gomock.Any()).Return(engine.ErrBuildingErrored).Times(1)
+
+		before := len(blobs)
+		cached := engine.NewBuildCacheEngine(inner, store, fetcher, extractor, "task", t.TempDir())
+		require.ErrorIs(t, cached.Build(ctx, &params), engine.ErrBuildingErrored)
+		assert.Len(t, blobs, before)
+	})
+}
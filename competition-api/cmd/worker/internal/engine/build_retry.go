@@ -96,6 +96,10 @@ func (r *BuildRetryEngine) RunPov(
 }
 
 // RunTests implements Engine.
-func (r *BuildRetryEngine) RunTests(ctx context.Context, data *Params, shouldPass bool) error {
+func (r *BuildRetryEngine) RunTests(
+	ctx context.Context,
+	data *Params,
+	shouldPass bool,
+) (*types.TestResults, error) {
 	return r.engine.RunTests(ctx, data, shouldPass)
 }
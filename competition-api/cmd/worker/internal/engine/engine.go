@@ -5,6 +5,8 @@ import (
 	"errors"
 
 	"go.opentelemetry.io/otel"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
 )
 
 var tracer = otel.Tracer("github.com/aixcyberchallenge/competition-api/worker/internal/engine")
@@ -25,11 +27,18 @@ type Engine interface {
 		data *Params,
 		patchPath string,
 	) error
+	// Returns the per-test outcomes when the test output has any, also along with a failure
 	RunTests(
 		ctx context.Context,
 		data *Params,
 		shouldPass bool,
-	) error
+	) (*types.TestResults, error)
+}
+
+// Implemented by engines keeping the test results of the unpatched head, a hit spares fetching the
+// head repo to run the tests. On a hit the results and error are what RunTests would return.
+type TestBaselineCache interface {
+	CachedTestBaseline(ctx context.Context, data *Params) (*types.TestResults, bool, error)
 }
 
 var (
@@ -37,4 +46,7 @@ var (
 	ErrBuildingErrored  = errors.New("building errored")
 	ErrAptUnreachable   = errors.New("failed to reach apt")
 	ErrMavenUnreachable = errors.New("failed to reach maven")
+	ErrPatchPolicy      = errors.New("patch violates the patch policy")
+	ErrTestsRegressed   = errors.New("tests regressed")
+	ErrLimitExceeded    = errors.New("command exceeded its limits")
 )
@@ -14,6 +14,7 @@ import (
 	reflect "reflect"
 
 	engine "github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/engine"
+	types "github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -98,11 +99,12 @@ func (mr *MockEngineMockRecorder) RunPov(ctx, data, triggerPath, shouldCrash any
 }
 
 // RunTests mocks base method.
-func (m *MockEngine) RunTests(ctx context.Context, data *engine.Params, shouldPass bool) error {
+func (m *MockEngine) RunTests(ctx context.Context, data *engine.Params, shouldPass bool) (*types.TestResults, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "RunTests", ctx, data, shouldPass)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(*types.TestResults)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // RunTests indicates an expected call of RunTests.
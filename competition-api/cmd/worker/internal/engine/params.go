@@ -1,11 +1,15 @@
 package engine
 
 import (
+	"time"
+
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/identifier"
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
 )
 
 type Params struct {
+	patchPolicy      *types.PatchPolicy
+	testBaseline     *types.TestResults
 	resultContext    types.ResultContext
 	repoDir          string
 	fuzzToolingDir   string
@@ -15,7 +19,11 @@ type Params struct {
 	harness          string
 	projectName      string
 	focus            string
+	imageTag         string
+	tempDir          string
 	allowedLanguages identifier.LanguageSlice
+	reproducibility  types.ReproducibilityPolicy
+	timeouts         types.EvalTimeouts
 }
 
 func NewParams(
@@ -30,6 +38,8 @@ func NewParams(
 		projectName:      projectName,
 		focus:            focus,
 		allowedLanguages: allowedLanguages,
+		reproducibility:  types.DefaultReproducibilityPolicy,
+		timeouts:         types.DefaultEvalTimeouts,
 	}
 }
 
@@ -45,3 +55,64 @@ func (d Params) WithFuzzToolingDir(fuzzToolingDir string) Params {
 
 	return d
 }
+
+// Sets how often a POV expected to crash is run and must crash
+func (d Params) WithReproducibility(policy types.ReproducibilityPolicy) Params {
+	d.reproducibility = policy
+
+	return d
+}
+
+// Sets how long steps run against the challenge may take
+func (d Params) WithTimeouts(timeouts types.EvalTimeouts) Params {
+	d.timeouts = timeouts
+
+	return d
+}
+
+// How long step `name` may take against the challenge, zero when it has no limit of its own.
+// run_pov runs the trigger several times, each run gets the whole POV timeout plus time for
+// run_pov.sh to start and tear down the container.
+func (d Params) StepTimeout(name types.EvalStepName) time.Duration {
+	timeout := d.timeouts.Step(name)
+	if name != types.EvalStepRunPov || timeout == 0 {
+		return timeout
+	}
+	runs := max(d.reproducibility.Runs, unexpectedCrashRuns)
+	return time.Duration(runs) * (timeout + povRunOverhead)
+}
+
+// Sets the tag the project image is built under instead of latest, so evaluations of the same
+// project sharing a docker daemon don't replace each other's image
+func (d Params) WithImageTag(tag string) Params {
+	d.imageTag = tag
+
+	return d
+}
+
+// Sets where scratch dirs handed to the challenge's containers are made, it must be visible to
+// the docker daemon. Empty uses the default temp dir.
+func (d Params) WithTempDir(dir string) Params {
+	d.tempDir = dir
+
+	return d
+}
+
+// Sets what patches may change beyond the allowed languages, nil only checks languages
+func (d Params) WithPatchPolicy(policy *types.PatchPolicy) Params {
+	d.patchPolicy = policy
+
+	return d
+}
+
+// Sets the results of the tests on the unpatched head, passing tests are then judged by what
+// regressed against them. nil judges by the test script's exit code.
+func (d Params) WithTestBaseline(baseline *types.TestResults) Params {
+	d.testBaseline = baseline
+
+	return d
+}
+
+func (d Params) ResultContext() types.ResultContext {
+	return d.resultContext
+}
@@ -0,0 +1,258 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gopkg.in/yaml.v2"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+	workererrors "github.com/aixcyberchallenge/competition-api/competition-api/internal/worker_errors"
+)
+
+// Ensure SimulatedEngine implementes Engine interface
+var _ Engine = (*SimulatedEngine)(nil)
+
+// Failures and errors of the simulated engine wrap this
+var ErrSimulated = errors.New("simulated")
+
+type SimulatedOutcome string
+
+const (
+	SimulatedPass    SimulatedOutcome = "pass"
+	SimulatedFail    SimulatedOutcome = "fail"
+	SimulatedError   SimulatedOutcome = "error"
+	SimulatedTimeout SimulatedOutcome = "timeout"
+)
+
+type (
+	// Outcome of the steps a rule matches. Empty fields match anything.
+	SimulationRule struct {
+		// Replaces the default latency
+		LatencyMs *int `yaml:"latency_ms"`
+		// Text in the trigger or patch of the evaluation. Only inputs already handed to the
+		// engine count, the trigger from its run_pov step on and the patch from apply_patch on.
+		Marker  string              `yaml:"marker"`
+		Project string              `yaml:"project"`
+		Step    types.EvalStepName  `yaml:"step"`
+		Context types.ResultContext `yaml:"context"`
+		Outcome SimulatedOutcome    `yaml:"outcome"`
+	}
+
+	// What a [SimulatedEngine] does, the first matching rule decides a step and steps no rule
+	// matches pass
+	SimulationRules struct {
+		Rules []SimulationRule `yaml:"rules"`
+		// Time every step takes
+		LatencyMs int `yaml:"latency_ms"`
+	}
+)
+
+// Reads and checks the rules in YAML file `path`
+func LoadSimulationRules(path string) (*SimulationRules, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := &SimulationRules{}
+	err = yaml.UnmarshalStrict(content, rules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode simulation rules: %w", err)
+	}
+
+	for i, rule := range rules.Rules {
+		switch rule.Outcome {
+		case SimulatedPass, SimulatedFail, SimulatedError, SimulatedTimeout:
+		default:
+			return nil, fmt.Errorf("rule %d: unknown outcome %q", i, rule.Outcome)
+		}
+		// fetching happens outside the engine
+		switch rule.Step {
+		case "", types.EvalStepCheck, types.EvalStepBuild, types.EvalStepRunPov,
+			types.EvalStepApplyPatch, types.EvalStepRunTests:
+		default:
+			return nil, fmt.Errorf("rule %d: unknown step %q", i, rule.Step)
+		}
+	}
+	return rules, nil
+}
+
+// Stands in for the challenge scripts in load and integration tests. Nothing is built or run,
+// every step sleeps and ends the way the rules say, so evaluations are cheap and can drive every
+// status.
+type SimulatedEngine struct {
+	rules *SimulationRules
+	// triggers and patches handed to the engine so far
+	inputs [][]byte
+	mu     sync.Mutex
+}
+
+func NewSimulatedEngine(rules *SimulationRules) *SimulatedEngine {
+	return &SimulatedEngine{rules: rules}
+}
+
+// Keeps the content of `path` for markers to match
+func (s *SimulatedEngine) addInput(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inputs = append(s.inputs, content)
+	return nil
+}
+
+func (s *SimulatedEngine) matches(
+	rule *SimulationRule,
+	step types.EvalStepName,
+	data *Params,
+) bool {
+	if rule.Step != "" && rule.Step != step {
+		return false
+	}
+	if rule.Project != "" && rule.Project != data.projectName {
+		return false
+	}
+	if rule.Context != "" && rule.Context != data.resultContext {
+		return false
+	}
+	if rule.Marker == "" {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, input := range s.inputs {
+		if bytes.Contains(input, []byte(rule.Marker)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Waits out the latency of `step` and ends it as the first matching rule says
+func (s *SimulatedEngine) simulate(
+	ctx context.Context,
+	step types.EvalStepName,
+	data *Params,
+) error {
+	ctx, span := tracer.Start(ctx, "SimulatedEngine.simulate", trace.WithAttributes(
+		attribute.String("step", string(step)),
+		attribute.String("data.projectName", data.projectName),
+		attribute.String("data.resultContext", string(data.resultContext)),
+	))
+	defer span.End()
+
+	outcome := SimulatedPass
+	latency := time.Duration(s.rules.LatencyMs) * time.Millisecond
+	for i := range s.rules.Rules {
+		rule := &s.rules.Rules[i]
+		if !s.matches(rule, step, data) {
+			continue
+		}
+		outcome = rule.Outcome
+		if rule.LatencyMs != nil {
+			latency = time.Duration(*rule.LatencyMs) * time.Millisecond
+		}
+		span.SetAttributes(attribute.Int("rule", i))
+		break
+	}
+	span.SetAttributes(
+		attribute.String("outcome", string(outcome)),
+		attribute.Int64("latencyMs", latency.Milliseconds()),
+	)
+
+	timer := time.NewTimer(latency)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		span.RecordError(ctx.Err())
+		span.SetStatus(codes.Error, "cancelled while simulating latency")
+		return ctx.Err()
+	case <-timer.C:
+	}
+
+	switch outcome {
+	case SimulatedFail:
+		err := fmt.Errorf("%w failure of %s", ErrSimulated, step)
+		if step == types.EvalStepBuild {
+			err = fmt.Errorf("%w: %w", ErrBuildingFailed, err)
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "simulated failure")
+		return workererrors.StatusErrorWrap(
+			types.SubmissionStatusFailed,
+			step == types.EvalStepRunTests,
+			err,
+		)
+	case SimulatedError:
+		err := fmt.Errorf("%w error in %s", ErrSimulated, step)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "simulated error")
+		return err
+	case SimulatedTimeout:
+		// the evaluation's own deadline ends the step, as it does a hung script
+		<-ctx.Done()
+		span.RecordError(ctx.Err())
+		span.SetStatus(codes.Error, "simulated timeout")
+		return ctx.Err()
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "simulated pass")
+	return nil
+}
+
+// Check implements Engine.
+func (s *SimulatedEngine) Check(ctx context.Context, data *Params) error {
+	return s.simulate(ctx, types.EvalStepCheck, data)
+}
+
+// Build implements Engine.
+func (s *SimulatedEngine) Build(ctx context.Context, data *Params) error {
+	return s.simulate(ctx, types.EvalStepBuild, data)
+}
+
+// RunPov implements Engine. Passing means the trigger did what `shouldCrash` asked for.
+func (s *SimulatedEngine) RunPov(
+	ctx context.Context,
+	data *Params,
+	triggerPath string,
+	_ bool,
+) error {
+	err := s.addInput(triggerPath)
+	if err != nil {
+		return err
+	}
+	return s.simulate(ctx, types.EvalStepRunPov, data)
+}
+
+// ApplyPatch implements Engine.
+func (s *SimulatedEngine) ApplyPatch(ctx context.Context, data *Params, patchPath string) error {
+	err := s.addInput(patchPath)
+	if err != nil {
+		return err
+	}
+	return s.simulate(ctx, types.EvalStepApplyPatch, data)
+}
+
+// RunTests implements Engine. Passing means the tests did what `shouldPass` asked for, there are
+// never per-test results.
+func (s *SimulatedEngine) RunTests(
+	ctx context.Context,
+	data *Params,
+	_ bool,
+) (*types.TestResults, error) {
+	return nil, s.simulate(ctx, types.EvalStepRunTests, data)
+}
@@ -0,0 +1,143 @@
+package engine_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/engine"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+	workererrors "github.com/aixcyberchallenge/competition-api/competition-api/internal/worker_errors"
+)
+
+const simulationRules = `
+latency_ms: 1
+rules:
+  - marker: SIM_TIMEOUT
+    outcome: timeout
+  - marker: SIM_NO_CRASH
+    step: run_pov
+    context: pov_test_head_repo
+    outcome: fail
+    latency_ms: 0
+  - project: broken
+    step: build
+    outcome: fail
+  - project: flaky
+    outcome: error
+  - step: run_tests
+    marker: SIM_BREAKS_TESTS
+    outcome: fail
+`
+
+func loadRules(t *testing.T, content string) (*engine.SimulationRules, error) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return engine.LoadSimulationRules(path)
+}
+
+func writeInput(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "input")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func requireStatus(t *testing.T, err error, patchTestsFailed bool) {
+	t.Helper()
+	var se workererrors.StatusError
+	require.ErrorAs(t, err, &se)
+	assert.Equal(t, types.SubmissionStatusFailed, se.Status)
+	assert.Equal(t, patchTestsFailed, se.PatchTestsFailed)
+	require.ErrorIs(t, err, engine.ErrSimulated)
+}
+
+func TestSimulatedEngine(t *testing.T) {
+	ctx := context.Background()
+	rules, err := loadRules(t, simulationRules)
+	require.NoError(t, err)
+
+	params := func(project string, resultContext types.ResultContext) *engine.Params {
+		p := engine.NewParams("address", "x86_64", "libfuzzer", "harness", project, "focus", nil).
+			WithRepo(resultContext, t.TempDir())
+		return &p
+	}
+
+	t.Run("PassByDefault", func(t *testing.T) {
+		sim := engine.NewSimulatedEngine(rules)
+		data := params("project", types.ResultCtxHeadRepoTest)
+		require.NoError(t, sim.Check(ctx, data))
+		require.NoError(t, sim.Build(ctx, data))
+		require.NoError(t, sim.RunPov(ctx, data, writeInput(t, "crash"), true))
+	})
+
+	t.Run("TriggerMarker", func(t *testing.T) {
+		sim := engine.NewSimulatedEngine(rules)
+		trigger := writeInput(t, "AAAA SIM_NO_CRASH AAAA")
+		require.NoError(
+			t,
+			sim.RunPov(ctx, params("project", types.ResultCtxBaseRepoTest), trigger, false),
+			"rule is limited to the head repo",
+		)
+		err := sim.RunPov(ctx, params("project", types.ResultCtxHeadRepoTest), trigger, true)
+		requireStatus(t, err, false)
+	})
+
+	t.Run("ProjectBuildFails", func(t *testing.T) {
+		sim := engine.NewSimulatedEngine(rules)
+		data := params("broken", types.ResultCtxHeadRepoTest)
+		require.NoError(t, sim.Check(ctx, data))
+		err := sim.Build(ctx, data)
+		requireStatus(t, err, false)
+		require.ErrorIs(t, err, engine.ErrBuildingFailed)
+	})
+
+	t.Run("ProjectErrors", func(t *testing.T) {
+		sim := engine.NewSimulatedEngine(rules)
+		err := sim.Check(ctx, params("flaky", types.ResultCtxHeadRepoTest))
+		require.ErrorIs(t, err, engine.ErrSimulated)
+		var se workererrors.StatusError
+		require.NotErrorAs(t, err, &se, "errors should not decide the submission")
+	})
+
+	t.Run("PatchMarker", func(t *testing.T) {
+		sim := engine.NewSimulatedEngine(rules)
+		data := params("project", types.ResultCtxHeadRepoTest)
+		_, err := sim.RunTests(ctx, data, true)
+		require.NoError(t, err, "marker only counts once the patch was applied")
+
+		require.NoError(t, sim.ApplyPatch(ctx, data, writeInput(t, "+// SIM_BREAKS_TESTS")))
+		results, err := sim.RunTests(ctx, data, true)
+		assert.Nil(t, results)
+		requireStatus(t, err, true)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		sim := engine.NewSimulatedEngine(rules)
+		data := params("project", types.ResultCtxHeadRepoTest)
+		timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		err := sim.RunPov(timeoutCtx, data, writeInput(t, "SIM_TIMEOUT"), true)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestLoadSimulationRules(t *testing.T) {
+	_, err := loadRules(t, "rules:\n  - outcome: explode\n")
+	require.ErrorContains(t, err, "unknown outcome")
+
+	_, err = loadRules(t, "rules:\n  - outcome: pass\n    step: fetch\n")
+	require.ErrorContains(t, err, "unknown step")
+
+	_, err = loadRules(t, "rules:\n  - outcome: pass\n    markr: typo\n")
+	require.Error(t, err, "unknown fields should be rejected")
+
+	rules, err := loadRules(t, "")
+	require.NoError(t, err)
+	assert.Empty(t, rules.Rules, "no rules pass everything")
+}
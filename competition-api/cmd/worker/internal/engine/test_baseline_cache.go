@@ -0,0 +1,260 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/fetch"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/upload"
+	workererrors "github.com/aixcyberchallenge/competition-api/competition-api/internal/worker_errors"
+)
+
+// Ensure TestBaselineCacheEngine implementes Engine and TestBaselineCache interfaces
+var (
+	_ Engine            = (*TestBaselineCacheEngine)(nil)
+	_ TestBaselineCache = (*TestBaselineCacheEngine)(nil)
+)
+
+// Reuses the test results of a task's unpatched head across patch evaluations. Every patch
+// against a task is judged against the same baseline, so the first evaluation uploads it and
+// later ones skip running the tests on the head.
+type TestBaselineCacheEngine struct {
+	engine  Engine
+	store   upload.Uploader
+	fetcher fetch.Fetcher
+	// identifies the unpatched head and the fuzz tooling whose image runs its tests
+	key string
+}
+
+// What a baseline run leaves in the cache, results are nil when the tests had no per-test output
+type cachedTestBaseline struct {
+	Results *types.TestResults `json:"results"`
+	Failed  bool               `json:"failed"`
+}
+
+func NewTestBaselineCacheEngine(
+	engine Engine, //nolint:revive // import-shadowing: no better variable name to use here
+	store upload.Uploader,
+	fetcher fetch.Fetcher,
+	key string,
+) *TestBaselineCacheEngine {
+	return &TestBaselineCacheEngine{
+		engine:  engine,
+		store:   store,
+		fetcher: fetcher,
+		key:     key,
+	}
+}
+
+// Name of the blob holding the baseline of `data`
+func (b *TestBaselineCacheEngine) blobName(data *Params) string {
+	hash := sha256.New()
+	for _, part := range []string{b.key, data.projectName, data.focus} {
+		// null separated so parts can't run into each other
+		fmt.Fprintf(hash, "%s\x00", part)
+	}
+	return "test-baseline/" + hex.EncodeToString(hash.Sum(nil))
+}
+
+// CachedTestBaseline implements TestBaselineCache. The repo of `data` is not needed.
+func (b *TestBaselineCacheEngine) CachedTestBaseline(
+	ctx context.Context,
+	data *Params,
+) (*types.TestResults, bool, error) {
+	blobName := b.blobName(data)
+	ctx, span := tracer.Start(
+		ctx,
+		"TestBaselineCacheEngine.CachedTestBaseline",
+		trace.WithAttributes(attribute.String("blob.name", blobName)),
+	)
+	defer span.End()
+
+	// a broken cache should never fail an evaluation, it only costs a test run
+	cached, err := b.restore(ctx, blobName)
+	if err != nil {
+		span.AddEvent("test_baseline_cache_miss", trace.WithAttributes(
+			attribute.String("reason", err.Error()),
+		))
+		span.RecordError(nil)
+		span.SetStatus(codes.Ok, "test baseline not restored")
+		return nil, false, nil
+	}
+
+	span.AddEvent("test_baseline_cache_hit")
+	if cached.Failed {
+		span.RecordError(nil)
+		span.SetStatus(codes.Error, "cached tests did not match expected state")
+		return cached.Results, true, workererrors.StatusErrorWrap(
+			types.SubmissionStatusFailed,
+			true,
+			nil,
+		)
+	}
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "restored test baseline from cache")
+	return cached.Results, true, nil
+}
+
+// RunTests implements Engine. Only runs against the unpatched head are cached.
+func (b *TestBaselineCacheEngine) RunTests(
+	ctx context.Context,
+	data *Params,
+	shouldPass bool,
+) (*types.TestResults, error) {
+	if data.resultContext != types.ResultCtxTestBaseline {
+		return b.engine.RunTests(ctx, data, shouldPass)
+	}
+
+	blobName := b.blobName(data)
+	ctx, span := tracer.Start(ctx, "TestBaselineCacheEngine.RunTests", trace.WithAttributes(
+		attribute.String("blob.name", blobName),
+		attribute.Bool("shouldPass", shouldPass),
+	))
+	defer span.End()
+
+	results, hit, err := b.CachedTestBaseline(ctx, data)
+	if hit {
+		span.RecordError(nil)
+		span.SetStatus(codes.Ok, "restored test baseline from cache")
+		return results, err
+	}
+
+	results, err = b.engine.RunTests(ctx, data, shouldPass)
+	var se workererrors.StatusError
+	failed := errors.As(err, &se) && se.Status == types.SubmissionStatusFailed
+	if err != nil && !failed {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to run tests")
+		return results, err
+	}
+
+	saveErr := b.save(ctx, blobName, cachedTestBaseline{Results: results, Failed: failed})
+	if saveErr != nil {
+		span.AddEvent("failed to save test baseline to cache", trace.WithAttributes(
+			attribute.String("error", saveErr.Error()),
+		))
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "ran tests")
+	return results, err
+}
+
+var errTestBaselineNotCached = errors.New("test baseline not cached")
+
+func (b *TestBaselineCacheEngine) restore(
+	ctx context.Context,
+	blobName string,
+) (*cachedTestBaseline, error) {
+	ctx, span := tracer.Start(ctx, "TestBaselineCacheEngine.restore", trace.WithAttributes(
+		attribute.String("blob.name", blobName),
+	))
+	defer span.End()
+
+	exists, err := b.store.Exists(ctx, blobName)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to check for cached test baseline")
+		return nil, err
+	}
+	if !exists {
+		span.RecordError(nil)
+		span.SetStatus(codes.Ok, "test baseline not cached")
+		return nil, errTestBaselineNotCached
+	}
+
+	url, err := b.store.PresignedReadURL(ctx, blobName, buildCacheURLExpiration)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to get presigned url for cached test baseline")
+		return nil, err
+	}
+
+	body, err := b.fetcher.Fetch(ctx, url)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to fetch cached test baseline")
+		return nil, err
+	}
+	defer body.Close()
+
+	var cached cachedTestBaseline
+	err = json.NewDecoder(body).Decode(&cached)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to decode cached test baseline")
+		return nil, err
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "restored cached test baseline")
+	return &cached, nil
+}
+
+func (b *TestBaselineCacheEngine) save(
+	ctx context.Context,
+	blobName string,
+	baseline cachedTestBaseline,
+) error {
+	ctx, span := tracer.Start(ctx, "TestBaselineCacheEngine.save", trace.WithAttributes(
+		attribute.String("blob.name", blobName),
+	))
+	defer span.End()
+
+	encoded, err := json.Marshal(baseline)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to encode test baseline")
+		return err
+	}
+
+	err = b.store.Upload(ctx, bytes.NewReader(encoded), int64(len(encoded)), blobName)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to upload test baseline")
+		return err
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "saved test baseline to cache")
+	return nil
+}
+
+// ApplyPatch implements Engine.
+func (b *TestBaselineCacheEngine) ApplyPatch(
+	ctx context.Context,
+	data *Params,
+	patchPath string,
+) error {
+	return b.engine.ApplyPatch(ctx, data, patchPath)
+}
+
+// Build implements Engine.
+func (b *TestBaselineCacheEngine) Build(ctx context.Context, data *Params) error {
+	return b.engine.Build(ctx, data)
+}
+
+// Check implements Engine.
+func (b *TestBaselineCacheEngine) Check(ctx context.Context, data *Params) error {
+	return b.engine.Check(ctx, data)
+}
+
+// RunPov implements Engine.
+func (b *TestBaselineCacheEngine) RunPov(
+	ctx context.Context,
+	data *Params,
+	triggerPath string,
+	shouldCrash bool,
+) error {
+	return b.engine.RunPov(ctx, data, triggerPath, shouldCrash)
+}
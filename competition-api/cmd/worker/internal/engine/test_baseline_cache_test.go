@@ -0,0 +1,105 @@
+package engine_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/engine"
+	mockengine "github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/engine/mock"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+	workererrors "github.com/aixcyberchallenge/competition-api/competition-api/internal/worker_errors"
+)
+
+func TestTestBaselineCache(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	blobs := map[string][]byte{}
+	store := memoryStore(ctrl, blobs)
+	fetcher := memoryFetcher(ctrl, blobs)
+
+	baseline := &types.TestResults{
+		Format: types.TestFormatTAP,
+		Cases: []types.TestCase{
+			{Name: "parses input", Outcome: types.TestPassed},
+			{Name: "reaches network", Outcome: types.TestFailed},
+		},
+	}
+	testsFailed := workererrors.StatusErrorWrap(types.SubmissionStatusFailed, true, nil)
+
+	t.Run("MissThenHit", func(t *testing.T) {
+		params, _ := buildParams(t, "address")
+		params = params.WithRepo(types.ResultCtxTestBaseline, t.TempDir())
+		inner := mockengine.NewMockEngine(ctrl)
+		inner.EXPECT().RunTests(gomock.Any(), gomock.Any(), true).
+			Return(baseline, testsFailed).
+			Times(1)
+
+		cached := engine.NewTestBaselineCacheEngine(inner, store, fetcher, "task")
+		results, err := cached.RunTests(ctx, &params, true)
+		require.ErrorIs(t, err, testsFailed)
+		assert.Equal(t, baseline, results)
+		assert.Len(t, blobs, 1, "baseline should be saved")
+
+		inner = mockengine.NewMockEngine(ctrl)
+		inner.EXPECT().RunTests(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		cached = engine.NewTestBaselineCacheEngine(inner, store, fetcher, "task")
+		results, err = cached.RunTests(ctx, &params, true)
+		var se workererrors.StatusError
+		require.ErrorAs(t, err, &se, "cached baseline should still report failing tests")
+		assert.Equal(t, types.SubmissionStatusFailed, se.Status)
+		assert.Equal(t, baseline, results)
+	})
+
+	t.Run("LookupWithoutRepo", func(t *testing.T) {
+		params, _ := buildParams(t, "address")
+		params = params.WithRepo(types.ResultCtxTestBaseline, "")
+		inner := mockengine.NewMockEngine(ctrl)
+		inner.EXPECT().RunTests(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		cached := engine.NewTestBaselineCacheEngine(inner, store, fetcher, "task")
+		results, hit, err := cached.CachedTestBaseline(ctx, &params)
+		assert.True(t, hit, "baseline cached by MissThenHit should be found")
+		require.ErrorIs(t, err, testsFailed)
+		assert.Equal(t, baseline, results)
+
+		cached = engine.NewTestBaselineCacheEngine(inner, store, fetcher, "uncached")
+		_, hit, err = cached.CachedTestBaseline(ctx, &params)
+		require.NoError(t, err)
+		assert.False(t, hit)
+	})
+
+	t.Run("PatchedNotCached", func(t *testing.T) {
+		params, _ := buildParams(t, "address")
+		inner := mockengine.NewMockEngine(ctrl)
+		inner.EXPECT().RunTests(gomock.Any(), gomock.Any(), true).Return(baseline, nil).Times(2)
+
+		cached := engine.NewTestBaselineCacheEngine(inner, store, fetcher, "patched")
+		for range 2 {
+			_, err := cached.RunTests(ctx, &params, true)
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("CorruptCacheRuns", func(t *testing.T) {
+		params, _ := buildParams(t, "address")
+		params = params.WithRepo(types.ResultCtxTestBaseline, t.TempDir())
+		inner := mockengine.NewMockEngine(ctrl)
+		inner.EXPECT().RunTests(gomock.Any(), gomock.Any(), true).Return(nil, nil).Times(2)
+
+		cached := engine.NewTestBaselineCacheEngine(inner, store, fetcher, "other")
+		_, err := cached.RunTests(ctx, &params, true)
+		require.NoError(t, err)
+		for name := range blobs {
+			blobs[name] = []byte("not json")
+		}
+
+		results, err := cached.RunTests(ctx, &params, true)
+		require.NoError(t, err)
+		assert.Nil(t, results)
+	})
+}
@@ -3,9 +3,13 @@ package evaluate
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"os"
+	"sync/atomic"
+	"time"
 
+	"github.com/google/uuid"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -15,6 +19,7 @@ import (
 	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/extract"
 	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/workerqueue"
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/fetch"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/logger"
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
 	workererrors "github.com/aixcyberchallenge/competition-api/competition-api/internal/worker_errors"
 )
@@ -23,12 +28,24 @@ var tracer = otel.Tracer(
 	"github.com/aixcyberchallenge/competition-api/competition-api/worker/internal/evaluate",
 )
 
+// A step ran past its timeout. The evaluation is inconclusive, like one running out of time.
+var ErrStepTimedOut = errors.New("step timed out")
+
+// The evaluation was cancelled before it could finish, no final status was sent for it
+var ErrCancelled = errors.New("evaluation cancelled")
+
 type Evaluator struct {
 	fetcher   fetch.Fetcher
 	extractor extract.Extractor
 	engine    engine.Engine
 	queuer    *workerqueue.WorkerQueuer
-	tempDir   string
+	// name of the step last started, read when the whole evaluation times out
+	running atomic.Value
+	tempDir string
+	// tells the steps of this run apart from those of earlier runs of the same evaluation
+	run string
+	// steps reported so far, numbers the next one
+	steps int
 }
 
 func NewEvaluator(
@@ -44,12 +61,14 @@ func NewEvaluator(
 		tempDir:   tempDir,
 		engine:    engine,
 		queuer:    workerQueuer,
+		run:       uuid.Must(uuid.NewV7()).String(),
 	}
 }
 
 func (e *Evaluator) Evaluate(
 	ctx context.Context,
-	fuzzToolingURL, headRepoURL, baseRepoURL, triggerURL, patchURL string, skipPatchTests bool,
+	fuzzToolingURL, headRepoURL, baseRepoURL, triggerURL, patchURL string,
+	skipPatchTests, buildHead bool,
 	commonEngineParams *engine.Params,
 ) error {
 	ctx, span := tracer.Start(ctx, "Evaluator.Evaluate", trace.WithAttributes(
@@ -58,11 +77,13 @@ func (e *Evaluator) Evaluate(
 		attribute.String("baseRepo.url", baseRepoURL),
 		attribute.String("trigger.url", triggerURL),
 		attribute.String("patch.url", patchURL),
+		attribute.Bool("buildHead", buildHead),
 	))
 	defer span.End()
 
 	status := types.SubmissionStatusPassed
 	patchTestsFailed := false
+	reason := ""
 
 	evalError := make(chan error)
 
@@ -77,6 +98,7 @@ func (e *Evaluator) Evaluate(
 			triggerURL,
 			patchURL,
 			skipPatchTests,
+			buildHead,
 			commonEngineParams,
 		)
 		evalError <- err
@@ -86,6 +108,7 @@ func (e *Evaluator) Evaluate(
 	case <-ctx.Done():
 		if ctx.Err() == context.DeadlineExceeded {
 			status = types.SubmissionStatusInconclusive
+			reason = e.timedOutReason()
 		}
 	case err := <-evalError:
 		if err != nil {
@@ -96,14 +119,26 @@ func (e *Evaluator) Evaluate(
 			} else {
 				status = types.SubmissionStatusErrored
 			}
+			if status == types.SubmissionStatusErrored || errors.Is(err, ErrStepTimedOut) {
+				reason = err.Error()
+			}
 		}
 		if ctx.Err() == context.DeadlineExceeded {
 			status = types.SubmissionStatusInconclusive
+			reason = e.timedOutReason()
 		}
 	}
 
-	ctx = context.WithoutCancel(ctx)
-	err := e.queuer.FinalMessage(ctx, status, patchTestsFailed)
+	// a cancelled evaluation says nothing about the submission, whoever cancelled it runs it again
+	if errors.Is(ctx.Err(), context.Canceled) {
+		err := fmt.Errorf("%w: %w", ErrCancelled, context.Cause(ctx))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "evaluation cancelled")
+		return err
+	}
+
+	// the evaluation may still be running after timing out, it keeps using ctx
+	err := e.queuer.FinalMessage(context.WithoutCancel(ctx), status, patchTestsFailed, reason)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to send final message")
@@ -117,13 +152,14 @@ func (e *Evaluator) Evaluate(
 
 func (e *Evaluator) evaluate(
 	ctx context.Context,
-	fuzzToolingURL, headRepoURL, baseRepoURL, triggerURL, patchURL string, skipPatchTests bool,
+	fuzzToolingURL, headRepoURL, baseRepoURL, triggerURL, patchURL string,
+	skipPatchTests, buildHead bool,
 	commonEngineParams *engine.Params,
 ) error {
 	ctx, span := tracer.Start(ctx, "Evaluator.evaluate")
 	defer span.End()
 
-	fuzzToolingDir, err := e.fetchExtractRepo(ctx, fuzzToolingURL)
+	fuzzToolingDir, err := e.fetchExtractRepo(ctx, "fuzz tooling", fuzzToolingURL)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to fetch fuzzToolingDir")
@@ -131,7 +167,7 @@ func (e *Evaluator) evaluate(
 	}
 	defer os.RemoveAll(fuzzToolingDir)
 
-	headRepoDir, err := e.fetchExtractRepo(ctx, headRepoURL)
+	headRepoDir, err := e.fetchExtractRepo(ctx, "head repo", headRepoURL)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to fetch headRepoDir")
@@ -143,18 +179,35 @@ func (e *Evaluator) evaluate(
 		WithFuzzToolingDir(fuzzToolingDir).
 		WithRepo(types.ResultCtxHeadRepoTest, headRepoDir)
 
+	done := e.startStep(ctx, types.EvalStepCheck, "", headChallenge.ResultContext())
 	err = e.engine.Check(ctx, &headChallenge)
+	done(err)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed check params")
 		return err
 	}
 
+	// Triggers and patches build the head repo themselves
+	if buildHead && triggerURL == "" && patchURL == "" {
+		done := e.startStep(ctx, types.EvalStepBuild, "", headChallenge.ResultContext())
+		err = e.runStep(ctx, types.EvalStepBuild, &headChallenge, func(ctx context.Context) error {
+			return e.engine.Build(ctx, &headChallenge)
+		})
+		done(err)
+		if err != nil {
+			err = wrapBuildError(err)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to build challenge")
+			return err
+		}
+	}
+
 	var triggerPath string
 	if triggerURL != "" {
 		var err error
 		var trigger *os.File
-		trigger, err = e.fetchFile(ctx, triggerURL)
+		trigger, err = e.fetchFile(ctx, "trigger", triggerURL)
 		if err != nil {
 			span.RecordError(err)
 			span.SetStatus(codes.Error, "failed to fetch trigger")
@@ -184,6 +237,7 @@ func (e *Evaluator) evaluate(
 		err := e.runPatchTests(
 			ctx,
 			&headChallenge,
+			headRepoURL,
 			triggerPath,
 			patchURL,
 			skipPatchTests,
@@ -213,15 +267,25 @@ func (e *Evaluator) runPovTests(
 	))
 	defer span.End()
 
-	err := e.engine.Build(ctx, challenge)
+	done := e.startStep(ctx, types.EvalStepBuild, "", challenge.ResultContext())
+	err := e.runStep(ctx, types.EvalStepBuild, challenge, func(ctx context.Context) error {
+		return e.engine.Build(ctx, challenge)
+	})
+	if err != nil {
+		err = wrapBuildError(err)
+	}
+	done(err)
 	if err != nil {
-		err = workererrors.StatusErrorWrap(types.SubmissionStatusErrored, false, err)
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to build challenge")
 		return err
 	}
 
-	err = e.engine.RunPov(ctx, challenge, triggerPath, shouldCrash)
+	done = e.startStep(ctx, types.EvalStepRunPov, "", challenge.ResultContext())
+	err = e.runStep(ctx, types.EvalStepRunPov, challenge, func(ctx context.Context) error {
+		return e.engine.RunPov(ctx, challenge, triggerPath, shouldCrash)
+	})
+	done(err)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to run pov")
@@ -233,7 +297,7 @@ func (e *Evaluator) runPovTests(
 	return nil
 }
 
-func (e Evaluator) runBaseTests(
+func (e *Evaluator) runBaseTests(
 	ctx context.Context,
 	headChallenge *engine.Params,
 	baseRepoURL, triggerPath string,
@@ -243,7 +307,7 @@ func (e Evaluator) runBaseTests(
 	))
 	defer span.End()
 
-	baseRepoDir, err := e.fetchExtractRepo(ctx, baseRepoURL)
+	baseRepoDir, err := e.fetchExtractRepo(ctx, "base repo", baseRepoURL)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to fetch baseRepo")
@@ -268,7 +332,7 @@ func (e Evaluator) runBaseTests(
 func (e *Evaluator) runPatchTests(
 	ctx context.Context,
 	headChallenge *engine.Params,
-	triggerPath, patchURL string,
+	headRepoURL, triggerPath, patchURL string,
 	skipPatchFunctionalityTests bool,
 ) error {
 	ctx, span := tracer.Start(ctx, "Evaluator.runPatchTests", trace.WithAttributes(
@@ -277,7 +341,7 @@ func (e *Evaluator) runPatchTests(
 	))
 	defer span.End()
 
-	patch, err := e.fetchFile(ctx, patchURL)
+	patch, err := e.fetchFile(ctx, "patch", patchURL)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to fetch patch")
@@ -285,14 +349,20 @@ func (e *Evaluator) runPatchTests(
 	}
 	defer patch.Close()
 
+	done := e.startStep(ctx, types.EvalStepApplyPatch, "", headChallenge.ResultContext())
 	err = e.engine.ApplyPatch(ctx, headChallenge, patch.Name())
+	done(err)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to apply patch")
 		return err
 	}
 
-	err = e.engine.Build(ctx, headChallenge)
+	done = e.startStep(ctx, types.EvalStepBuild, "", headChallenge.ResultContext())
+	err = e.runStep(ctx, types.EvalStepBuild, headChallenge, func(ctx context.Context) error {
+		return e.engine.Build(ctx, headChallenge)
+	})
+	done(err)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to build patch")
@@ -300,7 +370,11 @@ func (e *Evaluator) runPatchTests(
 	}
 
 	if triggerPath != "" {
-		err = e.engine.RunPov(ctx, headChallenge, triggerPath, false)
+		done := e.startStep(ctx, types.EvalStepRunPov, "", headChallenge.ResultContext())
+		err = e.runStep(ctx, types.EvalStepRunPov, headChallenge, func(ctx context.Context) error {
+			return e.engine.RunPov(ctx, headChallenge, triggerPath, false)
+		})
+		done(err)
 		if err != nil {
 			span.RecordError(err)
 			span.SetStatus(codes.Error, "failed to run pov on patch")
@@ -309,7 +383,21 @@ func (e *Evaluator) runPatchTests(
 	}
 
 	if !skipPatchFunctionalityTests {
-		err = e.engine.RunTests(ctx, headChallenge, true)
+		baseline, err := e.runTestBaseline(ctx, headChallenge, headRepoURL)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to run tests on unpatched head")
+			return err
+		}
+
+		patchedChallenge := headChallenge.WithTestBaseline(baseline)
+		done := e.startStep(ctx, types.EvalStepRunTests, "", patchedChallenge.ResultContext())
+		runTests := func(ctx context.Context) error {
+			_, err := e.engine.RunTests(ctx, &patchedChallenge, true)
+			return err
+		}
+		err = e.runStep(ctx, types.EvalStepRunTests, &patchedChallenge, runTests)
+		done(err)
 		if err != nil {
 			span.RecordError(err)
 			span.SetStatus(codes.Error, "failed to run tests")
@@ -322,29 +410,168 @@ func (e *Evaluator) runPatchTests(
 	return nil
 }
 
-func (e *Evaluator) fetchExtractRepo(ctx context.Context, url string) (string, error) {
+// Runs the tests on a fresh copy of the head repo, the patch was applied to the other one. Tests
+// failing there are the baseline the patch is judged against rather than its fault, the results
+// are nil when the tests had no per-test output.
+func (e *Evaluator) runTestBaseline(
+	ctx context.Context,
+	headChallenge *engine.Params,
+	headRepoURL string,
+) (*types.TestResults, error) {
+	ctx, span := tracer.Start(ctx, "Evaluator.runTestBaseline", trace.WithAttributes(
+		attribute.String("headRepo.url", headRepoURL),
+	))
+	defer span.End()
+
+	baselineChallenge := headChallenge.WithRepo(types.ResultCtxTestBaseline, "")
+
+	// a cached baseline spares fetching the head repo
+	var (
+		baseline *types.TestResults
+		hit      bool
+		err      error
+	)
+	if cache, ok := e.engine.(engine.TestBaselineCache); ok {
+		baseline, hit, err = cache.CachedTestBaseline(ctx, &baselineChallenge)
+	}
+	if hit {
+		done := e.startStep(ctx, types.EvalStepRunTests, "", baselineChallenge.ResultContext())
+		done(err)
+	} else {
+		baseline, err = e.runTestsOnHead(ctx, baselineChallenge, headRepoURL)
+	}
+
+	var se workererrors.StatusError
+	if err != nil && (!errors.As(err, &se) || se.Status != types.SubmissionStatusFailed) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to run tests on unpatched head")
+		return nil, err
+	}
+
+	span.SetAttributes(
+		attribute.Bool("baseline", baseline != nil),
+		attribute.Bool("cached", hit),
+	)
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "ran tests on unpatched head")
+	return baseline, nil
+}
+
+// Fetches the head repo for `baselineChallenge` and runs the tests on it
+func (e *Evaluator) runTestsOnHead(
+	ctx context.Context,
+	baselineChallenge engine.Params,
+	headRepoURL string,
+) (*types.TestResults, error) {
+	headRepoDir, err := e.fetchExtractRepo(ctx, "unpatched head repo", headRepoURL)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(headRepoDir)
+
+	baselineChallenge = baselineChallenge.WithRepo(types.ResultCtxTestBaseline, headRepoDir)
+
+	var baseline *types.TestResults
+	done := e.startStep(ctx, types.EvalStepRunTests, "", baselineChallenge.ResultContext())
+	runTests := func(ctx context.Context) error {
+		var err error
+		baseline, err = e.engine.RunTests(ctx, &baselineChallenge, true)
+		return err
+	}
+	err = e.runStep(ctx, types.EvalStepRunTests, &baselineChallenge, runTests)
+	done(err)
+	return baseline, err
+}
+
+// Runs `step` under the challenge's timeout for step `name`. A step running out of its own time
+// is inconclusive and its error names the step, the whole evaluation timing out is left to
+// Evaluate.
+func (e *Evaluator) runStep(
+	ctx context.Context,
+	name types.EvalStepName,
+	challenge *engine.Params,
+	step func(context.Context) error,
+) error {
+	timeout := challenge.StepTimeout(name)
+	if timeout <= 0 {
+		return step(ctx)
+	}
+
+	stepCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := step(stepCtx)
+	if err != nil && ctx.Err() == nil && stepCtx.Err() == context.DeadlineExceeded {
+		return workererrors.StatusErrorWrap(
+			types.SubmissionStatusInconclusive,
+			false,
+			fmt.Errorf("%s %w after %s", name, ErrStepTimedOut, timeout),
+		)
+	}
+	return err
+}
+
+// Build failures outside patches are errored, a build running out of time stays inconclusive
+func wrapBuildError(err error) error {
+	if errors.Is(err, ErrStepTimedOut) {
+		return err
+	}
+	return workererrors.StatusErrorWrap(types.SubmissionStatusErrored, false, err)
+}
+
+// Reason for the whole evaluation timing out, naming the step it was stuck in
+func (e *Evaluator) timedOutReason() string {
+	name, ok := e.running.Load().(types.EvalStepName)
+	if !ok {
+		return "evaluation timed out"
+	}
+	return fmt.Sprintf("evaluation timed out during %s step", name)
+}
+
+// Fetches and extracts the repo at `url`. `name` describes the repo in errors, which end up as
+// the reason for errored evaluations.
+func (e *Evaluator) fetchExtractRepo(
+	ctx context.Context,
+	name, url string,
+) (repoDir string, err error) {
 	ctx, span := tracer.Start(ctx, "Evaluator.fetchExtractRepo", trace.WithAttributes(
+		attribute.String("name", name),
 		attribute.String("url", url),
 	))
 	defer span.End()
 
+	done := e.startStep(ctx, types.EvalStepFetch, name, "")
+	defer func() { done(err) }()
+
 	repoCompressed, err := e.fetcher.Fetch(ctx, url)
 	if err != nil {
+		err = fmt.Errorf("failed to fetch %s: %w", name, err)
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to fetch url")
 		return "", err
 	}
-	defer repoCompressed.Close()
 
-	repoDir, err := os.MkdirTemp(e.tempDir, "repo-*")
+	repoDir, err = os.MkdirTemp(e.tempDir, "repo-*")
 	if err != nil {
+		repoCompressed.Close()
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to get tempdir")
 		return "", err
 	}
 
-	err = e.extractor.Extract(ctx, repoCompressed, repoDir)
-	if err != nil {
+	extractErr := e.extractor.Extract(ctx, repoCompressed, repoDir)
+	// closing verifies the whole download, a mismatch explains any extraction failure too
+	closeErr := repoCompressed.Close()
+	if errors.Is(closeErr, fetch.ErrHashMismatch) {
+		os.RemoveAll(repoDir)
+		err = fmt.Errorf("failed to fetch %s: %w", name, closeErr)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "fetched repo does not match expected sha256")
+		return "", err
+	}
+	if extractErr != nil {
+		os.RemoveAll(repoDir)
+		err = fmt.Errorf("failed to extract %s: %w", name, extractErr)
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to extract file to tempdir")
 		return "", err
@@ -355,12 +582,16 @@ func (e *Evaluator) fetchExtractRepo(ctx context.Context, url string) (string, e
 	return repoDir, nil
 }
 
-func (e *Evaluator) fetchFile(ctx context.Context, url string) (*os.File, error) {
+func (e *Evaluator) fetchFile(ctx context.Context, name, url string) (_ *os.File, err error) {
 	ctx, span := tracer.Start(ctx, "Evaluator.fetchFile", trace.WithAttributes(
+		attribute.String("name", name),
 		attribute.String("url", url),
 	))
 	defer span.End()
 
+	done := e.startStep(ctx, types.EvalStepFetch, name, "")
+	defer func() { done(err) }()
+
 	file, err := os.CreateTemp(e.tempDir, "file-*")
 	if err != nil {
 		span.RecordError(err)
@@ -371,15 +602,20 @@ func (e *Evaluator) fetchFile(ctx context.Context, url string) (*os.File, error)
 	buffer, err := e.fetcher.Fetch(ctx, url)
 	if err != nil {
 		file.Close()
+		err = fmt.Errorf("failed to fetch %s: %w", name, err)
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to fetch url")
 		return nil, err
 	}
-	defer buffer.Close()
 
 	_, err = io.Copy(file, buffer)
+	closeErr := buffer.Close()
+	if err == nil {
+		err = closeErr
+	}
 	if err != nil {
 		file.Close()
+		err = fmt.Errorf("failed to fetch %s: %w", name, err)
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to copy from body to file")
 		return nil, err
@@ -389,3 +625,58 @@ func (e *Evaluator) fetchFile(ctx context.Context, url string) (*os.File, error)
 	span.SetStatus(codes.Ok, "fetched file by url")
 	return file, nil
 }
+
+// Reports step `name` starting and returns a func reporting how it finished. Steps are only
+// informational, failing to report one is logged instead of failing the evaluation.
+func (e *Evaluator) startStep(
+	ctx context.Context,
+	name types.EvalStepName,
+	target string,
+	resultContext types.ResultContext,
+) func(error) {
+	step := types.EvalStep{
+		StartedAt: time.Now(),
+		Name:      name,
+		Outcome:   types.EvalStepRunning,
+		Target:    target,
+		Context:   resultContext,
+		Run:       e.run,
+		Seq:       e.steps,
+	}
+	e.steps++
+	e.running.Store(name)
+	e.sendStep(ctx, step)
+
+	return func(err error) {
+		outcome := types.EvalStepPassed
+		reason := ""
+		if err != nil {
+			outcome = types.EvalStepErrored
+			var se workererrors.StatusError
+			if errors.As(err, &se) && se.Status == types.SubmissionStatusFailed {
+				outcome = types.EvalStepFailed
+			}
+			if errors.Is(err, ErrStepTimedOut) {
+				outcome = types.EvalStepTimedOut
+			}
+			reason = err.Error()
+		}
+		// still report steps cut short by the evaluation timing out
+		e.sendStep(context.WithoutCancel(ctx), step.Finish(time.Now(), outcome, reason))
+	}
+}
+
+func (e *Evaluator) sendStep(ctx context.Context, step types.EvalStep) {
+	err := e.queuer.Step(ctx, step)
+	if err != nil {
+		logger.Logger.WarnContext(ctx,
+			"failed to report evaluation step",
+			"step",
+			step.Name,
+			"outcome",
+			step.Outcome,
+			"error",
+			err,
+		)
+	}
+}
@@ -2,8 +2,10 @@ package evaluate_test
 
 import (
 	"context"
+	"errors"
 	"io"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,6 +17,7 @@ import (
 	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/evaluate"
 	mockextract "github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/extract/mock"
 	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/workerqueue"
+	internalfetch "github.com/aixcyberchallenge/competition-api/competition-api/internal/fetch"
 	mockfetch "github.com/aixcyberchallenge/competition-api/competition-api/internal/fetch/mock"
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/identifier"
 	mockqueue "github.com/aixcyberchallenge/competition-api/competition-api/internal/queue/mock"
@@ -191,12 +194,19 @@ func TestEvaluatorDeltaJobPatchEval(t *testing.T) {
 		Do(runDataTest(t, &commonEngineParams, fuzzToolingDir, headRepoDir, types.ResultCtxHeadRepoTest)).
 		Times(1).
 		After(buildPatch).After(fetchTrigger).After(checkData)
+	fetchBaseline, baselineRepoDir := fetchAndExtract(tempDir, fetcher, extractor, headRepo)
+	baselineTests := engineMock.
+		EXPECT().
+		RunTests(gomock.Any(), gomock.Any(), gomock.Eq(true)).
+		Do(testDataTest(t, &commonEngineParams, fuzzToolingDir, baselineRepoDir, types.ResultCtxTestBaseline)).
+		Times(1).
+		After(buildPatch).After(fetchBaseline)
 	_ = engineMock.
 		EXPECT().
 		RunTests(gomock.Any(), gomock.Any(), gomock.Eq(true)).
 		Do(testDataTest(t, &commonEngineParams, fuzzToolingDir, headRepoDir, types.ResultCtxHeadRepoTest)).
 		Times(1).
-		After(buildPatch).After(checkData)
+		After(baselineTests).After(checkData)
 
 	evaluator := evaluate.NewEvaluator(
 		fetcher,
@@ -214,6 +224,7 @@ func TestEvaluatorDeltaJobPatchEval(t *testing.T) {
 		trigger,
 		patch,
 		false,
+		false,
 		&commonEngineParams,
 	)
 	if err != nil {
@@ -286,6 +297,7 @@ func TestEvaluatorDeltaScanPov(t *testing.T) {
 		trigger,
 		"",
 		true,
+		false,
 		&commonEngineParams,
 	)
 	if err != nil {
@@ -348,6 +360,57 @@ func TestEvaluatorFullScanPov(t *testing.T) {
 		trigger,
 		"",
 		true,
+		false,
+		&commonEngineParams,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// No Trigger or Patch
+func TestEvaluatorBuildHead(t *testing.T) {
+	tempDir := t.TempDir()
+	ctrl := gomock.NewController(t)
+
+	fetcher := mockfetch.NewMockFetcher(ctrl)
+	extractor := mockextract.NewMockExtractor(ctrl)
+	engineMock := mockengine.NewMockEngine(ctrl)
+	queuer := mockqueue.NewMockQueuer(ctrl)
+	queuer.EXPECT().Enqueue(gomock.Any(), gomock.Any()).MinTimes(1)
+
+	fetchFuzzTooling, fuzzToolingDir := fetchAndExtract(tempDir, fetcher, extractor, fuzzTooling)
+	fetchHeadRepo, headRepoDir := fetchAndExtract(tempDir, fetcher, extractor, headRepo)
+
+	checkData := engineMock.
+		EXPECT().
+		Check(gomock.Any(), gomock.Any()).
+		Do(checkDataTest(t, &commonEngineParams, fuzzToolingDir, headRepoDir, types.ResultCtxHeadRepoTest)).
+		Times(1).After(fetchFuzzTooling).After(fetchHeadRepo)
+	_ = engineMock.
+		EXPECT().
+		Build(gomock.Any(), gomock.Any()).
+		Do(buildDataTest(t, &commonEngineParams, fuzzToolingDir, headRepoDir, types.ResultCtxHeadRepoTest)).
+		Times(1).
+		After(checkData)
+
+	evaluator := evaluate.NewEvaluator(
+		fetcher,
+		extractor,
+		tempDir,
+		engineMock,
+		workerqueue.NewWorkerQueue(entityID, entityType, queuer),
+	)
+
+	err := evaluator.Evaluate(
+		context.Background(),
+		fuzzTooling,
+		headRepo,
+		"",
+		"",
+		"",
+		false,
+		true,
 		&commonEngineParams,
 	)
 	if err != nil {
@@ -392,12 +455,19 @@ func TestEvaluatorDeltaPatch(t *testing.T) {
 		Do(buildDataTest(t, &commonEngineParams, fuzzToolingDir, headRepoDir, types.ResultCtxHeadRepoTest)).
 		Times(1).
 		After(applyPatch).After(fetchFuzzTooling).After(checkData)
+	fetchBaseline, baselineRepoDir := fetchAndExtract(tempDir, fetcher, extractor, headRepo)
+	baselineTests := engineMock.
+		EXPECT().
+		RunTests(gomock.Any(), gomock.Any(), gomock.Eq(true)).
+		Do(testDataTest(t, &commonEngineParams, fuzzToolingDir, baselineRepoDir, types.ResultCtxTestBaseline)).
+		Times(1).
+		After(buildPatch).After(fetchBaseline)
 	_ = engineMock.
 		EXPECT().
 		RunTests(gomock.Any(), gomock.Any(), gomock.Eq(true)).
 		Do(testDataTest(t, &commonEngineParams, fuzzToolingDir, headRepoDir, types.ResultCtxHeadRepoTest)).
 		Times(1).
-		After(buildPatch).After(checkData)
+		After(baselineTests).After(checkData)
 
 	evaluator := evaluate.NewEvaluator(
 		fetcher,
@@ -415,6 +485,7 @@ func TestEvaluatorDeltaPatch(t *testing.T) {
 		"",
 		patch,
 		false,
+		false,
 		&commonEngineParams,
 	)
 	if err != nil {
@@ -460,12 +531,19 @@ func TestEvaluatorFullPatch(t *testing.T) {
 		Do(buildDataTest(t, &commonEngineParams, fuzzToolingDir, headRepoDir, types.ResultCtxHeadRepoTest)).
 		Times(1).
 		After(applyPatch).After(fetchFuzzTooling).After(checkData)
+	fetchBaseline, baselineRepoDir := fetchAndExtract(tempDir, fetcher, extractor, headRepo)
+	baselineTests := engineMock.
+		EXPECT().
+		RunTests(gomock.Any(), gomock.Any(), gomock.Eq(true)).
+		Do(testDataTest(t, &commonEngineParams, fuzzToolingDir, baselineRepoDir, types.ResultCtxTestBaseline)).
+		Times(1).
+		After(buildPatch).After(fetchBaseline)
 	_ = engineMock.
 		EXPECT().
 		RunTests(gomock.Any(), gomock.Any(), gomock.Eq(true)).
 		Do(testDataTest(t, &commonEngineParams, fuzzToolingDir, headRepoDir, types.ResultCtxHeadRepoTest)).
 		Times(1).
-		After(buildPatch).After(checkData)
+		After(baselineTests).After(checkData)
 
 	evaluator := evaluate.NewEvaluator(
 		fetcher,
@@ -483,6 +561,7 @@ func TestEvaluatorFullPatch(t *testing.T) {
 		"",
 		patch,
 		false,
+		false,
 		&commonEngineParams,
 	)
 	if err != nil {
@@ -493,18 +572,20 @@ func TestEvaluatorFullPatch(t *testing.T) {
 func TestEvaluatorFullPatchLowTimeout(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
-	expected := types.NewWorkerMsgFinal(
-		entityType,
-		entityID,
-		types.SubmissionStatusInconclusive,
-		nil,
-	)
 	fetcher := mockfetch.NewMockFetcher(ctrl)
 	extractor := mockextract.NewMockExtractor(ctrl)
 	engineMock := mockengine.NewMockEngine(ctrl)
 	queuer := mockqueue.NewMockQueuer(ctrl)
-	queuer.EXPECT().Enqueue(gomock.Any(), gomock.Eq(expected)).Times(1)
-	queuer.EXPECT().Enqueue(gomock.Any(), gomock.Any()).AnyTimes()
+	var final types.WorkerMsgFinal
+	queuer.EXPECT().
+		Enqueue(gomock.Any(), gomock.AssignableToTypeOf(types.WorkerMsgFinal{})).
+		Do(func(_ context.Context, msg any) {
+			final = msg.(types.WorkerMsgFinal)
+		}).
+		Times(1)
+	queuer.EXPECT().
+		Enqueue(gomock.Any(), gomock.AssignableToTypeOf(types.WorkerMsgStep{})).
+		AnyTimes()
 	fetcher.EXPECT().
 		Fetch(gomock.Any(), gomock.Any()).
 		DoAndReturn(func(_ context.Context, _ string) (io.ReadCloser, error) {
@@ -535,11 +616,309 @@ func TestEvaluatorFullPatchLowTimeout(t *testing.T) {
 		"",
 		patch,
 		false,
+		false,
 		&commonEngineParams,
 	)
 	if err != nil {
 		t.Fatal(err)
 	}
+
+	assert.Equal(t, types.SubmissionStatusInconclusive, final.Status)
+	assert.Contains(t, final.Reason, "evaluation timed out")
+}
+
+// A cancelled evaluation sends no final status, it is run again rather than judged
+func TestEvaluatorCancelled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	fetcher := mockfetch.NewMockFetcher(ctrl)
+	extractor := mockextract.NewMockExtractor(ctrl)
+	engineMock := mockengine.NewMockEngine(ctrl)
+	queuer := mockqueue.NewMockQueuer(ctrl)
+	queuer.EXPECT().
+		Enqueue(gomock.Any(), gomock.AssignableToTypeOf(types.WorkerMsgFinal{})).
+		Times(0)
+	queuer.EXPECT().
+		Enqueue(gomock.Any(), gomock.AssignableToTypeOf(types.WorkerMsgStep{})).
+		AnyTimes()
+	fetcher.EXPECT().
+		Fetch(gomock.Any(), gomock.Any()).
+		Return(nil, context.Canceled).
+		AnyTimes()
+
+	evaluator := evaluate.NewEvaluator(
+		fetcher,
+		extractor,
+		"",
+		engineMock,
+		workerqueue.NewWorkerQueue(entityID, entityType, queuer),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := evaluator.Evaluate(
+		ctx,
+		fuzzTooling,
+		headRepo,
+		"",
+		trigger,
+		"",
+		false,
+		false,
+		&commonEngineParams,
+	)
+	assert.ErrorIs(t, err, evaluate.ErrCancelled)
+}
+
+// The build running past its own timeout cuts the evaluation short as inconclusive
+func TestEvaluatorStepTimeout(t *testing.T) {
+	tempDir := t.TempDir()
+	ctrl := gomock.NewController(t)
+
+	fetcher := mockfetch.NewMockFetcher(ctrl)
+	extractor := mockextract.NewMockExtractor(ctrl)
+	engineMock := mockengine.NewMockEngine(ctrl)
+	queuer := mockqueue.NewMockQueuer(ctrl)
+
+	var final types.WorkerMsgFinal
+	var steps []types.EvalStep
+	queuer.EXPECT().
+		Enqueue(gomock.Any(), gomock.AssignableToTypeOf(types.WorkerMsgFinal{})).
+		Do(func(_ context.Context, msg any) {
+			final = msg.(types.WorkerMsgFinal)
+		}).
+		Times(1)
+	queuer.EXPECT().
+		Enqueue(gomock.Any(), gomock.AssignableToTypeOf(types.WorkerMsgStep{})).
+		Do(func(_ context.Context, msg any) {
+			steps = append(steps, msg.(types.WorkerMsgStep).Step)
+		}).
+		AnyTimes()
+
+	fetchAndExtract(tempDir, fetcher, extractor, fuzzTooling)
+	fetchAndExtract(tempDir, fetcher, extractor, headRepo)
+	fetch(tempDir, fetcher, trigger)
+	engineMock.EXPECT().Check(gomock.Any(), gomock.Any()).Times(1)
+	engineMock.EXPECT().
+		Build(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ *engine.Params) error {
+			<-ctx.Done()
+			return workererrors.StatusErrorWrap(types.SubmissionStatusErrored, false, ctx.Err())
+		}).
+		Times(1)
+	engineMock.EXPECT().RunPov(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	evaluator := evaluate.NewEvaluator(
+		fetcher,
+		extractor,
+		tempDir,
+		engineMock,
+		workerqueue.NewWorkerQueue(entityID, entityType, queuer),
+	)
+
+	params := commonEngineParams.WithTimeouts(types.EvalTimeouts{BuildSecs: 1, TotalSecs: 60})
+	err := evaluator.Evaluate(
+		context.Background(),
+		fuzzTooling,
+		headRepo,
+		"",
+		trigger,
+		"",
+		false,
+		false,
+		&params,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, types.SubmissionStatusInconclusive, final.Status)
+	assert.Contains(t, final.Reason, "build step timed out after 1s")
+	last := steps[len(steps)-1]
+	assert.Equal(t, types.EvalStepBuild, last.Name)
+	assert.Equal(t, types.EvalStepTimedOut, last.Outcome)
+}
+
+// Tests already failing on the unpatched head don't fail the patch, they become the baseline
+// the patched tests are judged against
+func TestEvaluatorPatchTestBaseline(t *testing.T) {
+	tempDir := t.TempDir()
+	ctrl := gomock.NewController(t)
+
+	fetcher := mockfetch.NewMockFetcher(ctrl)
+	extractor := mockextract.NewMockExtractor(ctrl)
+	engineMock := mockengine.NewMockEngine(ctrl)
+	queuer := mockqueue.NewMockQueuer(ctrl)
+
+	var final types.WorkerMsgFinal
+	var steps []types.EvalStep
+	queuer.EXPECT().
+		Enqueue(gomock.Any(), gomock.AssignableToTypeOf(types.WorkerMsgFinal{})).
+		Do(func(_ context.Context, msg any) {
+			final = msg.(types.WorkerMsgFinal)
+		}).
+		Times(1)
+	queuer.EXPECT().
+		Enqueue(gomock.Any(), gomock.AssignableToTypeOf(types.WorkerMsgStep{})).
+		Do(func(_ context.Context, msg any) {
+			steps = append(steps, msg.(types.WorkerMsgStep).Step)
+		}).
+		AnyTimes()
+
+	baseline := &types.TestResults{
+		Format: types.TestFormatCTest,
+		Cases: []types.TestCase{
+			{Name: "parser_test", Outcome: types.TestPassed},
+			{Name: "network_test", Outcome: types.TestFailed},
+		},
+	}
+
+	_, fuzzToolingDir := fetchAndExtract(tempDir, fetcher, extractor, fuzzTooling)
+	_, headRepoDir := fetchAndExtract(tempDir, fetcher, extractor, headRepo)
+	fetch(tempDir, fetcher, patch)
+	engineMock.EXPECT().Check(gomock.Any(), gomock.Any()).Times(1)
+	engineMock.EXPECT().ApplyPatch(gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
+	buildPatch := engineMock.EXPECT().Build(gomock.Any(), gomock.Any()).Times(1)
+	fetchBaseline, baselineRepoDir := fetchAndExtract(tempDir, fetcher, extractor, headRepo)
+	baselineTests := engineMock.
+		EXPECT().
+		RunTests(gomock.Any(), gomock.Any(), gomock.Eq(true)).
+		Do(testDataTest(t, &commonEngineParams, fuzzToolingDir, baselineRepoDir, types.ResultCtxTestBaseline)).
+		Return(baseline, workererrors.StatusErrorWrap(types.SubmissionStatusFailed, true, nil)).
+		Times(1).
+		After(buildPatch).After(fetchBaseline)
+	engineMock.
+		EXPECT().
+		RunTests(gomock.Any(), gomock.Any(), gomock.Eq(true)).
+		Do(func(_ context.Context, actual *engine.Params, _ bool) {
+			expected := commonEngineParams.
+				WithFuzzToolingDir(*fuzzToolingDir).
+				WithRepo(types.ResultCtxHeadRepoTest, *headRepoDir).
+				WithTestBaseline(baseline)
+			assert.Equal(t, &expected, actual, "wrong challenge data")
+		}).
+		Times(1).
+		After(baselineTests)
+
+	evaluator := evaluate.NewEvaluator(
+		fetcher,
+		extractor,
+		tempDir,
+		engineMock,
+		workerqueue.NewWorkerQueue(entityID, entityType, queuer),
+	)
+
+	err := evaluator.Evaluate(
+		context.Background(),
+		fuzzTooling,
+		headRepo,
+		"",
+		"",
+		patch,
+		false,
+		false,
+		&commonEngineParams,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, types.SubmissionStatusPassed, final.Status)
+	assert.False(t, final.PatchTestsFailed)
+
+	var baselineStep *types.EvalStep
+	for i := range steps {
+		if steps[i].Context == types.ResultCtxTestBaseline && steps[i].Outcome != types.EvalStepRunning {
+			baselineStep = &steps[i]
+		}
+	}
+	if assert.NotNil(t, baselineStep) {
+		assert.Equal(t, types.EvalStepRunTests, baselineStep.Name)
+		assert.Equal(t, types.EvalStepFailed, baselineStep.Outcome)
+	}
+}
+
+// an engine whose test baseline is always cached
+type cachedBaselineEngine struct {
+	*mockengine.MockEngine
+	baseline *types.TestResults
+}
+
+func (c cachedBaselineEngine) CachedTestBaseline(
+	context.Context,
+	*engine.Params,
+) (*types.TestResults, bool, error) {
+	return c.baseline, true, nil
+}
+
+// A cached test baseline doesn't fetch the head repo a second time
+func TestEvaluatorCachedTestBaseline(t *testing.T) {
+	tempDir := t.TempDir()
+	ctrl := gomock.NewController(t)
+
+	fetcher := mockfetch.NewMockFetcher(ctrl)
+	extractor := mockextract.NewMockExtractor(ctrl)
+	engineMock := mockengine.NewMockEngine(ctrl)
+	queuer := mockqueue.NewMockQueuer(ctrl)
+
+	var final types.WorkerMsgFinal
+	queuer.EXPECT().
+		Enqueue(gomock.Any(), gomock.AssignableToTypeOf(types.WorkerMsgFinal{})).
+		Do(func(_ context.Context, msg any) {
+			final = msg.(types.WorkerMsgFinal)
+		}).
+		Times(1)
+	queuer.EXPECT().
+		Enqueue(gomock.Any(), gomock.AssignableToTypeOf(types.WorkerMsgStep{})).
+		AnyTimes()
+
+	baseline := &types.TestResults{
+		Format: types.TestFormatCTest,
+		Cases:  []types.TestCase{{Name: "parser_test", Outcome: types.TestPassed}},
+	}
+
+	_, fuzzToolingDir := fetchAndExtract(tempDir, fetcher, extractor, fuzzTooling)
+	_, headRepoDir := fetchAndExtract(tempDir, fetcher, extractor, headRepo)
+	fetch(tempDir, fetcher, patch)
+	engineMock.EXPECT().Check(gomock.Any(), gomock.Any()).Times(1)
+	engineMock.EXPECT().ApplyPatch(gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
+	engineMock.EXPECT().Build(gomock.Any(), gomock.Any()).Times(1)
+	engineMock.
+		EXPECT().
+		RunTests(gomock.Any(), gomock.Any(), gomock.Eq(true)).
+		Do(func(_ context.Context, actual *engine.Params, _ bool) {
+			expected := commonEngineParams.
+				WithFuzzToolingDir(*fuzzToolingDir).
+				WithRepo(types.ResultCtxHeadRepoTest, *headRepoDir).
+				WithTestBaseline(baseline)
+			assert.Equal(t, &expected, actual, "wrong challenge data")
+		}).
+		Times(1)
+
+	evaluator := evaluate.NewEvaluator(
+		fetcher,
+		extractor,
+		tempDir,
+		cachedBaselineEngine{MockEngine: engineMock, baseline: baseline},
+		workerqueue.NewWorkerQueue(entityID, entityType, queuer),
+	)
+
+	err := evaluator.Evaluate(
+		context.Background(),
+		fuzzTooling,
+		headRepo,
+		"",
+		"",
+		patch,
+		false,
+		false,
+		&commonEngineParams,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, types.SubmissionStatusPassed, final.Status)
 }
 
 // No Trigger
@@ -553,8 +932,11 @@ func TestEvaluatorFullPatchBuildFailed(t *testing.T) {
 	engineMock := mockengine.NewMockEngine(ctrl)
 	queuer := mockqueue.NewMockQueuer(ctrl)
 	queuer.EXPECT().
-		Enqueue(gomock.Any(), gomock.Eq(types.NewWorkerMsgFinal(entityType, entityID, types.SubmissionStatusFailed, nil))).
+		Enqueue(gomock.Any(), gomock.Eq(types.NewWorkerMsgFinal(entityType, entityID, types.SubmissionStatusFailed, nil, ""))).
 		Times(1)
+	queuer.EXPECT().
+		Enqueue(gomock.Any(), gomock.AssignableToTypeOf(types.WorkerMsgStep{})).
+		AnyTimes()
 
 	fetchFuzzTooling, fuzzToolingDir := fetchAndExtract(tempDir, fetcher, extractor, fuzzTooling)
 	fetchHeadRepo, headRepoDir := fetchAndExtract(tempDir, fetcher, extractor, headRepo)
@@ -600,6 +982,7 @@ func TestEvaluatorFullPatchBuildFailed(t *testing.T) {
 		"",
 		patch,
 		false,
+		false,
 		&commonEngineParams,
 	)
 	if err != nil {
@@ -674,9 +1057,155 @@ func TestEvaluatorFullPatchSkipTests(t *testing.T) {
 		trigger,
 		patch,
 		true,
+		false,
+		&commonEngineParams,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEvaluatorHashMismatch(t *testing.T) {
+	tempDir := t.TempDir()
+	ctrl := gomock.NewController(t)
+
+	mockFetcher := mockfetch.NewMockFetcher(ctrl)
+	extractor := mockextract.NewMockExtractor(ctrl)
+	engineMock := mockengine.NewMockEngine(ctrl)
+	queuer := mockqueue.NewMockQueuer(ctrl)
+
+	var final types.WorkerMsgFinal
+	queuer.EXPECT().
+		Enqueue(gomock.Any(), gomock.AssignableToTypeOf(types.WorkerMsgFinal{})).
+		Do(func(_ context.Context, msg any) {
+			final = msg.(types.WorkerMsgFinal)
+		}).
+		Times(1)
+	queuer.EXPECT().
+		Enqueue(gomock.Any(), gomock.AssignableToTypeOf(types.WorkerMsgStep{})).
+		AnyTimes()
+
+	fetchFuzzTooling, _ := fetchAndExtract(tempDir, mockFetcher, extractor, fuzzTooling)
+	// the swapped head repo extracts fine, only its hash gives it away
+	fetch(tempDir, mockFetcher, headRepo)
+	extractor.EXPECT().
+		Extract(gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(1).
+		After(fetchFuzzTooling)
+	engineMock.EXPECT().Check(gomock.Any(), gomock.Any()).Times(0)
+
+	evaluator := evaluate.NewEvaluator(
+		internalfetch.NewVerifyingFetcher(mockFetcher, map[string]string{
+			headRepo: strings.Repeat("0", 64),
+		}),
+		extractor,
+		tempDir,
+		engineMock,
+		workerqueue.NewWorkerQueue(entityID, entityType, queuer),
+	)
+
+	err := evaluator.Evaluate(
+		context.Background(),
+		fuzzTooling,
+		headRepo,
+		"",
+		"",
+		"",
+		false,
+		true,
+		&commonEngineParams,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, types.SubmissionStatusErrored, final.Status)
+	assert.Contains(t, final.Reason, "failed to fetch head repo")
+	assert.Contains(t, final.Reason, internalfetch.ErrHashMismatch.Error())
+}
+
+func TestEvaluatorSteps(t *testing.T) {
+	tempDir := t.TempDir()
+	ctrl := gomock.NewController(t)
+
+	fetcher := mockfetch.NewMockFetcher(ctrl)
+	extractor := mockextract.NewMockExtractor(ctrl)
+	engineMock := mockengine.NewMockEngine(ctrl)
+	queuer := mockqueue.NewMockQueuer(ctrl)
+
+	var steps []types.EvalStep
+	queuer.EXPECT().
+		Enqueue(gomock.Any(), gomock.AssignableToTypeOf(types.WorkerMsgStep{})).
+		Do(func(_ context.Context, msg any) {
+			steps = append(steps, msg.(types.WorkerMsgStep).Step)
+		}).
+		AnyTimes()
+	queuer.EXPECT().
+		Enqueue(gomock.Any(), gomock.AssignableToTypeOf(types.WorkerMsgFinal{})).
+		Times(1)
+
+	fetchAndExtract(tempDir, fetcher, extractor, fuzzTooling)
+	fetchAndExtract(tempDir, fetcher, extractor, headRepo)
+	fetch(tempDir, fetcher, trigger)
+	engineMock.EXPECT().Check(gomock.Any(), gomock.Any()).Times(1)
+	engineMock.EXPECT().Build(gomock.Any(), gomock.Any()).Times(1)
+	engineMock.EXPECT().
+		RunPov(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Eq(true)).
+		Return(workererrors.StatusErrorWrap(types.SubmissionStatusFailed, false, errors.New("no crash"))).
+		Times(1)
+
+	evaluator := evaluate.NewEvaluator(
+		fetcher,
+		extractor,
+		tempDir,
+		engineMock,
+		workerqueue.NewWorkerQueue(entityID, entityType, queuer),
+	)
+
+	err := evaluator.Evaluate(
+		context.Background(),
+		fuzzTooling,
+		headRepo,
+		"",
+		trigger,
+		"",
+		false,
+		false,
 		&commonEngineParams,
 	)
 	if err != nil {
 		t.Fatal(err)
 	}
+
+	type reported struct {
+		name    types.EvalStepName
+		outcome types.EvalStepOutcome
+		target  string
+		seq     int
+	}
+	got := make([]reported, 0, len(steps))
+	for _, step := range steps {
+		got = append(got, reported{step.Name, step.Outcome, step.Target, step.Seq})
+		if step.Outcome == types.EvalStepRunning {
+			assert.Nil(t, step.FinishedAt, "running steps should not be finished")
+		} else {
+			assert.NotNil(t, step.FinishedAt, "finished steps should say when")
+		}
+	}
+	assert.Equal(t, []reported{
+		{types.EvalStepFetch, types.EvalStepRunning, "fuzz tooling", 0},
+		{types.EvalStepFetch, types.EvalStepPassed, "fuzz tooling", 0},
+		{types.EvalStepFetch, types.EvalStepRunning, "head repo", 1},
+		{types.EvalStepFetch, types.EvalStepPassed, "head repo", 1},
+		{types.EvalStepCheck, types.EvalStepRunning, "", 2},
+		{types.EvalStepCheck, types.EvalStepPassed, "", 2},
+		{types.EvalStepFetch, types.EvalStepRunning, "trigger", 3},
+		{types.EvalStepFetch, types.EvalStepPassed, "trigger", 3},
+		{types.EvalStepBuild, types.EvalStepRunning, "", 4},
+		{types.EvalStepBuild, types.EvalStepPassed, "", 4},
+		{types.EvalStepRunPov, types.EvalStepRunning, "", 5},
+		{types.EvalStepRunPov, types.EvalStepFailed, "", 5},
+	}, got)
+	assert.Equal(t, types.ResultCtxHeadRepoTest, steps[len(steps)-1].Context)
+	assert.Contains(t, steps[len(steps)-1].Error, "no crash")
 }
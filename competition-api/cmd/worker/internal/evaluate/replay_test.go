@@ -0,0 +1,247 @@
+package evaluate_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/command"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/engine"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/evaluate"
+	mockextract "github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/extract/mock"
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/workerqueue"
+	mockfetch "github.com/aixcyberchallenge/competition-api/competition-api/internal/fetch/mock"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/identifier"
+	mockqueue "github.com/aixcyberchallenge/competition-api/competition-api/internal/queue/mock"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+	mockupload "github.com/aixcyberchallenge/competition-api/competition-api/internal/upload/mock"
+)
+
+// Extracts the files of a tarball, by path within it, instead of reading the tarball
+func extractFiles(
+	t *testing.T,
+	tempDir string,
+	fetcher *mockfetch.MockFetcher,
+	extractor *mockextract.MockExtractor,
+	url string,
+	files map[string]string,
+) {
+	_, f := fetch(tempDir, fetcher, url)
+	extractor.EXPECT().
+		Extract(gomock.Any(), gomock.Eq(f), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ io.Reader, outDir string) error {
+			for name, content := range files {
+				path := filepath.Join(outDir, name)
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+				require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+			}
+			return nil
+		}).
+		Times(1)
+}
+
+// Everything the worker reports to the server during an evaluation
+type reports struct {
+	final     *types.WorkerMsgFinal
+	results   []*types.JobResult
+	artifacts []types.JobArtifact
+}
+
+func reportingQueuer(ctrl *gomock.Controller) (*mockqueue.MockQueuer, *reports) {
+	queuer := mockqueue.NewMockQueuer(ctrl)
+	got := &reports{}
+	queuer.EXPECT().
+		Enqueue(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, msg any) {
+			switch msg := msg.(type) {
+			case types.WorkerMsgCommandResult:
+				got.results = append(got.results, msg.Result)
+			case types.WorkerMsgArtifact:
+				got.artifacts = append(got.artifacts, msg.Artifact)
+			case types.WorkerMsgFinal:
+				got.final = &msg
+			}
+		}).
+		AnyTimes()
+	return queuer, got
+}
+
+// The fixtures in testdata/replay are written by hand after what the scripts and helper.py
+// print, down to which stream each line goes to, they were not recorded. Replace them with real
+// recordings of `worker eval --record-commands` against mock-c once one can be made.
+
+// A full scan POV evaluated by the real engine against the output of the scripts
+func TestEvaluatorReplayFullScanPov(t *testing.T) {
+	tempDir := t.TempDir()
+	ctrl := gomock.NewController(t)
+
+	replay, err := command.NewReplayExecutor(filepath.Join("testdata", "replay", "full_scan_pov"))
+	require.NoError(t, err)
+
+	fetcher := mockfetch.NewMockFetcher(ctrl)
+	extractor := mockextract.NewMockExtractor(ctrl)
+	uploader := mockupload.NewMockUploader(ctrl)
+	uploader.EXPECT().Exists(gomock.Any(), gomock.Any()).Return(false, nil).AnyTimes()
+	uploader.EXPECT().Upload(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	queuer, got := reportingQueuer(ctrl)
+
+	extractFiles(t, tempDir, fetcher, extractor, fuzzTooling, map[string]string{
+		"oss-fuzz/projects/mock-c/project.yaml": "language: c\nsanitizers: [address]\n",
+	})
+	extractFiles(t, tempDir, fetcher, extractor, headRepo, map[string]string{
+		"focus/.aixcc/challenge.yaml": "fuzz_tooling_project_name: mock-c\n" +
+			"fuzz_tooling_url: url\n" +
+			"fuzz_tooling_ref: ref\n" +
+			"harnesses: [fuzz_process_input]\n",
+	})
+	fetch(tempDir, fetcher, trigger)
+
+	workerqueuer := workerqueue.NewWorkerQueue(entityID, types.JobTypePOV, queuer)
+	evaluator := evaluate.NewEvaluator(
+		fetcher,
+		extractor,
+		tempDir,
+		engine.NewAixccEngine(replay, uploader, workerqueuer, types.JobTypePOV),
+		workerqueuer,
+	)
+
+	params := engine.NewParams(
+		"address",
+		string(types.ArchitectureX8664),
+		string(types.FuzzingEngineLibFuzzer),
+		"fuzz_process_input",
+		"mock-c",
+		focus,
+		nil,
+	)
+	err = evaluator.Evaluate(
+		context.Background(),
+		fuzzTooling,
+		headRepo,
+		"",
+		trigger,
+		"",
+		true,
+		false,
+		&params,
+	)
+	require.NoError(t, err)
+	assert.Zero(t, replay.Unplayed(), "every recorded command should be run")
+
+	require.NotNil(t, got.final)
+	assert.Equal(t, types.SubmissionStatusPassed, got.final.Status)
+
+	require.Len(t, got.results, 2)
+	assert.Equal(t, types.ResultCtxHeadRepoBuild, got.results[0].Context)
+	assert.Equal(t, types.ResultCtxHeadRepoTest, got.results[1].Context)
+	assert.Equal(t, &types.ResourceUsage{WallTimeMs: 6120}, got.results[1].Usage)
+
+	require.Len(t, got.artifacts, 1)
+	crash := got.artifacts[0].Crash
+	require.NotNil(t, crash, "recorded fuzz.out should be parsed")
+	assert.Equal(t, "stack-buffer-overflow", crash.Type)
+	assert.Equal(t, types.FileFuzzOutHead, got.artifacts[0].ArchivedFile)
+}
+
+// A patch whose functionality tests fail on the unpatched head the same way, replayed from
+// apply through both test runs
+func TestEvaluatorReplayPatchWithTests(t *testing.T) {
+	tempDir := t.TempDir()
+	ctrl := gomock.NewController(t)
+
+	replay, err := command.NewReplayExecutor(
+		filepath.Join("testdata", "replay", "patch_with_tests"),
+	)
+	require.NoError(t, err)
+
+	fetcher := mockfetch.NewMockFetcher(ctrl)
+	extractor := mockextract.NewMockExtractor(ctrl)
+	uploader := mockupload.NewMockUploader(ctrl)
+	uploader.EXPECT().Exists(gomock.Any(), gomock.Any()).Return(false, nil).AnyTimes()
+	uploader.EXPECT().Upload(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	queuer, got := reportingQueuer(ctrl)
+
+	headFiles := map[string]string{
+		"focus/.aixcc/challenge.yaml": "fuzz_tooling_project_name: mock-c\n" +
+			"fuzz_tooling_url: url\n" +
+			"fuzz_tooling_ref: ref\n" +
+			"harnesses: [fuzz_process_input]\n",
+		"focus/mock.c": "#include <string.h>\n\nvoid process_input(char *input, size_t len) {\n" +
+			"  char buf[10];\n  memcpy(buf, input, len);\n}\n",
+	}
+	extractFiles(t, tempDir, fetcher, extractor, fuzzTooling, map[string]string{
+		"oss-fuzz/projects/mock-c/project.yaml": "language: c\nsanitizers: [address]\n",
+	})
+	extractFiles(t, tempDir, fetcher, extractor, headRepo, headFiles)
+	_, patchFile := fetch(tempDir, fetcher, patch)
+	_, err = patchFile.WriteString(`diff --git a/mock.c b/mock.c
+--- a/mock.c
++++ b/mock.c
+@@ -3,4 +3,6 @@
+ void process_input(char *input, size_t len) {
+   char buf[10];
+-  memcpy(buf, input, len);
++  if (len <= sizeof(buf)) {
++    memcpy(buf, input, len);
++  }
+ }
+`)
+	require.NoError(t, err)
+	_, err = patchFile.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	// the baseline runs on a fresh copy of the head repo
+	extractFiles(t, tempDir, fetcher, extractor, headRepo, headFiles)
+
+	workerqueuer := workerqueue.NewWorkerQueue(entityID, types.JobTypePatch, queuer)
+	evaluator := evaluate.NewEvaluator(
+		fetcher,
+		extractor,
+		tempDir,
+		engine.NewAixccEngine(replay, uploader, workerqueuer, types.JobTypePatch),
+		workerqueuer,
+	)
+
+	params := engine.NewParams(
+		"address",
+		string(types.ArchitectureX8664),
+		string(types.FuzzingEngineLibFuzzer),
+		"fuzz_process_input",
+		"mock-c",
+		focus,
+		identifier.LanguageSlice{identifier.LanguageC},
+	)
+	err = evaluator.Evaluate(
+		context.Background(),
+		fuzzTooling,
+		headRepo,
+		"",
+		"",
+		patch,
+		false,
+		false,
+		&params,
+	)
+	require.NoError(t, err)
+	assert.Zero(t, replay.Unplayed(), "every recorded command should be run")
+
+	require.NotNil(t, got.final)
+	assert.Equal(t, types.SubmissionStatusPassed, got.final.Status)
+	assert.False(t, got.final.PatchTestsFailed, "the failing test already failed on the head")
+
+	contexts := make([]types.ResultContext, 0, len(got.results))
+	for _, result := range got.results {
+		contexts = append(contexts, result.Context)
+	}
+	assert.Equal(t, []types.ResultContext{
+		types.ResultCtxApplyPatch,
+		types.ResultCtxHeadRepoBuild,
+		types.ResultCtxTestBaseline,
+		types.ResultCtxRunTests,
+	}, contexts)
+}
@@ -0,0 +1,363 @@
+package extract
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Ensure NativeTarGzExtractor implements Extractor interface.
+var _ Extractor = (*NativeTarGzExtractor)(nil)
+
+var (
+	ErrLimitExceeded = errors.New("archive exceeds extraction limit")
+	ErrUnsafeEntry   = errors.New("archive entry escapes output directory")
+)
+
+const (
+	// How many entries are extracted between progress events
+	progressInterval = 1000
+	// Symlinks followed while resolving a single path before giving up, matching Linux's limit
+	maxSymlinkHops = 40
+)
+
+// Limits on what an archive may extract to. Zero or negative values are unlimited.
+type Limits struct {
+	// Total size of regular files
+	MaxBytes int64
+	// Number of entries of any type
+	MaxFiles int
+	// Number of path components in an entry name
+	MaxDepth int
+}
+
+var DefaultLimits = Limits{
+	MaxBytes: 32 << 30,
+	MaxFiles: 1_000_000,
+	MaxDepth: 64,
+}
+
+// .tar.gz extractor that does not shell out. Every entry is written through an [os.Root] so
+// nothing is written outside the output directory, and symlinks that would resolve outside it are
+// rejected.
+type NativeTarGzExtractor struct {
+	limits Limits
+}
+
+func NewNativeTarGzExtractor(limits Limits) *NativeTarGzExtractor {
+	return &NativeTarGzExtractor{
+		limits: limits,
+	}
+}
+
+type extraction struct {
+	root     *os.Root
+	span     trace.Span
+	outDir   string
+	symlinks []string
+	limits   Limits
+	bytes    int64
+	files    int
+}
+
+func (e *NativeTarGzExtractor) Extract(ctx context.Context, reader io.Reader, outDir string) error {
+	ctx, span := tracer.Start(ctx, "NativeTarGzExtractor.Extract", trace.WithAttributes(
+		attribute.String("outDir", outDir),
+		attribute.Int64("limits.maxBytes", e.limits.MaxBytes),
+		attribute.Int("limits.maxFiles", e.limits.MaxFiles),
+		attribute.Int("limits.maxDepth", e.limits.MaxDepth),
+	))
+	defer span.End()
+
+	root, err := os.OpenRoot(outDir)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to open output dir")
+		return err
+	}
+	defer root.Close()
+
+	gz, err := gzip.NewReader(reader)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to read gzip header")
+		return err
+	}
+	defer gz.Close()
+
+	x := extraction{
+		root:   root,
+		span:   span,
+		outDir: outDir,
+		limits: e.limits,
+	}
+
+	tr := tar.NewReader(gz)
+	for {
+		if err = ctx.Err(); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "extraction cancelled")
+			return err
+		}
+
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to read tar entry")
+			return err
+		}
+
+		err = x.extractEntry(header, tr)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to extract tar entry")
+			return err
+		}
+	}
+
+	err = x.checkSymlinks()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "archive contains unsafe symlink")
+		return err
+	}
+
+	span.SetAttributes(
+		attribute.Int64("extracted.bytes", x.bytes),
+		attribute.Int("extracted.files", x.files),
+	)
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "extracted tar")
+	return nil
+}
+
+func (x *extraction) extractEntry(header *tar.Header, contents io.Reader) error {
+	name := filepath.Clean(filepath.FromSlash(header.Name))
+	if !filepath.IsLocal(name) {
+		return fmt.Errorf("%w: %s", ErrUnsafeEntry, header.Name)
+	}
+	if name == "." {
+		return nil
+	}
+
+	x.files++
+	if x.limits.MaxFiles > 0 && x.files > x.limits.MaxFiles {
+		return fmt.Errorf("%w: more than %d entries", ErrLimitExceeded, x.limits.MaxFiles)
+	}
+	depth := strings.Count(name, string(filepath.Separator)) + 1
+	if x.limits.MaxDepth > 0 && depth > x.limits.MaxDepth {
+		return fmt.Errorf(
+			"%w: %s is deeper than %d",
+			ErrLimitExceeded,
+			header.Name,
+			x.limits.MaxDepth,
+		)
+	}
+	if x.files%progressInterval == 0 {
+		x.span.AddEvent("extract_progress", trace.WithAttributes(
+			attribute.Int64("bytes", x.bytes),
+			attribute.Int("files", x.files),
+		))
+	}
+
+	switch header.Typeflag {
+	case tar.TypeDir:
+		return x.mkdirAll(name)
+	case tar.TypeReg:
+		return x.writeFile(name, header, contents)
+	case tar.TypeLink:
+		target := filepath.Clean(filepath.FromSlash(header.Linkname))
+		if !filepath.IsLocal(target) {
+			return fmt.Errorf("%w: %s links to %s", ErrUnsafeEntry, header.Name, header.Linkname)
+		}
+		return x.copyHardlink(name, target, header)
+	case tar.TypeSymlink:
+		return x.symlink(name, header.Linkname)
+	default:
+		// devices, fifos and the like have no business in a challenge repo
+		x.span.AddEvent("skipping unsupported entry", trace.WithAttributes(
+			attribute.String("name", header.Name),
+			attribute.Int("type", int(header.Typeflag)),
+		))
+		return nil
+	}
+}
+
+func (x *extraction) mkdirAll(name string) error {
+	current := ""
+	for _, part := range strings.Split(name, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+		err := x.root.Mkdir(current, 0755)
+		if err != nil && !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (x *extraction) reserveBytes(name string, size int64) error {
+	x.bytes += size
+	if x.limits.MaxBytes > 0 && x.bytes > x.limits.MaxBytes {
+		return fmt.Errorf(
+			"%w: more than %d bytes at %s",
+			ErrLimitExceeded,
+			x.limits.MaxBytes,
+			name,
+		)
+	}
+	return nil
+}
+
+func (x *extraction) writeFile(name string, header *tar.Header, contents io.Reader) error {
+	err := x.reserveBytes(name, header.Size)
+	if err != nil {
+		return err
+	}
+
+	err = x.mkdirAll(filepath.Dir(name))
+	if err != nil {
+		return err
+	}
+
+	f, err := x.root.OpenFile(
+		name,
+		os.O_CREATE|os.O_TRUNC|os.O_WRONLY,
+		header.FileInfo().Mode().Perm(),
+	)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, contents)
+	if err != nil {
+		return err
+	}
+
+	// keep mtimes so builds see the same file ages tar would have restored
+	return os.Chtimes(filepath.Join(x.outDir, name), header.AccessTime, header.ModTime)
+}
+
+// Hardlinks are extracted as copies so they can't be used to reach files outside the root
+func (x *extraction) copyHardlink(name string, target string, header *tar.Header) error {
+	src, err := x.root.Open(target)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%w: %s links to non-regular file", ErrUnsafeEntry, header.Name)
+	}
+
+	linked := *header
+	linked.Size = info.Size()
+	linked.Mode = int64(info.Mode().Perm())
+	return x.writeFile(name, &linked, src)
+}
+
+func (x *extraction) symlink(name string, target string) error {
+	if filepath.IsAbs(target) {
+		return fmt.Errorf("%w: %s links to absolute path %s", ErrUnsafeEntry, name, target)
+	}
+	if !filepath.IsLocal(filepath.Join(filepath.Dir(name), target)) {
+		return fmt.Errorf("%w: %s links to %s", ErrUnsafeEntry, name, target)
+	}
+
+	err := x.mkdirAll(filepath.Dir(name))
+	if err != nil {
+		return err
+	}
+
+	// the parent may itself be a symlink, so make sure it still resolves inside the root
+	parent, err := x.resolve(filepath.Dir(name))
+	if err != nil {
+		return err
+	}
+
+	err = os.Symlink(target, filepath.Join(x.outDir, parent, filepath.Base(name)))
+	if err != nil {
+		return err
+	}
+	x.symlinks = append(x.symlinks, name)
+
+	_, err = x.resolve(name)
+	return err
+}
+
+// Symlinks are checked lexically as they are created, but a later entry can replace a path they
+// traverse with another symlink. Once everything is extracted each one is resolved for real.
+func (x *extraction) checkSymlinks() error {
+	for _, name := range x.symlinks {
+		_, err := x.resolve(name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Resolves `name` relative to the output dir the way the kernel would, following symlinks, and
+// fails if it ever steps outside. Components that don't exist are resolved lexically.
+func (x *extraction) resolve(name string) (string, error) {
+	var resolved []string
+	pending := strings.Split(name, string(filepath.Separator))
+	hops := 0
+
+	for len(pending) > 0 {
+		part := pending[0]
+		pending = pending[1:]
+
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if len(resolved) == 0 {
+				return "", fmt.Errorf("%w: %s", ErrUnsafeEntry, name)
+			}
+			resolved = resolved[:len(resolved)-1]
+			continue
+		}
+
+		current := filepath.Join(append(resolved, part)...)
+		info, err := os.Lstat(filepath.Join(x.outDir, current))
+		if err != nil || info.Mode()&fs.ModeSymlink == 0 {
+			resolved = append(resolved, part)
+			continue
+		}
+
+		hops++
+		if hops > maxSymlinkHops {
+			return "", fmt.Errorf("%w: too many levels of symlinks in %s", ErrUnsafeEntry, name)
+		}
+
+		target, err := os.Readlink(filepath.Join(x.outDir, current))
+		if err != nil {
+			return "", err
+		}
+		if filepath.IsAbs(target) {
+			return "", fmt.Errorf("%w: %s resolves to absolute path", ErrUnsafeEntry, name)
+		}
+		pending = append(strings.Split(target, string(filepath.Separator)), pending...)
+	}
+
+	return filepath.Join(resolved...), nil
+}
@@ -0,0 +1,179 @@
+package extract_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/extract"
+)
+
+type tarEntry struct {
+	name     string
+	linkname string
+	contents string
+	typeflag byte
+}
+
+func makeTarGz(t *testing.T, entries ...tarEntry) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+
+	for _, entry := range entries {
+		header := &tar.Header{
+			Name:     entry.name,
+			Linkname: entry.linkname,
+			Typeflag: entry.typeflag,
+			Mode:     0644,
+			Size:     int64(len(entry.contents)),
+		}
+		if entry.typeflag == tar.TypeDir {
+			header.Mode = 0755
+		}
+		require.NoError(t, tw.WriteHeader(header))
+		_, err := tw.Write([]byte(entry.contents))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf
+}
+
+func TestNative(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Valid", func(t *testing.T) {
+		outDir := t.TempDir()
+
+		extractor := extract.NewNativeTarGzExtractor(extract.DefaultLimits)
+		tar, err := os.Open("./test.tar.gz")
+		require.NoError(t, err, "failed to open tar")
+		defer tar.Close()
+
+		err = extractor.Extract(ctx, tar, outDir)
+		require.NoError(t, err, "failed to extract")
+
+		contents, err := os.ReadFile(filepath.Join(outDir, "dind", "Containerfile"))
+		require.NoError(t, err, "failed to read Containerfile")
+		assert.Contains(t, string(contents), "FROM", "missing expected fragment")
+
+		info, err := os.Stat(filepath.Join(outDir, "dind", "entrypoint.sh"))
+		require.NoError(t, err)
+		assert.NotZero(t, info.Mode().Perm()&0100, "should keep executable bit")
+	})
+
+	t.Run("Links", func(t *testing.T) {
+		outDir := t.TempDir()
+
+		extractor := extract.NewNativeTarGzExtractor(extract.DefaultLimits)
+		err := extractor.Extract(ctx, makeTarGz(t,
+			tarEntry{name: "repo/", typeflag: tar.TypeDir},
+			tarEntry{name: "repo/a", typeflag: tar.TypeReg, contents: "a"},
+			tarEntry{name: "repo/sub/b", typeflag: tar.TypeLink, linkname: "repo/a"},
+			tarEntry{name: "repo/sub/c", typeflag: tar.TypeSymlink, linkname: "../a"},
+		), outDir)
+		require.NoError(t, err, "failed to extract")
+
+		contents, err := os.ReadFile(filepath.Join(outDir, "repo", "sub", "b"))
+		require.NoError(t, err)
+		assert.Equal(t, "a", string(contents))
+
+		target, err := os.Readlink(filepath.Join(outDir, "repo", "sub", "c"))
+		require.NoError(t, err)
+		assert.Equal(t, "../a", target)
+	})
+
+	t.Run("Invalid Outdir", func(t *testing.T) {
+		extractor := extract.NewNativeTarGzExtractor(extract.DefaultLimits)
+		err := extractor.Extract(ctx, makeTarGz(t), "foobar")
+		require.Error(t, err, "should fail to extract")
+	})
+
+	t.Run("Not a Tar", func(t *testing.T) {
+		extractor := extract.NewNativeTarGzExtractor(extract.DefaultLimits)
+		err := extractor.Extract(ctx, bytes.NewBufferString("hello world"), t.TempDir())
+		assert.Error(t, err, "should fail")
+	})
+
+	for name, entries := range map[string][]tarEntry{
+		"Traversal": {
+			{name: "../evil", typeflag: tar.TypeReg, contents: "x"},
+		},
+		"Absolute": {
+			{name: "/tmp/evil", typeflag: tar.TypeReg, contents: "x"},
+		},
+		"Absolute Symlink": {
+			{name: "link", typeflag: tar.TypeSymlink, linkname: "/etc/passwd"},
+		},
+		"Escaping Symlink": {
+			{name: "a/link", typeflag: tar.TypeSymlink, linkname: "../../etc"},
+		},
+		"Escaping Hardlink": {
+			{name: "link", typeflag: tar.TypeLink, linkname: "../etc/passwd"},
+		},
+		"Symlink Chain": {
+			// lexically inside, but resolves through a/l to the parent of the output dir
+			{name: "s", typeflag: tar.TypeSymlink, linkname: "a/l/.."},
+			{name: "a/l", typeflag: tar.TypeSymlink, linkname: ".."},
+		},
+		"Write Through Symlink": {
+			{name: "a", typeflag: tar.TypeSymlink, linkname: "."},
+			{name: "a/b", typeflag: tar.TypeSymlink, linkname: ".."},
+			{name: "a/b/evil", typeflag: tar.TypeReg, contents: "x"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			parent := t.TempDir()
+			outDir := filepath.Join(parent, "out")
+			require.NoError(t, os.Mkdir(outDir, 0700))
+
+			extractor := extract.NewNativeTarGzExtractor(extract.DefaultLimits)
+			err := extractor.Extract(ctx, makeTarGz(t, entries...), outDir)
+			require.ErrorIs(t, err, extract.ErrUnsafeEntry)
+
+			_, err = os.Stat(filepath.Join(parent, "evil"))
+			assert.ErrorIs(t, err, os.ErrNotExist, "should not write outside output dir")
+		})
+	}
+
+	for name, tc := range map[string]struct {
+		entries []tarEntry
+		limits  extract.Limits
+	}{
+		"Bytes": {
+			limits: extract.Limits{MaxBytes: 4},
+			entries: []tarEntry{
+				{name: "a", typeflag: tar.TypeReg, contents: "abc"},
+				{name: "b", typeflag: tar.TypeReg, contents: "abc"},
+			},
+		},
+		"Files": {
+			limits: extract.Limits{MaxFiles: 1},
+			entries: []tarEntry{
+				{name: "a", typeflag: tar.TypeReg},
+				{name: "b", typeflag: tar.TypeReg},
+			},
+		},
+		"Depth": {
+			limits: extract.Limits{MaxDepth: 2},
+			entries: []tarEntry{
+				{name: "a/b/c", typeflag: tar.TypeReg},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			extractor := extract.NewNativeTarGzExtractor(tc.limits)
+			err := extractor.Extract(ctx, makeTarGz(t, tc.entries...), t.TempDir())
+			assert.ErrorIs(t, err, extract.ErrLimitExceeded)
+		})
+	}
+}
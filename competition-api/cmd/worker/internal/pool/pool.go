@@ -0,0 +1,221 @@
+package pool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/logger"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/queue"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+)
+
+var tracer = otel.Tracer(
+	"github.com/aixcyberchallenge/competition-api/competition-api/worker/internal/pool",
+)
+
+// Runs a single evaluation. Errors wrapped with [queue.WrapPoisonError] drop the request, any
+// other error puts it back on the queue for another worker.
+type EvalFunc func(ctx context.Context, request *types.EvalRequest) error
+
+// Pulls evaluation requests off a queue and runs up to `concurrency` of them at once
+type Pool struct {
+	queuer      queue.Queuer
+	eval        EvalFunc
+	concurrency int
+	// longest an evaluation may run, the queue hides a request from other workers for this long
+	timeout time.Duration
+	// pause after failing to dequeue so a broken queue isn't hammered
+	retryDelay time.Duration
+}
+
+func New(queuer queue.Queuer, concurrency int, timeout time.Duration, eval EvalFunc) *Pool {
+	return &Pool{
+		queuer:      queuer,
+		eval:        eval,
+		concurrency: max(concurrency, 1),
+		timeout:     timeout,
+		retryDelay:  time.Second * 30,
+	}
+}
+
+// Handles requests until `ctx` is cancelled, then waits for the running evaluations to finish.
+// Evaluations don't see the cancellation, only their own timeout.
+func (p *Pool) Run(ctx context.Context) {
+	ctx, span := tracer.Start(ctx, "Pool.Run", trace.WithAttributes(
+		attribute.Int("concurrency", p.concurrency),
+		attribute.String("timeout", p.timeout.String()),
+	))
+	defer span.End()
+
+	var wg sync.WaitGroup
+	for slot := range p.concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.runSlot(ctx, slot)
+		}()
+	}
+	wg.Wait()
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "pool stopped")
+}
+
+func (p *Pool) runSlot(ctx context.Context, slot int) {
+	for ctx.Err() == nil {
+		err := func() error {
+			//nolint:govet // shadow: intentionally shadow ctx and span to avoid using the incorrect one.
+			ctx, span := tracer.Start(ctx, "Pool.Loop", trace.WithAttributes(
+				attribute.Int("slot", slot),
+			))
+			defer span.End()
+
+			// stopping only ends the wait for a request, one already dequeued is evaluated to the
+			// end so no final status is ever sent for a cancelled evaluation
+			dequeueCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+			defer cancel()
+			gate := &startGate{cancel: cancel}
+			defer context.AfterFunc(ctx, gate.stop)()
+
+			err := p.queuer.Dequeue(dequeueCtx, p.timeout, &handler{
+				eval:    p.eval,
+				gate:    gate,
+				timeout: p.timeout,
+			})
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, "failed to dequeue and handle request")
+				return err
+			}
+
+			span.RecordError(nil)
+			span.SetStatus(codes.Ok, "handled request")
+			return nil
+		}()
+		if err == nil || ctx.Err() != nil {
+			continue
+		}
+
+		logger.Logger.WarnContext(ctx, "failed to dequeue eval request", "slot", slot, "error", err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(p.retryDelay):
+		}
+	}
+}
+
+// Returned for a request dequeued as the pool stopped, it goes back on the queue
+var ErrStopped = errors.New("pool stopped")
+
+// Returned for a request whose total timeout the pool can't wait for, it is dropped rather than
+// cancelled and handed out again forever
+var ErrTimeoutTooLong = errors.New("eval timeout exceeds the pool's")
+
+// Time an evaluation has after its own total timeout to send its final status
+const reportMargin = 10 * time.Minute
+
+// Decides between the pool stopping and the handler of one dequeue starting, whichever comes first
+type startGate struct {
+	cancel  context.CancelFunc
+	mu      sync.Mutex
+	started bool
+	stopped bool
+}
+
+// Ends the wait for a request unless its handler already started
+func (g *startGate) stop() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.started {
+		g.stopped = true
+		g.cancel()
+	}
+}
+
+// Whether the handler may start, false once the pool stopped
+func (g *startGate) start() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.stopped {
+		return false
+	}
+	g.started = true
+	return true
+}
+
+type handler struct {
+	eval    EvalFunc
+	gate    *startGate
+	timeout time.Duration
+}
+
+func (h *handler) Handle(ctx context.Context, message []byte) error {
+	ctx, span := tracer.Start(ctx, "Pool.Handle")
+	defer span.End()
+
+	if !h.gate.start() {
+		span.RecordError(ErrStopped)
+		span.SetStatus(codes.Error, "pool stopped before handling request")
+		return ErrStopped
+	}
+
+	var request types.EvalRequest
+	err := json.Unmarshal(message, &request)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to unmarshal eval request")
+		return queue.WrapPoisonError(err)
+	}
+
+	// link to whoever asked for the evaluation like a job does from its env
+	requested := otel.GetTextMapPropagator().Extract(
+		context.Background(),
+		propagation.MapCarrier(request.TraceContext),
+	)
+	span.AddLink(trace.LinkFromContext(requested))
+	span.SetAttributes(
+		attribute.String("entity.type", string(request.Spec.Entity)),
+		attribute.String("entity.id", request.Spec.EntityID),
+	)
+
+	err = request.Spec.Validate()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid eval spec")
+		return queue.WrapPoisonError(err)
+	}
+
+	// the evaluation ends itself with a final status once its total timeout is up, the handler
+	// only outlives it long enough to send that status
+	total := request.Spec.EvalTimeouts().Total()
+	if total+reportMargin > h.timeout {
+		err = fmt.Errorf("%w: %s plus %s to report, the pool waits %s",
+			ErrTimeoutTooLong, total, reportMargin, h.timeout)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "eval timeout too long")
+		return queue.WrapPoisonError(err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, total+reportMargin)
+	defer cancel()
+
+	err = h.eval(ctx, &request)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to evaluate")
+		return err
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "evaluated")
+	return nil
+}
@@ -0,0 +1,195 @@
+package pool_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/pool"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/queue"
+	mockqueue "github.com/aixcyberchallenge/competition-api/competition-api/internal/queue/mock"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+)
+
+// Queue handing out `messages` once each, recording what the handler returned. Blocks once empty
+// until the context is cancelled like a real queue would.
+func memoryQueue(
+	ctrl *gomock.Controller,
+	messages []string,
+) (*mockqueue.MockQueuer, func() []error) {
+	var mu sync.Mutex
+	var results []error
+	next := 0
+
+	queuer := mockqueue.NewMockQueuer(ctrl)
+	queuer.EXPECT().
+		Dequeue(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ time.Duration, handler queue.MessageHandler) error {
+			mu.Lock()
+			if next == len(messages) {
+				mu.Unlock()
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			message := messages[next]
+			next++
+			mu.Unlock()
+
+			err := handler.Handle(ctx, []byte(message))
+			mu.Lock()
+			results = append(results, err)
+			mu.Unlock()
+			return nil
+		}).
+		AnyTimes()
+
+	return queuer, func() []error {
+		mu.Lock()
+		defer mu.Unlock()
+		return results
+	}
+}
+
+const validRequest = `{"spec": {
+	"version": 1,
+	"entity": "pov",
+	"entity_id": "id",
+	"head_repo": {"url": "head"},
+	"fuzz_tooling": {"url": "fuzz-tooling"},
+	"focus": "focus",
+	"project_name": "project",
+	"architecture": "x86_64"
+}}`
+
+// Fits the default total timeout of an evaluation with room to report
+const poolTimeout = 9 * time.Hour
+
+func TestPoolHandle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	queuer, results := memoryQueue(ctrl, []string{
+		"not json",
+		`{"spec": {"version": 1, "entity": "pov", "entity_id": "id"}}`,
+		`{"spec": {"version": 999}}`,
+		`{"spec": {
+			"version": 1,
+			"entity": "pov",
+			"entity_id": "id",
+			"head_repo": {"url": "head"},
+			"fuzz_tooling": {"url": "fuzz-tooling"},
+			"focus": "focus",
+			"project_name": "project",
+			"architecture": "x86_64",
+			"timeouts": {"total_secs": 36000}
+		}}`,
+		validRequest,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var evaluated []*types.EvalRequest
+	var deadline time.Time
+	p := pool.New(queuer, 1, poolTimeout, func(ctx context.Context, request *types.EvalRequest) error {
+		evaluated = append(evaluated, request)
+		deadline, _ = ctx.Deadline()
+		cancel()
+		return nil
+	})
+	p.Run(ctx)
+
+	got := results()
+	require.Len(t, got, 5)
+	var pe *queue.PoisonError
+	assert.ErrorAs(t, got[0], &pe, "bad json should be poisoned")
+	assert.ErrorAs(t, got[1], &pe, "incomplete spec should be poisoned")
+	require.ErrorAs(t, got[2], &pe, "unknown spec version should be poisoned")
+	require.ErrorIs(t, got[2], types.ErrEvalSpecVersion)
+	require.ErrorAs(t, got[3], &pe, "timeout longer than the pool's should be poisoned")
+	require.ErrorIs(t, got[3], pool.ErrTimeoutTooLong)
+	assert.NoError(t, got[4])
+
+	require.Len(t, evaluated, 1)
+	assert.Equal(t, types.JobTypePOV, evaluated[0].Spec.Entity)
+	assert.Equal(t, "id", evaluated[0].Spec.EntityID)
+	assert.Equal(t, "project", evaluated[0].Spec.ProjectName)
+
+	// bounded by the evaluation's own timeout rather than the pool's
+	total := types.DefaultEvalTimeouts.Total()
+	assert.True(t, deadline.After(time.Now().Add(total)), "deadline %s too early", deadline)
+	assert.True(t, deadline.Before(time.Now().Add(poolTimeout)), "deadline %s too late", deadline)
+}
+
+func TestPoolEvalError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	queuer, results := memoryQueue(ctrl, []string{validRequest})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	evalErr := errors.New("docker went away")
+	p := pool.New(queuer, 1, poolTimeout, func(context.Context, *types.EvalRequest) error {
+		cancel()
+		return evalErr
+	})
+	p.Run(ctx)
+
+	got := results()
+	require.Len(t, got, 1)
+	require.ErrorIs(t, got[0], evalErr)
+	var pe *queue.PoisonError
+	assert.False(t, errors.As(got[0], &pe), "eval errors should be retried")
+}
+
+func TestPoolConcurrency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	messages := make([]string, 6)
+	for i := range messages {
+		messages[i] = validRequest
+	}
+	queuer, results := memoryQueue(ctrl, messages)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var running, most, done atomic.Int32
+	p := pool.New(queuer, 2, poolTimeout, func(context.Context, *types.EvalRequest) error {
+		now := running.Add(1)
+		for {
+			seen := most.Load()
+			if now <= seen || most.CompareAndSwap(seen, now) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		running.Add(-1)
+
+		if done.Add(1) == int32(len(messages)) {
+			cancel()
+		}
+		return nil
+	})
+	p.Run(ctx)
+
+	assert.Len(t, results(), len(messages))
+	assert.Equal(t, int32(2), most.Load(), "should run up to concurrency evals at once")
+}
+
+func TestPoolStopFinishesRunning(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	queuer, results := memoryQueue(ctrl, []string{validRequest, validRequest})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var evalErrs []error
+	p := pool.New(queuer, 1, poolTimeout, func(ctx context.Context, _ *types.EvalRequest) error {
+		cancel()
+		time.Sleep(10 * time.Millisecond)
+		evalErrs = append(evalErrs, ctx.Err())
+		return nil
+	})
+	p.Run(ctx)
+
+	require.Len(t, evalErrs, 1, "no request should be taken after stopping")
+	require.NoError(t, evalErrs[0], "stopping should not cancel the running evaluation")
+	assert.Len(t, results(), 1)
+}
@@ -0,0 +1,225 @@
+package testresults
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+)
+
+// Test output can have huge lines, longer lines end the parse
+const maxLineSize = 1 << 20
+
+var (
+	// 1/3 Test #1: parser_test ......................   Passed    0.01 sec
+	// 2/3 Test #2: lexer_test .......................***Failed    0.02 sec
+	ctestLine = regexp.MustCompile(`^\s*\d+/\d+\s+Test\s+#\d+:\s+(\S+)\s+\.*\s*\**(\w+)`)
+	// not ok 2 - handles empty input # TODO not implemented
+	tapLine = regexp.MustCompile(`^(not )?ok\b(?:\s+(\d+))?(?:\s*-)?\s*([^#]*?)\s*(?:#\s*(\w+).*)?$`)
+	// 1..4, other tools print lines starting with ok too
+	tapPlan = regexp.MustCompile(`(?m)^1\.\.\d+`)
+	// neither skipped tests nor ones marked as not done yet count as failing
+	tapDirective = map[string]types.TestOutcome{
+		"skip": types.TestSkipped,
+		"todo": types.TestSkipped,
+	}
+)
+
+type (
+	junitSuite struct {
+		Suites []junitSuite `xml:"testsuite"`
+		Cases  []junitCase  `xml:"testcase"`
+	}
+
+	junitCase struct {
+		Failure   *struct{} `xml:"failure"`
+		Error     *struct{} `xml:"error"`
+		Skipped   *struct{} `xml:"skipped"`
+		Classname string    `xml:"classname,attr"`
+		Name      string    `xml:"name,attr"`
+	}
+)
+
+// Reads per-test outcomes of a test suite run. JUnit XML files the suite left in `junitDir` win,
+// otherwise ctest or TAP lines in its `output` are used. Returns nil when neither has any.
+func Parse(output []byte, junitDir string) (*types.TestResults, error) {
+	if junitDir != "" {
+		results, err := parseJUnitDir(junitDir)
+		if err != nil || results != nil {
+			return results, err
+		}
+	}
+
+	for _, parse := range []func([]byte) (*types.TestResults, error){parseCTest, parseTAP} {
+		results, err := parse(output)
+		if err != nil || results != nil {
+			return results, err
+		}
+	}
+
+	return nil, nil
+}
+
+func parseJUnitDir(dir string) (*types.TestResults, error) {
+	results := newResults(types.TestFormatJUnit)
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(path), ".xml") {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		var suite junitSuite
+		// the suite decides what else lands in its out dir, skip what is not a report
+		if xml.Unmarshal(data, &suite) != nil {
+			return nil
+		}
+		results.addSuite(suite)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results.done(), nil
+}
+
+func parseCTest(output []byte) (*types.TestResults, error) {
+	results := newResults(types.TestFormatCTest)
+	err := scanLines(output, func(line string) {
+		match := ctestLine.FindStringSubmatch(line)
+		if match == nil {
+			return
+		}
+
+		outcome := types.TestFailed
+		switch match[2] {
+		case "Passed":
+			outcome = types.TestPassed
+		case "Skipped":
+			outcome = types.TestSkipped
+		}
+		results.add(match[1], outcome)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results.done(), nil
+}
+
+func parseTAP(output []byte) (*types.TestResults, error) {
+	if !tapPlan.Match(output) {
+		return nil, nil
+	}
+
+	results := newResults(types.TestFormatTAP)
+	err := scanLines(output, func(line string) {
+		match := tapLine.FindStringSubmatch(line)
+		if match == nil {
+			return
+		}
+
+		name := match[3]
+		if name == "" {
+			name = match[2]
+		}
+		if name == "" {
+			return
+		}
+
+		outcome := types.TestPassed
+		if match[1] != "" {
+			outcome = types.TestFailed
+		}
+		if directive, ok := tapDirective[strings.ToLower(match[4])]; ok {
+			outcome = directive
+		}
+		results.add(name, outcome)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results.done(), nil
+}
+
+func scanLines(output []byte, line func(string)) error {
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		line(scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// Collects cases in the order they were first seen
+type resultsBuilder struct {
+	index   map[string]int
+	results types.TestResults
+}
+
+func newResults(format types.TestFormat) *resultsBuilder {
+	return &resultsBuilder{
+		index:   map[string]int{},
+		results: types.TestResults{Format: format},
+	}
+}
+
+// Adds a case, a test reported more than once keeps its worst outcome
+func (b *resultsBuilder) add(name string, outcome types.TestOutcome) {
+	i, ok := b.index[name]
+	if !ok {
+		b.index[name] = len(b.results.Cases)
+		b.results.Cases = append(b.results.Cases, types.TestCase{Name: name, Outcome: outcome})
+		return
+	}
+
+	current := &b.results.Cases[i]
+	if outcome == types.TestFailed ||
+		(outcome == types.TestSkipped && current.Outcome == types.TestPassed) {
+		current.Outcome = outcome
+	}
+}
+
+func (b *resultsBuilder) addSuite(suite junitSuite) {
+	for _, c := range suite.Cases {
+		name := c.Name
+		if c.Classname != "" {
+			name = c.Classname + "." + c.Name
+		}
+
+		outcome := types.TestPassed
+		switch {
+		case c.Failure != nil || c.Error != nil:
+			outcome = types.TestFailed
+		case c.Skipped != nil:
+			outcome = types.TestSkipped
+		}
+		b.add(name, outcome)
+	}
+
+	for _, nested := range suite.Suites {
+		b.addSuite(nested)
+	}
+}
+
+// The collected results, nil when there were no cases
+func (b *resultsBuilder) done() *types.TestResults {
+	if len(b.results.Cases) == 0 {
+		return nil
+	}
+	return &b.results
+}
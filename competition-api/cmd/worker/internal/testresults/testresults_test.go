@@ -0,0 +1,141 @@
+package testresults_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/cmd/worker/internal/testresults"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+)
+
+const junitReport = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="parser">
+    <testcase classname="parser" name="empty"/>
+    <testcase classname="parser" name="nested">
+      <failure message="expected 2 got 3">parser_test.c:40</failure>
+    </testcase>
+    <testsuite name="unicode">
+      <testcase classname="parser.unicode" name="bom"><skipped/></testcase>
+    </testsuite>
+  </testsuite>
+  <testsuite name="lexer">
+    <testcase name="tokens"><error type="SIGSEGV"/></testcase>
+  </testsuite>
+</testsuites>
+`
+
+const ctestOutput = `Test project /src/build
+    Start 1: parser_test
+1/4 Test #1: parser_test ......................   Passed    0.01 sec
+    Start 2: lexer_test
+2/4 Test #2: lexer_test .......................***Failed    0.02 sec
+    Start 3: fuzz_regression
+3/4 Test #3: fuzz_regression ..................***Exception: SegFault  0.10 sec
+    Start 4: network_test
+4/4 Test #4: network_test .....................***Skipped   0.00 sec
+
+50% tests passed, 2 tests failed out of 4
+`
+
+const tapOutput = `TAP version 13
+1..5
+ok 1 - parses empty input
+not ok 2 - parses nested input
+  ---
+  message: expected 2 got 3
+  ...
+ok 3 # SKIP no network
+not ok 4 - handles unicode # TODO not implemented
+ok 5
+`
+
+func TestParseJUnit(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "reports"), 0o755))
+	require.NoError(
+		t,
+		os.WriteFile(filepath.Join(dir, "reports", "TEST-all.xml"), []byte(junitReport), 0o600),
+	)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.xml"), []byte("<nope"), 0o600))
+
+	// JUnit reports win over output in other formats
+	results, err := testresults.Parse([]byte(ctestOutput), dir)
+	require.NoError(t, err)
+	require.NotNil(t, results)
+
+	assert.Equal(t, &types.TestResults{
+		Format: types.TestFormatJUnit,
+		Cases: []types.TestCase{
+			{Name: "parser.empty", Outcome: types.TestPassed},
+			{Name: "parser.nested", Outcome: types.TestFailed},
+			{Name: "parser.unicode.bom", Outcome: types.TestSkipped},
+			{Name: "tokens", Outcome: types.TestFailed},
+		},
+	}, results)
+}
+
+func TestParseCTest(t *testing.T) {
+	results, err := testresults.Parse([]byte(ctestOutput), t.TempDir())
+	require.NoError(t, err)
+
+	assert.Equal(t, &types.TestResults{
+		Format: types.TestFormatCTest,
+		Cases: []types.TestCase{
+			{Name: "parser_test", Outcome: types.TestPassed},
+			{Name: "lexer_test", Outcome: types.TestFailed},
+			{Name: "fuzz_regression", Outcome: types.TestFailed},
+			{Name: "network_test", Outcome: types.TestSkipped},
+		},
+	}, results)
+}
+
+func TestParseTAP(t *testing.T) {
+	results, err := testresults.Parse([]byte(tapOutput), "")
+	require.NoError(t, err)
+
+	assert.Equal(t, &types.TestResults{
+		Format: types.TestFormatTAP,
+		Cases: []types.TestCase{
+			{Name: "parses empty input", Outcome: types.TestPassed},
+			{Name: "parses nested input", Outcome: types.TestFailed},
+			{Name: "3", Outcome: types.TestSkipped},
+			{Name: "handles unicode", Outcome: types.TestSkipped},
+			{Name: "5", Outcome: types.TestPassed},
+		},
+	}, results)
+}
+
+func TestParseUnknown(t *testing.T) {
+	// go test prints ok lines without a TAP plan
+	output := "ok  \tgithub.com/example/pkg\t0.012s\nall tests passed\n"
+
+	results, err := testresults.Parse([]byte(output), t.TempDir())
+	require.NoError(t, err)
+	assert.Nil(t, results)
+}
+
+func TestRegressions(t *testing.T) {
+	baseline := &types.TestResults{Cases: []types.TestCase{
+		{Name: "a", Outcome: types.TestPassed},
+		{Name: "b", Outcome: types.TestFailed},
+		{Name: "c", Outcome: types.TestPassed},
+		{Name: "d", Outcome: types.TestPassed},
+		{Name: "e", Outcome: types.TestSkipped},
+	}}
+	patched := &types.TestResults{Cases: []types.TestCase{
+		{Name: "a", Outcome: types.TestPassed},
+		{Name: "b", Outcome: types.TestFailed},
+		{Name: "d", Outcome: types.TestFailed},
+		{Name: "e", Outcome: types.TestFailed},
+		{Name: "f", Outcome: types.TestFailed},
+	}}
+
+	// c went missing and d broke, b and e never passed and f is new
+	assert.Equal(t, []string{"c", "d"}, baseline.Regressions(patched))
+	assert.Empty(t, baseline.Regressions(baseline))
+}
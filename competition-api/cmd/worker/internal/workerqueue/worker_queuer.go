@@ -71,20 +71,43 @@ func (q WorkerQueuer) CommandResult(ctx context.Context, result *types.JobResult
 	return nil
 }
 
+func (q WorkerQueuer) Step(ctx context.Context, step types.EvalStep) error {
+	ctx, span := tracer.Start(ctx, "WorkerQueuer.Step", trace.WithAttributes(
+		attribute.String("entity.type", string(q.entityType)),
+		attribute.String("entity.id", q.entityID),
+		attribute.String("step.name", string(step.Name)),
+		attribute.String("step.outcome", string(step.Outcome)),
+	))
+	defer span.End()
+
+	err := q.queuer.Enqueue(ctx, types.NewWorkerMsgStep(q.entityType, q.entityID, step))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to enqueue message")
+		return err
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "enqueued message")
+	return nil
+}
+
 func (q WorkerQueuer) FinalMessage(
 	ctx context.Context,
 	status types.SubmissionStatus,
 	patchTestsFailed bool,
+	reason string,
 ) error {
 	ctx, span := tracer.Start(ctx, "WorkerQueuer.FinalMessage", trace.WithAttributes(
 		attribute.String("entity.type", string(q.entityType)),
 		attribute.String("entity.id", q.entityID),
+		attribute.String("reason", reason),
 	))
 	defer span.End()
 
 	err := q.queuer.Enqueue(
 		ctx,
-		types.NewWorkerMsgFinal(q.entityType, q.entityID, status, &patchTestsFailed),
+		types.NewWorkerMsgFinal(q.entityType, q.entityID, status, &patchTestsFailed, reason),
 	)
 	if err != nil {
 		span.RecordError(err)
@@ -3,6 +3,7 @@ package workerqueue_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
@@ -73,7 +74,7 @@ func TestFinalMessage(t *testing.T) {
 	queuer.EXPECT().Enqueue(gomock.Any(), expected).Times(1)
 
 	wq := workerqueue.NewWorkerQueue(entityID, entityType, queuer)
-	err := wq.FinalMessage(ctx, status, patchTestsFailed)
+	err := wq.FinalMessage(ctx, status, patchTestsFailed, "")
 	if !assert.NoError(t, err, "failed to queue artifact") {
 		return
 	}
@@ -117,3 +118,35 @@ func TestCommandResult(t *testing.T) {
 		return
 	}
 }
+
+func TestStep(t *testing.T) {
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	queuer := mockqueue.NewMockQueuer(ctrl)
+
+	step := types.EvalStep{
+		StartedAt: time.Unix(0, 0),
+		Name:      types.EvalStepBuild,
+		Outcome:   types.EvalStepRunning,
+		Context:   types.ResultCtxHeadRepoTest,
+		Seq:       2,
+	}
+
+	expected := types.WorkerMsgStep{
+		WorkerMsg: types.WorkerMsg{
+			MsgType:  types.MsgTypeStep,
+			Entity:   entityType,
+			EntityID: entityID,
+		},
+		Step: step,
+	}
+
+	queuer.EXPECT().Enqueue(gomock.Any(), expected).Times(1)
+
+	wq := workerqueue.NewWorkerQueue(entityID, entityType, queuer)
+	err := wq.Step(ctx, step)
+	if !assert.NoError(t, err, "failed to queue step") {
+		return
+	}
+}
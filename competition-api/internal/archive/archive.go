@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/audit"
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
@@ -21,9 +23,12 @@ var tracer = otel.Tracer("github.com/aixcyberchallenge/competition-api/competiti
 type FileMetadata struct {
 	LocalFilePath *string
 	Buffer        *[]byte
-	ArchivedFile  types.ArchivedFile
-	Entity        audit.FileArchivedEntity
-	EntityID      string
+	// Object that was already archived, such as a cached package. Only the audit log message is
+	// generated for it.
+	ObjectName   *string
+	ArchivedFile types.ArchivedFile
+	Entity       audit.FileArchivedEntity
+	EntityID     string
 }
 
 // TODO: use interfaces for file metadata instead of static dispatch
@@ -41,13 +46,33 @@ func ArchiveFile(
 	var buffer io.ReadSeeker
 	var size int64
 
-	if metadata.LocalFilePath == nil && metadata.Buffer == nil {
-		err := errors.New("tried to archive a file without a buffer or file path")
-		span.SetStatus(codes.Error, "can't archive a file without a buffer or file path")
+	if metadata.LocalFilePath == nil && metadata.Buffer == nil && metadata.ObjectName == nil {
+		err := errors.New("tried to archive a file without a buffer, file path or object name")
+		span.SetStatus(codes.Error, "can't archive a file without a buffer, file path or object")
 		span.RecordError(err)
 		return err
 	}
 
+	if metadata.ObjectName != nil {
+		span.AddEvent("archiving previously archived object")
+		span.SetAttributes(attribute.String("object.name", *metadata.ObjectName))
+
+		exists, err := u.Exists(ctx, *metadata.ObjectName)
+		if err != nil {
+			span.SetStatus(codes.Error, "failed to check if object exists")
+			span.RecordError(err)
+			return err
+		}
+		if !exists {
+			err = fmt.Errorf("previously archived object is missing: %s", *metadata.ObjectName)
+			span.SetStatus(codes.Error, "previously archived object is missing")
+			span.RecordError(err)
+			return err
+		}
+
+		return logArchived(ctx, auditContext, u, *metadata.ObjectName, metadata)
+	}
+
 	if metadata.LocalFilePath != nil {
 		span.AddEvent("archiving from local file")
 		span.SetAttributes(attribute.String("path", *metadata.LocalFilePath))
@@ -83,6 +108,18 @@ func ArchiveFile(
 		return err
 	}
 
+	return logArchived(ctx, auditContext, u, objectName, metadata)
+}
+
+func logArchived(
+	ctx context.Context,
+	auditContext audit.Context,
+	u upload.Uploader,
+	objectName string,
+	metadata *FileMetadata,
+) error {
+	span := trace.SpanFromContext(ctx)
+
 	identifier, err := u.StoreIdentifier(ctx)
 	if err != nil {
 		span.RecordError(err)
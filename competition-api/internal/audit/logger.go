@@ -788,3 +788,151 @@ func LogFreeformSubmission(c Context) {
 	// TODO: should this go to stderr?
 	fmt.Println(string(evtStr))
 }
+
+func LogRoundTransition(c Context, fromState types.RoundState, toState types.RoundState) {
+	event := RoundTransition{}
+	event.Type = EvtRoundTransition
+
+	event.LogContext = logContext
+	event.SchemaVersion = schemaVersion
+
+	event.Timestamp = types.UnixMilli(time.Now().UTC().UnixMilli())
+	event.RoundID = c.RoundID
+	event.TeamID = c.TeamID
+	event.TaskID = c.TaskID
+
+	event.Disposition = DispositionNeutral
+
+	event.Event.FromState = fromState
+	event.Event.ToState = toState
+
+	evtStr, err := json.Marshal(event)
+	if err != nil {
+		logger.Logger.Error(
+			"could not serialize RoundTransition event",
+			"roundId",
+			c.RoundID,
+			"fromState",
+			fromState,
+			"toState",
+			toState,
+		)
+		return
+	}
+
+	// TODO: should this go to stderr?
+	fmt.Println(string(evtStr))
+}
+
+func LogTaskDeadlineExtended(
+	c Context,
+	oldDeadline types.UnixMilli,
+	newDeadline types.UnixMilli,
+	reacceptedSubmissions int,
+) {
+	event := TaskDeadlineExtended{}
+	event.Type = EvtTaskDeadlineExtended
+
+	event.LogContext = logContext
+	event.SchemaVersion = schemaVersion
+
+	event.Timestamp = types.UnixMilli(time.Now().UTC().UnixMilli())
+	event.RoundID = c.RoundID
+	event.TeamID = c.TeamID
+	event.TaskID = c.TaskID
+
+	event.Disposition = DispositionNeutral
+
+	event.Event.OldDeadline = oldDeadline
+	event.Event.NewDeadline = newDeadline
+	event.Event.ReacceptedSubmissions = reacceptedSubmissions
+
+	evtStr, err := json.Marshal(event)
+	if err != nil {
+		logger.Logger.Error(
+			"could not serialize TaskDeadlineExtended event",
+			"roundId",
+			c.RoundID,
+			"taskId",
+			c.TaskID,
+			"newDeadline",
+			newDeadline,
+		)
+		return
+	}
+
+	// TODO: should this go to stderr?
+	fmt.Println(string(evtStr))
+}
+
+func LogChallengePreflight(c Context, challengeName string, report *types.PreflightReport) {
+	event := ChallengePreflight{}
+	event.Type = EvtChallengePreflight
+
+	event.LogContext = logContext
+	event.SchemaVersion = schemaVersion
+
+	event.Timestamp = types.UnixMilli(time.Now().UTC().UnixMilli())
+	event.RoundID = c.RoundID
+	event.TeamID = c.TeamID
+	event.TaskID = c.TaskID
+
+	event.Disposition = DispositionGood
+	if !report.Passed {
+		event.Disposition = DispositionBad
+	}
+
+	event.Event.ChallengeName = challengeName
+	event.Event.Checks = report.Checks
+	event.Event.Passed = report.Passed
+	event.Event.Blocked = report.Blocked
+
+	evtStr, err := json.Marshal(event)
+	if err != nil {
+		logger.Logger.Error(
+			"could not serialize ChallengePreflight event",
+			"roundId",
+			c.RoundID,
+			"challengeName",
+			challengeName,
+		)
+		return
+	}
+
+	// TODO: should this go to stderr?
+	fmt.Println(string(evtStr))
+}
+
+func LogPackageCache(c Context, kind string, key string, hit bool) {
+	event := PackageCache{}
+	event.Type = EvtPackageCache
+
+	event.LogContext = logContext
+	event.SchemaVersion = schemaVersion
+
+	event.Timestamp = types.UnixMilli(time.Now().UTC().UnixMilli())
+	event.RoundID = c.RoundID
+	event.TeamID = c.TeamID
+	event.TaskID = c.TaskID
+
+	event.Disposition = DispositionNeutral
+
+	event.Event.Kind = kind
+	event.Event.Key = key
+	event.Event.Hit = hit
+
+	evtStr, err := json.Marshal(event)
+	if err != nil {
+		logger.Logger.Error(
+			"could not serialize PackageCache event",
+			"roundId",
+			c.RoundID,
+			"key",
+			key,
+		)
+		return
+	}
+
+	// TODO: should this go to stderr?
+	fmt.Println(string(evtStr))
+}
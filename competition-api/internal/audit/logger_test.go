@@ -384,3 +384,70 @@ func TestLogFreeformSubmission(t *testing.T) {
 	)
 	assert.Regexp(t, expect, got)
 }
+
+func TestLogRoundTransition(t *testing.T) {
+	ctx := Context{
+		RoundID: "round",
+	}
+	got, err := captureStdout(func() {
+		LogRoundTransition(ctx, types.RoundStateOpen, types.RoundStateFrozen)
+	})
+	require.NoError(t, err)
+
+	expect := regexp.MustCompile(
+		`{"event":{"from_state":"open","to_state":"frozen"},"task_id":null,"team_id":null,"log_context":"audit","version":"\d.\d.\d","round_id":"round","disposition":"neutral","event_type":"round_transition","timestamp":\d+}`,
+	)
+	assert.Regexp(t, expect, got)
+}
+
+func TestLogTaskDeadlineExtended(t *testing.T) {
+	taskID := "task"
+	ctx := Context{
+		RoundID: "round",
+		TaskID:  &taskID,
+	}
+	got, err := captureStdout(func() {
+		LogTaskDeadlineExtended(ctx, types.UnixMilli(1000), types.UnixMilli(2000), 3)
+	})
+	require.NoError(t, err)
+
+	expect := regexp.MustCompile(
+		`{"task_id":"task","team_id":null,"log_context":"audit","version":"\d.\d.\d","round_id":"round","disposition":"neutral","event_type":"task_deadline_extended","timestamp":\d+,"event":{"old_deadline":1000,"new_deadline":2000,"reaccepted_submissions":3}}`,
+	)
+	assert.Regexp(t, expect, got)
+}
+
+func TestLogChallengePreflight(t *testing.T) {
+	got, err := captureStdout(func() {
+		LogChallengePreflight(Context{RoundID: "round"}, "chal", &types.PreflightReport{
+			Checks: []types.PreflightCheckResult{
+				{
+					Check:       types.PreflightCheckPOV,
+					JobID:       "job",
+					Status:      types.SubmissionStatusFailed,
+					GroundTruth: "povs/crash",
+					Reason:      "expected passed, got failed",
+				},
+			},
+			Blocked: true,
+		})
+	})
+	require.NoError(t, err)
+
+	expect := regexp.MustCompile(
+		`{"task_id":null,"team_id":null,"log_context":"audit","version":"\d.\d.\d","round_id":"round","disposition":"bad","event_type":"challenge_preflight","timestamp":\d+,"event":{"challenge_name":"chal","checks":\[{"check":"pov","job_id":"job","status":"failed","ground_truth":"povs/crash","reason":"expected passed, got failed","passed":false}\],"passed":false,"blocked":true}}`,
+	)
+	assert.Regexp(t, expect, got)
+}
+
+func TestLogPackageCache(t *testing.T) {
+	got, err := captureStdout(func() {
+		LogPackageCache(Context{RoundID: "round"}, "fuzz_tooling", "abc", true)
+	})
+	require.NoError(t, err)
+
+	expect := regexp.MustCompile(
+		`{"task_id":null,"team_id":null,"log_context":"audit","version":"\d.\d.\d","round_id":"round","disposition":"neutral","event_type":"package_cache","timestamp":\d+,"event":{"kind":"fuzz_tooling","key":"abc","hit":true}}`,
+	)
+	assert.Regexp(t, expect, got)
+}
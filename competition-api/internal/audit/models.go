@@ -48,6 +48,10 @@ const (
 	EvtBroadcastSucceeded    EventType = "broadcast_succeeded"
 	EvtBroadcastFailed       EventType = "broadcast_failed"
 	EvtFreeformSubmission    EventType = "freeform_submission"
+	EvtRoundTransition       EventType = "round_transition"
+	EvtTaskDeadlineExtended  EventType = "task_deadline_extended"
+	EvtChallengePreflight    EventType = "challenge_preflight"
+	EvtPackageCache          EventType = "package_cache"
 )
 
 type Message struct {
@@ -259,3 +263,48 @@ type FreeformSubmission struct {
 	Event FreeformSubmissionEvent `json:"event"`
 	Message
 }
+
+type RoundTransitionEvent struct {
+	FromState types.RoundState `json:"from_state" validate:"required"`
+	ToState   types.RoundState `json:"to_state"   validate:"required"`
+}
+
+type RoundTransition struct {
+	Event RoundTransitionEvent `json:"event" validate:"required"`
+	Message
+}
+
+type TaskDeadlineExtendedEvent struct {
+	OldDeadline types.UnixMilli `json:"old_deadline" validate:"required"`
+	NewDeadline types.UnixMilli `json:"new_deadline" validate:"required"`
+	// Number of deadline_exceeded submissions that fall within the new deadline
+	ReacceptedSubmissions int `json:"reaccepted_submissions"`
+}
+
+type TaskDeadlineExtended struct {
+	Message
+	Event TaskDeadlineExtendedEvent `json:"event" validate:"required"`
+}
+
+type ChallengePreflightEvent struct {
+	ChallengeName string                       `json:"challenge_name" validate:"required"`
+	Checks        []types.PreflightCheckResult `json:"checks"`
+	Passed        bool                         `json:"passed"`
+	Blocked       bool                         `json:"blocked"`
+}
+
+type ChallengePreflight struct {
+	Message
+	Event ChallengePreflightEvent `json:"event" validate:"required"`
+}
+
+type PackageCacheEvent struct {
+	Kind string `json:"kind" validate:"required"`
+	Key  string `json:"key"  validate:"required"`
+	Hit  bool   `json:"hit"`
+}
+
+type PackageCache struct {
+	Message
+	Event PackageCacheEvent `json:"event" validate:"required"`
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/logger"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/validator"
 )
 
@@ -65,6 +66,8 @@ type AzureStorageAccountContainerConfig struct {
 type AzureStorageAccountQueueConfig struct {
 	URL     string `mapstructure:"url"     validate:"required"`
 	Results string `mapstructure:"results" validate:"required"`
+	// Evaluation requests for `worker serve`, only used in pool eval mode
+	Evals string `mapstructure:"evals"`
 }
 
 type SlogConfig struct {
@@ -92,14 +95,32 @@ type NodeAssignment struct {
 	Toleration        *K8SLabel `mapstructure:"toleration"          validate:"required"`
 }
 
+// Node-local cache eval pods share for source tarballs
+type FetchCacheConfig struct {
+	// Directory on the node, caching is disabled when empty
+	HostPath string `mapstructure:"host_path"`
+	MaxBytes int64  `mapstructure:"max_bytes"`
+}
+
+const (
+	// One kubernetes job per evaluation
+	EvalModeJob = "job"
+	// Evaluation requests are queued for long running `worker serve` pods
+	EvalModePool = "pool"
+)
+
 type K8sConfig struct {
-	EvalNodeAssignment      *NodeAssignment `mapstructure:"eval_node_assignment"      validate:"required"`
-	BroadcastNodeAssignment *NodeAssignment `mapstructure:"broadcast_node_assignment" validate:"required"`
-	ScoringNodeAssignment   *NodeAssignment `mapstructure:"scoring_node_assignment"   validate:"required"`
-	Namespace               string          `mapstructure:"namespace"                 validate:"required"`
-	JobImage                string          `mapstructure:"job_image"                 validate:"required"`
-	DINDImage               string          `mapstructure:"dind_image"                validate:"required"`
-	InCluster               bool            `mapstructure:"in_cluster"`
+	EvalNodeAssignment      *NodeAssignment   `mapstructure:"eval_node_assignment"      validate:"required"`
+	BroadcastNodeAssignment *NodeAssignment   `mapstructure:"broadcast_node_assignment" validate:"required"`
+	ScoringNodeAssignment   *NodeAssignment   `mapstructure:"scoring_node_assignment"   validate:"required"`
+	FetchCache              *FetchCacheConfig `mapstructure:"fetch_cache"`
+	Namespace               string            `mapstructure:"namespace"                 validate:"required"`
+	JobImage                string            `mapstructure:"job_image"                 validate:"required"`
+	DINDImage               string            `mapstructure:"dind_image"                validate:"required"`
+	EvalMode                string            `mapstructure:"eval_mode"                 validate:"oneof=job pool"`
+	SimulateRules           string            `mapstructure:"simulate_rules"`
+	InCluster               bool              `mapstructure:"in_cluster"`
+	BuildCache              bool              `mapstructure:"build_cache"`
 }
 
 type GithubConfig struct {
@@ -130,10 +151,61 @@ type RateLimitConfig struct {
 	FailOpen        bool   `mapstructure:"fail_open"`
 }
 
+// Where a challenge's repositories are fetched from
+type ChallengeSourceConfig struct {
+	// Optional diff tarball for tarball sources. Its presence makes the challenge a delta scan.
+	DiffTarball *string `mapstructure:"diff_tarball"`
+	// One of github (default), git, local or tarball
+	Type string `mapstructure:"type" validate:"omitempty,oneof=github git local tarball"`
+	// Credentials for git sources. HTTPS remotes use username/password, SSH remotes use the key.
+	Username       string `mapstructure:"username"`
+	Password       string `mapstructure:"password"`
+	SSHKeyPath     string `mapstructure:"ssh_key_path"`
+	SSHKeyPassword string `mapstructure:"ssh_key_password"`
+	// Directory holding the git repos for local sources, looked up by repo name
+	Dir string `mapstructure:"dir" validate:"required_if=Type local"`
+	// Tarballs for tarball sources
+	RepoTarball        string `mapstructure:"repo_tarball"         validate:"required_if=Type tarball"`
+	FuzzToolingTarball string `mapstructure:"fuzz_tooling_tarball" validate:"required_if=Type tarball"`
+}
+
+// Controls the checks run against a challenge's packaged sources before it is sent to CRSs
+type PreflightConfig struct {
+	// How long to wait for all pre-flight jobs before treating the challenge as broken
+	Timeout time.Duration `mapstructure:"timeout"`
+	// How often to poll the pre-flight jobs for results
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	Enabled      bool          `mapstructure:"enabled"`
+	// Stop the task from being sent when pre-flight fails instead of sending it flagged
+	Block bool `mapstructure:"block"`
+}
+
+// Timeouts evaluations run with when the challenge.yaml does not set its own. Zero leaves a
+// step limited only by the total.
+type EvalTimeoutsConfig struct {
+	Build  time.Duration `mapstructure:"build"`
+	RunPov time.Duration `mapstructure:"run_pov"`
+	Tests  time.Duration `mapstructure:"tests"`
+	Total  time.Duration `mapstructure:"total"`
+}
+
+func (c *EvalTimeoutsConfig) EvalTimeouts() types.EvalTimeouts {
+	if c == nil {
+		return types.DefaultEvalTimeouts
+	}
+	return types.EvalTimeouts{
+		BuildSecs:  int(c.Build.Seconds()),
+		RunPovSecs: int(c.RunPov.Seconds()),
+		TestsSecs:  int(c.Tests.Seconds()),
+		TotalSecs:  int(c.Total.Seconds()),
+	}
+}
+
 type GenerateRepoConfig struct {
-	RepoURL *string `mapstructure:"repo_url" validate:"required"`
-	HeadRef *string `mapstructure:"head_ref" validate:"required"`
-	BaseRef *string `mapstructure:"base_ref"`
+	Source  *ChallengeSourceConfig `mapstructure:"source"`
+	RepoURL *string                `mapstructure:"repo_url" validate:"required"`
+	HeadRef *string                `mapstructure:"head_ref" validate:"required"`
+	BaseRef *string                `mapstructure:"base_ref"`
 }
 
 type GenerateChallengeConfig struct {
@@ -150,22 +222,25 @@ type GenerateConfig struct {
 
 // See competitionapi.yaml for an example config
 type Config struct {
-	RoundID                  *string          `mapstructure:"round_id"                     validate:"required"`
-	Postgres                 *PostgresConfig  `mapstructure:"postgres"                     validate:"required"`
-	Azure                    *AzureConfig     `mapstructure:"azure"                        validate:"required"`
-	Logging                  *LoggingConfig   `mapstructure:"logging"                      validate:"required"`
-	K8s                      *K8sConfig       `mapstructure:"k8s"                          validate:"required"`
-	Github                   *GithubConfig    `mapstructure:"github"                       validate:"required"`
-	S3Archive                *S3ArchiveConfig `mapstructure:"s3_archive"                   validate:"required"`
-	CRSStatusPollTimeSeconds *int             `mapstructure:"crs_status_poll_time_seconds"`
-	RateLimit                *RateLimitConfig `mapstructure:"ratelimit"`
-	TempDir                  *string          `mapstructure:"temp_dir"`
-	Generate                 *GenerateConfig  `mapstructure:"generate"                     validate:"required"`
-	IgnoredRepos             *[]string        `mapstructure:"ignored_repos"`
-	CacheKey                 *string          `mapstructure:"cache_key"                    validate:"required"`
-	ListenAddress            string           `mapstructure:"listen_address"               validate:"required"`
-	Teams                    []Team           `mapstructure:"teams"                        validate:"required"`
-	GracefulShutdownSecs     int64            `mapstructure:"graceful_shutdown_secs"`
+	RoundID                  *string             `mapstructure:"round_id"                     validate:"required"`
+	Postgres                 *PostgresConfig     `mapstructure:"postgres"                     validate:"required"`
+	Azure                    *AzureConfig        `mapstructure:"azure"                        validate:"required"`
+	Logging                  *LoggingConfig      `mapstructure:"logging"                      validate:"required"`
+	K8s                      *K8sConfig          `mapstructure:"k8s"                          validate:"required"`
+	Github                   *GithubConfig       `mapstructure:"github"                       validate:"required"`
+	S3Archive                *S3ArchiveConfig    `mapstructure:"s3_archive"                   validate:"required"`
+	CRSStatusPollTimeSeconds *int                `mapstructure:"crs_status_poll_time_seconds"`
+	ReleasePollTimeSeconds   *int                `mapstructure:"release_poll_time_seconds"`
+	RateLimit                *RateLimitConfig    `mapstructure:"ratelimit"`
+	Preflight                *PreflightConfig    `mapstructure:"preflight"`
+	EvalTimeouts             *EvalTimeoutsConfig `mapstructure:"eval_timeouts"`
+	TempDir                  *string             `mapstructure:"temp_dir"`
+	Generate                 *GenerateConfig     `mapstructure:"generate"                     validate:"required"`
+	IgnoredRepos             *[]string           `mapstructure:"ignored_repos"`
+	CacheKey                 *string             `mapstructure:"cache_key"                    validate:"required"`
+	ListenAddress            string              `mapstructure:"listen_address"               validate:"required"`
+	Teams                    []Team              `mapstructure:"teams"                        validate:"required"`
+	GracefulShutdownSecs     int64               `mapstructure:"graceful_shutdown_secs"`
 }
 
 const (
@@ -174,12 +249,18 @@ const (
 	AzureStorageAccountKey     string = "azure.storage_account.key"
 	CRSStatusPollTimeSeconds   string = "crs_status_poll_time_seconds"
 	EnvPrefix                  string = "competitionapi"
+	EvalTimeoutsBuild          string = "eval_timeouts.build"
+	EvalTimeoutsRunPov         string = "eval_timeouts.run_pov"
+	EvalTimeoutsTests          string = "eval_timeouts.tests"
+	EvalTimeoutsTotal          string = "eval_timeouts.total"
 	UseOTLP                    string = "logging.use_otlp"
 	GlobalPerMinute            string = "ratelimit.global_per_minute"
 	GormLogLevel               string = "logging.gorm.level"
 	GormTraceQueries           string = "logging.gorm.trace_queries"
 	GracefulShutdownSecs       string = "graceful_shutdown_secs"
 	K8sDINDImage               string = "k8s.dind_image"
+	K8sEvalMode                string = "k8s.eval_mode"
+	K8sFetchCacheMaxBytes      string = "k8s.fetch_cache.max_bytes"
 	K8sJobImage                string = "k8s.job_image"
 	ListenAddress              string = "listen_address"
 	PostgresDatabase           string = "postgres.database"
@@ -190,7 +271,12 @@ const (
 	PostgresMaxIdleConnections string = "postgres.max_idle_connections"
 	PostgresMaxOpenConnections string = "postgres.max_open_connections"
 	PostgresConnectonTTL       string = "postgres.connection_ttl"
+	PreflightBlock             string = "preflight.block"
+	PreflightEnabled           string = "preflight.enabled"
+	PreflightPollInterval      string = "preflight.poll_interval"
+	PreflightTimeout           string = "preflight.timeout"
 	RateLimitFailOpen          string = "ratelimit.fail_open"
+	ReleasePollTimeSeconds     string = "release_poll_time_seconds"
 	RedisHost                  string = "ratelimit.redis_host"
 	RoundID                    string = "round_id"
 	S3AccessKeyID              string = "s3_archive.access_key_id"
@@ -275,6 +361,7 @@ func GetConfig() (*Config, error) {
 	v.SetDefault(S3ArchiveEnabled, true)
 	v.SetDefault(S3SSLEnabled, true)
 	v.SetDefault(CRSStatusPollTimeSeconds, 60)
+	v.SetDefault(ReleasePollTimeSeconds, 15)
 
 	v.SetDefault(RedisHost, "localhost")
 	v.SetDefault(GlobalPerMinute, 0)
@@ -284,6 +371,20 @@ func GetConfig() (*Config, error) {
 	v.SetDefault(UseOTLP, false)
 
 	v.SetDefault(TempDir, "/tmp")
+
+	v.SetDefault(PreflightEnabled, false)
+	v.SetDefault(PreflightBlock, true)
+	v.SetDefault(PreflightTimeout, 4*time.Hour)
+	v.SetDefault(PreflightPollInterval, 30*time.Second)
+
+	v.SetDefault(EvalTimeoutsBuild, time.Duration(0))
+	v.SetDefault(EvalTimeoutsRunPov, 30*time.Minute)
+	v.SetDefault(EvalTimeoutsTests, time.Duration(0))
+	v.SetDefault(EvalTimeoutsTotal, 8*time.Hour)
+
+	v.SetDefault(K8sFetchCacheMaxBytes, int64(50<<30))
+	v.SetDefault(K8sEvalMode, EvalModeJob)
+
 	v.SetDefault(GracefulShutdownSecs, 30)
 
 	v.SetDefault(GenerateRoundID, "integration-testing-round-1234")
@@ -0,0 +1,361 @@
+package fetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Ensure CachingFetcher implements Fetcher interface.
+var _ Fetcher = (*CachingFetcher)(nil)
+
+var ErrHashMismatch = errors.New("fetched content does not match expected sha256")
+
+// Content addressed cache in front of another fetcher. The cache directory can be shared by
+// several processes, such as every eval pod on a node through a hostPath volume, and is kept
+// under `maxBytes` by evicting the least recently used objects.
+//
+// Only urls with an expected sha256 are cached, everything else is passed through.
+type CachingFetcher struct {
+	fetcher Fetcher
+	// sha256 of the content expected at each url
+	hashes   map[string]string
+	dir      string
+	maxBytes int64
+}
+
+func NewCachingFetcher(
+	fetcher Fetcher,
+	dir string,
+	maxBytes int64,
+	hashes map[string]string,
+) *CachingFetcher {
+	return &CachingFetcher{
+		fetcher:  fetcher,
+		hashes:   hashes,
+		dir:      dir,
+		maxBytes: maxBytes,
+	}
+}
+
+func (c *CachingFetcher) objectsDir() string {
+	return filepath.Join(c.dir, "objects")
+}
+
+func (c *CachingFetcher) tmpDir() string {
+	return filepath.Join(c.dir, "tmp")
+}
+
+func (c *CachingFetcher) locksDir() string {
+	return filepath.Join(c.dir, "locks")
+}
+
+func (c *CachingFetcher) Fetch(ctx context.Context, url string) (io.ReadCloser, error) {
+	ctx, span := tracer.Start(ctx, "CachingFetcher.Fetch", trace.WithAttributes(
+		attribute.String("url", url),
+		attribute.String("cache.dir", c.dir),
+	))
+	defer span.End()
+
+	hash := c.hashes[url]
+	if hash == "" {
+		span.AddEvent("no expected sha256, not caching")
+		span.RecordError(nil)
+		span.SetStatus(codes.Ok, "passed through uncached url")
+		return c.fetcher.Fetch(ctx, url)
+	}
+	span.SetAttributes(attribute.String("sha256", hash))
+
+	// the hash names files in the cache so it must not be able to name anything else
+	decoded, err := hex.DecodeString(hash)
+	if err != nil || len(decoded) != sha256.Size || hex.EncodeToString(decoded) != hash {
+		err = fmt.Errorf("invalid sha256: %q", hash)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid sha256")
+		return nil, err
+	}
+
+	for _, dir := range []string{c.objectsDir(), c.tmpDir(), c.locksDir()} {
+		err = os.MkdirAll(dir, 0700)
+		if err != nil {
+			span.AddEvent("cache dir unusable, not caching", trace.WithAttributes(
+				attribute.String("error", err.Error()),
+			))
+			span.RecordError(nil)
+			span.SetStatus(codes.Ok, "passed through url with unusable cache")
+			return c.fetcher.Fetch(ctx, url)
+		}
+	}
+
+	object := c.open(ctx, hash)
+	if object != nil {
+		span.AddEvent("fetch_cache_hit")
+		span.RecordError(nil)
+		span.SetStatus(codes.Ok, "fetched from cache")
+		return object, nil
+	}
+
+	// another process may be downloading the same object, wait for it instead of downloading
+	// it twice
+	unlock, err := lockFile(ctx, filepath.Join(c.locksDir(), hash+".lock"))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to lock object")
+		return nil, err
+	}
+	defer unlock()
+
+	object = c.open(ctx, hash)
+	if object != nil {
+		span.AddEvent("fetch_cache_hit_after_wait")
+		span.RecordError(nil)
+		span.SetStatus(codes.Ok, "fetched from cache")
+		return object, nil
+	}
+
+	span.AddEvent("fetch_cache_miss")
+	err = c.insert(ctx, url, hash)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to insert into cache")
+		return nil, err
+	}
+
+	err = c.evict(ctx, hash)
+	if err != nil {
+		// over the limit is better than failing the evaluation
+		span.AddEvent("failed to evict", trace.WithAttributes(
+			attribute.String("error", err.Error()),
+		))
+	}
+
+	object = c.open(ctx, hash)
+	if object == nil {
+		err = fmt.Errorf("cached object disappeared: %s", hash)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "cached object disappeared")
+		return nil, err
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "fetched into cache")
+	return object, nil
+}
+
+// Opens a cached object, marking it as recently used. Returns nil if it isn't cached.
+func (c *CachingFetcher) open(ctx context.Context, hash string) *os.File {
+	span := trace.SpanFromContext(ctx)
+	objectPath := filepath.Join(c.objectsDir(), hash)
+
+	f, err := os.Open(objectPath)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			span.AddEvent("failed to open cached object", trace.WithAttributes(
+				attribute.String("error", err.Error()),
+			))
+		}
+		return nil
+	}
+
+	now := time.Now()
+	err = os.Chtimes(objectPath, now, now)
+	if err != nil {
+		span.AddEvent("failed to touch cached object", trace.WithAttributes(
+			attribute.String("error", err.Error()),
+		))
+	}
+
+	return f
+}
+
+// Downloads `url` into the cache, only keeping it when it matches `hash`
+func (c *CachingFetcher) insert(ctx context.Context, url string, hash string) error {
+	ctx, span := tracer.Start(ctx, "CachingFetcher.insert", trace.WithAttributes(
+		attribute.String("sha256", hash),
+	))
+	defer span.End()
+
+	body, err := c.fetcher.Fetch(ctx, url)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to fetch url")
+		return err
+	}
+	defer body.Close()
+
+	tmp, err := os.CreateTemp(c.tmpDir(), hash+"-*")
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to create temp file")
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hasher), body)
+	if err != nil {
+		tmp.Close()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to download")
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to close temp file")
+		return err
+	}
+
+	actual := hex.EncodeToString(hasher.Sum(nil))
+	span.SetAttributes(attribute.Int64("size", size))
+	if actual != hash {
+		err = fmt.Errorf("%w: expected %s, got %s", ErrHashMismatch, hash, actual)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "sha256 mismatch")
+		return err
+	}
+
+	err = os.Rename(tmp.Name(), filepath.Join(c.objectsDir(), hash))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to move object into cache")
+		return err
+	}
+
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "inserted object")
+	return nil
+}
+
+type cachedObject struct {
+	modTime time.Time
+	name    string
+	size    int64
+}
+
+// Removes least recently used objects until the cache fits in `maxBytes`, never removing `keep`.
+// Objects open elsewhere stay readable until they are closed.
+func (c *CachingFetcher) evict(ctx context.Context, keep string) error {
+	ctx, span := tracer.Start(ctx, "CachingFetcher.evict", trace.WithAttributes(
+		attribute.Int64("maxBytes", c.maxBytes),
+	))
+	defer span.End()
+
+	if c.maxBytes <= 0 {
+		span.RecordError(nil)
+		span.SetStatus(codes.Ok, "cache is unbounded")
+		return nil
+	}
+
+	unlock, err := lockFile(ctx, filepath.Join(c.dir, "evict.lock"))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to lock cache for eviction")
+		return err
+	}
+	defer unlock()
+
+	entries, err := os.ReadDir(c.objectsDir())
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to list cached objects")
+		return err
+	}
+
+	var total int64
+	objects := make([]cachedObject, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			// evicted by someone else in the meantime
+			continue
+		}
+		total += info.Size()
+		objects = append(objects, cachedObject{
+			modTime: info.ModTime(),
+			name:    entry.Name(),
+			size:    info.Size(),
+		})
+	}
+
+	slices.SortFunc(objects, func(a, b cachedObject) int {
+		return a.modTime.Compare(b.modTime)
+	})
+
+	evicted := 0
+	for _, object := range objects {
+		if total <= c.maxBytes {
+			break
+		}
+		if object.name == keep {
+			continue
+		}
+
+		err = os.Remove(filepath.Join(c.objectsDir(), object.name))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to evict object")
+			return err
+		}
+		total -= object.size
+		evicted++
+	}
+
+	span.SetAttributes(
+		attribute.Int("evicted", evicted),
+		attribute.Int64("totalBytes", total),
+	)
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "evicted objects")
+	return nil
+}
+
+// How often a lock held by another process is tried again
+const lockPollInterval = 100 * time.Millisecond
+
+// Takes an exclusive lock on `path`, waiting until it is available or `ctx` ends
+func lockFile(ctx context.Context, path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	ticker := time.NewTicker(lockPollInterval)
+	defer ticker.Stop()
+	for {
+		err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			f.Close()
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
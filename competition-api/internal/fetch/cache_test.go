@@ -0,0 +1,174 @@
+package fetch_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/fetch"
+	mockfetcher "github.com/aixcyberchallenge/competition-api/competition-api/internal/fetch/mock"
+)
+
+func sha256Hex(content string) string {
+	hash := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(hash[:])
+}
+
+func body(content string) io.ReadCloser {
+	return io.NopCloser(bytes.NewBufferString(content))
+}
+
+func readAll(t *testing.T, r io.ReadCloser) string {
+	defer r.Close()
+	content, err := io.ReadAll(r)
+	require.NoError(t, err, "failed to read content")
+	return string(content)
+}
+
+func TestCaching(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("MissThenHit", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		inner := mockfetcher.NewMockFetcher(ctrl)
+		inner.EXPECT().Fetch(gomock.Any(), "a").Return(body("content"), nil).Times(1)
+
+		dir := t.TempDir()
+		hashes := map[string]string{"a": sha256Hex("content")}
+		fetcher := fetch.NewCachingFetcher(inner, dir, 0, hashes)
+
+		r, err := fetcher.Fetch(ctx, "a")
+		require.NoError(t, err)
+		assert.Equal(t, "content", readAll(t, r))
+
+		// another pod on the node sharing the cache dir
+		fetcher = fetch.NewCachingFetcher(inner, dir, 0, hashes)
+		r, err = fetcher.Fetch(ctx, "a")
+		require.NoError(t, err)
+		assert.Equal(t, "content", readAll(t, r))
+	})
+
+	t.Run("HashMismatch", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		inner := mockfetcher.NewMockFetcher(ctrl)
+		inner.EXPECT().Fetch(gomock.Any(), "a").Return(body("tampered"), nil).Times(1)
+
+		dir := t.TempDir()
+		fetcher := fetch.NewCachingFetcher(
+			inner,
+			dir,
+			0,
+			map[string]string{"a": sha256Hex("content")},
+		)
+
+		_, err := fetcher.Fetch(ctx, "a")
+		require.ErrorIs(t, err, fetch.ErrHashMismatch)
+
+		entries, err := os.ReadDir(filepath.Join(dir, "objects"))
+		require.NoError(t, err)
+		assert.Empty(t, entries, "mismatched content should not be cached")
+	})
+
+	t.Run("InvalidHash", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		inner := mockfetcher.NewMockFetcher(ctrl)
+
+		fetcher := fetch.NewCachingFetcher(
+			inner,
+			t.TempDir(),
+			0,
+			map[string]string{"a": "../../etc/passwd"},
+		)
+
+		_, err := fetcher.Fetch(ctx, "a")
+		assert.Error(t, err)
+	})
+
+	t.Run("LockWaitEndsWithContext", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		inner := mockfetcher.NewMockFetcher(ctrl)
+
+		dir := t.TempDir()
+		hash := sha256Hex("content")
+		fetcher := fetch.NewCachingFetcher(inner, dir, 0, map[string]string{"a": hash})
+
+		// another process downloading the same object
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "locks"), 0700))
+		lock, err := os.Create(filepath.Join(dir, "locks", hash+".lock"))
+		require.NoError(t, err)
+		defer lock.Close()
+		require.NoError(t, syscall.Flock(int(lock.Fd()), syscall.LOCK_EX))
+
+		ctx, cancel := context.WithTimeout(ctx, 300*time.Millisecond)
+		defer cancel()
+		_, err = fetcher.Fetch(ctx, "a")
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("Passthrough", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		inner := mockfetcher.NewMockFetcher(ctrl)
+		inner.EXPECT().
+			Fetch(gomock.Any(), "b").
+			DoAndReturn(func(context.Context, string) (io.ReadCloser, error) {
+				return body("other"), nil
+			}).
+			Times(2)
+
+		fetcher := fetch.NewCachingFetcher(inner, t.TempDir(), 0, map[string]string{})
+		for range 2 {
+			r, err := fetcher.Fetch(ctx, "b")
+			require.NoError(t, err)
+			assert.Equal(t, "other", readAll(t, r))
+		}
+	})
+
+	t.Run("EvictsLeastRecentlyUsed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		inner := mockfetcher.NewMockFetcher(ctrl)
+		inner.EXPECT().Fetch(gomock.Any(), "a").Return(body("aaaa"), nil).Times(1)
+		inner.EXPECT().Fetch(gomock.Any(), "b").Return(body("bbbb"), nil).Times(1)
+		inner.EXPECT().Fetch(gomock.Any(), "c").Return(body("cccc"), nil).Times(1)
+
+		dir := t.TempDir()
+		hashes := map[string]string{
+			"a": sha256Hex("aaaa"),
+			"b": sha256Hex("bbbb"),
+			"c": sha256Hex("cccc"),
+		}
+		fetcher := fetch.NewCachingFetcher(inner, dir, 8, hashes)
+
+		for _, url := range []string{"a", "b"} {
+			r, err := fetcher.Fetch(ctx, url)
+			require.NoError(t, err)
+			readAll(t, r)
+		}
+
+		// a was used most recently, so b goes when c is inserted
+		old := time.Now().Add(-time.Hour)
+		require.NoError(t, os.Chtimes(filepath.Join(dir, "objects", hashes["b"]), old, old))
+		r, err := fetcher.Fetch(ctx, "a")
+		require.NoError(t, err)
+		readAll(t, r)
+
+		r, err = fetcher.Fetch(ctx, "c")
+		require.NoError(t, err)
+		readAll(t, r)
+
+		_, err = os.Stat(filepath.Join(dir, "objects", hashes["b"]))
+		require.ErrorIs(t, err, os.ErrNotExist, "least recently used object should be evicted")
+		_, err = os.Stat(filepath.Join(dir, "objects", hashes["a"]))
+		require.NoError(t, err, "recently used object should be kept")
+	})
+}
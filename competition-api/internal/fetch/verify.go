@@ -0,0 +1,122 @@
+package fetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Ensure VerifyingFetcher implements Fetcher interface.
+var _ Fetcher = (*VerifyingFetcher)(nil)
+
+// Checks the sha256 of everything fetched from urls with a known hash as it is read. Reading to
+// the end or closing the body returns [ErrHashMismatch] when the content doesn't match, so callers
+// must check the error from Close.
+type VerifyingFetcher struct {
+	fetcher Fetcher
+	// sha256 of the content expected at each url
+	hashes map[string]string
+}
+
+func NewVerifyingFetcher(fetcher Fetcher, hashes map[string]string) *VerifyingFetcher {
+	return &VerifyingFetcher{
+		fetcher: fetcher,
+		hashes:  hashes,
+	}
+}
+
+func (v *VerifyingFetcher) Fetch(ctx context.Context, url string) (io.ReadCloser, error) {
+	ctx, span := tracer.Start(ctx, "VerifyingFetcher.Fetch", trace.WithAttributes(
+		attribute.String("url", url),
+	))
+	defer span.End()
+
+	body, err := v.fetcher.Fetch(ctx, url)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to fetch")
+		return nil, err
+	}
+
+	expected := v.hashes[url]
+	if expected == "" {
+		span.AddEvent("no expected sha256, not verifying")
+		span.RecordError(nil)
+		span.SetStatus(codes.Ok, "fetched unverified url")
+		return body, nil
+	}
+
+	span.SetAttributes(attribute.String("sha256", expected))
+	span.RecordError(nil)
+	span.SetStatus(codes.Ok, "fetched url to verify")
+	return &verifyingReader{
+		body:     body,
+		hasher:   sha256.New(),
+		expected: expected,
+	}, nil
+}
+
+type verifyingReader struct {
+	body     io.ReadCloser
+	hasher   hash.Hash
+	err      error
+	expected string
+	verified bool
+}
+
+func (r *verifyingReader) verify() error {
+	if r.verified {
+		return r.err
+	}
+	r.verified = true
+
+	actual := hex.EncodeToString(r.hasher.Sum(nil))
+	if actual != r.expected {
+		r.err = fmt.Errorf("%w: expected %s, got %s", ErrHashMismatch, r.expected, actual)
+	}
+	return r.err
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	if r.verified {
+		if r.err != nil {
+			return 0, r.err
+		}
+		return 0, io.EOF
+	}
+
+	n, err := r.body.Read(p)
+	r.hasher.Write(p[:n])
+	if errors.Is(err, io.EOF) {
+		if verifyErr := r.verify(); verifyErr != nil {
+			return n, verifyErr
+		}
+	}
+	return n, err
+}
+
+// Reads whatever the consumer left unread so the whole body is verified
+func (r *verifyingReader) Close() error {
+	if !r.verified {
+		_, err := io.Copy(r.hasher, r.body)
+		if err != nil {
+			r.body.Close()
+			return err
+		}
+	}
+
+	err := r.verify()
+	closeErr := r.body.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
@@ -0,0 +1,75 @@
+package fetch_test
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/fetch"
+	mockfetcher "github.com/aixcyberchallenge/competition-api/competition-api/internal/fetch/mock"
+)
+
+func TestVerifying(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Match", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		inner := mockfetcher.NewMockFetcher(ctrl)
+		inner.EXPECT().Fetch(gomock.Any(), "a").Return(body("content"), nil).Times(1)
+
+		fetcher := fetch.NewVerifyingFetcher(inner, map[string]string{"a": sha256Hex("content")})
+
+		r, err := fetcher.Fetch(ctx, "a")
+		require.NoError(t, err)
+		content, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, "content", string(content))
+		assert.NoError(t, r.Close())
+	})
+
+	t.Run("MismatchAtEOF", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		inner := mockfetcher.NewMockFetcher(ctrl)
+		inner.EXPECT().Fetch(gomock.Any(), "a").Return(body("tampered"), nil).Times(1)
+
+		fetcher := fetch.NewVerifyingFetcher(inner, map[string]string{"a": sha256Hex("content")})
+
+		r, err := fetcher.Fetch(ctx, "a")
+		require.NoError(t, err)
+		_, err = io.ReadAll(r)
+		require.ErrorIs(t, err, fetch.ErrHashMismatch)
+		assert.ErrorIs(t, r.Close(), fetch.ErrHashMismatch)
+	})
+
+	t.Run("MismatchOnClose", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		inner := mockfetcher.NewMockFetcher(ctrl)
+		inner.EXPECT().Fetch(gomock.Any(), "a").Return(body("tampered"), nil).Times(1)
+
+		fetcher := fetch.NewVerifyingFetcher(inner, map[string]string{"a": sha256Hex("content")})
+
+		// consumers like tar stop reading before the end of the body
+		r, err := fetcher.Fetch(ctx, "a")
+		require.NoError(t, err)
+		buf := make([]byte, 3)
+		_, err = r.Read(buf)
+		require.NoError(t, err)
+		assert.ErrorIs(t, r.Close(), fetch.ErrHashMismatch)
+	})
+
+	t.Run("Passthrough", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		inner := mockfetcher.NewMockFetcher(ctrl)
+		inner.EXPECT().Fetch(gomock.Any(), "b").Return(body("other"), nil).Times(1)
+
+		fetcher := fetch.NewVerifyingFetcher(inner, map[string]string{"a": sha256Hex("content")})
+
+		r, err := fetcher.Fetch(ctx, "b")
+		require.NoError(t, err)
+		assert.Equal(t, "other", readAll(t, r))
+	})
+}
@@ -0,0 +1,211 @@
+// Checks patches against a challenge's [types.PatchPolicy]. The server checks submissions
+// before accepting them and the worker checks again before applying, so both read patches the
+// same way through this package.
+package patchpolicy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+)
+
+// Parses a unified diff. Names are trimmed and are paths in the repo like `git apply -p1` reads
+// them, traditional diffs keep their first component and have it dropped here like git headers
+// do whatever it is.
+func Parse(r io.Reader) ([]*gitdiff.File, error) {
+	patch, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading patch file: %w", err)
+	}
+
+	var files []*gitdiff.File
+	for _, section := range splitGitHeaders(patch) {
+		sectionFiles, _, err := gitdiff.Parse(bytes.NewReader(section))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing patch file: %w", err)
+		}
+
+		for i, file := range sectionFiles {
+			file.NewName = strings.TrimSpace(file.NewName)
+			file.OldName = strings.TrimSpace(file.OldName)
+			// the parser already dropped the first component of names under a git header
+			if i == 0 && bytes.HasPrefix(section, gitHeader) {
+				continue
+			}
+			file.NewName, err = dropComponent(file.NewName)
+			if err != nil {
+				return nil, err
+			}
+			file.OldName, err = dropComponent(file.OldName)
+			if err != nil {
+				return nil, err
+			}
+		}
+		files = append(files, sectionFiles...)
+	}
+
+	return files, nil
+}
+
+var gitHeader = []byte("diff --git ")
+
+// Splits `patch` before every git file header. Files after the first in a section are
+// traditional diffs, like all files of a first section without a git header.
+func splitGitHeaders(patch []byte) [][]byte {
+	var sections [][]byte
+	start := 0
+	for offset := 0; offset < len(patch); {
+		end := bytes.IndexByte(patch[offset:], '\n')
+		next := len(patch)
+		if end >= 0 {
+			next = offset + end + 1
+		}
+		if offset > start && bytes.HasPrefix(patch[offset:], gitHeader) {
+			sections = append(sections, patch[start:offset])
+			start = offset
+		}
+		offset = next
+	}
+	return append(sections, patch[start:])
+}
+
+// Drops the first component of a traditional diff's `name` like `git apply -p1`, repeated
+// slashes count as one
+func dropComponent(name string) (string, error) {
+	if name == "" {
+		return name, nil
+	}
+	_, repoPath, _ := strings.Cut(name, "/")
+	repoPath = strings.TrimLeft(repoPath, "/")
+	if repoPath == "" {
+		return "", fmt.Errorf("patch path %q has no component for git to drop", name)
+	}
+	return repoPath, nil
+}
+
+// Lists everything in `files` that breaks `policy`, one violation per file and rule
+func Check(policy *types.PatchPolicy, files []*gitdiff.File) []types.PatchViolation {
+	if policy == nil {
+		return nil
+	}
+
+	var violations []types.PatchViolation
+	if policy.MaxFiles > 0 && len(files) > policy.MaxFiles {
+		violations = append(violations, types.PatchViolation{Rule: types.PatchRuleMaxFiles})
+	}
+
+	hunks := 0
+	for _, file := range files {
+		hunks += len(file.TextFragments)
+		if file.BinaryFragment != nil {
+			hunks++
+		}
+
+		for _, name := range changedPaths(file) {
+			if !inRepo(name) {
+				violations = append(violations, types.PatchViolation{
+					Path: name,
+					Rule: types.PatchRuleInvalidPath,
+				})
+				continue
+			}
+			if rule, ok := checkPath(policy, name); !ok {
+				violations = append(violations, types.PatchViolation{Path: name, Rule: rule})
+			}
+		}
+	}
+	if policy.MaxHunks > 0 && hunks > policy.MaxHunks {
+		violations = append(violations, types.PatchViolation{Rule: types.PatchRuleMaxHunks})
+	}
+
+	return violations
+}
+
+// Describes `violations` for errors and reasons, one file after the other
+func Describe(violations []types.PatchViolation) string {
+	descriptions := make([]string, 0, len(violations))
+	for _, v := range violations {
+		if v.Path == "" {
+			descriptions = append(descriptions, string(v.Rule))
+			continue
+		}
+		descriptions = append(descriptions, fmt.Sprintf("%s: %s", v.Path, v.Rule))
+	}
+	return strings.Join(descriptions, "; ")
+}
+
+// Paths a file's change touches, renames and copies touch both
+func changedPaths(file *gitdiff.File) []string {
+	var paths []string
+	if !file.IsNew && file.OldName != "" {
+		paths = append(paths, file.OldName)
+	}
+	if !file.IsDelete && file.NewName != "" && !slices.Contains(paths, file.NewName) {
+		paths = append(paths, file.NewName)
+	}
+	return paths
+}
+
+// Whether git writes `name` inside the repo
+func inRepo(name string) bool {
+	return !path.IsAbs(name) &&
+		path.Clean(name) == name &&
+		!slices.Contains(strings.Split(name, "/"), "..")
+}
+
+func checkPath(policy *types.PatchPolicy, name string) (types.PatchPolicyRule, bool) {
+	if slices.ContainsFunc(policy.HarnessSources, func(pattern string) bool {
+		return Match(pattern, name)
+	}) {
+		return types.PatchRuleHarnessSource, false
+	}
+	if slices.ContainsFunc(policy.Deny, func(pattern string) bool {
+		return Match(pattern, name)
+	}) {
+		return types.PatchRuleDenied, false
+	}
+	if len(policy.Allow) > 0 && !slices.ContainsFunc(policy.Allow, func(pattern string) bool {
+		return Match(pattern, name)
+	}) {
+		return types.PatchRuleNotAllowed, false
+	}
+	return "", true
+}
+
+// Reports whether `name` matches glob `pattern`. Segments match like [path.Match] and a `**`
+// segment matches any number of directories. Malformed patterns match nothing.
+func Match(pattern, name string) bool {
+	return matchSegments(
+		strings.Split(path.Clean(pattern), "/"),
+		strings.Split(path.Clean(name), "/"),
+	)
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := range len(name) + 1 {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		ok, err := path.Match(pattern[0], name[0])
+		if err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,149 @@
+package patchpolicy_test
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/patchpolicy"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/types"
+)
+
+func diff(name string, hunks int) string {
+	var b strings.Builder
+	b.WriteString("diff --git a/" + name + " b/" + name + "\n")
+	b.WriteString("--- a/" + name + "\n")
+	b.WriteString("+++ b/" + name + "\n")
+	for i := range hunks {
+		line := strconv.Itoa(i*10 + 1)
+		b.WriteString("@@ -" + line + ",1 +" + line + ",1 @@\n")
+		b.WriteString("-old\n+new\n")
+	}
+	return b.String()
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"src/*.c", "src/main.c", true},
+		{"src/*.c", "src/lib/main.c", false},
+		{"**/*_test.c", "tests/unit/a_test.c", true},
+		{"**/*_test.c", "a_test.c", true},
+		{"fuzz/**", "fuzz/harness/fuzzer.c", true},
+		{"fuzz/**", "src/fuzzer.c", false},
+		{"build.sh", "build.sh", true},
+		{"[", "[", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.match, patchpolicy.Match(tt.pattern, tt.name))
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	patch := diff("src/main.c", 2) + diff("fuzz/fuzzer.c", 1) + diff("tests/run.sh", 1)
+	files, err := patchpolicy.Parse(strings.NewReader(patch))
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+
+	tests := []struct {
+		name       string
+		policy     *types.PatchPolicy
+		violations []types.PatchViolation
+	}{
+		{name: "NoPolicy"},
+		{name: "Permissive", policy: &types.PatchPolicy{MaxFiles: 3, MaxHunks: 4}},
+		{
+			name:   "HarnessSource",
+			policy: &types.PatchPolicy{HarnessSources: []string{"fuzz/fuzzer.c"}},
+			violations: []types.PatchViolation{
+				{Path: "fuzz/fuzzer.c", Rule: types.PatchRuleHarnessSource},
+			},
+		},
+		{
+			name:   "Deny",
+			policy: &types.PatchPolicy{Deny: []string{"tests/**", "**/*.sh"}},
+			violations: []types.PatchViolation{
+				{Path: "tests/run.sh", Rule: types.PatchRuleDenied},
+			},
+		},
+		{
+			name:   "Allow",
+			policy: &types.PatchPolicy{Allow: []string{"src/**"}},
+			violations: []types.PatchViolation{
+				{Path: "fuzz/fuzzer.c", Rule: types.PatchRuleNotAllowed},
+				{Path: "tests/run.sh", Rule: types.PatchRuleNotAllowed},
+			},
+		},
+		{
+			name:   "Limits",
+			policy: &types.PatchPolicy{MaxFiles: 2, MaxHunks: 3},
+			violations: []types.PatchViolation{
+				{Rule: types.PatchRuleMaxFiles},
+				{Rule: types.PatchRuleMaxHunks},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.violations, patchpolicy.Check(tt.policy, files))
+		})
+	}
+}
+
+func TestCheckDeletedHarness(t *testing.T) {
+	patch := "diff --git a/fuzz/fuzzer.c b/fuzz/fuzzer.c\n" +
+		"deleted file mode 100644\n" +
+		"--- a/fuzz/fuzzer.c\n" +
+		"+++ /dev/null\n" +
+		"@@ -1 +0,0 @@\n" +
+		"-int main() {}\n"
+	files, err := patchpolicy.Parse(strings.NewReader(patch))
+	require.NoError(t, err)
+
+	violations := patchpolicy.Check(
+		&types.PatchPolicy{HarnessSources: []string{"fuzz/*.c"}},
+		files,
+	)
+	assert.Equal(t, []types.PatchViolation{
+		{Path: "fuzz/fuzzer.c", Rule: types.PatchRuleHarnessSource},
+	}, violations)
+	assert.Equal(t, "fuzz/fuzzer.c: harness_source", patchpolicy.Describe(violations))
+}
+
+// git apply -p1 drops any first component, not only a/ and b/
+func TestCheckNonStandardPrefixes(t *testing.T) {
+	modify := func(oldName, newName string) string {
+		return "--- " + oldName + "\n+++ " + newName + "\n@@ -1 +1 @@\n-old\n+new\n"
+	}
+	patch := modify("x/fuzz/harness.c", "y/fuzz/harness.c") +
+		"diff --git x/src/main.c y/src/main.c\n" + modify("x/src/main.c", "y/src/main.c") +
+		modify("a/../outside.c", "b/../outside.c") +
+		modify("a//tests/run.sh", "b//tests/run.sh")
+	files, err := patchpolicy.Parse(strings.NewReader(patch))
+	require.NoError(t, err)
+	require.Len(t, files, 4)
+	assert.Equal(t, "src/main.c", files[1].NewName, "git headers drop any first component too")
+
+	violations := patchpolicy.Check(
+		&types.PatchPolicy{HarnessSources: []string{"fuzz/*.c"}, Deny: []string{"tests/**"}},
+		files,
+	)
+	assert.Equal(t, []types.PatchViolation{
+		{Path: "fuzz/harness.c", Rule: types.PatchRuleHarnessSource},
+		{Path: "../outside.c", Rule: types.PatchRuleInvalidPath},
+		{Path: "tests/run.sh", Rule: types.PatchRuleDenied},
+	}, violations)
+
+	_, err = patchpolicy.Parse(strings.NewReader(modify("harness.c", "harness.c")))
+	require.Error(t, err, "git can't drop a component of a bare name")
+}
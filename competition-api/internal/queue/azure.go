@@ -139,11 +139,26 @@ loop:
 	if err != nil {
 		var pe *PoisonError
 		if !errors.As(err, &pe) {
-			// let timeout expire and the message go back to the queue
 			span.AddEvent("failed_message_handler", trace.WithAttributes(
 				attribute.String("error", err.Error()),
 			))
-			// TODO: update message to reduce the timeout
+
+			// make the message visible again right away rather than once its timeout expires,
+			// also when the handler failed because ctx was cancelled
+			visibleNow := int32(0)
+			_, err = q.az.UpdateMessage(
+				context.WithoutCancel(ctx),
+				*msgInstance.MessageID,
+				*msgInstance.PopReceipt,
+				*msgInstance.MessageText,
+				&azqueue.UpdateMessageOptions{VisibilityTimeout: &visibleNow},
+			)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, "failed to release message")
+				return err
+			}
+
 			span.RecordError(nil)
 			span.SetStatus(codes.Ok, "dequeued message but failed to handle")
 			return nil
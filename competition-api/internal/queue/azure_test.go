@@ -3,6 +3,7 @@ package queue_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -114,5 +115,26 @@ func TestAzure(t *testing.T) {
 			err := queuer.Dequeue(ctx, time.Minute, handler)
 			require.NoError(t, err, "failed to dequeue message")
 		})
+
+		t.Run("HandlerFailed", func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			handler := mockqueue.NewMockMessageHandler(ctrl)
+
+			msg := "def"
+			_, err = queueclient.EnqueueMessage(ctx, msg, nil)
+			require.NoError(t, err, "enqueing message")
+
+			handler.EXPECT().
+				Handle(gomock.Any(), gomock.Eq([]byte(msg))).
+				Return(errors.New("handler failed")).
+				Times(1)
+			require.NoError(t, queuer.Dequeue(ctx, time.Hour, handler))
+
+			// back in the queue long before the hour is up
+			handler.EXPECT().Handle(gomock.Any(), gomock.Eq([]byte(msg))).Times(1)
+			cctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			defer cancel()
+			require.NoError(t, queuer.Dequeue(cctx, time.Hour, handler))
+		})
 	})
 }
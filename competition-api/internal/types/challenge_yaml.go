@@ -13,13 +13,31 @@ import (
 	"github.com/aixcyberchallenge/competition-api/competition-api/internal/validator"
 )
 
+// A known vulnerability shipped with the challenge, used by pre-flight to check the challenge
+// works end to end. Paths are relative to the .aixcc directory.
+type GroundTruth struct {
+	POV       string `yaml:"pov"       json:"pov"       validate:"required"`
+	Harness   string `yaml:"harness"   json:"harness"   validate:"required"`
+	Sanitizer string `yaml:"sanitizer" json:"sanitizer" validate:"required"`
+	Engine    string `yaml:"engine"    json:"engine"`
+	// Optional patch expected to fix the POV
+	Patch string `yaml:"patch" json:"patch"`
+}
+
 type ChallengeYAML struct {
-	FuzzToolingProjectName string   `yaml:"fuzz_tooling_project_name" validate:"required"`
-	FuzzToolingURL         string   `yaml:"fuzz_tooling_url"          validate:"required"`
-	FuzzToolingRef         string   `yaml:"fuzz_tooling_ref"          validate:"required"`
-	HarnessesList          []string `yaml:"harnesses"`
-	MemoryGB               int      `yaml:"required_memory_gb"`
-	CPUs                   int      `yaml:"cpus"`
+	// How often POVs must crash the challenge, nil uses [DefaultReproducibilityPolicy]
+	Reproducibility *ReproducibilityPolicy `yaml:"reproducibility"           json:"reproducibility"`
+	// What patches may change, nil only checks languages
+	PatchPolicy            *PatchPolicy  `yaml:"patch_policy"              json:"patch_policy"`
+	FuzzToolingProjectName string        `yaml:"fuzz_tooling_project_name" json:"fuzz_tooling_project_name" validate:"required"`
+	FuzzToolingURL         string        `yaml:"fuzz_tooling_url"          json:"fuzz_tooling_url"          validate:"required"`
+	FuzzToolingRef         string        `yaml:"fuzz_tooling_ref"          json:"fuzz_tooling_ref"          validate:"required"`
+	HarnessesList          []string      `yaml:"harnesses"                 json:"harnesses"`
+	GroundTruth            []GroundTruth `yaml:"ground_truth"              json:"ground_truth"              validate:"dive"`
+	MemoryGB               int           `yaml:"required_memory_gb"        json:"required_memory_gb"`
+	CPUs                   int           `yaml:"cpus"                      json:"cpus"`
+	// Unset timeouts use the server's configured defaults
+	Timeouts EvalTimeouts `yaml:"timeouts" json:"timeouts"`
 }
 
 func ParseChallengeYAML(
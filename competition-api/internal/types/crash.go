@@ -0,0 +1,24 @@
+package types
+
+type (
+	CrashFrame struct {
+		Function string `json:"function"`
+		// Source file and line when symbolized, otherwise the module and offset
+		Location string `json:"location,omitempty"`
+	}
+
+	// What a POV triggered, parsed from the fuzzer output
+	CrashReport struct {
+		// Sanitizer that reported the crash, like AddressSanitizer or Jazzer
+		Sanitizer string `json:"sanitizer"`
+		// Kind of crash, like heap-buffer-overflow or the Java exception class
+		Type string `json:"type"`
+		// Faulting address, empty when the report has none
+		Address string `json:"address,omitempty"`
+		// Sanitizer SUMMARY line, or the exception line for Jazzer
+		Summary string `json:"summary,omitempty"`
+		// Type and top frames without addresses, equal for crashes with the same cause
+		Signature string       `json:"signature"`
+		Frames    []CrashFrame `json:"frames"`
+	}
+)
@@ -0,0 +1,144 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/identifier"
+	"github.com/aixcyberchallenge/competition-api/competition-api/internal/validator"
+)
+
+// Version of [EvalSpec] this build writes and understands. Bump it on changes older workers or
+// servers can't handle.
+const EvalSpecVersion = 1
+
+var ErrEvalSpecVersion = errors.New("unsupported eval spec version")
+
+type (
+	// A file the worker fetches, checked against SHA256 when it is known
+	EvalSource struct {
+		URL    string `json:"url"              validate:"required"`
+		SHA256 string `json:"sha256,omitempty"`
+	}
+
+	// Trigger to run against the head repo, and the base repo for delta scans
+	EvalPOV struct {
+		Trigger     EvalSource    `json:"trigger"`
+		Sanitizer   string        `json:"sanitizer"    validate:"required"`
+		HarnessName string        `json:"harness_name" validate:"required"`
+		Engine      FuzzingEngine `json:"engine"       validate:"required"`
+	}
+
+	// Patch to apply to the head repo before building
+	EvalPatch struct {
+		// What the patch may change beyond its language, nil only checks languages
+		Policy           *PatchPolicy             `json:"policy,omitempty"`
+		Patch            EvalSource               `json:"patch"`
+		AllowedLanguages identifier.LanguageSlice `json:"allowed_languages" validate:"required,dive,oneof=c java"`
+		SkipTests        bool                     `json:"skip_tests"`
+	}
+
+	// What the task's evaluations are given, each container of the challenge scripts is limited
+	// to it
+	EvalResources struct {
+		MemoryGB int `json:"memory_gb" validate:"gte=0"`
+		CPUs     int `json:"cpus"      validate:"gte=0"`
+	}
+
+	// Everything `worker eval` needs to run one evaluation. Built by the server, stored on the
+	// evaluated row so it can be rerun the same way and handed to the worker as JSON.
+	EvalSpec struct {
+		// Only set for delta scans, POVs must not crash it
+		BaseRepo *EvalSource `json:"base_repo,omitempty"`
+		POV      *EvalPOV    `json:"pov,omitempty"`
+		Patch    *EvalPatch  `json:"patch,omitempty"`
+		// Containers are unlimited when nil
+		Resources *EvalResources `json:"resources,omitempty"`
+		// How often the POV must crash the head repo, nil uses [DefaultReproducibilityPolicy]
+		Reproducibility *ReproducibilityPolicy `json:"reproducibility,omitempty"`
+		// Limits on the evaluation and its steps, nil uses [DefaultEvalTimeouts]
+		Timeouts    *EvalTimeouts `json:"timeouts,omitempty"`
+		HeadRepo    EvalSource    `json:"head_repo"`
+		FuzzTooling EvalSource    `json:"fuzz_tooling"`
+		// Row the worker reports results for, empty for manual runs
+		Entity       JobType      `json:"entity,omitempty"    validate:"omitempty,oneof=job pov patch"`
+		EntityID     string       `json:"entity_id,omitempty" validate:"required_with=Entity"`
+		Focus        string       `json:"focus"               validate:"required"`
+		ProjectName  string       `json:"project_name"        validate:"required"`
+		Architecture Architecture `json:"architecture"        validate:"required"`
+		Version      int          `json:"version"`
+		// Build the head repo even when there is no POV or patch to test
+		Build         bool `json:"build,omitempty"`
+		ArchiveS3     bool `json:"archive_s3,omitempty"`
+		ExportResults bool `json:"export_results,omitempty"`
+	}
+)
+
+func NewEvalPOV(
+	triggerURL string,
+	triggerSHA256 string,
+	sanitizer string,
+	harnessName string,
+	engine FuzzingEngine,
+) *EvalPOV {
+	if engine == "" {
+		engine = FuzzingEngineLibFuzzer
+	}
+	return &EvalPOV{
+		Trigger:     EvalSource{URL: triggerURL, SHA256: triggerSHA256},
+		Sanitizer:   sanitizer,
+		HarnessName: harnessName,
+		Engine:      engine,
+	}
+}
+
+// Patches may change any language the worker knows how to build, within the task's `policy`
+func NewEvalPatch(
+	patchURL string,
+	patchSHA256 string,
+	skipTests bool,
+	policy *PatchPolicy,
+) *EvalPatch {
+	return &EvalPatch{
+		Policy: policy,
+		Patch:  EvalSource{URL: patchURL, SHA256: patchSHA256},
+		AllowedLanguages: identifier.LanguageSlice{
+			identifier.LanguageC,
+			identifier.LanguageJava,
+		},
+		SkipTests: skipTests,
+	}
+}
+
+// Checks the spec was written for this version and is complete
+func (s *EvalSpec) Validate() error {
+	if s.Version != EvalSpecVersion {
+		return fmt.Errorf("%w: %d, expected %d", ErrEvalSpecVersion, s.Version, EvalSpecVersion)
+	}
+
+	v := validator.Create()
+	return v.Validate(s)
+}
+
+// Timeouts the evaluation runs with, unset ones filled from [DefaultEvalTimeouts]
+func (s *EvalSpec) EvalTimeouts() EvalTimeouts {
+	if s.Timeouts == nil {
+		return DefaultEvalTimeouts
+	}
+	return s.Timeouts.WithDefaults(DefaultEvalTimeouts)
+}
+
+func ParseEvalSpec(data []byte) (*EvalSpec, error) {
+	var spec EvalSpec
+	err := json.Unmarshal(data, &spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal eval spec: %w", err)
+	}
+
+	err = spec.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid eval spec: %w", err)
+	}
+	return &spec, nil
+}
@@ -0,0 +1,87 @@
+package types
+
+import (
+	"slices"
+	"time"
+)
+
+type (
+	EvalStepName    string
+	EvalStepOutcome string
+
+	// One phase of an evaluation. Workers report it once when it starts and again with the
+	// outcome when it finishes, both times with the same Run and Seq.
+	EvalStep struct {
+		StartedAt  time.Time       `json:"started_at"`
+		FinishedAt *time.Time      `json:"finished_at,omitempty"`
+		Name       EvalStepName    `json:"name"`
+		Outcome    EvalStepOutcome `json:"outcome"`
+		// What was fetched, only set for fetch steps
+		Target string `json:"target,omitempty"`
+		// Repo the step ran against, empty for steps not tied to one
+		Context ResultContext `json:"context,omitempty"`
+		// Why the step failed or errored
+		Error string `json:"error,omitempty"`
+		// Run of the evaluation the step belongs to, a UUIDv7 so later runs sort after earlier
+		// ones. An evaluation picked up again after its worker failed starts a new run.
+		Run        string `json:"run,omitempty"`
+		DurationMS int64  `json:"duration_ms,omitempty"`
+		// Order the step started in within its run
+		Seq int `json:"seq"`
+	}
+
+	EvalStepsResponse struct {
+		Steps []EvalStep `json:"steps"`
+	}
+)
+
+const (
+	EvalStepFetch      EvalStepName = "fetch"
+	EvalStepCheck      EvalStepName = "check"
+	EvalStepBuild      EvalStepName = "build"
+	EvalStepApplyPatch EvalStepName = "apply_patch"
+	EvalStepRunPov     EvalStepName = "run_pov"
+	EvalStepRunTests   EvalStepName = "run_tests"
+
+	EvalStepRunning EvalStepOutcome = "running"
+	EvalStepPassed  EvalStepOutcome = "passed"
+	EvalStepFailed  EvalStepOutcome = "failed"
+	EvalStepErrored EvalStepOutcome = "errored"
+	// Ran out of its own time, see [EvalTimeouts]
+	EvalStepTimedOut EvalStepOutcome = "timed_out"
+)
+
+// Finishes the step at `now` with `outcome`
+func (s EvalStep) Finish(now time.Time, outcome EvalStepOutcome, reason string) EvalStep {
+	s.FinishedAt = &now
+	s.Outcome = outcome
+	s.Error = reason
+	s.DurationMS = now.Sub(s.StartedAt).Milliseconds()
+	return s
+}
+
+// Adds `step` to `steps` ordered by Seq. Queue messages can arrive out of order, so a finished
+// step is never replaced by its own start. `steps` only hold the latest run, the first step of a
+// new run replaces them and late steps of an earlier run are dropped.
+func MergeEvalStep(steps []EvalStep, step EvalStep) []EvalStep {
+	if len(steps) > 0 && steps[0].Run != step.Run {
+		if step.Run < steps[0].Run {
+			return steps
+		}
+		steps = nil
+	}
+
+	i, found := slices.BinarySearchFunc(steps, step.Seq, func(s EvalStep, seq int) int {
+		return s.Seq - seq
+	})
+	if !found {
+		return slices.Insert(slices.Clone(steps), i, step)
+	}
+	if steps[i].FinishedAt != nil && step.FinishedAt == nil {
+		return steps
+	}
+
+	steps = slices.Clone(steps)
+	steps[i] = step
+	return steps
+}
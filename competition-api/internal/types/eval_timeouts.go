@@ -0,0 +1,55 @@
+package types
+
+import "time"
+
+// How long an evaluation and its steps may take in seconds. Zero leaves a step without a limit
+// of its own, it is still bounded by the whole evaluation's.
+type EvalTimeouts struct {
+	BuildSecs int `yaml:"build_secs"   json:"build_secs,omitempty"   validate:"min=0"`
+	// Each run of the trigger, run_pov.sh is handed it as the fuzzer timeout
+	RunPovSecs int `yaml:"run_pov_secs" json:"run_pov_secs,omitempty" validate:"min=0"`
+	TestsSecs  int `yaml:"tests_secs"   json:"tests_secs,omitempty"   validate:"min=0"`
+	// Whole evaluation, including fetching the sources
+	TotalSecs int `yaml:"total_secs"   json:"total_secs,omitempty"   validate:"min=0"`
+}
+
+// What evaluations ran with before timeouts were configurable
+var DefaultEvalTimeouts = EvalTimeouts{
+	RunPovSecs: 1800,
+	TotalSecs:  int((8 * time.Hour).Seconds()),
+}
+
+// Fills the timeouts `t` leaves unset from `defaults`
+func (t EvalTimeouts) WithDefaults(defaults EvalTimeouts) EvalTimeouts {
+	if t.BuildSecs == 0 {
+		t.BuildSecs = defaults.BuildSecs
+	}
+	if t.RunPovSecs == 0 {
+		t.RunPovSecs = defaults.RunPovSecs
+	}
+	if t.TestsSecs == 0 {
+		t.TestsSecs = defaults.TestsSecs
+	}
+	if t.TotalSecs == 0 {
+		t.TotalSecs = defaults.TotalSecs
+	}
+	return t
+}
+
+// Limit for step `name`, zero when it has none. For run_pov this is a single run of the trigger.
+func (t EvalTimeouts) Step(name EvalStepName) time.Duration {
+	var secs int
+	switch name {
+	case EvalStepBuild:
+		secs = t.BuildSecs
+	case EvalStepRunPov:
+		secs = t.RunPovSecs
+	case EvalStepRunTests:
+		secs = t.TestsSecs
+	}
+	return time.Duration(secs) * time.Second
+}
+
+func (t EvalTimeouts) Total() time.Duration {
+	return time.Duration(t.TotalSecs) * time.Second
+}
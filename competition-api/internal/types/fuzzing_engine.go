@@ -4,4 +4,23 @@ type FuzzingEngine string
 
 const (
 	FuzzingEngineLibFuzzer FuzzingEngine = "libfuzzer"
+	FuzzingEngineAFL       FuzzingEngine = "afl"
+	FuzzingEngineHonggfuzz FuzzingEngine = "honggfuzz"
 )
+
+// Engines the worker can build and reproduce with, what a project supports when its project.yaml
+// lists none unless it is a JVM project
+var DefaultFuzzingEngines = []FuzzingEngine{
+	FuzzingEngineLibFuzzer,
+	FuzzingEngineAFL,
+	FuzzingEngineHonggfuzz,
+}
+
+// Engines a project in `language` supports when its project.yaml lists none. JVM projects are
+// only fuzzed with libfuzzer, through jazzer.
+func DefaultFuzzingEnginesFor(language string) []FuzzingEngine {
+	if language == "jvm" {
+		return []FuzzingEngine{FuzzingEngineLibFuzzer}
+	}
+	return DefaultFuzzingEngines
+}
@@ -16,6 +16,7 @@ const (
 	FileSARIFSubmission       ArchivedFile = "sarif_submission"
 	FileSARIFBroadcast        ArchivedFile = "sarif_broadcast"
 	FileFreeformPOV           ArchivedFile = "freeform_pov"
+	FileTestResults           ArchivedFile = "test_results"
 )
 
 type (
@@ -27,26 +28,43 @@ type (
 	}
 
 	JobArtifact struct {
-		Blob         Blob          `json:"blob"`
-		ArchivedFile ArchivedFile  `json:"archived_file"`
-		Filename     string        `json:"filename"`
-		Context      ResultContext `json:"context"`
+		// Parsed from fuzzer output artifacts that reported a crash
+		Crash *CrashReport `json:"crash,omitempty"`
+		// Crash rate of the runs behind head repo fuzzer output
+		Reproducibility *Reproducibility `json:"reproducibility,omitempty"`
+		Blob            Blob             `json:"blob"`
+		ArchivedFile    ArchivedFile     `json:"archived_file"`
+		Filename        string           `json:"filename"`
+		Context         ResultContext    `json:"context"`
 	}
 
 	JobResult struct {
-		StdoutBlob Blob          `json:"stdout_blob,omitempty"`
-		StderrBlob Blob          `json:"stderr_blob,omitempty"`
-		ExitCode   *int          `json:"return_code,omitempty"`
-		Context    ResultContext `json:"context,omitempty"`
-		Cmd        []string      `json:"cmd,omitempty"`
+		// What the command used, nil for workers that don't report it
+		Usage      *ResourceUsage `json:"usage,omitempty"`
+		StdoutBlob Blob           `json:"stdout_blob,omitempty"`
+		StderrBlob Blob           `json:"stderr_blob,omitempty"`
+		ExitCode   *int           `json:"return_code,omitempty"`
+		Context    ResultContext  `json:"context,omitempty"`
+		Cmd        []string       `json:"cmd,omitempty"`
 	}
 
 	JobResponse struct {
+		// What the job's commands used, nil when none reported usage
+		Usage *ResourceUsageTotal `json:"usage,omitempty"`
+		// Crash rate of the POV against the head repo, nil when it was not run
+		Reproducibility           *Reproducibility `json:"reproducibility,omitempty"`
 		JobID                     string           `json:"job_id"                      validate:"required"`
 		Status                    SubmissionStatus `json:"status"                      validate:"required"`
 		FunctionalityTestsPassing *bool            `json:"functionality_tests_passing"`
+		Reason                    string           `json:"reason,omitempty"`
 		Results                   []JobResult      `json:"results"`
 		Artifacts                 []JobArtifact    `json:"artifacts"`
+		Steps                     []EvalStep       `json:"steps"`
+	}
+
+	// Commands run evaluating a submission
+	SubmissionResultsResponse struct {
+		Results []JobResult `json:"results"`
 	}
 
 	JobArgs struct {
@@ -73,6 +91,8 @@ type (
 		BaseTarballURL     *string `json:"diff_tarball_url"`
 		MemoryGB           *int    `json:"memory_gb"            validate:"required_without=TaskID"`
 		CPUs               *int    `json:"cpus"                 validate:"required_without=TaskID"`
+		// Overrides the task's policy for how often the POV must crash
+		Reproducibility *ReproducibilityPolicy `json:"reproducibility"`
 
 		CacheKey      *string `json:"cache_key"      validate:"required"`
 		OverrideCache *bool   `json:"override_cache" validate:"required"`
@@ -80,6 +100,11 @@ type (
 )
 
 const (
-	ResultCtxHeadRepoTest ResultContext = "pov_test_head_repo"
-	ResultCtxBaseRepoTest ResultContext = "delta_test_base_repo"
+	ResultCtxHeadRepoTest  ResultContext = "pov_test_head_repo"
+	ResultCtxBaseRepoTest  ResultContext = "delta_test_base_repo"
+	ResultCtxHeadRepoBuild ResultContext = "build_head_repo"
+	ResultCtxBaseRepoBuild ResultContext = "build_base_repo"
+	ResultCtxApplyPatch    ResultContext = "apply_patch"
+	ResultCtxRunTests      ResultContext = "run_tests"
+	ResultCtxTestBaseline  ResultContext = "test_baseline"
 )
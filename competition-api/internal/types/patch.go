@@ -15,3 +15,38 @@ type (
 		Status                    SubmissionStatus `json:"status"                                    validate:"required,eq=accepted|eq=errored|eq=passed|eq=failed|eq=deadline_exceeded"`
 	}
 )
+
+// Rule a patch broke, see [PatchPolicy]
+type PatchPolicyRule string
+
+const (
+	PatchRuleDenied        PatchPolicyRule = "denied"
+	PatchRuleNotAllowed    PatchPolicyRule = "not_allowed"
+	PatchRuleHarnessSource PatchPolicyRule = "harness_source"
+	PatchRuleMaxFiles      PatchPolicyRule = "max_files"
+	PatchRuleMaxHunks      PatchPolicyRule = "max_hunks"
+	// The path leaves the repo
+	PatchRuleInvalidPath PatchPolicyRule = "invalid_path"
+)
+
+type (
+	// What a challenge's patches may change, on top of the allowed languages. Paths and globs
+	// are relative to the focus repo, `**` matches any number of directories.
+	PatchPolicy struct {
+		// Files matching any of these may not change
+		Deny []string `yaml:"deny"            json:"deny,omitempty"`
+		// When set, only files matching one of these may change
+		Allow []string `yaml:"allow"           json:"allow,omitempty"`
+		// Fuzz harness sources, changing them could hide a crash instead of fixing it
+		HarnessSources []string `yaml:"harness_sources" json:"harness_sources,omitempty"`
+		// Zero leaves the number of changed files or hunks unlimited
+		MaxFiles int `yaml:"max_files"       json:"max_files,omitempty"       validate:"min=0"`
+		MaxHunks int `yaml:"max_hunks"       json:"max_hunks,omitempty"       validate:"min=0"`
+	}
+
+	// A file, or the whole patch when Path is empty, breaking the patch policy
+	PatchViolation struct {
+		Path string          `json:"path,omitempty"`
+		Rule PatchPolicyRule `json:"rule"`
+	}
+)
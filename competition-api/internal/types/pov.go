@@ -15,7 +15,7 @@ type (
 		// 4KiB max size
 		Sanitizer    string        `json:"sanitizer"    validate:"required,max=4096"`
 		Architecture Architecture  `json:"architecture" validate:"required,eq=x86_64"`
-		Engine       FuzzingEngine `json:"engine"       validate:"required,eq=libfuzzer"`
+		Engine       FuzzingEngine `json:"engine"       validate:"required,oneof=libfuzzer afl honggfuzz"`
 	}
 
 	POVSubmissionResponse struct {
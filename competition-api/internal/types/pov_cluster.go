@@ -0,0 +1,28 @@
+package types
+
+type (
+	POVClusterMember struct {
+		POVID       string `json:"pov_id"       validate:"required"`
+		SubmitterID string `json:"submitter_id" validate:"required"`
+		// UNIX millisecond timestamp for when the POV was submitted
+		SubmittedAt UnixMilli `json:"submitted_at" validate:"required"`
+	}
+
+	// Passed POVs of a task that triggered the same crash. The earliest submission found the bug,
+	// the rest are duplicates.
+	POVCluster struct {
+		ClusterID string `json:"cluster_id" validate:"required"`
+		TaskID    string `json:"task_id"    validate:"required"`
+		// Normalized crash signature every POV in the cluster shares
+		Signature        string             `json:"signature"          validate:"required"`
+		FirstPOVID       string             `json:"first_pov_id"       validate:"required"`
+		FirstSubmitterID string             `json:"first_submitter_id" validate:"required"`
+		POVs             []POVClusterMember `json:"povs"               validate:"required"`
+		// UNIX millisecond timestamp for when the first POV was submitted
+		FirstSubmittedAt UnixMilli `json:"first_submitted_at" validate:"required"`
+	}
+
+	POVClusterListResponse struct {
+		Clusters []POVCluster `json:"clusters" validate:"required"`
+	}
+)
@@ -0,0 +1,33 @@
+package types
+
+type PreflightCheck string
+
+const (
+	// Check and build the challenge without a trigger
+	PreflightCheckBuild PreflightCheck = "build"
+	// Ground truth POV is expected to crash the head repo (and not the base repo for delta scans)
+	PreflightCheckPOV PreflightCheck = "pov"
+	// Ground truth patch is expected to apply, build, stop the POV crashing and pass tests
+	PreflightCheckPatch PreflightCheck = "patch"
+)
+
+type (
+	PreflightCheckResult struct {
+		FunctionalityTestsPassing *bool            `json:"functionality_tests_passing,omitempty"`
+		Check                     PreflightCheck   `json:"check"`
+		JobID                     string           `json:"job_id"`
+		Status                    SubmissionStatus `json:"status"`
+		// Ground truth file the check used, relative to .aixcc
+		GroundTruth string `json:"ground_truth,omitempty"`
+		// Why the check failed, empty when it passed
+		Reason string `json:"reason,omitempty"`
+		Passed bool   `json:"passed"`
+	}
+
+	PreflightReport struct {
+		Checks []PreflightCheckResult `json:"checks"`
+		Passed bool                   `json:"passed"`
+		// Whether tasking was stopped because pre-flight failed
+		Blocked bool `json:"blocked"`
+	}
+)
@@ -0,0 +1,54 @@
+package types
+
+type ReleaseStatus string
+
+const (
+	ReleaseStatusScheduled ReleaseStatus = "scheduled" // Waiting for its release time
+	ReleaseStatusPaused    ReleaseStatus = "paused"    // Will not be released until resumed
+	ReleaseStatusCancelled ReleaseStatus = "cancelled" // Will never be released
+	ReleaseStatusReleasing ReleaseStatus = "releasing" // Claimed by the scheduler and currently being tasked
+	ReleaseStatusReleased  ReleaseStatus = "released"  // Tasked out to the target teams
+	ReleaseStatusFailed    ReleaseStatus = "failed"    // Tasking failed, see the error on the release
+)
+
+type ReleaseSubmission struct {
+	// If provided a delta scan is released, otherwise a full scan
+	BaseRef *string `json:"base_ref"        validate:"omitempty"`
+	// Round the task belongs to. Defaults to the current round.
+	RoundID *string `json:"round_id"        validate:"omitempty"`
+	// GitHub app installation used to clone the repo. Defaults to the configured installation.
+	InstallationID *int64 `json:"installation_id" validate:"omitempty"`
+	// Human readable name of the challenge
+	Name    string `json:"name"            validate:"required"`
+	RepoURL string `json:"repo_url"        validate:"required,url"`
+	HeadRef string `json:"head_ref"        validate:"required"`
+	// Team IDs to task. If empty all teams are tasked.
+	Teams []string `json:"teams"           validate:"omitempty,dive,uuid_rfc4122"`
+	// Time in seconds the task will remain open once released
+	DurationSecs int64 `json:"duration_secs"   validate:"required,gt=0"`
+	// UNIX millisecond timestamp for when the task should be released
+	ReleaseAt UnixMilli `json:"release_at"      validate:"required"`
+}
+
+type Release struct {
+	BaseRef        *string    `json:"base_ref"`
+	InstallationID *int64     `json:"installation_id"`
+	ReleasedAt     *UnixMilli `json:"released_at"`
+	Error          *string    `json:"error"`
+	// Set when pre-flight failed and blocked the release
+	Preflight    *PreflightReport `json:"preflight,omitempty"`
+	ReleaseID    string           `json:"release_id"      validate:"required,uuid_rfc4122"    format:"uuid"`
+	Name         string           `json:"name"            validate:"required"`
+	Type         TaskType         `json:"type"            validate:"required,eq=full|eq=delta"`
+	RepoURL      string           `json:"repo_url"        validate:"required"`
+	HeadRef      string           `json:"head_ref"        validate:"required"`
+	RoundID      string           `json:"round_id"        validate:"required"`
+	Status       ReleaseStatus    `json:"status"          validate:"required"`
+	Teams        []string         `json:"teams"`
+	DurationSecs int64            `json:"duration_secs"   validate:"required"`
+	ReleaseAt    UnixMilli        `json:"release_at"      validate:"required"`
+}
+
+type ReleaseListResponse struct {
+	Releases []Release `json:"releases" validate:"required"`
+}
@@ -0,0 +1,36 @@
+package types
+
+type (
+	// How often a POV has to crash the head repo to pass. Crashes that only reproduce some of the
+	// time would otherwise pass or fail by luck.
+	ReproducibilityPolicy struct {
+		// Times the trigger is run
+		Runs int `yaml:"runs"      json:"runs"      validate:"min=1,max=100"`
+		// Fraction of runs that must crash
+		Threshold float64 `yaml:"threshold" json:"threshold" validate:"gt=0,lte=1"`
+	}
+
+	// What repeated runs of a POV against the head repo measured
+	Reproducibility struct {
+		Runs      int     `json:"runs"`
+		Crashes   int     `json:"crashes"`
+		CrashRate float64 `json:"crash_rate"`
+		// Crash rate the POV needed to pass
+		Threshold float64 `json:"threshold"`
+	}
+)
+
+// Runs a POV once and passes it if that run crashed
+var DefaultReproducibilityPolicy = ReproducibilityPolicy{Runs: 1, Threshold: 1}
+
+func (p ReproducibilityPolicy) Measure(runs, crashes int) Reproducibility {
+	r := Reproducibility{Runs: runs, Crashes: crashes, Threshold: p.Threshold}
+	if runs > 0 {
+		r.CrashRate = float64(crashes) / float64(runs)
+	}
+	return r
+}
+
+func (r Reproducibility) Passed() bool {
+	return r.Runs > 0 && r.CrashRate >= r.Threshold
+}
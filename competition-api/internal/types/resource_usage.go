@@ -0,0 +1,55 @@
+package types
+
+type (
+	// What a command took. Containers started through docker are not children of the command,
+	// the rusage of its process has neither their CPU time nor their memory so only the wall time
+	// is reported.
+	ResourceUsage struct {
+		// Signal that killed the process, like SIGKILL, empty when it exited
+		Signal     string `json:"signal,omitempty"`
+		WallTimeMs int64  `json:"wall_time_ms"`
+	}
+
+	// Usage of all commands run for an evaluation, or for many of them. CPU time and memory are
+	// left out, the rusage of a command only covers the helper scripts and docker CLI and not
+	// the containers doing the work.
+	ResourceUsageTotal struct {
+		WallTimeMs int64 `json:"wall_time_ms"`
+		Commands   int   `json:"commands"`
+		// Commands killed by a signal, mostly timeouts and the OOM killer
+		Signaled int `json:"signaled"`
+	}
+
+	// What evaluating a task's submissions used, next to what its evaluations are given
+	TaskResourceUsage struct {
+		TaskID      string             `json:"task_id"      validate:"required"`
+		ProjectName string             `json:"project_name" validate:"required"`
+		Usage       ResourceUsageTotal `json:"usage"        validate:"required"`
+		MemoryGB    int                `json:"memory_gb"    validate:"required"`
+		CPUs        int                `json:"cpus"         validate:"required"`
+		// Submissions with usage reported
+		Evaluations int `json:"evaluations" validate:"required"`
+	}
+
+	TaskResourceUsageListResponse struct {
+		Tasks []TaskResourceUsage `json:"tasks" validate:"required"`
+	}
+)
+
+// Adds a command's usage to the total
+func (t ResourceUsageTotal) Add(usage ResourceUsage) ResourceUsageTotal {
+	t.Commands++
+	if usage.Signal != "" {
+		t.Signaled++
+	}
+	t.WallTimeMs += usage.WallTimeMs
+	return t
+}
+
+// Combines two totals, like the usage of two evaluations
+func (t ResourceUsageTotal) Merge(other ResourceUsageTotal) ResourceUsageTotal {
+	t.Commands += other.Commands
+	t.Signaled += other.Signaled
+	t.WallTimeMs += other.WallTimeMs
+	return t
+}
@@ -0,0 +1,29 @@
+package types
+
+type RoundState string
+
+const (
+	RoundStatePlanned RoundState = "planned" // Round exists but has not started yet
+	RoundStateOpen    RoundState = "open"    // Round is running and accepting submissions
+	RoundStateFrozen  RoundState = "frozen"  // Round is paused. Submissions are rejected until it is reopened.
+	RoundStateClosed  RoundState = "closed"  // Round is over. Submissions are rejected.
+)
+
+type RoundSubmission struct {
+	// UNIX millisecond timestamp for when the round is planned to start
+	StartTime *UnixMilli `json:"start_time" validate:"omitempty"`
+	// UNIX millisecond timestamp for when the round is planned to end
+	EndTime *UnixMilli `json:"end_time"   validate:"omitempty"`
+	RoundID string     `json:"round_id"   validate:"required,max=256"`
+}
+
+type Round struct {
+	StartTime *UnixMilli `json:"start_time"`
+	EndTime   *UnixMilli `json:"end_time"`
+	RoundID   string     `json:"round_id"   validate:"required"`
+	State     RoundState `json:"state"      validate:"required,eq=planned|eq=open|eq=frozen|eq=closed"`
+}
+
+type RoundListResponse struct {
+	Rounds []Round `json:"rounds" validate:"required"`
+}
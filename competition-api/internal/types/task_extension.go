@@ -0,0 +1,19 @@
+package types
+
+type TaskExtensionSubmission struct {
+	// UNIX millisecond timestamp for the new task deadline. Must not be before the current
+	// deadline, repeating the current one retries what a previous extension left undone.
+	Deadline UnixMilli `json:"deadline" validate:"required"`
+}
+
+type TaskExtensionResponse struct {
+	TaskID string `json:"task_id" validate:"required"`
+	// Number of submissions marked deadline_exceeded that were accepted under the new deadline
+	ReacceptedSubmissions int `json:"reaccepted_submissions"`
+	// UNIX millisecond timestamp for the new task deadline
+	Deadline UnixMilli `json:"deadline" validate:"required"`
+	// Re-accepted POVs and patches whose evaluation has not been started yet
+	PendingEvaluations int `json:"pending_evaluations"`
+	// Whether CRSs were sent the task with the new deadline
+	TaskResent bool `json:"task_resent"`
+}
@@ -0,0 +1,75 @@
+package types
+
+import "slices"
+
+type (
+	TestOutcome string
+	// Where per-test results were read from
+	TestFormat string
+
+	TestCase struct {
+		Name    string      `json:"name"`
+		Outcome TestOutcome `json:"outcome"`
+	}
+
+	// Per-test outcomes of a run of a challenge's test suite
+	TestResults struct {
+		Format TestFormat `json:"format"`
+		Cases  []TestCase `json:"cases"`
+	}
+)
+
+const (
+	TestPassed  TestOutcome = "passed"
+	TestFailed  TestOutcome = "failed"
+	TestSkipped TestOutcome = "skipped"
+)
+
+const (
+	TestFormatJUnit TestFormat = "junit"
+	TestFormatCTest TestFormat = "ctest"
+	TestFormatTAP   TestFormat = "tap"
+)
+
+// Names of the tests that passed in `r` but did not pass or went missing in `patched`. Tests
+// that already failed in `r` are not held against the patch.
+func (r *TestResults) Regressions(patched *TestResults) []string {
+	outcomes := make(map[string]TestOutcome, len(patched.Cases))
+	for _, c := range patched.Cases {
+		outcomes[c.Name] = c.Outcome
+	}
+
+	var regressions []string
+	for _, c := range r.Cases {
+		if c.Outcome != TestPassed {
+			continue
+		}
+		outcome, ok := outcomes[c.Name]
+		if !ok || outcome != TestPassed {
+			regressions = append(regressions, c.Name)
+		}
+	}
+	slices.Sort(regressions)
+	return regressions
+}
+
+// Whether the tests failing in `patched` are all ones that already failed in `r`, and any do, so
+// a failing run can be put down to the suite's known failures
+func (r *TestResults) ExplainsFailures(patched *TestResults) bool {
+	failedBefore := make(map[string]bool, len(r.Cases))
+	for _, c := range r.Cases {
+		failedBefore[c.Name] = c.Outcome == TestFailed
+	}
+
+	failing := false
+	for _, c := range patched.Cases {
+		if c.Outcome != TestFailed {
+			continue
+		}
+		if !failedBefore[c.Name] {
+			return false
+		}
+		failing = true
+	}
+	return failing
+}
@@ -21,14 +21,34 @@ type (
 		WorkerMsg
 	}
 
+	// Sent when an evaluation step starts and again when it finishes
+	WorkerMsgStep struct {
+		WorkerMsg
+		Step EvalStep `json:"step"`
+	}
+
 	WorkerMsgFinal struct {
 		WorkerMsg
 		Status SubmissionStatus `json:"status"`
 
+		// why the evaluation ended, only set when Status == Errored
+		Reason string `json:"reason,omitempty"`
+
 		// only meaningful if Entity == Patch && Status == Failed
 		PatchTestsFailed bool `json:"patch_tests_failed"`
 	}
 
+	// Asks a pooled worker to run `Spec`, instead of starting a job for it
+	EvalRequest struct {
+		// trace context of whoever asked for the evaluation
+		TraceContext map[string]string `json:"trace_context,omitempty"`
+		// worker path of simulated engine rules to evaluate with, the challenge scripts when empty
+		SimulateRules string `json:"simulate_rules,omitempty"`
+		// reuse unpatched builds across evaluations with the same key, disabled when empty
+		BuildCacheKey string   `json:"build_cache_key,omitempty"`
+		Spec          EvalSpec `json:"spec"`
+	}
+
 	MsgType string
 	JobKind string
 	JobType string
@@ -38,6 +58,7 @@ const (
 	MsgTypeFinal         = "final"
 	MsgTypeArtifact      = "artifact"
 	MsgTypeCommandResult = "command_result"
+	MsgTypeStep          = "step"
 
 	JobKindEval      = "eval"
 	JobKindBroadcast = "broadcast"
@@ -79,11 +100,23 @@ func NewWorkerMsgCommandResult(
 	}
 }
 
+func NewWorkerMsgStep(entity JobType, entityID string, step EvalStep) WorkerMsgStep {
+	return WorkerMsgStep{
+		WorkerMsg: WorkerMsg{
+			MsgType:  MsgTypeStep,
+			Entity:   entity,
+			EntityID: entityID,
+		},
+		Step: step,
+	}
+}
+
 func NewWorkerMsgFinal(
 	entity JobType,
 	entityID string,
 	status SubmissionStatus,
 	patchTestsFailed *bool,
+	reason string,
 ) WorkerMsgFinal {
 	failed := false
 	if patchTestsFailed != nil {
@@ -97,6 +130,7 @@ func NewWorkerMsgFinal(
 		},
 		PatchTestsFailed: failed,
 		Status:           status,
+		Reason:           reason,
 	}
 }
 
@@ -22,7 +22,7 @@ die() {
 	exit 201
 }
 
-VERSION="v1.8.1"
+VERSION="v1.9.0"
 print_ver() {
 	echo "$VERSION"
 }
@@ -38,6 +38,8 @@ Options:
                               {address,none,memory,undefined,thread,coverage,introspector,hwaddress}
                               the default is address
     -a ARCHITECTURE           set arch for build {i386,x86_64,aarch64}
+    -E ENGINE                 set fuzzing engine for build {libfuzzer,afl,honggfuzz}
+                              the default is libfuzzer
     -d IMAGE_TAG              set the project docker image tag (default: latest)
     -e PROPAGATE_EXIT_CODE    propagate exit code from helper.py
 
@@ -67,6 +69,7 @@ build_challenge_repository() {
 	${PYTHON} infra/helper.py build_fuzzers --clean \
 		--architecture "${ARCHITECTURE}" \
 		--sanitizer "${SANITIZER}" \
+		--engine "${ENGINE}" \
 		${DOCKER_IMAGETAG_ARG} \
 		${PROPAGATE_EXIT_CODE_ARG} \
 		"${PROJECT_NAME}" "${MY_LOCAL_PROJ_REPO}"
@@ -92,6 +95,7 @@ build_challenge_repository() {
 		${PROPAGATE_EXIT_CODE_ARG} \
 		--architecture "${ARCHITECTURE}" \
 		--sanitizer "${SANITIZER}" \
+		--engine "${ENGINE}" \
 		"${PROJECT_NAME}"
 	CHECK_BUILD_EXIT=${PIPESTATUS[0]}
 	set -e
@@ -114,7 +118,7 @@ build_challenge_repository() {
 	succeed "Successfully built"
 }
 
-while getopts ":p:r:o:s:a:d:l:hve" opt; do
+while getopts ":p:r:o:s:a:d:l:E:hve" opt; do
 	case ${opt} in
 	h)
 		print_usage
@@ -142,6 +146,9 @@ while getopts ":p:r:o:s:a:d:l:hve" opt; do
 	a)
 		ARCHITECTURE="${OPTARG}"
 		;;
+	E)
+		ENGINE="${OPTARG}"
+		;;
 	d)
 		IMAGE_TAG="${OPTARG}"
 		;;
@@ -174,6 +181,7 @@ fi
 # set default values if null is provided from github action
 [ "${SANITIZER}" == "null" ] && SANITIZER="address"
 [ "${ARCHITECTURE}" == "null" ] && ARCHITECTURE="x86_64"
+[ "${ENGINE}" == "null" ] && ENGINE="libfuzzer"
 [ "${IMAGE_TAG}" == "null" ] && IMAGE_TAG="latest"
 
 # set defaults
@@ -181,6 +189,7 @@ fi
 : "${PYTHON:="python3"}"
 : "${SANITIZER:="address"}"
 : "${ARCHITECTURE:="x86_64"}"
+: "${ENGINE:="libfuzzer"}"
 
 MY_LOCAL_PROJ_REPO=$(realpath "$LOCAL_PROJ_REPO")
 
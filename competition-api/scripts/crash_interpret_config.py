@@ -37,7 +37,7 @@ def parse_args():
     parser.add_argument(
         "--engine",
         required=True,
-        help="Engine name {libfuzzer}",
+        help="Engine name {libfuzzer,afl,honggfuzz}",
     )
     parser.add_argument(
         "--sanitizer",
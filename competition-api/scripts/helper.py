@@ -811,6 +811,9 @@ def docker_run(
     else:
         command.append("--rm")
 
+    # limits the worker runs the helper under, containers are not its children
+    command.extend(shlex.split(os.getenv("DOCKER_RUN_LIMITS", "")))
+
     # Support environments with a TTY.
     if sys.stdin.isatty():
         command.append("-i")
@@ -26,7 +26,7 @@ fail() {
 	exit 202
 }
 
-VERSION="v3.1.1"
+VERSION="v3.2.0"
 print_ver() {
 	echo "$VERSION"
 }
@@ -45,9 +45,10 @@ Options:
 }
 
 run_pov() {
-	STDOUT_FILE="/tmp/fuzzer_stdout.txt"
-	STDERR_FILE="/tmp/fuzzer_stderr.txt"
-	COMBINED_FILE="/tmp/fuzz.out"
+	# concurrent runs each set their own POV_OUT_DIR
+	STDOUT_FILE="${POV_OUT_DIR}/fuzzer_stdout.txt"
+	STDERR_FILE="${POV_OUT_DIR}/fuzzer_stderr.txt"
+	COMBINED_FILE="${POV_OUT_DIR}/fuzz.out"
 
 	pushd "${LOCAL_OSS_FUZZ_REPO}" >/dev/null || die "Could not pushd"
 
@@ -55,8 +56,10 @@ run_pov() {
 	TIMEOUT_ARG=${TIMEOUT_SEC:+"--timeout ${TIMEOUT_SEC}"}
 
 	set +e
+	# the harness was built for this engine, reproduce has to run it the same way
 	"${PYTHON}" infra/helper.py reproduce --architecture "${ARCHITECTURE}" \
 		--propagate_exit_codes \
+		-e "FUZZING_ENGINE=${ENGINE}" \
 		${PRIVILEGED_FLAG} \
 		${TIMEOUT_ARG} \
 		--err_result 201 \
@@ -211,6 +214,7 @@ done
 # note: *not* defaulting TIMEOUT_SEC to keep it backwards compatible with oss-fuzz-aixcc v1.1.0
 : "${PYTHON:="python3"}"
 : "${ARCHITECTURE:="x86_64"}"
+: "${POV_OUT_DIR:="/tmp"}"
 
 # Check if the specified paths exist:
 if [ ! -d "${LOCAL_OSS_FUZZ_REPO}" ]; then
@@ -16,7 +16,7 @@ die() {
 	exit 201
 }
 
-VERSION="v2.4.0"
+VERSION="v2.5.0"
 print_ver() {
 	echo "$VERSION"
 }
@@ -31,17 +31,32 @@ Options:
     -i DOCKER_IMAGE     set docker image name (default aixcc-afc/<proj_name>)
                         overrides IMAGE_TAG
     -d IMAGE_TAG        set docker image tag (default: latest)
-    -x                  set -x when success is not expected, this affects exit code"
+    -x                  set -x when success is not expected, this affects exit code
+
+Environment:
+    TEST_OUT_DIR        directory the test script can write JUnit XML results to, it is
+                        mounted into the container and handed to the script under the same name
+    DOCKER_RUN_LIMITS   extra docker run flags limiting the test container"
 }
 
 run_tests() {
 
 	LOCAL_SRC_MNT="/local-source-mount"
 	TEST_MNT="/test-mnt.sh"
+	TEST_OUT_MNT="/test-out"
+
+	OUT_ARGS=()
+	if [ -n "${TEST_OUT_DIR}" ]; then
+		OUT_ARGS=(-v "$(realpath "${TEST_OUT_DIR}"):${TEST_OUT_MNT}" -e "TEST_OUT_DIR=${TEST_OUT_MNT}")
+	fi
 
+	# word splitting is wanted, the limits are several flags
+	# shellcheck disable=SC2086
 	docker run --rm \
+		${DOCKER_RUN_LIMITS} \
 		-v "${LOCAL_PROJ_REPO_ABS}:${LOCAL_SRC_MNT}" \
 		-v "${TEST_SCRIPT_ABS}:${TEST_MNT}" \
+		"${OUT_ARGS[@]}" \
 		"${DOCKER_IMAGE}" \
 		/bin/bash -c "pushd \$SRC && rm -rf ${WORK_DIR} \
                         && cp -r ${LOCAL_SRC_MNT} ${WORK_DIR} \
@@ -51,7 +66,7 @@ run_tests() {
 
 	case $EXITCODE in
 	125 | 126 | 127 | 137)
-		die "Docker failed to mount and run the test script."
+		die "Docker failed to mount and run the test script, or the container ran out of memory."
 		;;
 	esac
 